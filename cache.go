@@ -0,0 +1,104 @@
+package snips
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"sync/atomic"
+	"time"
+
+	"github.com/garrettladley/snips/internal/lru"
+)
+
+// defaultCacheSize is the number of snippets whose HTML is cached, unless
+// ConfigureCache sets it.
+const defaultCacheSize = 256
+
+// cacheKey is the cache key of highlighted HTML, the hash of the contents and
+// the options that change the HTML.
+type cacheKey struct {
+	hash           [sha256.Size]byte
+	style          string
+	lang           string
+	fileName       string
+	tabWidth       int
+	baseLine       int
+	lines          bool
+	linesTable     bool
+	rawInvalidUTF8 bool
+}
+
+// cache of the HTML highlighted by Highlight and Component, nil if disabled.
+var cache atomic.Pointer[lru.Cache[cacheKey, string]]
+
+func init() {
+	cache.Store(lru.New[cacheKey, string](defaultCacheSize, 0))
+}
+
+// ConfigureCache replaces the cache of the HTML that Highlight and Component
+// highlight, which keeps the 256 most recently used snippets by default, with
+// one of size snippets, whose HTML is highlighted again ttl after it was
+// cached, or never if ttl is zero. A size of zero disables caching. Cached
+// HTML and counters are dropped, see ReadCacheStats.
+func ConfigureCache(size int, ttl time.Duration) {
+	if size <= 0 {
+		cache.Store(nil)
+		return
+	}
+	cache.Store(lru.New[cacheKey, string](size, ttl))
+}
+
+// CacheStats are the counters of the cache of Highlight and Component, to size
+// it with ConfigureCache: many evictions with few hits call for a larger
+// cache, and few entries for a smaller one.
+type CacheStats = lru.Stats
+
+// ReadCacheStats returns the counters of the cache since it was configured,
+// which are zero while it's disabled.
+func ReadCacheStats() CacheStats {
+	c := cache.Load()
+	if c == nil {
+		return CacheStats{}
+	}
+	return c.Stats()
+}
+
+// CacheVar is the counters of the cache as an expvar.Var, in JSON, which
+// importing the cachevar package publishes as snips.cache, or which can be
+// published under another name, e.g.
+//
+//	expvar.Publish("highlight_cache", snips.CacheVar{})
+type CacheVar struct{}
+
+func (CacheVar) String() string {
+	b, _ := json.Marshal(ReadCacheStats())
+	return string(b)
+}
+
+// cachedHighlight returns the highlighted HTML of contents, from the cache if
+// it's there. Snippets highlighted with WithGenerateOptions are always
+// highlighted again, since the options can't be compared.
+func (o highlightOptions) cachedHighlight(contents []byte) (html string, err error) {
+	c := cache.Load()
+	if c == nil || len(o.generateOpts) > 0 {
+		return o.highlight(contents)
+	}
+	key := cacheKey{
+		hash:           sha256.Sum256(contents),
+		style:          o.style,
+		lang:           o.lang,
+		fileName:       o.fileName,
+		tabWidth:       o.tabWidth,
+		baseLine:       o.baseLine,
+		lines:          o.lines,
+		linesTable:     o.linesTable,
+		rawInvalidUTF8: o.rawInvalidUTF8,
+	}
+	if html, ok := c.Get(key); ok {
+		return html, nil
+	}
+	if html, err = o.highlight(contents); err != nil {
+		return "", err
+	}
+	c.Add(key, html)
+	return html, nil
+}
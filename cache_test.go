@@ -0,0 +1,40 @@
+package snips_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/garrettladley/snips"
+)
+
+func TestCache(t *testing.T) {
+	t.Cleanup(func() { snips.ConfigureCache(256, 0) })
+	snips.ConfigureCache(1, time.Hour)
+
+	for _, contents := range []string{"a", "a", "b", "a"} {
+		if _, err := snips.Highlight(context.Background(), []byte(contents)); err != nil {
+			t.Fatalf("failed to highlight: %v", err)
+		}
+	}
+	want := snips.CacheStats{Hits: 1, Misses: 3, Evictions: 2, Entries: 1, Size: 1}
+	if got := snips.ReadCacheStats(); got != want {
+		t.Errorf("ReadCacheStats() = %+v, want %+v", got, want)
+	}
+	var published snips.CacheStats
+	if err := json.Unmarshal([]byte(snips.CacheVar{}.String()), &published); err != nil {
+		t.Fatalf("invalid expvar JSON: %v", err)
+	}
+	if published != want {
+		t.Errorf("expected the expvar to publish %+v, got %+v", want, published)
+	}
+
+	snips.ConfigureCache(0, 0)
+	if _, err := snips.Highlight(context.Background(), []byte("a")); err != nil {
+		t.Fatalf("failed to highlight: %v", err)
+	}
+	if got := snips.ReadCacheStats(); got != (snips.CacheStats{}) {
+		t.Errorf("expected no counters with the cache disabled, got %+v", got)
+	}
+}
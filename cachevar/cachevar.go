@@ -0,0 +1,17 @@
+// Package cachevar publishes the counters of the cache of snips.Highlight and
+// snips.Component as the snips.cache expvar, served by expvar at /debug/vars,
+// so that servers can size the cache with snips.ConfigureCache. It's imported
+// for its side effect:
+//
+//	import _ "github.com/garrettladley/snips/cachevar"
+package cachevar
+
+import (
+	"expvar"
+
+	"github.com/garrettladley/snips"
+)
+
+func init() {
+	expvar.Publish("snips.cache", snips.CacheVar{})
+}
@@ -0,0 +1,31 @@
+package cachevar_test
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"testing"
+
+	"github.com/garrettladley/snips"
+	_ "github.com/garrettladley/snips/cachevar"
+)
+
+func TestPublish(t *testing.T) {
+	for range 2 {
+		if _, err := snips.Highlight(context.Background(), []byte("package main\n")); err != nil {
+			t.Fatalf("failed to highlight: %v", err)
+		}
+	}
+	v := expvar.Get("snips.cache")
+	if v == nil {
+		t.Fatal("expected the snips.cache expvar to be published")
+	}
+	var published snips.CacheStats
+	if err := json.Unmarshal([]byte(v.String()), &published); err != nil {
+		t.Fatalf("invalid expvar JSON: %v", err)
+	}
+	// The default cache is published without configuring it.
+	if want := snips.ReadCacheStats(); published != want || published.Hits == 0 {
+		t.Errorf("expected the expvar to publish %+v, got %+v", want, published)
+	}
+}
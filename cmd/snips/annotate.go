@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/garrettladley/snips"
+	"github.com/garrettladley/snips/cmd/snips/generatecmd"
+	"github.com/garrettladley/snips/cmd/snips/generatecmd/watcher"
+)
+
+const annotateUsageText = `usage: snips annotate [<args>...]
+
+Inserts a go:generate directive into each package with snippets, so that go generate ./...
+generates them without listing the packages by hand:
+  //go:generate snips generate -dir .
+Existing snips generate directives in the Go files of a package are updated to it, keeping
+the command that runs snips, e.g. go run github.com/garrettladley/snips/cmd/snips. Packages
+without one get a snips_generate.go file. The directive refers to the config file, if any,
+relative to the package.
+
+Args:
+  -path <path>
+    Annotates the packages in path. (default .)
+  -config <file>
+    The config file for the directives to load. (default snips.yaml or snips.toml,
+    whichever exists)
+  -check
+    Lists the packages whose directive is missing or outdated instead of writing it, failing
+    if there are any. (default false)
+  -help
+    Print help and exit.
+`
+
+// annotateFileName is the file that the directives of packages without one
+// are written to.
+const annotateFileName = "snips_generate.go"
+
+// generateDirective matches the go:generate directives running snips
+// generate, capturing the command that runs snips.
+var generateDirective = regexp.MustCompile(`^//go:generate\s+(snips|go\s+run\s+\S*github\.com/garrettladley/snips/cmd/snips\S*)\s+generate(?:\s.*)?$`)
+
+func annotateCmd(stdout, stderr io.Writer, args []string) (code int) {
+	cmd := flag.NewFlagSet("annotate", flag.ContinueOnError)
+	cmd.SetOutput(io.Discard)
+	path := cmd.String("path", ".", "")
+	configFileName := cmd.String("config", "", "")
+	check := cmd.Bool("check", false, "")
+	help := cmd.Bool("help", false, "")
+	if err := cmd.Parse(args); err != nil || cmd.NArg() > 0 {
+		fmt.Fprint(stderr, annotateUsageText)
+		return 64 // EX_USAGE
+	}
+	if *help {
+		fmt.Fprint(stdout, annotateUsageText)
+		return 0
+	}
+	fail := func(err error) int {
+		color.New(color.FgRed).Fprint(stderr, "(✗) ")
+		fmt.Fprintln(stderr, "Command failed: "+err.Error())
+		return 1
+	}
+
+	if *configFileName == "" {
+		var err error
+		if *configFileName, err = generatecmd.DefaultConfigFile(""); err != nil {
+			return fail(err)
+		}
+	} else if _, err := os.Stat(*configFileName); err != nil {
+		return fail(fmt.Errorf("failed to read config: %w", err))
+	}
+	dirs, err := snippetDirs(*path)
+	if err != nil {
+		return fail(err)
+	}
+	var changed []string
+	for _, dir := range dirs {
+		fileName, ok, err := annotateDir(dir, *configFileName, !*check)
+		if err != nil {
+			return fail(err)
+		}
+		if !ok {
+			fmt.Fprintln(stdout, fileName)
+			changed = append(changed, fileName)
+		}
+	}
+	if *check && len(changed) > 0 {
+		color.New(color.FgRed).Fprint(stderr, "(✗) ")
+		fmt.Fprintf(stderr, "%d packages have a missing or outdated go:generate directive, run snips annotate\n", len(changed))
+		return 1
+	}
+	if len(changed) > 0 && !*check {
+		color.New(color.FgGreen).Fprint(stdout, "(✓) ")
+		fmt.Fprintf(stdout, "Annotated %d packages, run go generate ./... to generate their snippets\n", len(changed))
+	}
+	return 0
+}
+
+// snippetDirs returns the directories in path that contain snippets, skipping
+// the directories that generate skips.
+func snippetDirs(path string) (dirs []string, err error) {
+	err = filepath.WalkDir(path, func(fileName string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			rel, err := filepath.Rel(path, fileName)
+			if err != nil {
+				return err
+			}
+			if watcher.SkipDir(filepath.ToSlash(rel)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if dir := filepath.Dir(fileName); generatecmd.IsSnippet(d.Name()) && !slices.Contains(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+		return nil
+	})
+	return dirs, err
+}
+
+// directive returns the canonical go:generate directive of the package in dir,
+// run by command, e.g. "snips".
+func directive(command, dir, configFileName string) (string, error) {
+	s := "//go:generate " + command + " generate -dir ."
+	if configFileName == "" {
+		return s, nil
+	}
+	absConfig, err := filepath.Abs(configFileName)
+	if err != nil {
+		return "", err
+	}
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(absDir, absConfig)
+	if err != nil {
+		return "", err
+	}
+	return s + " -config " + filepath.ToSlash(rel), nil
+}
+
+// annotateDir updates the go:generate directives of the package in dir, or
+// adds one, writing the changes if write is set. It returns the file that has,
+// or would have, the directive, and whether it was already up to date.
+func annotateDir(dir, configFileName string, write bool) (fileName string, ok bool, err error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read directory: %w", err)
+	}
+	var annotated []string
+	ok = true
+	for _, entry := range entries {
+		name := entry.Name()
+		// Generated files are rewritten by generate.
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") || snips.ContainsDotCodeDot(name) {
+			continue
+		}
+		fileName = filepath.Join(dir, name)
+		contents, err := os.ReadFile(fileName)
+		if err != nil {
+			return "", false, fmt.Errorf("failed to read %q: %w", fileName, err)
+		}
+		lines := bytes.Split(contents, []byte("\n"))
+		var found bool
+		for i, line := range lines {
+			m := generateDirective.FindSubmatch(bytes.TrimRight(line, "\r"))
+			if m == nil {
+				continue
+			}
+			found = true
+			canonical, err := directive(string(m[1]), dir, configFileName)
+			if err != nil {
+				return "", false, err
+			}
+			if string(bytes.TrimRight(line, "\r")) != canonical {
+				lines[i] = []byte(canonical)
+				ok = false
+			}
+		}
+		if !found {
+			continue
+		}
+		annotated = append(annotated, fileName)
+		if !ok && write {
+			if err = os.WriteFile(fileName, bytes.Join(lines, []byte("\n")), 0o644); err != nil {
+				return "", false, fmt.Errorf("failed to write %q: %w", fileName, err)
+			}
+		}
+	}
+	if len(annotated) > 0 {
+		return annotated[0], ok, nil
+	}
+
+	fileName = filepath.Join(dir, annotateFileName)
+	canonical, err := directive("snips", dir, configFileName)
+	if err != nil {
+		return "", false, err
+	}
+	if write {
+		contents := "package " + snips.PackageName(dir) + "\n\n" + canonical + "\n"
+		if err = os.WriteFile(fileName, []byte(contents), 0o644); err != nil {
+			return "", false, fmt.Errorf("failed to write %q: %w", fileName, err)
+		}
+	}
+	return fileName, false, nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAnnotateCmd(t *testing.T) {
+	dir := t.TempDir()
+	for name, contents := range map[string]string{
+		"docs/a.code.go":      "package main\n",
+		"docs/docs.go":        "package docs\n",
+		"api/b.code.sql":      "SELECT 1;\n",
+		"api/api.go":          "package api\n\n//go:generate go run github.com/garrettladley/snips/cmd/snips@v0.1.0 generate -path .\n",
+		"_skipped/c.code.go":  "package main\n",
+		"plain/plain.go":      "package plain\n",
+		"docs/docs_test.go":   "package docs\n",
+		"api/b.code.sql.json": "{}\n",
+	} {
+		fileName := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fileName), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fileName, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run(&stdout, &stderr, []string{"snips", "annotate", "-path", dir, "-check"}); code != 1 {
+		t.Fatalf("expected -check to fail, got code %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "2 packages") {
+		t.Errorf("expected both packages to be listed, got:\n%s", stderr.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if code := run(&stdout, &stderr, []string{"snips", "annotate", "-path", dir}); code != 0 {
+		t.Fatalf("annotate failed with code %d: %s", code, stderr.String())
+	}
+	for name, expected := range map[string]string{
+		"docs/snips_generate.go": "package docs\n\n//go:generate snips generate -dir .\n",
+		"api/api.go":             "package api\n\n//go:generate go run github.com/garrettladley/snips/cmd/snips@v0.1.0 generate -dir .\n",
+	} {
+		if contents, _ := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name))); string(contents) != expected {
+			t.Errorf("expected %s:\n%s\ngot:\n%s", name, expected, contents)
+		}
+	}
+	for _, name := range []string{"_skipped/snips_generate.go", "plain/snips_generate.go"} {
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name))); err == nil {
+			t.Errorf("expected no %s", name)
+		}
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if code := run(&stdout, &stderr, []string{"snips", "annotate", "-path", dir, "-check"}); code != 0 {
+		t.Fatalf("expected the directives to be up to date, got code %d: %s%s", code, stdout.String(), stderr.String())
+	}
+}
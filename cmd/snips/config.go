@@ -0,0 +1,188 @@
+package main
+
+import (
+	_ "embed"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/garrettladley/snips/cmd/snips/generatecmd"
+	"gopkg.in/yaml.v3"
+)
+
+const configUsageText = `usage: snips config <command> [<args>...]
+
+Validates and prints the config file, which sets defaults for the flags of snips generate.
+The config file is YAML, or TOML if its name ends in .toml. Keys are flag names without the
+leading dash, e.g.
+
+  style: monokai            |  style = "monokai"
+  line-numbers: true        |  line-numbers = true
+  size-budget: 512KB        |  size-budget = "512KB"
+  var:                      |  [var]
+    VERSION: v1.2.3         |  VERSION = "v1.2.3"
+
+commands:
+  validate          Checks the config file and flags, without generating anything
+  print-effective   Prints the settings snips generate would use, and where each came from
+  schema            Prints the JSON schema of the config file, for editor completion
+
+Args:
+  -config <file>
+    Config file to use. (default snips.yaml or snips.toml, whichever exists)
+  Other snips generate flags are merged with the config file as snips generate would.
+`
+
+//go:embed snips.schema.json
+var configSchema []byte
+
+func configCmd(stdout, stderr io.Writer, args []string) (code int) {
+	if len(args) < 1 {
+		fmt.Fprint(stderr, configUsageText)
+		return 64 // EX_USAGE
+	}
+	switch args[0] {
+	case "schema":
+		stdout.Write(configSchema)
+		return 0
+	case "validate", "print-effective":
+	case "help", "-help", "--help", "-h":
+		fmt.Fprint(stdout, configUsageText)
+		return 0
+	default:
+		fmt.Fprint(stderr, configUsageText)
+		return 64 // EX_USAGE
+	}
+
+	f, err := parseGenerateFlags(io.Discard, args[1:], flag.ContinueOnError)
+	if err == nil && f.help {
+		fmt.Fprint(stdout, configUsageText)
+		return 0
+	}
+	if err == nil {
+		err = generatecmd.Validate(f.args)
+	}
+	if err != nil {
+		color.New(color.FgRed).Fprint(stderr, "(✗) ")
+		fmt.Fprintln(stderr, "Invalid config: "+err.Error())
+		return 1
+	}
+
+	if args[0] == "print-effective" {
+		if err = printEffective(stdout, f); err != nil {
+			color.New(color.FgRed).Fprint(stderr, "(✗) ")
+			fmt.Fprintln(stderr, "Command failed: "+err.Error())
+			return 1
+		}
+		return 0
+	}
+	if f.config == "" {
+		color.New(color.FgYellow).Fprint(stdout, "(!) ")
+		fmt.Fprintln(stdout, "No config file found, flags are valid")
+		return 0
+	}
+	color.New(color.FgGreen).Fprint(stdout, "(✓) ")
+	fmt.Fprintln(stdout, f.config+" is valid")
+	return 0
+}
+
+// printEffective prints the settings as a config file, with the source of each
+// setting as a comment. SNIPS_ environment variables are included, unless in
+// hermetic mode, since they're available to snippets as variables too.
+func printEffective(w io.Writer, f *generateFlags) error {
+	settings := &yaml.Node{Kind: yaml.MappingNode}
+	add := func(parent *yaml.Node, name string, value any, source string) error {
+		var v yaml.Node
+		if err := v.Encode(value); err != nil {
+			return err
+		}
+		key := &yaml.Node{Kind: yaml.ScalarNode, Value: name}
+		if v.Kind == yaml.SequenceNode && len(v.Content) > 0 {
+			// Block sequences start on the next line.
+			key.LineComment = source
+		} else {
+			v.LineComment = source
+		}
+		parent.Content = append(parent.Content, key, &v)
+		return nil
+	}
+
+	var err error
+	f.flagSet.VisitAll(func(fl *flag.Flag) {
+		if _, ok := fl.Value.(generatecmd.MapFlag); err != nil || generatecmd.Unconfigurable[fl.Name] || ok {
+			return
+		}
+		err = add(settings, fl.Name, fl.Value.(flag.Getter).Get(), f.sources[fl.Name])
+	})
+	if err != nil {
+		return err
+	}
+
+	vars := make(map[string]string)
+	varSources := make(map[string]string)
+	if !f.args.Hermetic {
+		for _, kv := range os.Environ() {
+			if k, v, ok := strings.Cut(kv, "="); ok && strings.HasPrefix(k, "SNIPS_") {
+				vars[k], varSources[k] = v, generatecmd.SourceEnv
+			}
+		}
+	}
+	for name, value := range f.args.Vars {
+		vars[name], varSources[name] = value, f.sources["var."+name]
+	}
+	buildTagSources := make(map[string]string)
+	for dir := range f.args.BuildTags {
+		buildTagSources[dir] = f.sources["build-tags."+dir]
+	}
+	styleAliasSources := make(map[string]string)
+	for alias := range f.args.StyleAliases {
+		styleAliasSources[alias] = f.sources["style-alias."+alias]
+	}
+	messageSources := make(map[string]string)
+	for id := range f.args.Messages {
+		messageSources[id] = f.sources["message."+id]
+	}
+	pluginSources := make(map[string]string)
+	for stage := range f.args.Plugins {
+		pluginSources[stage] = f.sources["plugin."+stage]
+	}
+	for _, m := range []struct {
+		name    string
+		values  map[string]string
+		sources map[string]string
+	}{
+		{"build-tags", f.args.BuildTags, buildTagSources},
+		{"message", f.args.Messages, messageSources},
+		{"plugin", f.args.Plugins, pluginSources},
+		{"style-alias", f.args.StyleAliases, styleAliasSources},
+		{"var", vars, varSources},
+	} {
+		if len(m.values) == 0 {
+			continue
+		}
+		node := &yaml.Node{Kind: yaml.MappingNode}
+		for _, key := range slices.Sorted(maps.Keys(m.values)) {
+			if err := add(node, key, m.values[key], m.sources[key]); err != nil {
+				return err
+			}
+		}
+		settings.Content = append(settings.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: m.name}, node)
+	}
+
+	if f.config != "" {
+		fmt.Fprintf(w, "# Effective settings, from %s and flags.\n", f.config)
+	} else {
+		fmt.Fprintln(w, "# Effective settings, from flags.")
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(settings); err != nil {
+		return err
+	}
+	return enc.Close()
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/garrettladley/snips/cmd/snips/generatecmd"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestConfigSchemaMatchesFlags(t *testing.T) {
+	var schema struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	if err := json.Unmarshal(configSchema, &schema); err != nil {
+		t.Fatalf("invalid schema: %v", err)
+	}
+	var properties []string
+	for name := range schema.Properties {
+		properties = append(properties, name)
+	}
+	slices.Sort(properties)
+
+	var flags []string
+	newGenerateFlagSet(&generateFlags{}, flag.ContinueOnError).VisitAll(func(f *flag.Flag) {
+		if !generatecmd.Unconfigurable[f.Name] {
+			flags = append(flags, f.Name)
+		}
+	})
+	if diff := cmp.Diff(flags, properties); diff != "" {
+		t.Errorf("schema properties don't match the configurable flags (-flags +schema):\n%s", diff)
+	}
+}
+
+func TestApplyConfig(t *testing.T) {
+	f := &generateFlags{}
+	cmd := newGenerateFlagSet(f, flag.ContinueOnError)
+	if err := cmd.Parse([]string{"-style", "dracula", "-var", "A=flag", "-build-tags", "internal=!docs", "-exclude", "**/legacy/**"}); err != nil {
+		t.Fatal(err)
+	}
+	sources, err := generatecmd.ApplyConfig(cmd, generatecmd.Config{Settings: map[string]any{
+		"style":        "monokai",
+		"tab-width":    4,
+		"line-numbers": true,
+		"var":          map[string]any{"A": "config", "B": 2},
+		"build-tags":   map[string]any{".": "docs", "internal": "docs"},
+		"pragmas":      []any{"//nolint:all", "//coverage:ignore"},
+		"message":      map[string]any{"source": "Quelle:"},
+		"include":      []any{"docs/**", "examples/*.code.go"},
+		"exclude":      "**/draft.code.go",
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if f.args.Style != "dracula" {
+		t.Errorf("expected the flag to take precedence, got style %q", f.args.Style)
+	}
+	if f.args.TabWidth != 4 || !f.args.Lines {
+		t.Errorf("expected config values to be applied, got tab width %d, line numbers %v", f.args.TabWidth, f.args.Lines)
+	}
+	if diff := cmp.Diff(map[string]string{"A": "flag", "B": "2"}, f.args.Vars); diff != "" {
+		t.Errorf("unexpected vars:\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string]string{".": "docs", "internal": "!docs"}, f.args.BuildTags); diff != "" {
+		t.Errorf("unexpected build tags:\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string]string{"source": "Quelle:"}, f.args.Messages); diff != "" {
+		t.Errorf("unexpected messages:\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"docs/**", "examples/*.code.go"}, f.args.Include); diff != "" {
+		t.Errorf("unexpected include:\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"**/legacy/**"}, f.args.Exclude); diff != "" {
+		t.Errorf("expected the flag to take precedence, got exclude:\n%s", diff)
+	}
+	if f.args.Header.Pragmas != "//nolint:all\n//coverage:ignore" {
+		t.Errorf("expected the pragmas to be joined by lines, got %q", f.args.Header.Pragmas)
+	}
+	for name, want := range map[string]string{
+		"style":     generatecmd.SourceFlag,
+		"tab-width": generatecmd.SourceConfig,
+		"base-line": generatecmd.SourceDefault,
+		"var.A":     generatecmd.SourceFlag,
+		"var.B":     generatecmd.SourceConfig,
+
+		"build-tags..":        generatecmd.SourceConfig,
+		"build-tags.internal": generatecmd.SourceFlag,
+	} {
+		if got := sources[name]; got != want {
+			t.Errorf("source of %s = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestApplyConfigErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		settings map[string]any
+	}{
+		{name: "unknown", settings: map[string]any{"colour": "red"}},
+		{name: "unconfigurable", settings: map[string]any{"stdout": true}},
+		{name: "invalid value", settings: map[string]any{"tab-width": "wide"}},
+		{name: "invalid type", settings: map[string]any{"style": []any{"a"}}},
+		{name: "invalid vars", settings: map[string]any{"var": "A=1"}},
+		{name: "invalid build tags", settings: map[string]any{"build-tags": 1}},
+		{name: "invalid pragmas", settings: map[string]any{"pragmas": []any{1}}},
+		{name: "invalid include", settings: map[string]any{"include": []any{true}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := newGenerateFlagSet(&generateFlags{}, flag.ContinueOnError)
+			if _, err := generatecmd.ApplyConfig(cmd, generatecmd.Config{Settings: tt.settings}); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestConfigCmd(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "snips.yaml")
+	if err := os.WriteFile(fileName, []byte("style: monokai\nsize-budget: 512KB\nbuild-tags: docs\nvar:\n  VERSION: v1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run(&stdout, &stderr, []string{"snips", "config", "validate", "-config", fileName}); code != 0 {
+		t.Fatalf("validate failed with code %d: %s", code, stderr.String())
+	}
+
+	stdout.Reset()
+	if code := run(&stdout, &stderr, []string{"snips", "config", "print-effective", "-config", fileName, "-hermetic", "-tab-width", "2"}); code != 1 {
+		t.Fatalf("expected hermetic mode without inputs to be invalid, got code %d", code)
+	}
+	if code := run(&stdout, &stderr, []string{"snips", "config", "print-effective", "-config", fileName, "-tab-width", "2"}); code != 0 {
+		t.Fatalf("print-effective failed with code %d: %s", code, stderr.String())
+	}
+	for _, expected := range []string{
+		"style: monokai # config",
+		"tab-width: 2 # flag",
+		"line-numbers: false # default",
+		"VERSION: v1 # config",
+		".: docs # config",
+	} {
+		if !strings.Contains(stdout.String(), expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, stdout.String())
+		}
+	}
+
+	if code := run(&stdout, &stderr, []string{"snips", "config", "validate", "-config", fileName, "-message", "truncated=..."}); code != 1 {
+		t.Errorf("expected an unknown message to fail validation, got code %d", code)
+	}
+
+	if err := os.WriteFile(fileName, []byte("size-budget: huge\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if code := run(&stdout, &stderr, []string{"snips", "config", "validate", "-config", fileName}); code != 1 {
+		t.Errorf("expected an invalid size budget to fail validation, got code %d", code)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err = os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	if cfg, err := generatecmd.LoadConfig(""); err != nil || cfg.FileName != "" {
+		t.Fatalf("expected no config without a config file, got %q, %v", cfg.FileName, err)
+	}
+
+	if err = os.WriteFile("snips.toml", []byte("style = \"monokai\"\ntab-width = 4\npragmas = [\"//nolint:all\", \"//coverage:ignore\"]\n\n[var]\nVERSION = \"v1\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := generatecmd.LoadConfig("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.FileName != "snips.toml" {
+		t.Errorf("expected snips.toml to be loaded, got %q", cfg.FileName)
+	}
+	f := &generateFlags{}
+	cmd := newGenerateFlagSet(f, flag.ContinueOnError)
+	if err = cmd.Parse([]string{"-style", "dracula"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = generatecmd.ApplyConfig(cmd, cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if f.args.Style != "dracula" || f.args.Vars["VERSION"] != "v1" {
+		t.Errorf("expected the flag to take precedence over snips.toml, got style %q, vars %v", f.args.Style, f.args.Vars)
+	}
+	if f.args.TabWidth != 4 || f.args.Header.Pragmas != "//nolint:all\n//coverage:ignore" {
+		t.Errorf("expected the settings of snips.toml to be applied, got tab width %d, pragmas %q", f.args.TabWidth, f.args.Header.Pragmas)
+	}
+
+	if err = os.WriteFile("snips.yaml", []byte("style: monokai\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = generatecmd.LoadConfig(""); err == nil {
+		t.Error("expected an error when both snips.yaml and snips.toml exist")
+	}
+	if cfg, err = generatecmd.LoadConfig("snips.yaml"); err != nil || cfg.Settings["style"] != "monokai" {
+		t.Errorf("expected -config to choose between them, got %v, %v", cfg.Settings, err)
+	}
+}
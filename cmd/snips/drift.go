@@ -0,0 +1,229 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"flag"
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"html"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+	"github.com/garrettladley/snips/cmd/snips/generatecmd"
+)
+
+const driftUsageText = `usage: snips drift [<args>...]
+
+Compares the generated files on disk with those generated now, e.g. after upgrading snips,
+whose chroma updates silently change highlighted output, so that upgrades land intentionally.
+Lists the files that drifted, grouped by cause:
+  content     the text of the highlighted code changed, e.g. the snippet was edited
+  lexer       the code is split into different tokens
+  style       only the colors and fonts of the tokens changed
+  generator   only the Go code around the highlighted HTML changed
+  new         the file hasn't been generated yet
+Fails if any files drifted. Accepts the args of snips generate, and reads the config file.
+Nothing is written. A -chroma-version pin is reported, instead of failing the run.
+
+Args:
+  -help
+    Print help and exit.
+`
+
+// The causes of drift, in the order they're reported.
+const (
+	driftContent   = "content"
+	driftLexer     = "lexer"
+	driftStyle     = "style"
+	driftGenerator = "generator"
+	driftNew       = "new"
+)
+
+var driftCauses = []string{driftContent, driftLexer, driftStyle, driftGenerator, driftNew}
+
+var (
+	// markupTag matches the tags of highlighted HTML, and the CSS of style
+	// elements.
+	markupTag = regexp.MustCompile(`<style>[^<]*</style>|<[^>]*>`)
+	// markupStyle matches the inline styles of highlighted HTML, and the CSS of
+	// style elements.
+	markupStyle = regexp.MustCompile(` style="[^"]*"|<style>[^<]*</style>`)
+)
+
+// generatedFiles collects generated files by their paths relative to -path.
+type generatedFiles struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+func (g *generatedFiles) WriteFile(rel string, contents []byte) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.files[rel] = slices.Clone(contents)
+	return nil
+}
+
+func driftCmd(stdout, stderr io.Writer, args []string) (code int) {
+	f := &generateFlags{}
+	f.flagSet = newGenerateFlagSet(f, flag.ContinueOnError)
+	f.flagSet.SetOutput(io.Discard)
+	if err := f.flagSet.Parse(args); err != nil || f.flagSet.NArg() > 0 {
+		fmt.Fprint(stderr, driftUsageText)
+		return 64 // EX_USAGE
+	}
+	if f.help {
+		fmt.Fprint(stdout, driftUsageText)
+		return 0
+	}
+	fail := func(err error) int {
+		color.New(color.FgRed).Fprint(stderr, "(✗) ")
+		fmt.Fprintln(stderr, "Command failed: "+err.Error())
+		return 1
+	}
+	if err := f.applyConfigFile(); err != nil {
+		return fail(err)
+	}
+	root, err := filepath.Abs(f.args.Path)
+	if err != nil {
+		return fail(fmt.Errorf("failed to get absolute path: %w", err))
+	}
+	f.args.Path = root
+
+	version := generatecmd.ChromaVersion()
+	if version == "" {
+		version = "(unknown)"
+	}
+	if pinned := f.args.ChromaVersion; pinned != "" && pinned != version {
+		fmt.Fprintf(stdout, "chroma %s, pinned %s\n", version, pinned)
+	} else {
+		fmt.Fprintf(stdout, "chroma %s\n", version)
+	}
+	f.args.ChromaVersion = ""
+
+	generated := &generatedFiles{files: make(map[string][]byte)}
+	if err = generateInto(f, "drift", generated, stderr); err != nil {
+		return fail(err)
+	}
+
+	drifted := make(map[string][]string)
+	for _, rel := range slices.Sorted(maps.Keys(generated.files)) {
+		committed, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(rel)))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fail(err)
+		}
+		var cause string
+		if err != nil {
+			cause = driftNew
+		} else if cause, err = driftCause(committed, generated.files[rel]); err != nil {
+			return fail(fmt.Errorf("%s: %w", rel, err))
+		}
+		if cause != "" {
+			drifted[cause] = append(drifted[cause], rel)
+		}
+	}
+	if len(drifted) == 0 {
+		color.New(color.FgGreen).Fprint(stderr, "(✓) ")
+		fmt.Fprintln(stderr, "No generated files drifted")
+		return 0
+	}
+	var n int
+	var summary []string
+	for _, cause := range driftCauses {
+		if len(drifted[cause]) == 0 {
+			continue
+		}
+		fmt.Fprintf(stdout, "%s (%d):\n", cause, len(drifted[cause]))
+		for _, rel := range drifted[cause] {
+			fmt.Fprintln(stdout, "  "+rel)
+		}
+		n += len(drifted[cause])
+		summary = append(summary, fmt.Sprintf("%d %s", len(drifted[cause]), cause))
+	}
+	color.New(color.FgRed).Fprint(stderr, "(✗) ")
+	fmt.Fprintf(stderr, "%d generated files drifted: %s, run snips generate to update them\n", n, strings.Join(summary, ", "))
+	return 1
+}
+
+// driftCause returns why the generated file drifted from the committed one,
+// or "" if they're the same. The highlighted HTML of both is compared as text,
+// then as markup without inline styles, then with them.
+func driftCause(committed, generated []byte) (cause string, err error) {
+	if bytes.Equal(committed, generated) {
+		return "", nil
+	}
+	before, err := stringLiterals(committed)
+	if err != nil {
+		return "", err
+	}
+	after, err := stringLiterals(generated)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case htmlText(before) != htmlText(after):
+		return driftContent, nil
+	case markupStyle.ReplaceAllString(before, "") != markupStyle.ReplaceAllString(after, ""):
+		return driftLexer, nil
+	case before != after:
+		return driftStyle, nil
+	}
+	return driftGenerator, nil
+}
+
+// stringLiterals returns the string literals of a Go file, which hold the
+// highlighted HTML, concatenated. Streamed components are decompressed.
+func stringLiterals(src []byte) (string, error) {
+	var s scanner.Scanner
+	fset := token.NewFileSet()
+	var errs scanner.ErrorList
+	s.Init(fset.AddFile("", fset.Base(), len(src)), src, func(pos token.Position, msg string) {
+		errs.Add(pos, msg)
+	}, 0)
+	var sb strings.Builder
+	for {
+		_, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok != token.STRING {
+			continue
+		}
+		value, err := strconv.Unquote(lit)
+		if err != nil {
+			return "", err
+		}
+		if strings.HasPrefix(value, "\x1f\x8b") {
+			if value, err = gunzip(value); err != nil {
+				return "", err
+			}
+		}
+		sb.WriteString(value)
+	}
+	return sb.String(), errs.Err()
+}
+
+// gunzip decompresses the blob of a streamed component.
+func gunzip(blob string) (string, error) {
+	zr, err := gzip.NewReader(strings.NewReader(blob))
+	if err != nil {
+		return "", err
+	}
+	b, err := io.ReadAll(zr)
+	return string(b), err
+}
+
+// htmlText returns the text of HTML, without its tags.
+func htmlText(s string) string {
+	return html.UnescapeString(markupTag.ReplaceAllString(s, ""))
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDriftCmd(t *testing.T) {
+	dir := writeSnippets(t, map[string]string{"main.code.go": "package main\n"})
+	snippet := filepath.Join(dir, "main.code.go")
+	drift := func(args ...string) (code int, stdout string) {
+		t.Helper()
+		var out, stderr bytes.Buffer
+		code = run(&out, &stderr, append([]string{"snips", "drift", "-path", dir}, args...))
+		return code, out.String()
+	}
+
+	if code, stdout := drift(); code != 1 || !strings.Contains(stdout, "new (1):\n  main.code.go_templ.go\n") {
+		t.Fatalf("expected the generated file to be new, got code %d:\n%s", code, stdout)
+	}
+	var stdout, stderr bytes.Buffer
+	if code := run(&stdout, &stderr, []string{"snips", "generate", "-path", dir}); code != 0 {
+		t.Fatalf("generate failed with code %d: %s", code, stderr.String())
+	}
+	if code, stdout := drift(); code != 0 {
+		t.Fatalf("expected no drift, got code %d:\n%s", code, stdout)
+	}
+	if code, stdout := drift("-chroma-version", "v0.0.0"); code != 0 || !strings.Contains(stdout, ", pinned v0.0.0\n") {
+		t.Errorf("expected the pin to be reported, got code %d:\n%s", code, stdout)
+	}
+	if err := os.WriteFile(snippet, []byte("package docs\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if code, stdout := drift(); code != 1 || !strings.Contains(stdout, "content (1):\n") {
+		t.Errorf("expected a content change, got code %d:\n%s", code, stdout)
+	}
+}
+
+func TestDriftCause(t *testing.T) {
+	file := func(html string) []byte {
+		return []byte("package docs\n\nfunc f() {\n\tw.WriteString(`" + html + "`)\n}\n")
+	}
+	tests := []struct {
+		name                 string
+		committed, generated []byte
+		expected             string
+	}{
+		{
+			name:      "unchanged",
+			committed: file(`<span style="color:#000">x</span>`),
+			generated: file(`<span style="color:#000">x</span>`),
+		},
+		{
+			name:      "content",
+			committed: file(`<span style="color:#000">x</span>`),
+			generated: file(`<span style="color:#000">y</span>`),
+			expected:  driftContent,
+		},
+		{
+			name:      "lexer",
+			committed: file(`<span style="color:#000">x</span>y`),
+			generated: file(`<span style="color:#000">x</span><span>y</span>`),
+			expected:  driftLexer,
+		},
+		{
+			name:      "style",
+			committed: file(`<span style="color:#000">x</span>`),
+			generated: file(`<span style="color:#fff">x</span>`),
+			expected:  driftStyle,
+		},
+		{
+			name:      "generator",
+			committed: file(`<span>x</span>`),
+			generated: []byte("package docs\n\nfunc f() {\n\t_, _ = w.WriteString(`<span>x</span>`)\n}\n"),
+			expected:  driftGenerator,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cause, err := driftCause(tt.committed, tt.generated)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cause != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, cause)
+			}
+		})
+	}
+}
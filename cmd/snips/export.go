@@ -0,0 +1,287 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/garrettladley/snips"
+	"github.com/garrettladley/snips/cmd/snips/generatecmd"
+	"github.com/garrettladley/snips/runtime"
+)
+
+const exportUsageText = `usage: snips export -o <file> [<args>...]
+
+Generates all snippets into a gzipped tar archive, for consumers that don't build from the
+repository, instead of writing generated files. Accepts the args of snips generate, and reads
+the config file, so that the archive matches what generate writes. Paths are relative to -path:
+  go/<dir>/<snippet>_templ.go   generated code, and the -dedupe package
+  html/<dir>/<Component>.html   highlighted HTML of each component
+  css/snips.css                 styles of wrappers and of the runtime package
+  css/themes.css                CSS of the light and dark styles, with -themes
+  manifest.json                 the components, with their slug front matter, and the SHA-256
+                                hash of every file
+Entries are sorted, and their modification times zeroed, so that the same snippets give the
+same archive.
+
+Args:
+  -o <file>
+    Writes the archive to file, or to stdout with -.
+  -help
+    Print help and exit.
+`
+
+// exportManifest lists the contents of an export archive.
+type exportManifest struct {
+	// Version of snips that generated the archive.
+	Version    string              `json:"version"`
+	Components []exportedComponent `json:"components"`
+	Files      []exportedFile      `json:"files"`
+}
+
+// exportedComponent is a generated component of an export archive.
+type exportedComponent struct {
+	Name string `json:"name"`
+	// Slug is the slug front matter of the snippet, set on its main
+	// component, to key components by URL-safe names.
+	Slug string `json:"slug,omitempty"`
+	// Snippet is the path of the snippet, relative to -path.
+	Snippet string `json:"snippet"`
+	// Go and HTML are the paths in the archive of the generated code and the
+	// highlighted HTML of the component.
+	Go   string `json:"go,omitempty"`
+	HTML string `json:"html"`
+}
+
+// exportedFile is a file of an export archive.
+type exportedFile struct {
+	Path   string `json:"path"`
+	SHA256 string `json:"sha256"`
+	Size   int    `json:"size"`
+}
+
+// exportArchive collects the files generated for an export archive.
+type exportArchive struct {
+	// root is the absolute path that archive paths are relative to.
+	root string
+
+	mu sync.Mutex
+	// files by path in the archive.
+	files map[string][]byte
+	// goFiles are the archive paths of generated code, by snippet path.
+	goFiles    map[string]string
+	components []exportedComponent
+}
+
+func newExportArchive(root string) *exportArchive {
+	return &exportArchive{
+		root:    root,
+		files:   make(map[string][]byte),
+		goFiles: make(map[string]string),
+	}
+}
+
+// rel returns the slash separated path of fileName relative to the root.
+func (a *exportArchive) rel(fileName string) (string, error) {
+	rel, err := filepath.Rel(a.root, fileName)
+	if err != nil {
+		return "", err
+	}
+	rel = filepath.ToSlash(rel)
+	if rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", fmt.Errorf("%q is outside of %q", fileName, a.root)
+	}
+	return rel, nil
+}
+
+// WriteFile adds a generated file to the archive, given its name relative to
+// the root, as -out writers are.
+func (a *exportArchive) WriteFile(rel string, contents []byte) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.files["go/"+rel] = slices.Clone(contents)
+	if snippet, ok := strings.CutSuffix(rel, "_templ_test.go"); ok {
+		a.goFiles[snippet] = "go/" + rel
+	} else if snippet, ok := strings.CutSuffix(rel, "_templ.go"); ok {
+		a.goFiles[snippet] = "go/" + rel
+	}
+	return nil
+}
+
+// addHTML adds the highlighted HTML of a component to the archive.
+func (a *exportArchive) addHTML(fileName, componentName, html string) {
+	snippet, err := a.rel(fileName)
+	if err != nil {
+		// Snippets are always found within the root.
+		return
+	}
+	name := path.Join("html", path.Dir(snippet), componentName+".html")
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.files[name] = []byte(html)
+	c := exportedComponent{Name: componentName, Snippet: snippet, HTML: name}
+	if componentName == generatecmd.ComponentName(fileName) {
+		// The snippet generated, so its front matter is valid.
+		if contents, err := os.ReadFile(fileName); err == nil {
+			fm, _, _ := snips.ParseFrontMatter(contents)
+			c.Slug = fm.Slug
+		}
+	}
+	a.components = append(a.components, c)
+}
+
+// add adds a file to the archive.
+func (a *exportArchive) add(name string, contents []byte) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.files[name] = contents
+}
+
+// writeFile writes the archive to the named file.
+func (a *exportArchive) writeFile(name string) error {
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err = a.write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// write writes the archive, with the manifest last.
+func (a *exportArchive) write(w io.Writer) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	manifest := exportManifest{Version: strings.TrimSpace(snips.Version()), Components: a.components, Files: []exportedFile{}}
+	for i, c := range manifest.Components {
+		manifest.Components[i].Go = a.goFiles[c.Snippet]
+	}
+	slices.SortFunc(manifest.Components, func(a, b exportedComponent) int {
+		return strings.Compare(a.HTML, b.HTML)
+	})
+	slugs := make(map[string]string)
+	for _, c := range manifest.Components {
+		if c.Slug == "" {
+			continue
+		}
+		if other, ok := slugs[c.Slug]; ok {
+			return fmt.Errorf("duplicate slug %q of %s and %s", c.Slug, other, c.Snippet)
+		}
+		slugs[c.Slug] = c.Snippet
+	}
+	names := slices.Sorted(maps.Keys(a.files))
+	for _, name := range names {
+		hash := sha256.Sum256(a.files[name])
+		manifest.Files = append(manifest.Files, exportedFile{Path: name, SHA256: hex.EncodeToString(hash[:]), Size: len(a.files[name])})
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(w)
+	tw := tar.NewWriter(zw)
+	add := func(name string, contents []byte) error {
+		err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     name,
+			Mode:     0o644,
+			Size:     int64(len(contents)),
+			ModTime:  time.Unix(0, 0),
+			Format:   tar.FormatUSTAR,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to add %q: %w", name, err)
+		}
+		_, err = tw.Write(contents)
+		return err
+	}
+	for _, name := range names {
+		if err = add(name, a.files[name]); err != nil {
+			return err
+		}
+	}
+	if err = add("manifest.json", append(data, '\n')); err != nil {
+		return err
+	}
+	if err = tw.Close(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func exportCmd(stdout, stderr io.Writer, args []string) (code int) {
+	f := &generateFlags{}
+	f.flagSet = newGenerateFlagSet(f, flag.ContinueOnError)
+	f.flagSet.SetOutput(io.Discard)
+	out := f.flagSet.String("o", "", "")
+	if err := f.flagSet.Parse(args); err != nil || f.flagSet.NArg() > 0 {
+		fmt.Fprint(stderr, exportUsageText)
+		return 64 // EX_USAGE
+	}
+	if f.help {
+		fmt.Fprint(stdout, exportUsageText)
+		return 0
+	}
+	fail := func(err error) int {
+		color.New(color.FgRed).Fprint(stderr, "(✗) ")
+		fmt.Fprintln(stderr, "Command failed: "+err.Error())
+		return 1
+	}
+	if *out == "" {
+		fmt.Fprint(stderr, exportUsageText)
+		return 64 // EX_USAGE
+	}
+	if err := f.applyConfigFile(); err != nil {
+		return fail(err)
+	}
+	root, err := filepath.Abs(f.args.Path)
+	if err != nil {
+		return fail(fmt.Errorf("failed to get absolute path: %w", err))
+	}
+	f.args.Path = root
+	archive := newExportArchive(root)
+	f.args.HTMLReport = archive.addHTML
+	if err = generateInto(f, "export", archive, stderr); err != nil {
+		return fail(err)
+	}
+
+	archive.add("css/snips.css", []byte(runtime.CSS()+"\n"))
+	if f.args.Themes != "" {
+		light, dark, err := generatecmd.NewGenerate(nil, f.args).Themes()
+		if err != nil {
+			return fail(err)
+		}
+		css, err := runtime.ThemeCSS(light, dark)
+		if err != nil {
+			return fail(fmt.Errorf("invalid themes: %w", err))
+		}
+		archive.add("css/themes.css", []byte(css+"\n"))
+	}
+
+	if *out == "-" {
+		err = archive.write(stdout)
+	} else {
+		err = archive.writeFile(*out)
+	}
+	if err != nil {
+		return fail(fmt.Errorf("failed to write archive: %w", err))
+	}
+	return 0
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExportCmd(t *testing.T) {
+	dir := writeSnippets(t, map[string]string{
+		"main.code.go":         "---\nslug: main-example\n---\npackage main\n",
+		"views/query.code.sql": "SELECT 1;\n",
+	})
+
+	export := func() []byte {
+		t.Helper()
+		var stdout, stderr bytes.Buffer
+		if code := run(&stdout, &stderr, []string{"snips", "export", "-path", dir, "-o", "-", "-themes", "github,monokai", "-line-numbers", "-linkable-lines"}); code != 0 {
+			t.Fatalf("export failed with code %d: %s", code, stderr.String())
+		}
+		return stdout.Bytes()
+	}
+	archive := export()
+	if !bytes.Equal(export(), archive) {
+		t.Error("expected exporting the same snippets to give the same archive")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "main.code.go_templ.go")); !os.IsNotExist(err) {
+		t.Errorf("expected no generated files to be written, got %v", err)
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(zr)
+	files := make(map[string][]byte)
+	var names []string
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if files[h.Name], err = io.ReadAll(tr); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, h.Name)
+	}
+	expected := []string{
+		"css/snips.css",
+		"css/themes.css",
+		"go/main.code.go_templ.go",
+		"go/views/query.code.sql_templ.go",
+		"html/MainGo.html",
+		"html/views/QuerySql.html",
+		"manifest.json",
+	}
+	if diff := cmp.Diff(expected, names); diff != "" {
+		t.Errorf("unexpected entries (-want +got):\n%s", diff)
+	}
+
+	var manifest exportManifest
+	if err := json.Unmarshal(files["manifest.json"], &manifest); err != nil {
+		t.Fatalf("invalid manifest: %v", err)
+	}
+	expectedComponents := []exportedComponent{
+		{Name: "MainGo", Slug: "main-example", Snippet: "main.code.go", Go: "go/main.code.go_templ.go", HTML: "html/MainGo.html"},
+		{Name: "QuerySql", Snippet: "views/query.code.sql", Go: "go/views/query.code.sql_templ.go", HTML: "html/views/QuerySql.html"},
+	}
+	if diff := cmp.Diff(expectedComponents, manifest.Components); diff != "" {
+		t.Errorf("unexpected components (-want +got):\n%s", diff)
+	}
+	if len(manifest.Files) != len(expected)-1 {
+		t.Errorf("expected every file but the manifest to be listed, got %+v", manifest.Files)
+	}
+	for _, f := range manifest.Files {
+		hash := sha256.Sum256(files[f.Path])
+		if f.SHA256 != hex.EncodeToString(hash[:]) || f.Size != len(files[f.Path]) {
+			t.Errorf("the hash or size of %s doesn't match its contents", f.Path)
+		}
+	}
+	if !bytes.Contains(files["html/MainGo.html"], []byte(`<pre class="chroma">`)) {
+		t.Errorf("expected the HTML of the component, got:\n%s", files["html/MainGo.html"])
+	}
+	if !bytes.Contains(files["html/MainGo.html"], []byte(`id="main-example"`)) || !bytes.Contains(files["html/MainGo.html"], []byte(`href="#main-example-L0"`)) {
+		t.Errorf("expected the slug to set the id and line anchors, got:\n%s", files["html/MainGo.html"])
+	}
+	if !bytes.Contains(files["html/views/QuerySql.html"], []byte(`href="#L0"`)) {
+		t.Errorf("expected snippets without a slug to keep their line anchors, got:\n%s", files["html/views/QuerySql.html"])
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/fatih/color"
+	"github.com/garrettladley/snips"
+	"github.com/garrettladley/snips/cmd/snips/generatecmd/watcher"
+)
+
+const fmtUsageText = `usage: snips fmt [<args>...]
+
+Formats snippets, so that hand edits produce clean diffs: orders front matter keys, trims
+trailing whitespace and trailing blank lines, and respells snips:region, snips:endregion,
+snips:redact and snips:ignore directives, e.g. "#snips: End-Region" becomes
+"# snips:endregion". Trailing whitespace is kept in diff and Markdown snippets, where it's
+significant.
+
+Args:
+  -path <path>
+    Formats all snippets in path. (default .)
+  -f <file>
+    Optionally formats a single snippet, e.g. -f snippet.code.go
+  -check
+    Lists the snippets that aren't formatted instead of rewriting them, failing if there are
+    any. (default false)
+  -help
+    Print help and exit.
+`
+
+func fmtCmd(stdout, stderr io.Writer, args []string) (code int) {
+	cmd := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	cmd.SetOutput(io.Discard)
+	path := cmd.String("path", ".", "")
+	fileName := cmd.String("f", "", "")
+	check := cmd.Bool("check", false, "")
+	help := cmd.Bool("help", false, "")
+	if err := cmd.Parse(args); err != nil || cmd.NArg() > 0 {
+		fmt.Fprint(stderr, fmtUsageText)
+		return 64 // EX_USAGE
+	}
+	if *help {
+		fmt.Fprint(stdout, fmtUsageText)
+		return 0
+	}
+
+	fileNames := []string{*fileName}
+	if *fileName == "" {
+		var err error
+		if fileNames, err = snippetFiles(*path); err != nil {
+			color.New(color.FgRed).Fprint(stderr, "(✗) ")
+			fmt.Fprintln(stderr, "Command failed: "+err.Error())
+			return 1
+		}
+	}
+	unformatted, err := formatSnippets(fileNames, !*check)
+	if err != nil {
+		color.New(color.FgRed).Fprint(stderr, "(✗) ")
+		fmt.Fprintln(stderr, "Command failed: "+err.Error())
+		return 1
+	}
+	for _, name := range unformatted {
+		fmt.Fprintln(stdout, name)
+	}
+	if *check && len(unformatted) > 0 {
+		color.New(color.FgRed).Fprint(stderr, "(✗) ")
+		fmt.Fprintf(stderr, "%d snippets aren't formatted, run snips fmt\n", len(unformatted))
+		return 1
+	}
+	return 0
+}
+
+// snippetFiles returns the snippets in path, skipping the directories that
+// generate skips.
+func snippetFiles(path string) (fileNames []string, err error) {
+	err = filepath.WalkDir(path, func(fileName string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			rel, err := filepath.Rel(path, fileName)
+			if err != nil {
+				return err
+			}
+			if watcher.SkipDir(filepath.ToSlash(rel)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if snips.ContainsDotCodeDot(d.Name()) {
+			fileNames = append(fileNames, fileName)
+		}
+		return nil
+	})
+	return fileNames, err
+}
+
+// formatSnippets returns the snippets that aren't formatted, rewriting them if
+// write is set.
+func formatSnippets(fileNames []string, write bool) (unformatted []string, err error) {
+	for _, fileName := range fileNames {
+		contents, err := os.ReadFile(fileName)
+		if err != nil {
+			return unformatted, fmt.Errorf("failed to read %q: %w", fileName, err)
+		}
+		formatted, err := snips.Format(fileName, contents)
+		if err != nil {
+			return unformatted, fmt.Errorf("%s: %w", fileName, err)
+		}
+		if bytes.Equal(formatted, contents) {
+			continue
+		}
+		unformatted = append(unformatted, fileName)
+		if !write {
+			continue
+		}
+		info, err := os.Stat(fileName)
+		if err != nil {
+			return unformatted, err
+		}
+		if err = os.WriteFile(fileName, formatted, info.Mode().Perm()); err != nil {
+			return unformatted, fmt.Errorf("failed to write %q: %w", fileName, err)
+		}
+	}
+	return unformatted, nil
+}
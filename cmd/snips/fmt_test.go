@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFmtCmd(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"formatted.code.go":          "package main\n",
+		"unformatted.code.go":        "package main  \n\n",
+		"vendor/skipped.code.go":     "package main  \n",
+		"docs/unformatted.code.yaml": "---\nid: config\nclass: example\n---\nkey: value\n",
+	}
+	for name, contents := range files {
+		fileName := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(fileName), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fileName, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run(&stdout, &stderr, []string{"snips", "fmt", "-path", dir, "-check"}); code != 1 {
+		t.Fatalf("expected -check to fail, got code %d: %s", code, stderr.String())
+	}
+	expected := filepath.Join(dir, "docs", "unformatted.code.yaml") + "\n" + filepath.Join(dir, "unformatted.code.go") + "\n"
+	if stdout.String() != expected {
+		t.Errorf("expected the unformatted snippets to be listed:\n%s\ngot:\n%s", expected, stdout.String())
+	}
+
+	stdout.Reset()
+	if code := run(&stdout, &stderr, []string{"snips", "fmt", "-path", dir}); code != 0 {
+		t.Fatalf("fmt failed with code %d: %s", code, stderr.String())
+	}
+	for name, want := range map[string]string{
+		"unformatted.code.go":        "package main\n",
+		"vendor/skipped.code.go":     "package main  \n",
+		"docs/unformatted.code.yaml": "---\nclass: example\nid: config\n---\nkey: value\n",
+	} {
+		contents, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil || string(contents) != want {
+			t.Errorf("expected %s to be %q, got %q, %v", name, want, contents, err)
+		}
+	}
+
+	stdout.Reset()
+	if code := run(&stdout, &stderr, []string{"snips", "fmt", "-path", dir, "-check"}); code != 0 || strings.TrimSpace(stdout.String()) != "" {
+		t.Errorf("expected the snippets to be formatted, got code %d: %s", code, stdout.String())
+	}
+}
@@ -0,0 +1,194 @@
+package generatecmd
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/garrettladley/snips/generator"
+)
+
+// aggregateFileName is the name of the file that the snippets of a directory
+// are generated into with -aggregate-dirs.
+const aggregateFileName = "snips_generated_templ.go"
+
+// aggregates collects the generated code of the snippets of each directory,
+// and writes it to a single file per directory, with a function per snippet,
+// so that folders with hundreds of tiny snippets don't have as many files to
+// compile.
+type aggregates struct {
+	// forceOverwrite overwrites files that were edited by hand.
+	forceOverwrite bool
+
+	mu sync.Mutex
+	// code by directory, then snippet file name.
+	code map[string]map[string][]byte
+	// dirty directories, whose file needs to be written.
+	dirty map[string]struct{}
+}
+
+func newAggregates() *aggregates {
+	return &aggregates{
+		code:  make(map[string]map[string][]byte),
+		dirty: make(map[string]struct{}),
+	}
+}
+
+// set records the generated code of a snippet, reporting whether it changed.
+func (a *aggregates) set(fileName string, code []byte) (changed bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	dir := filepath.Dir(fileName)
+	files, ok := a.code[dir]
+	if !ok {
+		files = make(map[string][]byte)
+		a.code[dir] = files
+	}
+	if prev, ok := files[fileName]; ok && string(prev) == string(code) {
+		return false
+	}
+	files[fileName] = code
+	a.dirty[dir] = struct{}{}
+	return true
+}
+
+// remove forgets a deleted snippet.
+func (a *aggregates) remove(fileName string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	dir := filepath.Dir(fileName)
+	if _, ok := a.code[dir][fileName]; ok {
+		delete(a.code[dir], fileName)
+		a.dirty[dir] = struct{}{}
+	}
+}
+
+// write the file of each directory that has changed since the last write.
+// The file is removed from directories that no longer have any snippets.
+func (a *aggregates) write(writer Writer) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var errs []error
+	for dir := range a.dirty {
+		if err := a.writeDir(dir, writer); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		delete(a.dirty, dir)
+	}
+	return errors.Join(errs...)
+}
+
+func (a *aggregates) writeDir(dir string, writer Writer) error {
+	fileName := filepath.Join(dir, aggregateFileName)
+	files := a.code[dir]
+	if len(files) == 0 {
+		if _, err := os.Stat(fileName); errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return writeOutputs(writer, outputs{removes: []string{fileName}})
+	}
+	var sources []namedSource
+	for _, snippet := range slices.Sorted(maps.Keys(files)) {
+		sources = append(sources, namedSource{name: filepath.Base(snippet), src: files[snippet]})
+	}
+	code, err := mergeSources(sources)
+	if err != nil {
+		return fmt.Errorf("failed to merge the generated code of %q: %w", dir, err)
+	}
+	// The header of the first snippet is kept, with its hash.
+	code = generator.StampProvenance(code)
+	if existing, err := os.ReadFile(fileName); err == nil && !a.forceOverwrite {
+		if err = checkHandEdited(fileName, existing); err != nil {
+			return err
+		}
+	}
+	return writeOutputs(writer, outputs{writes: []output{{name: fileName, contents: code}}})
+}
+
+// namedSource is the generated code of a snippet.
+type namedSource struct {
+	name string
+	src  []byte
+}
+
+// mergeSources merges the generated files of the snippets of a package into
+// one, keeping the header of the first, the imports of all of them, and the
+// declarations of each after a comment naming its snippet.
+func mergeSources(sources []namedSource) ([]byte, error) {
+	var head string
+	// imports are the import specs, by path and spec.
+	imports := make(map[[2]string]struct{})
+	var bodies strings.Builder
+	for i, s := range sources {
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, s.name, s.src, parser.ParseComments)
+		if err != nil {
+			return nil, err
+		}
+		// The declarations start after the imports, or the package clause.
+		bodyStart := fset.Position(f.Name.End()).Offset
+		headEnd := bodyStart
+		for j, decl := range f.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.IMPORT {
+				break
+			}
+			if j == 0 {
+				headEnd = fset.Position(gen.Pos()).Offset
+			}
+			bodyStart = fset.Position(gen.End()).Offset
+		}
+		if i == 0 {
+			head = string(s.src[:headEnd])
+		}
+		for _, spec := range f.Imports {
+			var name string
+			if spec.Name != nil {
+				name = spec.Name.Name + " "
+			}
+			imports[[2]string{spec.Path.Value, name + spec.Path.Value}] = struct{}{}
+		}
+		bodies.WriteString("\n// Generated from " + s.name + ".\n")
+		bodies.Write(s.src[bodyStart:])
+	}
+
+	var sb strings.Builder
+	sb.WriteString(head)
+	if len(imports) > 0 {
+		// The imports are laid out like those of generated files, the standard
+		// library first, then other packages, each sorted by path.
+		var std, other []string
+		for _, spec := range slices.SortedFunc(maps.Keys(imports), func(a, b [2]string) int {
+			return cmp.Or(strings.Compare(a[0], b[0]), strings.Compare(a[1], b[1]))
+		}) {
+			path, err := strconv.Unquote(spec[0])
+			if err != nil {
+				return nil, err
+			}
+			if generator.IsStandardImportPath(path) {
+				std = append(std, "\t"+spec[1]+"\n")
+			} else {
+				other = append(other, "\t"+spec[1]+"\n")
+			}
+		}
+		sb.WriteString("\nimport (\n" + strings.Join(std, ""))
+		if len(std) > 0 && len(other) > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(strings.Join(other, "") + ")\n")
+	}
+	sb.WriteString(bodies.String())
+	return format.Source([]byte(sb.String()))
+}
@@ -0,0 +1,133 @@
+package generatecmd
+
+import (
+	"context"
+	"go/parser"
+	"go/token"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMergeSources(t *testing.T) {
+	merged, err := mergeSources([]namedSource{
+		{name: "a.code.go", src: []byte("// Code generated by snips - DO NOT EDIT.\n\npackage docs\n\nimport \"github.com/a-h/templ\"\nimport templruntime \"github.com/a-h/templ/runtime\"\n\nfunc AGo() templ.Component { return templruntime.GeneratedTemplate(nil) }\n\nvar _ = templruntime.GeneratedTemplate\n")},
+		{name: "b.code.sh", src: []byte("// Code generated by snips - DO NOT EDIT.\n\npackage docs\n\nimport \"compress/gzip\"\nimport \"github.com/a-h/templ\"\n\nconst snipsBlobBSh = \"\"\n\nfunc BSh() templ.Component { _ = gzip.NewReader; return nil }\n")},
+	})
+	if err != nil {
+		t.Fatalf("failed to merge: %v", err)
+	}
+	s := string(merged)
+	f, err := parser.ParseFile(token.NewFileSet(), "", merged, parser.ImportsOnly)
+	if err != nil {
+		t.Fatalf("merged invalid Go: %v\n%s", err, s)
+	}
+	if len(f.Imports) != 3 {
+		t.Errorf("expected the 3 distinct imports, got %d:\n%s", len(f.Imports), s)
+	}
+	if strings.Count(s, "// Code generated by snips - DO NOT EDIT.") != 1 {
+		t.Errorf("expected a single header, got:\n%s", s)
+	}
+	for _, expected := range []string{
+		"package docs",
+		"// Generated from a.code.go.",
+		"func AGo() templ.Component",
+		"// Generated from b.code.sh.",
+		"const snipsBlobBSh",
+		"func BSh() templ.Component",
+	} {
+		if !strings.Contains(s, expected) {
+			t.Errorf("expected merged code to contain %q, got:\n%s", expected, s)
+		}
+	}
+}
+
+func TestAggregatesWrite(t *testing.T) {
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, aggregateFileName)
+	src := func(component string) []byte {
+		return []byte("package docs\n\nimport \"github.com/a-h/templ\"\n\nfunc " + component + "() templ.Component { return nil }\n")
+	}
+
+	a := newAggregates()
+	if !a.set(filepath.Join(dir, "a.code.go"), src("AGo")) || !a.set(filepath.Join(dir, "b.code.go"), src("BGo")) {
+		t.Fatal("expected new snippets to change the directory")
+	}
+	if err := a.write(localWriter{}); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+	written, err := os.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("expected the directory's file to be written: %v", err)
+	}
+	if !strings.Contains(string(written), "func AGo()") || !strings.Contains(string(written), "func BGo()") {
+		t.Errorf("expected both components, got:\n%s", written)
+	}
+
+	if a.set(filepath.Join(dir, "a.code.go"), src("AGo")) {
+		t.Error("expected unchanged code not to change the directory")
+	}
+	a.remove(filepath.Join(dir, "a.code.go"))
+	if err = a.write(localWriter{}); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+	if written, err = os.ReadFile(fileName); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(written), "func AGo()") {
+		t.Errorf("expected the removed snippet's component to be dropped, got:\n%s", written)
+	}
+
+	// The file is removed with the last snippet.
+	a.remove(filepath.Join(dir, "b.code.go"))
+	if err = a.write(localWriter{}); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+	if _, err = os.Stat(fileName); !os.IsNotExist(err) {
+		t.Errorf("expected the directory's file to be removed, got %v", err)
+	}
+}
+
+func TestGenerateAggregateDirs(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "docs")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, contents := range map[string]string{
+		"a.code.go":          "package main\n",
+		"b.code.sh":          "echo hello\n",
+		"a.code.go_templ.go": "package docs\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	if err := Run(context.Background(), log, Arguments{Path: dir, AggregateDirs: true, NoLock: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	written, err := os.ReadFile(filepath.Join(dir, aggregateFileName))
+	if err != nil {
+		t.Fatalf("expected the directory's file to be written: %v", err)
+	}
+	for _, expected := range []string{"func AGo() templ.Component", "func BSh() templ.Component"} {
+		if !strings.Contains(string(written), expected) {
+			t.Errorf("expected %q, got:\n%s", expected, written)
+		}
+	}
+	if _, err = os.Stat(filepath.Join(dir, "a.code.go_templ.go")); !os.IsNotExist(err) {
+		t.Errorf("expected the snippet's own generated file to be removed, got %v", err)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "b.code.sh_templ.go")); !os.IsNotExist(err) {
+		t.Errorf("expected no file per snippet, got %v", err)
+	}
+
+	err = Run(context.Background(), log, Arguments{Path: dir, AggregateDirs: true, FileName: filepath.Join(dir, "a.code.go")})
+	if err == nil || !strings.Contains(err.Error(), "-aggregate-dirs") {
+		t.Errorf("expected -f to be refused, got %v", err)
+	}
+}
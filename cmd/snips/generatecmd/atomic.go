@@ -0,0 +1,174 @@
+package generatecmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// output is a file generated from a snippet.
+type output struct {
+	name     string
+	contents []byte
+}
+
+// outputs are the changes to the generated files of a snippet, which are
+// made together, so that a failure never leaves them mismatched.
+type outputs struct {
+	writes []output
+	// removes are the names of files to remove, e.g. the code generated into
+	// another sub-package before.
+	removes []string
+}
+
+func (o outputs) empty() bool {
+	return len(o.writes) == 0 && len(o.removes) == 0
+}
+
+// transactionWriter is a Writer that can make the changes to the generated
+// files of a snippet as a transaction, all or none of them.
+type transactionWriter interface {
+	Writer
+	WriteFiles(o outputs) error
+}
+
+// Remover is a Writer that can remove the generated files it wrote, e.g. those
+// of deleted snippets. The stale files of Writers that aren't Removers are left
+// in place.
+type Remover interface {
+	Writer
+	// Remove removes the file name, returning an error satisfying
+	// errors.Is(err, fs.ErrNotExist) if it doesn't exist.
+	Remove(name string) error
+}
+
+// writeOutputs makes the changes to the generated files of a snippet, as a
+// transaction if w supports it, and otherwise one after the other.
+func writeOutputs(w Writer, o outputs) error {
+	if tw, ok := w.(transactionWriter); ok {
+		return tw.WriteFiles(o)
+	}
+	for _, out := range o.writes {
+		if err := w.WriteFile(out.name, out.contents); err != nil {
+			return fmt.Errorf("failed to write target file %q: %w", out.name, err)
+		}
+	}
+	r, ok := w.(Remover)
+	if !ok {
+		return nil
+	}
+	for _, name := range o.removes {
+		if err := r.Remove(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove stale generated file %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// localWriter writes generated files to the local filesystem, replacing each
+// file by renaming a temporary file over it, so that readers never see a
+// partially written file.
+type localWriter struct{}
+
+func (localWriter) WriteFile(name string, contents []byte) error {
+	return localWriter{}.WriteFiles(outputs{writes: []output{{name: name, contents: contents}}})
+}
+
+// WriteFiles stages the files to write as temporary files beside them, then
+// moves the files they replace, and those to remove, aside, before renaming
+// the temporary files into place. If any step fails, the files moved aside
+// are restored.
+func (localWriter) WriteFiles(o outputs) (err error) {
+	// The names of temporary files don't contain .code., so that they aren't
+	// mistaken for snippets by watchers.
+	var staged []string
+	defer func() {
+		for _, tmp := range staged {
+			os.Remove(tmp)
+		}
+	}()
+	for _, out := range o.writes {
+		tmp, err := stageFile(out)
+		if err != nil {
+			return fmt.Errorf("failed to write target file %q: %w", out.name, err)
+		}
+		staged = append(staged, tmp)
+	}
+
+	// moved are the files moved aside, by their backups, and written are the
+	// files renamed into place, which are undone on failure.
+	moved := make(map[string]string)
+	var written []string
+	defer func() {
+		if err != nil {
+			for _, name := range written {
+				os.Remove(name)
+			}
+			for backup, name := range moved {
+				os.Rename(backup, name)
+			}
+			return
+		}
+		for backup := range moved {
+			os.Remove(backup)
+		}
+	}()
+	moveAside := func(name string) error {
+		backup, err := reserveName(filepath.Dir(name), ".snips-*.bak")
+		if err != nil {
+			return err
+		}
+		if err = os.Rename(name, backup); errors.Is(err, os.ErrNotExist) {
+			os.Remove(backup)
+			return nil
+		}
+		if err != nil {
+			os.Remove(backup)
+			return err
+		}
+		moved[backup] = name
+		return nil
+	}
+	for _, name := range o.removes {
+		if err = moveAside(name); err != nil {
+			return fmt.Errorf("failed to remove stale generated file %q: %w", name, err)
+		}
+	}
+	for i, out := range o.writes {
+		if err = moveAside(out.name); err != nil {
+			return fmt.Errorf("failed to write target file %q: %w", out.name, err)
+		}
+		if err = os.Rename(staged[i], out.name); err != nil {
+			return fmt.Errorf("failed to write target file %q: %w", out.name, err)
+		}
+		written = append(written, out.name)
+	}
+	return nil
+}
+
+// stageFile writes the contents of out to a temporary file in its directory,
+// returning its name.
+func stageFile(out output) (name string, err error) {
+	f, err := os.CreateTemp(filepath.Dir(out.name), ".snips-*.tmp")
+	if err != nil {
+		return "", err
+	}
+	_, err = f.Write(out.contents)
+	err = errors.Join(err, f.Chmod(0o644), f.Close())
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// reserveName creates an empty file in dir matching pattern, so that its name
+// is unique, returning its name.
+func reserveName(dir, pattern string) (string, error) {
+	f, err := os.CreateTemp(dir, pattern)
+	if err != nil {
+		return "", err
+	}
+	return f.Name(), f.Close()
+}
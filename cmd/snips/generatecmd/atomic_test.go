@@ -0,0 +1,87 @@
+package generatecmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalWriterWriteFiles(t *testing.T) {
+	// leftovers returns the temporary files and backups left in dir.
+	leftovers := func(t *testing.T, dir string) (names []string) {
+		t.Helper()
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range entries {
+			if strings.HasPrefix(e.Name(), ".snips-") {
+				names = append(names, e.Name())
+			}
+		}
+		return names
+	}
+	setup := func(t *testing.T) (dir, existing, stale string) {
+		t.Helper()
+		dir = t.TempDir()
+		existing, stale = filepath.Join(dir, "a.code.go_templ.go"), filepath.Join(dir, "b.code.go_templ.go")
+		for _, name := range []string{existing, stale} {
+			if err := os.WriteFile(name, []byte("old"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return dir, existing, stale
+	}
+
+	t.Run("commit", func(t *testing.T) {
+		dir, existing, stale := setup(t)
+		created := filepath.Join(dir, "c.code.go_templ.go")
+		err := localWriter{}.WriteFiles(outputs{
+			writes:  []output{{name: existing, contents: []byte("new")}, {name: created, contents: []byte("new")}},
+			removes: []string{stale},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range []string{existing, created} {
+			data, err := os.ReadFile(name)
+			if err != nil || string(data) != "new" {
+				t.Errorf("expected %q to be written, got %q, %v", name, data, err)
+			}
+			if info, err := os.Stat(name); err == nil && info.Mode().Perm() != 0o644 {
+				t.Errorf("expected %q to be 0644, got %v", name, info.Mode().Perm())
+			}
+		}
+		if _, err := os.Stat(stale); !os.IsNotExist(err) {
+			t.Errorf("expected %q to be removed, got %v", stale, err)
+		}
+		if names := leftovers(t, dir); len(names) > 0 {
+			t.Errorf("expected no temporary files, got %v", names)
+		}
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		dir, existing, stale := setup(t)
+		// A directory can't be replaced by a file.
+		blocked := filepath.Join(dir, "d.code.go_templ.go")
+		if err := os.MkdirAll(filepath.Join(blocked, "x"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		err := localWriter{}.WriteFiles(outputs{
+			writes:  []output{{name: existing, contents: []byte("new")}, {name: blocked, contents: []byte("new")}},
+			removes: []string{stale},
+		})
+		if err == nil {
+			t.Fatal("expected the write to fail")
+		}
+		for _, name := range []string{existing, stale} {
+			if data, err := os.ReadFile(name); err != nil || string(data) != "old" {
+				t.Errorf("expected %q to be restored, got %q, %v", name, data, err)
+			}
+		}
+		if names := leftovers(t, dir); len(names) > 0 {
+			t.Errorf("expected no temporary files, got %v", names)
+		}
+	})
+}
@@ -0,0 +1,183 @@
+package generatecmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// baselineFile is the JSON of a baseline, e.g.
+//
+//	{"files": [{"file": "legacy/old.code.go", "error": "..."}]}
+type baselineFile struct {
+	Files []baselineEntry `json:"files"`
+}
+
+// baselineEntry is a snippet known to fail, by its slash separated path
+// relative to the generated path. The error is only recorded for readers.
+type baselineEntry struct {
+	File  string `json:"file"`
+	Error string `json:"error,omitempty"`
+}
+
+// baseline tolerates the snippets known to fail, so that snips can be adopted
+// in a snippet tree that doesn't generate cleanly, while failing on snippets
+// that break and on baseline entries that no longer fail. It's safe for
+// concurrent use.
+type baseline struct {
+	fileName string
+	root     string
+	// update records the snippets that fail as the new baseline, instead of
+	// checking them.
+	update bool
+	// known snippets, by path relative to root.
+	known map[string]bool
+
+	mu sync.Mutex
+	// processed snippets in the current pass.
+	processed map[string]bool
+	// failed snippets in the current pass, with their errors.
+	failed map[string]string
+}
+
+// loadBaseline reads the baseline at fileName, of the snippets in root. It
+// starts empty if update is set and it doesn't exist yet.
+func loadBaseline(fileName, root string, update bool) (*baseline, error) {
+	b := &baseline{
+		fileName:  fileName,
+		root:      root,
+		update:    update,
+		known:     make(map[string]bool),
+		processed: make(map[string]bool),
+		failed:    make(map[string]string),
+	}
+	data, err := os.ReadFile(fileName)
+	if errors.Is(err, os.ErrNotExist) && update {
+		return b, nil
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("baseline %q not found, create it with -update-baseline", fileName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline: %w", err)
+	}
+	var f baselineFile
+	if err = json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid baseline %q: %w", fileName, err)
+	}
+	for _, e := range f.Files {
+		b.known[e.File] = true
+	}
+	return b, nil
+}
+
+// rel returns the path of a snippet relative to the root.
+func (b *baseline) rel(fileName string) string {
+	if !filepath.IsAbs(fileName) {
+		fileName = filepath.Join(b.root, fileName)
+	}
+	rel, err := filepath.Rel(b.root, fileName)
+	if err != nil {
+		return filepath.ToSlash(fileName)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// record records the result of generating a snippet, reporting whether its
+// error is tolerated, because the snippet is in the baseline, or the baseline
+// is being updated.
+func (b *baseline) record(fileName string, err error) (tolerated bool) {
+	rel := b.rel(fileName)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.processed[rel] = true
+	if err == nil {
+		delete(b.failed, rel)
+		return false
+	}
+	// Errors name snippets by absolute path, which differs between machines.
+	b.failed[rel] = strings.ReplaceAll(err.Error(), b.root+string(filepath.Separator), "")
+	return b.update || b.known[rel]
+}
+
+// reset starts a new pass over the snippets.
+func (b *baseline) reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.processed = make(map[string]bool)
+	b.failed = make(map[string]string)
+}
+
+// check fails if snippets in the baseline no longer fail, so that it's
+// cleaned up as they're fixed. Only the snippets processed are checked,
+// unless all of them were, when those that were deleted are also stale.
+func (b *baseline) check(all bool) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var stale []string
+	for rel := range b.known {
+		if _, failed := b.failed[rel]; !failed && (all || b.processed[rel]) {
+			stale = append(stale, rel)
+		}
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+	slices.Sort(stale)
+	return fmt.Errorf("%d snippets in the baseline no longer fail, remove them from %s or run with -update-baseline: %s", len(stale), b.fileName, strings.Join(stale, ", "))
+}
+
+// write writes the snippets that failed as the baseline.
+func (b *baseline) write() (n int, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	f := baselineFile{Files: []baselineEntry{}}
+	for rel, msg := range b.failed {
+		f.Files = append(f.Files, baselineEntry{File: rel, Error: msg})
+	}
+	slices.SortFunc(f.Files, func(a, b baselineEntry) int { return strings.Compare(a.File, b.File) })
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	if err = os.WriteFile(b.fileName, append(data, '\n'), 0o644); err != nil {
+		return 0, fmt.Errorf("failed to write baseline: %w", err)
+	}
+	return len(f.Files), nil
+}
+
+// AddToBaseline adds a snippet of root that fails with snippetErr to the
+// baseline at fileName, creating it if it doesn't exist, so that its errors
+// are tolerated by runs with the baseline, see Arguments.Baseline.
+func AddToBaseline(fileName, root, snippet string, snippetErr error) error {
+	var f baselineFile
+	data, err := os.ReadFile(fileName)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read baseline: %w", err)
+	}
+	if err == nil {
+		if err = json.Unmarshal(data, &f); err != nil {
+			return fmt.Errorf("invalid baseline %q: %w", fileName, err)
+		}
+	}
+	b := baseline{root: root}
+	entry := baselineEntry{
+		File:  b.rel(snippet),
+		Error: strings.ReplaceAll(snippetErr.Error(), root+string(filepath.Separator), ""),
+	}
+	f.Files = slices.DeleteFunc(f.Files, func(e baselineEntry) bool { return e.File == entry.File })
+	f.Files = append(f.Files, entry)
+	slices.SortFunc(f.Files, func(a, b baselineEntry) int { return strings.Compare(a.File, b.File) })
+	if data, err = json.MarshalIndent(f, "", "  "); err != nil {
+		return err
+	}
+	if err = os.WriteFile(fileName, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write baseline: %w", err)
+	}
+	return nil
+}
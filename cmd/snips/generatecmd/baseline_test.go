@@ -0,0 +1,92 @@
+package generatecmd
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRunBaseline(t *testing.T) {
+	invalid := &fstest.MapFile{Data: []byte("---\nlanguage: unknown\n---\npackage main\n")}
+	valid := &fstest.MapFile{Data: []byte("package main\n")}
+	baselineFile := filepath.Join(t.TempDir(), "snips-baseline.json")
+	run := func(fsys fstest.MapFS, update bool) error {
+		t.Helper()
+		args := Arguments{
+			Path:           t.TempDir(),
+			FS:             fsys,
+			Out:            t.TempDir(),
+			WorkerCount:    1,
+			Baseline:       baselineFile,
+			UpdateBaseline: update,
+		}
+		return Run(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)), args)
+	}
+
+	if err := run(fstest.MapFS{"a.code.go": invalid}, false); err == nil || !strings.Contains(err.Error(), "-update-baseline") {
+		t.Fatalf("expected a missing baseline to fail, got %v", err)
+	}
+	if err := run(fstest.MapFS{"legacy/a.code.go": invalid, "b.code.go": valid}, true); err != nil {
+		t.Fatalf("failed to update the baseline: %v", err)
+	}
+	data, err := os.ReadFile(baselineFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{
+  "files": [
+    {
+      "file": "legacy/a.code.go",
+      "error": "failed to generate code for \"legacy/a.code.go\": legacy/a.code.go generation error: unknown language \"unknown\""
+    }
+  ]
+}
+`
+	if diff := cmp.Diff(expected, string(data)); diff != "" {
+		t.Errorf("unexpected baseline (-want +got):\n%s", diff)
+	}
+
+	tests := []struct {
+		name    string
+		fsys    fstest.MapFS
+		wantErr string
+	}{
+		{
+			name: "known failure",
+			fsys: fstest.MapFS{"legacy/a.code.go": invalid, "b.code.go": valid},
+		},
+		{
+			name:    "new failure",
+			fsys:    fstest.MapFS{"legacy/a.code.go": invalid, "b.code.go": invalid},
+			wantErr: "generation completed with 1 errors",
+		},
+		{
+			name:    "fixed",
+			fsys:    fstest.MapFS{"legacy/a.code.go": valid, "b.code.go": valid},
+			wantErr: "1 snippets in the baseline no longer fail",
+		},
+		{
+			name:    "deleted",
+			fsys:    fstest.MapFS{"b.code.go": valid},
+			wantErr: "1 snippets in the baseline no longer fail",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := run(tt.fsys, false)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
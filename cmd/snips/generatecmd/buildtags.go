@@ -0,0 +1,57 @@
+package generatecmd
+
+import (
+	"fmt"
+	"go/build/constraint"
+	"path/filepath"
+	"strings"
+)
+
+// buildConstraints are the build constraints of generated files, by the
+// directory they apply to, and the directories below it.
+type buildConstraints struct {
+	root  string
+	byDir map[string]string
+}
+
+// newBuildConstraints returns the build constraints of tags, which are by
+// directory relative to root, "." for all of them.
+func newBuildConstraints(root string, tags map[string]string) buildConstraints {
+	byDir := make(map[string]string, len(tags))
+	for dir, expr := range tags {
+		byDir[filepath.Join(root, filepath.FromSlash(dir))] = strings.TrimSpace(expr)
+	}
+	return buildConstraints{root: root, byDir: byDir}
+}
+
+// forDir returns the build constraint of the files generated in dir, that of
+// the closest directory it's in, or "" if there's none.
+func (b buildConstraints) forDir(dir string) string {
+	for {
+		if expr, ok := b.byDir[dir]; ok {
+			return expr
+		}
+		parent := filepath.Dir(dir)
+		if dir == b.root || parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// checkBuildTags checks that the build constraints are valid, and that their
+// directories are within the path.
+func checkBuildTags(tags map[string]string) error {
+	for dir, expr := range tags {
+		if filepath.IsAbs(dir) || dir == ".." || strings.HasPrefix(filepath.ToSlash(filepath.Clean(dir)), "../") {
+			return fmt.Errorf("build tags directory %q must be relative to the path", dir)
+		}
+		if strings.TrimSpace(expr) == "" {
+			continue
+		}
+		if _, err := constraint.Parse("//go:build " + expr); err != nil {
+			return fmt.Errorf("invalid build tags %q: %w", expr, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package generatecmd
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestBuildConstraints(t *testing.T) {
+	root := t.TempDir()
+	tags := map[string]string{
+		".":             "docs",
+		"examples":      "docs && examples",
+		"examples/prod": "",
+	}
+	if err := checkBuildTags(tags); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b := newBuildConstraints(root, tags)
+	for dir, expected := range map[string]string{
+		".":                   "docs",
+		"guides":              "docs",
+		"examples":            "docs && examples",
+		"examples/go/http":    "docs && examples",
+		"examples/prod":       "",
+		"examples/prod/linux": "",
+	} {
+		if got := b.forDir(filepath.Join(root, filepath.FromSlash(dir))); got != expected {
+			t.Errorf("constraint of %s = %q, expected %q", dir, got, expected)
+		}
+	}
+	if got := newBuildConstraints(root, map[string]string{"examples": "docs"}).forDir(root); got != "" {
+		t.Errorf("expected no constraint outside the configured directories, got %q", got)
+	}
+
+	for _, invalid := range []map[string]string{
+		{".": "docs &&"},
+		{"../docs": "docs"},
+		{filepath.Join(root, "docs"): "docs"},
+	} {
+		if err := checkBuildTags(invalid); err == nil {
+			t.Errorf("expected an error for %v", invalid)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package generatecmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// checkWriter compares generated files with those on disk instead of writing
+// them, recording the files that are missing, out of date, or stale.
+type checkWriter struct {
+	mu    sync.Mutex
+	stale []string
+}
+
+func (w *checkWriter) WriteFile(name string, contents []byte) error {
+	existing, err := os.ReadFile(name)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err == nil && bytes.Equal(existing, contents) {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stale = append(w.stale, name)
+	return nil
+}
+
+// WriteFiles compares the files to write with those on disk, and records the
+// files to remove that exist, without removing them.
+func (w *checkWriter) WriteFiles(o outputs) error {
+	for _, out := range o.writes {
+		if err := w.WriteFile(out.name, out.contents); err != nil {
+			return err
+		}
+	}
+	for _, name := range o.removes {
+		if _, err := os.Stat(name); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+		w.mu.Lock()
+		w.stale = append(w.stale, name)
+		w.mu.Unlock()
+	}
+	return nil
+}
+
+// err returns an error listing the stale files, if there are any.
+func (w *checkWriter) err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.stale) == 0 {
+		return nil
+	}
+	slices.Sort(w.stale)
+	return fmt.Errorf("%d generated files are out of date, run snips generate: %s", len(w.stale), strings.Join(w.stale, ", "))
+}
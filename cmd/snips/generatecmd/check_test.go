@@ -0,0 +1,59 @@
+package generatecmd
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "views")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	fileName := filepath.Join(dir, "a.code.go")
+	if err := os.WriteFile(fileName, []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	args := Arguments{Path: dir, Check: true, Hermetic: true, Files: []string{fileName}}
+
+	err := Run(context.Background(), log, args)
+	if err == nil || !strings.Contains(err.Error(), fileName+"_templ.go") {
+		t.Fatalf("expected a missing generated file to be reported, got %v", err)
+	}
+	if _, err = os.Stat(fileName + "_templ.go"); !os.IsNotExist(err) {
+		t.Errorf("expected nothing to be written in check mode, got %v", err)
+	}
+
+	args.Check = false
+	if err = Run(context.Background(), log, args); err != nil {
+		t.Fatalf("failed to generate: %v", err)
+	}
+	args.Check = true
+	if err = Run(context.Background(), log, args); err != nil {
+		t.Errorf("expected up to date generated files to pass, got %v", err)
+	}
+
+	if err = os.WriteFile(fileName, []byte("package main // changed\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err = Run(context.Background(), log, args); err == nil {
+		t.Error("expected out of date generated files to fail")
+	}
+
+	// The generated files of ignored snippets are stale, but aren't removed.
+	if err = os.WriteFile(fileName, []byte("// snips:ignore\npackage main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err = Run(context.Background(), log, args); err == nil || !strings.Contains(err.Error(), fileName+"_templ.go") {
+		t.Errorf("expected the generated file of an ignored snippet to be reported, got %v", err)
+	}
+	if _, err = os.Stat(fileName + "_templ.go"); err != nil {
+		t.Errorf("expected nothing to be removed in check mode, got %v", err)
+	}
+}
@@ -0,0 +1,39 @@
+package generatecmd
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// chromaModule is the module of the highlighter, whose updates change the
+// highlighted output of snippets.
+const chromaModule = "github.com/alecthomas/chroma/v2"
+
+// ChromaVersion returns the version of chroma that snips was built with, e.g.
+// "v2.14.0", or "" if it's unknown.
+func ChromaVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, dep := range info.Deps {
+		if dep.Path != chromaModule {
+			continue
+		}
+		if dep.Replace != nil {
+			return dep.Replace.Version
+		}
+		return dep.Version
+	}
+	return ""
+}
+
+// checkChromaVersion fails if snips was built with a different version of
+// chroma than the pinned one, so that upgrades, which change the highlighted
+// output, are made intentionally.
+func checkChromaVersion(pinned, actual string) error {
+	if pinned == "" || actual == "" || pinned == actual {
+		return nil
+	}
+	return fmt.Errorf("snips was built with chroma %s, but %s is pinned, run snips drift to review the changes to generated files, then update the pin", actual, pinned)
+}
@@ -0,0 +1,20 @@
+package generatecmd
+
+import "testing"
+
+func TestCheckChromaVersion(t *testing.T) {
+	tests := []struct {
+		pinned, actual string
+		fails          bool
+	}{
+		{pinned: "", actual: "v2.14.0"},
+		{pinned: "v2.14.0", actual: ""},
+		{pinned: "v2.14.0", actual: "v2.14.0"},
+		{pinned: "v2.13.0", actual: "v2.14.0", fails: true},
+	}
+	for _, tt := range tests {
+		if err := checkChromaVersion(tt.pinned, tt.actual); (err != nil) != tt.fails {
+			t.Errorf("checkChromaVersion(%q, %q) = %v", tt.pinned, tt.actual, err)
+		}
+	}
+}
@@ -5,17 +5,27 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"net"
+	"os"
 	"path"
 	"path/filepath"
 	"runtime"
+	"slices"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
-	"github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
 	"github.com/fsnotify/fsnotify"
+	"github.com/garrettladley/snips"
 	"github.com/garrettladley/snips/cmd/snips/generatecmd/modcheck"
+	"github.com/garrettladley/snips/cmd/snips/generatecmd/tracing"
 	"github.com/garrettladley/snips/cmd/snips/generatecmd/watcher"
+	"github.com/garrettladley/snips/generator"
+	snipsruntime "github.com/garrettladley/snips/runtime"
 )
 
 func NewGenerate(log *slog.Logger, args Arguments) (g *Generate) {
@@ -32,6 +42,8 @@ func NewGenerate(log *slog.Logger, args Arguments) (g *Generate) {
 type Generate struct {
 	Log  *slog.Logger
 	Args *Arguments
+	// history records the run, if enabled.
+	history *history
 }
 
 type GenerationEvent struct {
@@ -40,19 +52,494 @@ type GenerationEvent struct {
 	TextUpdated bool
 }
 
-func (cmd Generate) Run(ctx context.Context) (err error) {
-	if cmd.Args.Watch && cmd.Args.FileName != "" {
-		return fmt.Errorf("cannot watch a single file, remove the -f or -watch flag")
+// generateOpts returns the generate options set by the arguments, which apply
+// to every snippet.
+func (cmd Generate) generateOpts(styles *styleSet) (opts []generator.GenerateOpt, err error) {
+	if cmd.Args.WrapperClass != "" {
+		opts = append(opts, generator.WithClass(cmd.Args.WrapperClass))
+	}
+	if cmd.Args.LinesWidth != 0 {
+		opts = append(opts, generator.WithLineNumbersWidth(cmd.Args.LinesWidth))
+	}
+	if cmd.Args.LinesClass != "" {
+		opts = append(opts, generator.WithLineNumbersClass(cmd.Args.LinesClass))
+	}
+	if cmd.Args.Focus != "" {
+		focus, err := snips.ParseLineRanges(cmd.Args.Focus)
+		if err != nil {
+			return nil, fmt.Errorf("invalid focus: %w", err)
+		}
+		opts = append(opts, generator.WithFocusLines(focus))
+	}
+	if cmd.Args.WordDiff {
+		opts = append(opts, generator.WithWordDiff())
+	}
+	if cmd.Args.LanguageBadge {
+		opts = append(opts, generator.WithLanguageBadge(cmd.Args.LanguageLabel))
+	}
+	if cmd.Args.Style != "" {
+		style, err := styles.get(cmd.Args.Style)
+		if err != nil {
+			return nil, fmt.Errorf("invalid style: %w", err)
+		}
+		opts = append(opts, generator.WithStyle(style))
+	}
+	if cmd.Args.Themes != "" {
+		light, dark, err := cmd.themes(styles)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, generator.WithThemes(light, dark))
+	}
+	if cmd.Args.Runtime {
+		opts = append(opts, generator.WithRuntime())
+	}
+	if cmd.Args.AnalyticsAttrs {
+		opts = append(opts, generator.WithAnalyticsAttributes())
+	}
+	if cmd.Args.ContentHash {
+		opts = append(opts, generator.WithContentHash())
+	}
+	if cmd.Args.TableVariants {
+		opts = append(opts, generator.WithTableVariants())
+	}
+	opts = append(opts, generator.WithProvenanceHash())
+	if cmd.Args.InvalidUTF8 == invalidUTF8Raw {
+		opts = append(opts, generator.WithRawInvalidUTF8())
+	}
+	if cmd.Args.AttributionFooter {
+		opts = append(opts, generator.WithAttributionFooter())
+	}
+	if len(cmd.Args.Messages) > 0 {
+		if err := snipsruntime.Messages(cmd.Args.Messages).Validate(); err != nil {
+			return nil, fmt.Errorf("invalid message: %w", err)
+		}
+		opts = append(opts, generator.WithMessages(cmd.Args.Messages))
+	}
+	if cmd.Args.BidiSafe {
+		opts = append(opts, generator.WithBidiSafety())
+	}
+	if cmd.Args.Header != (generator.Header{}) {
+		if err := cmd.Args.Header.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid header: %w", err)
+		}
+		opts = append(opts, generator.WithHeader(cmd.Args.Header))
+	}
+	// Hermetic builds only use the variables they're explicitly given.
+	var environ []string
+	if !cmd.Args.Hermetic {
+		environ = os.Environ()
+	}
+	if vars := variables(cmd.Args.Vars, environ); len(vars) > 0 {
+		opts = append(opts, generator.WithVariables(vars))
+	}
+	if cmd.Args.StreamThreshold != "" {
+		threshold, err := parseSize(cmd.Args.StreamThreshold)
+		if err != nil {
+			return nil, fmt.Errorf("invalid stream threshold: %w", err)
+		}
+		opts = append(opts, generator.WithStreaming(threshold))
+	}
+	if cmd.Args.Compat != "" {
+		opts = append(opts, generator.WithCompat(cmd.Args.Compat))
+	}
+	if cmd.Args.Text && cmd.Args.BaseLine != 0 {
+		opts = append(opts, generator.WithBaseLine(cmd.Args.BaseLine))
+	}
+	if cmd.Args.SymbolsFile != "" {
+		links, err := readSymbolLinks(cmd.Args.SymbolsFile)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, generator.WithSymbolLinks(links))
+	}
+	return opts, nil
+}
+
+// Themes returns the chroma styles of the light and dark themes set by
+// -themes, resolving style aliases.
+func (cmd Generate) Themes() (light, dark string, err error) {
+	styles, err := newStyleSet(cmd.Args.StyleAliases)
+	if err != nil {
+		return "", "", err
+	}
+	return cmd.themes(styles)
+}
+
+// Style returns the chroma style set by -style, resolving style aliases and
+// loading XML style files.
+func (cmd Generate) Style() (*chroma.Style, error) {
+	if cmd.Args.Style == "" {
+		return styles.Fallback, nil
+	}
+	set, err := newStyleSet(cmd.Args.StyleAliases)
+	if err != nil {
+		return nil, err
+	}
+	style, err := set.get(cmd.Args.Style)
+	if err != nil {
+		return nil, fmt.Errorf("invalid style: %w", err)
+	}
+	return style, nil
+}
+
+func (cmd Generate) themes(styles *styleSet) (light, dark string, err error) {
+	light, dark, ok := strings.Cut(cmd.Args.Themes, ",")
+	if !ok || strings.Contains(dark, ",") {
+		return "", "", fmt.Errorf("invalid themes %q, expected <light>,<dark>", cmd.Args.Themes)
 	}
+	if light, err = styles.registered(strings.TrimSpace(light)); err != nil {
+		return "", "", fmt.Errorf("invalid themes: %w", err)
+	}
+	if dark, err = styles.registered(strings.TrimSpace(dark)); err != nil {
+		return "", "", fmt.Errorf("invalid themes: %w", err)
+	}
+	return light, dark, nil
+}
+
+// checkHermetic checks that a hermetic run only reads its declared inputs: the
+// snippets given by -f or -files and the files named by flags. Features that
+// read anything else, such as go.mod files, directory listings or the desktop
+// environment, are refused.
+func (cmd Generate) checkHermetic() error {
+	if !cmd.Args.Hermetic {
+		return nil
+	}
+	if cmd.Args.FileName == "" && len(cmd.Args.Files) == 0 {
+		return fmt.Errorf("hermetic mode requires explicit inputs, add the -f or -files flag")
+	}
+	refused := []struct {
+		flag string
+		set  bool
+	}{
+		{flag: "-watch", set: cmd.Args.Watch},
+		{flag: "-notify", set: cmd.Args.Notify},
+		{flag: "-dedupe", set: cmd.Args.SharedDir != ""},
+		{flag: "-max-files-per-package", set: cmd.Args.MaxFilesPerPackage > 0},
+		{flag: "-cache-dir", set: cmd.Args.CacheDir != ""},
+		{flag: "-feed", set: cmd.Args.Feed != ""},
+		{flag: "-check-links", set: cmd.Args.CheckLinks},
+		{flag: "-history", set: cmd.Args.History},
+		{flag: "-plugin", set: len(cmd.Args.Plugins) > 0},
+		{flag: "-otlp-endpoint", set: cmd.Args.OTLPEndpoint != ""},
+		{flag: "-http", set: cmd.Args.HTTPAddr != ""},
+		{flag: "-out", set: cmd.Args.Out != "" && !strings.EqualFold(parseOut(cmd.Args.Out).Scheme, "file")},
+	}
+	for _, r := range refused {
+		if r.set {
+			return fmt.Errorf("cannot use %s in hermetic mode, remove the %s or -hermetic flag", r.flag, r.flag)
+		}
+	}
+	return nil
+}
+
+// checkOffline checks that an offline run won't access the network, refusing
+// the features that would, rather than failing when they're attempted.
+func (cmd Generate) checkOffline() error {
+	if !cmd.Args.Offline {
+		return nil
+	}
+	refused := []struct {
+		flag string
+		set  bool
+	}{
+		{flag: "-otlp-endpoint", set: cmd.Args.OTLPEndpoint != ""},
+		{flag: "-out", set: cmd.Args.Out != "" && !strings.EqualFold(parseOut(cmd.Args.Out).Scheme, "file")},
+		{flag: "-http", set: cmd.Args.HTTPAddr != "" && !isLoopbackAddr(cmd.Args.HTTPAddr)},
+		{flag: "-check-links", set: cmd.Args.CheckLinks},
+	}
+	for _, r := range refused {
+		if r.set {
+			return fmt.Errorf("cannot use %s in offline mode, remove the %s or -offline flag", r.flag, r.flag)
+		}
+	}
+	return nil
+}
+
+// isLoopbackAddr reports whether the address only listens on the loopback
+// interface, e.g. localhost:7331, rather than on every interface, e.g. :7331.
+func isLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// checkFS checks that the features used can read from and write to FS, rather
+// than the local filesystem.
+func (cmd Generate) checkFS() error {
+	if cmd.Args.FS == nil {
+		return nil
+	}
+	if _, ok := cmd.Args.FS.(WriteFS); !ok && cmd.Args.FileWriter == nil && cmd.Args.Out == "" {
+		return fmt.Errorf("FS is read-only, use a WriteFS or set Out or FileWriter")
+	}
+	unsupported := []struct {
+		name string
+		set  bool
+	}{
+		{name: "Watch", set: cmd.Args.Watch},
+		{name: "SharedDir", set: cmd.Args.SharedDir != ""},
+		{name: "MaxFilesPerPackage", set: cmd.Args.MaxFilesPerPackage > 0},
+		{name: "AggregateDirs", set: cmd.Args.AggregateDirs},
+		{name: "Feed", set: cmd.Args.Feed != ""},
+		{name: "History", set: cmd.Args.History},
+	}
+	for _, u := range unsupported {
+		if u.set {
+			return fmt.Errorf("cannot use %s with FS, it only works on the local filesystem", u.name)
+		}
+	}
+	return nil
+}
+
+// checkArgs checks that the arguments can be used together.
+func (cmd Generate) checkArgs() error {
 	writingToWriter := cmd.Args.FileWriter != nil
+	if len(cmd.Args.Files) > 0 && (cmd.Args.FileName != "" || cmd.Args.Watch) {
+		return fmt.Errorf("cannot use -files with -f or -watch")
+	}
+	if cmd.Args.Since != "" && cmd.Args.Staged {
+		return fmt.Errorf("cannot use -since with -staged")
+	}
+	changes := cmd.Args.Since != "" || cmd.Args.Staged
+	if changes && (cmd.Args.FileName != "" || cmd.Args.Watch || len(cmd.Args.Files) > 0) {
+		return fmt.Errorf("cannot use -since or -staged with -f, -files or -watch")
+	}
+	if len(cmd.Args.Only) > 0 && (cmd.Args.FileName != "" || len(cmd.Args.Files) > 0 || changes || cmd.Args.Watch) {
+		return fmt.Errorf("cannot use -only with -f, -files, -since, -staged or -watch")
+	}
+	if (len(cmd.Args.Include) > 0 || len(cmd.Args.Exclude) > 0) && (cmd.Args.FileName != "" || len(cmd.Args.Files) > 0) {
+		return fmt.Errorf("cannot use -include or -exclude with -f or -files, which name the snippets to generate")
+	}
+	if cmd.Args.Dir != "" && (cmd.Args.FileName != "" || len(cmd.Args.Files) > 0 || len(cmd.Args.Only) > 0 || changes || cmd.Args.Watch || cmd.Args.FS != nil) {
+		return fmt.Errorf("cannot use -dir with -f, -files, -only, -since, -staged, -watch or FS")
+	}
+	if changes && cmd.Args.FS != nil {
+		return fmt.Errorf("cannot use -since or -staged with FS, they only work on the local filesystem")
+	}
+	if cmd.Args.Check && (writingToWriter || cmd.Args.Out != "" || cmd.Args.Watch) {
+		return fmt.Errorf("cannot use -check with -stdout, -out or -watch")
+	}
+	if cmd.Args.CheckLinks && cmd.Args.Watch {
+		return fmt.Errorf("cannot use -check-links with -watch, which would check the links on every change")
+	}
+	if cmd.Args.Check && cmd.Args.MaxFilesPerPackage > 0 {
+		return fmt.Errorf("cannot use -check with -max-files-per-package, which moves generated files")
+	}
 	if cmd.Args.FileName == "" && writingToWriter {
 		return fmt.Errorf("only a single file can be output to stdout, add the -f flag to specify the file to generate code for")
 	}
-	// Default to writing to files.
-	if cmd.Args.FileWriter == nil {
-		cmd.Args.FileWriter = FileWriter
+	if cmd.Args.Watch && writingToWriter {
+		return fmt.Errorf("cannot watch a file when writing to stdout, remove the -watch or -stdout flag")
+	}
+	if cmd.Args.SharedDir != "" && writingToWriter {
+		return fmt.Errorf("cannot write a shared package to stdout, remove the -dedupe or -stdout flag")
+	}
+	if cmd.Args.Fast && cmd.Args.FileName == "" {
+		return fmt.Errorf("-fast only generates a single file, add the -f flag to specify the file to generate code for")
 	}
+	if cmd.Args.Fast && (cmd.Args.Watch || cmd.Args.SharedDir != "" || cmd.Args.MaxFilesPerPackage > 0 || cmd.Args.OTLPEndpoint != "") {
+		return fmt.Errorf("cannot use -fast with -watch, -dedupe, -max-files-per-package or -otlp-endpoint")
+	}
+	if cmd.Args.WatchBatch < 0 {
+		return fmt.Errorf("watch batch window must not be negative, got %v", cmd.Args.WatchBatch)
+	}
+	if cmd.Args.WatchBatch > 0 && !cmd.Args.Watch {
+		return fmt.Errorf("-watch-batch only applies to watch mode, add the -watch flag")
+	}
+	if cmd.Args.FileTimeout < 0 {
+		return fmt.Errorf("file timeout must not be negative, got %v", cmd.Args.FileTimeout)
+	}
+	if cmd.Args.UpdateBaseline && cmd.Args.Baseline == "" {
+		return fmt.Errorf("-update-baseline requires -baseline")
+	}
+	if cmd.Args.UpdateBaseline && (cmd.Args.FileName != "" || len(cmd.Args.Files) > 0 || len(cmd.Args.Only) > 0 || cmd.Args.Since != "" || cmd.Args.Staged || cmd.Args.Watch) {
+		return fmt.Errorf("-update-baseline records every failing snippet, so can't be used with -f, -files, -only, -since, -staged or -watch")
+	}
+	if cmd.Args.MaxErrors < 0 {
+		return fmt.Errorf("max errors must not be negative, got %d", cmd.Args.MaxErrors)
+	}
+	if cmd.Args.MaxOpenFiles < 0 {
+		return fmt.Errorf("max open files must not be negative, got %d", cmd.Args.MaxOpenFiles)
+	}
+	if err := checkBuildTags(cmd.Args.BuildTags); err != nil {
+		return err
+	}
+	if err := checkPlugins(cmd.Args.Plugins); err != nil {
+		return err
+	}
+	if cmd.Args.MaxFilesPerPackage < 0 {
+		return fmt.Errorf("max files per package must not be negative, got %d", cmd.Args.MaxFilesPerPackage)
+	}
+	if cmd.Args.MaxFilesPerPackage > 0 && writingToWriter {
+		return fmt.Errorf("cannot write sub-packages to stdout, remove the -max-files-per-package or -stdout flag")
+	}
+	if cmd.Args.Out != "" && writingToWriter {
+		return fmt.Errorf("cannot use -out with -stdout")
+	}
+	if cmd.Args.Out != "" && cmd.Args.MaxFilesPerPackage > 0 {
+		return fmt.Errorf("cannot use -out with -max-files-per-package, which manages local sub-packages")
+	}
+	if err := cmd.checkAggregateDirs(); err != nil {
+		return err
+	}
+	if (cmd.Args.Examples || cmd.Args.TableVariants) && cmd.Args.Text {
+		return fmt.Errorf("-format text doesn't generate components, so can't be used with -examples or -table-variants")
+	}
+	if cmd.Args.PositionComments && (cmd.Args.Text || cmd.Args.SharedDir != "") {
+		return fmt.Errorf("-position-comments comments the literals of generated components, so can't be used with -format text or -dedupe")
+	}
+	if cmd.Args.TestPackage && (cmd.Args.MaxFilesPerPackage > 0 || cmd.Args.AggregateDirs) {
+		return fmt.Errorf("-test-package generates a _test.go file per snippet, so can't be used with -max-files-per-package or -aggregate-dirs")
+	}
+	if cmd.Args.Text && (cmd.Args.SharedDir != "" || cmd.Args.MaxFilesPerPackage > 0 || cmd.Args.AggregateDirs || cmd.Args.Feed != "") {
+		return fmt.Errorf("-format text writes a text file per snippet, so can't be used with -dedupe, -max-files-per-package, -aggregate-dirs or -feed")
+	}
+	if err := cmd.checkFS(); err != nil {
+		return err
+	}
+	if err := checkScanUnicode(cmd.Args.ScanUnicode); err != nil {
+		return err
+	}
+	if err := checkInvalidUTF8(cmd.Args.InvalidUTF8); err != nil {
+		return err
+	}
+	if cmd.Args.Compat != "" {
+		if _, err := generator.CheckCompat(cmd.Args.Compat); err != nil {
+			return fmt.Errorf("invalid compat: %w", err)
+		}
+	}
+	if err := cmd.checkHermetic(); err != nil {
+		return err
+	}
+	return cmd.checkOffline()
+}
+
+// checkAggregateDirs checks that -aggregate-dirs generates every snippet of
+// the directories it writes, since each directory's file is generated from
+// all of them.
+func (cmd Generate) checkAggregateDirs() error {
+	if !cmd.Args.AggregateDirs {
+		return nil
+	}
+	if cmd.Args.FileName != "" || len(cmd.Args.Files) > 0 || len(cmd.Args.Only) > 0 || cmd.Args.Since != "" || cmd.Args.Staged || len(cmd.Args.Include) > 0 || len(cmd.Args.Exclude) > 0 {
+		return fmt.Errorf("-aggregate-dirs generates every snippet of each directory, so can't be used with -f, -files, -only, -since, -staged, -include or -exclude")
+	}
+	if cmd.Args.MaxFilesPerPackage > 0 {
+		return fmt.Errorf("cannot use -aggregate-dirs with -max-files-per-package")
+	}
+	if cmd.Args.Out != "" {
+		return fmt.Errorf("cannot use -out with -aggregate-dirs, which writes beside the snippets")
+	}
+	return nil
+}
+
+// changedSnippets returns the snippets affected by the changes since the
+// -since ref, or staged for commit with -staged, or all if every snippet needs
+// generating.
+func (cmd Generate) changedSnippets(ctx context.Context) (files []string, all bool, err error) {
+	var changed []string
+	if cmd.Args.Staged {
+		changed, err = stagedFiles(ctx, cmd.Args.Path)
+	} else {
+		changed, err = changedFiles(ctx, cmd.Args.Path, cmd.Args.Since)
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to list changes: %w", err)
+	}
+	var inputs []string
+	if cmd.Args.SymbolsFile != "" {
+		symbolsFile, err := filepath.Abs(cmd.Args.SymbolsFile)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to get absolute path: %w", err)
+		}
+		inputs = append(inputs, symbolsFile)
+	}
+	files, all, err = affectedSnippets(cmd.Args.Path, changed, inputs)
+	if err != nil {
+		return nil, false, err
+	}
+	cmd.Log.Debug("Changed snippets", slog.Int("count", len(files)), slog.Bool("all", all))
+	return files, all, nil
+}
+
+// exportTrace exports the trace of the run to the OTLP endpoint. Failing to
+// export is only a warning, since the run itself succeeded or failed already.
+func (cmd Generate) exportTrace(ctx context.Context, tracer *tracing.Tracer) {
+	headers, err := tracing.ParseHeaders(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"))
+	if err != nil {
+		cmd.Log.Warn("Ignoring OTEL_EXPORTER_OTLP_HEADERS", slog.Any("error", err))
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if err := tracer.Export(ctx, cmd.Args.OTLPEndpoint, headers); err != nil {
+		cmd.Log.Warn("Failed to export trace", slog.Any("error", err))
+		return
+	}
+	cmd.Log.Debug("Exported trace", slog.String("endpoint", cmd.Args.OTLPEndpoint))
+}
+
+// rebuild regenerates every snippet with a new event handler, without the
+// caches of previous generations, on a Rebuild in watch mode.
+func (cmd Generate) rebuild(fseh *atomic.Pointer[FSEventHandler], newHandler func(devMode bool) (*FSEventHandler, error), walk func() error) error {
+	cmd.Log.Info("Rebuilding all files")
+	h, err := newHandler(true)
+	if err != nil {
+		return fmt.Errorf("failed to rebuild: %w", err)
+	}
+	fseh.Store(h)
+	if err = walk(); err != nil {
+		return fmt.Errorf("failed to rebuild: failed to walk files: %w", err)
+	}
+	return nil
+}
 
+// Validate checks the arguments, including the values that need parsing,
+// without generating anything.
+func (cmd Generate) Validate() error {
+	if err := cmd.checkArgs(); err != nil {
+		return err
+	}
+	if _, err := parseSize(cmd.Args.SizeBudget); err != nil {
+		return fmt.Errorf("invalid size budget: %w", err)
+	}
+	if _, err := cmd.memoryLimit(); err != nil {
+		return fmt.Errorf("invalid max memory: %w", err)
+	}
+	if _, err := newSelection(cmd.Args.Path, cmd.Args.Include, cmd.Args.Exclude); err != nil {
+		return err
+	}
+	styles, err := newStyleSet(cmd.Args.StyleAliases)
+	if err != nil {
+		return err
+	}
+	_, err = cmd.generateOpts(styles)
+	return err
+}
+
+func (cmd Generate) Run(ctx context.Context) (err error) {
+	if err = cmd.checkArgs(); err != nil {
+		return err
+	}
+	if err = checkChromaVersion(cmd.Args.ChromaVersion, ChromaVersion()); err != nil {
+		return err
+	}
+	var trace *tracing.Span
+	if cmd.Args.OTLPEndpoint != "" {
+		tracer := tracing.New("snips", snips.Version())
+		trace = tracer.Start("snips generate", tracing.Bool("watch", cmd.Args.Watch))
+		defer func() {
+			trace.SetError(err)
+			trace.End()
+			cmd.exportTrace(context.WithoutCancel(ctx), tracer)
+		}()
+	}
 	// Use absolute path.
 	if !path.IsAbs(cmd.Args.Path) {
 		cmd.Args.Path, err = filepath.Abs(cmd.Args.Path)
@@ -60,42 +547,375 @@ func (cmd Generate) Run(ctx context.Context) (err error) {
 			return fmt.Errorf("failed to get absolute path: %w", err)
 		}
 	}
+	sel, err := newSelection(cmd.Args.Path, cmd.Args.Include, cmd.Args.Exclude)
+	if err != nil {
+		return err
+	}
+	if cmd.Args.History {
+		cmd.history = newHistory(cmd.Args.Path, cmd.Args.Options, cmd.Args.Watch)
+		defer func() {
+			err = errors.Join(err, cmd.history.flush(err))
+		}()
+	}
+	var base *baseline
+	if cmd.Args.Baseline != "" {
+		if base, err = loadBaseline(cmd.Args.Baseline, cmd.Args.Path, cmd.Args.UpdateBaseline); err != nil {
+			return err
+		}
+	}
+	lockDir, locked := cmd.lockDir()
+	if cmd.Args.Out != "" {
+		if cmd.Args.FileWriter, err = OpenWriter(cmd.Args.Out, cmd.Args.Path); err != nil {
+			return err
+		}
+	}
+	if fsys, ok := cmd.Args.FS.(WriteFS); ok && cmd.Args.FileWriter == nil {
+		cmd.Args.FileWriter = relativeWriter{root: cmd.Args.Path, w: fsys}
+	}
+	// Default to writing to files.
+	if cmd.Args.FileWriter == nil {
+		cmd.Args.FileWriter = localWriter{}
+	}
+	if locked {
+		// Watch mode only holds the lock while writing, and always waits
+		// for it, so that other processes can write between changes.
+		lock := newFileLock(cmd.Log, lockDir, cmd.Args.Wait || cmd.Args.Watch)
+		if cmd.Args.Watch {
+			// The production pass runs once the context is cancelled.
+			cmd.Args.FileWriter = &lockingWriter{ctx: context.WithoutCancel(ctx), lock: lock, w: cmd.Args.FileWriter}
+		} else {
+			if err = lock.lock(ctx); err != nil {
+				return err
+			}
+			defer func() {
+				err = errors.Join(err, lock.unlock())
+			}()
+		}
+	}
+	if cmd.Args.Check {
+		cw := &checkWriter{}
+		cmd.Args.FileWriter = cw
+		defer func() {
+			if err == nil {
+				err = cw.err()
+			}
+		}()
+	}
+	if cmd.history != nil {
+		cmd.Args.FileWriter = cmd.history.writer(cmd.Args.FileWriter)
+	}
+	if cmd.Args.Since != "" || cmd.Args.Staged {
+		files, all, err := cmd.changedSnippets(ctx)
+		if err != nil {
+			return err
+		}
+		if !all && len(files) == 0 {
+			cmd.Log.Info("No snippets changed")
+			return nil
+		}
+		if !all {
+			cmd.Args.Files = files
+		}
+	}
+	if len(cmd.Args.Only) > 0 {
+		if cmd.Args.Files, err = cmd.onlySnippets(ctx); err != nil {
+			return err
+		}
+	}
+	if cmd.Args.Dir != "" {
+		if cmd.Args.Files, err = dirSnippets(cmd.Args.Dir); err != nil {
+			return err
+		}
+		if len(cmd.Args.Files) == 0 {
+			cmd.Log.Info("No snippets in " + cmd.Args.Dir)
+			return nil
+		}
+	}
+	src := source{root: cmd.Args.Path, fsys: cmd.Args.FS}
+	memoryLimit, err := cmd.memoryLimit()
+	if err != nil {
+		return fmt.Errorf("invalid max memory: %w", err)
+	}
+	for i, fileName := range cmd.Args.Files {
+		if cmd.Args.Files[i], err = checkSnippet(src, fileName); err != nil {
+			return err
+		}
+	}
+	if cmd.Args.FileName != "" && (cmd.Args.Watch || cmd.Args.FS != nil) {
+		// Match the names of watcher events, and of files in FS.
+		cmd.Args.FileName, err = src.abs(cmd.Args.FileName)
+		if err != nil {
+			return fmt.Errorf("failed to get absolute path: %w", err)
+		}
+	}
 
-	opts := []html.Option{
-		html.TabWidth(cmd.Args.TabWidth),
-		html.BaseLineNumber(cmd.Args.BaseLine),
-		html.WithLineNumbers(cmd.Args.Lines),
-		html.LineNumbersInTable(cmd.Args.LinesTable),
-		html.WithLinkableLineNumbers(cmd.Args.LinkableLines, "L"),
+	formatter := formatterOptions{
+		TabWidth:      cmd.Args.TabWidth,
+		BaseLine:      cmd.Args.BaseLine,
+		Lines:         cmd.Args.Lines,
+		LinesTable:    cmd.Args.LinesTable,
+		LinkableLines: cmd.Args.LinkableLines,
 	}
+	opts := formatter.htmlOpts("")
 
 	// Check the version of the templ module.
-	if err := modcheck.Check(cmd.Args.Path); err != nil {
-		cmd.Log.Warn("templ version check: " + err.Error())
+	if !cmd.Args.Hermetic && !cmd.Args.Fast && cmd.Args.FS == nil {
+		if err := modcheck.Check(cmd.Args.Path); err != nil {
+			cmd.Log.Warn("templ version check: " + err.Error())
+		}
 	}
 
-	fseh := NewFSEventHandler(
-		cmd.Log,
-		cmd.Args.Path,
-		cmd.Args.Watch,
-		opts,
-		cmd.Args.KeepOrphanedFiles,
-		cmd.Args.FileWriter,
-		cmd.Args.Lazy,
-	)
+	var fsehOpts []FSEventHandlerOpt
+	if cmd.Args.FS != nil {
+		fsehOpts = append(fsehOpts, WithFS(cmd.Args.FS))
+	}
+	if cmd.Args.Notify {
+		fsehOpts = append(fsehOpts, WithNotify())
+	}
+	fsehOpts = append(fsehOpts, withFormatter(formatter))
+	if cmd.Args.TestPackage {
+		fsehOpts = append(fsehOpts, withTestPackage())
+	}
+	if cmd.Args.Examples {
+		fsehOpts = append(fsehOpts, withExamples())
+	}
+	if cmd.Args.ForceOverwrite {
+		fsehOpts = append(fsehOpts, withForceOverwrite())
+	}
+	if cmd.Args.TableVariants {
+		fsehOpts = append(fsehOpts, withTableVariants())
+	}
+	if cmd.Args.PositionComments {
+		fsehOpts = append(fsehOpts, withPositionComments())
+	}
+	if cmd.Args.Lang != "" {
+		if lexers.Get(cmd.Args.Lang) == nil {
+			return fmt.Errorf("invalid lang: unknown language %q", cmd.Args.Lang)
+		}
+		fsehOpts = append(fsehOpts, withLang(cmd.Args.Lang))
+	}
+	if sel != nil {
+		fsehOpts = append(fsehOpts, withSelection(sel))
+	}
+	if len(cmd.Args.Plugins) > 0 {
+		fsehOpts = append(fsehOpts, withPlugins(newPlugins(cmd.Args.Path, cmd.Args.Plugins)))
+	}
+	if cmd.Args.Fast {
+		fsehOpts = append(fsehOpts, withFast())
+	}
+	buildTags := newBuildConstraints(cmd.Args.Path, cmd.Args.BuildTags)
+	if len(cmd.Args.BuildTags) > 0 {
+		fsehOpts = append(fsehOpts, withBuildTags(buildTags))
+	}
+	var shared *sharedLiterals
+	if cmd.Args.SharedDir != "" {
+		if shared, err = newSharedLiterals(cmd.Args.SharedDir); err != nil {
+			return err
+		}
+		shared.header = cmd.Args.Header
+		shared.buildConstraint = buildTags.forDir(shared.dir)
+		fsehOpts = append(fsehOpts, withSharedLiterals(shared))
+	}
+	if cmd.Args.Split {
+		fsehOpts = append(fsehOpts, WithSplit())
+	}
+	if cmd.Args.ScanUnicode != "" {
+		fsehOpts = append(fsehOpts, withUnicodeScan(cmd.Args.ScanUnicode))
+	}
+	if cmd.Args.InvalidUTF8 != "" {
+		fsehOpts = append(fsehOpts, withInvalidUTF8(cmd.Args.InvalidUTF8))
+	}
+	if cmd.Args.FailOnSecrets {
+		fsehOpts = append(fsehOpts, withSecretCheck())
+	}
+	if trace != nil {
+		fsehOpts = append(fsehOpts, withTrace(trace))
+	}
+	if cmd.Args.FileTimeout > 0 {
+		fsehOpts = append(fsehOpts, withFileTimeout(cmd.Args.FileTimeout))
+	}
+	var sh *shards
+	if cmd.Args.MaxFilesPerPackage > 0 {
+		sh = newShards(cmd.Args.MaxFilesPerPackage)
+		sh.header = cmd.Args.Header
+		sh.buildTags = buildTags
+		fsehOpts = append(fsehOpts, withShards(sh))
+	}
+	if cmd.Args.Text {
+		fsehOpts = append(fsehOpts, withText())
+	}
+	var agg *aggregates
+	if cmd.Args.AggregateDirs {
+		agg = newAggregates()
+		agg.forceOverwrite = cmd.Args.ForceOverwrite
+		fsehOpts = append(fsehOpts, withAggregates(agg))
+	}
+	if cmd.Args.HTMLReport != nil {
+		fsehOpts = append(fsehOpts, withHTMLReport(cmd.Args.HTMLReport))
+	}
+	if cmd.Args.TokenReport != nil {
+		fsehOpts = append(fsehOpts, withTokenReport(cmd.Args.TokenReport))
+	}
+	var detections *detectReport
+	if cmd.Args.DetectReport != nil {
+		detections = newDetectReport(cmd.Args.Path)
+		fsehOpts = append(fsehOpts, withDetectReport(detections))
+	}
+	var formatters *formatterReport
+	if cmd.Args.FormatterReport != nil {
+		formatters = newFormatterReport(cmd.Args.Path)
+		fsehOpts = append(fsehOpts, withFormatterReport(formatters))
+	}
+	var fd *feed
+	if cmd.Args.Feed != "" {
+		if fd, err = newFeed(cmd.Args.Path, cmd.Args.Feed, filepath.Join(cmd.Args.Path, ".snips", "feed")); err != nil {
+			return err
+		}
+		fsehOpts = append(fsehOpts, withFeed(fd))
+	}
+	var links *linkChecks
+	if cmd.Args.CheckLinks {
+		links = newLinkChecks(cmd.Args.Path)
+		fsehOpts = append(fsehOpts, withLinkChecks(links))
+	}
+	var sizes *sizeReport
+	if cmd.Args.SizeReport || cmd.Args.SizeBudget != "" {
+		budget, err := parseSize(cmd.Args.SizeBudget)
+		if err != nil {
+			return fmt.Errorf("invalid size budget: %w", err)
+		}
+		sizes = newSizeReport(budget)
+		fsehOpts = append(fsehOpts, withSizeReport(sizes))
+	}
+	if cmd.Args.Watch || cmd.Args.CacheDir != "" {
+		tokens := newTokenCache(cmd.Args.CacheDir, cmd.Args.Watch)
+		fsehOpts = append(fsehOpts, WithGenerateOpts(generator.WithTokenCache(tokens)))
+	}
+	fsehOpts = append(fsehOpts, withFormatCache(newFormatCache(cmd.Args.CacheDir)))
+	// newHandler returns an event handler without the caches of previous
+	// generations, loading the styles again, so that rebuilds pick up
+	// changes to XML style files.
+	newHandler := func(devMode bool) (*FSEventHandler, error) {
+		styles, err := newStyleSet(cmd.Args.StyleAliases)
+		if err != nil {
+			return nil, err
+		}
+		genOpts, err := cmd.generateOpts(styles)
+		if err != nil {
+			return nil, err
+		}
+		return NewFSEventHandler(
+			cmd.Log,
+			cmd.Args.Path,
+			devMode,
+			opts,
+			cmd.Args.KeepOrphanedFiles,
+			cmd.Args.FileWriter,
+			cmd.Args.Lazy,
+			append(slices.Clip(fsehOpts), WithGenerateOpts(genOpts...), withStyles(styles))...,
+		), nil
+	}
+	// The handler is replaced by rebuilds while events are being handled.
+	var fseh atomic.Pointer[FSEventHandler]
+	initial, err := newHandler(cmd.Args.Watch)
+	if err != nil {
+		return err
+	}
+	fseh.Store(initial)
+
+	status := newRunStatus(cmd.Args.Path, cmd.Args.Watch, cmd.Args.FileTimeout)
+	if cmd.Args.HTTPAddr != "" {
+		stop, err := cmd.serveStatus(status)
+		if err != nil {
+			return err
+		}
+		defer stop()
+	}
+
+	// batchComplete runs after each batch of generation.
+	batchComplete := func() error {
+		defer status.generated()
+		if sizes != nil {
+			sizes.checkBudget(cmd.Log)
+		}
+		var errs []error
+		if sh != nil {
+			errs = append(errs, sh.write(cmd.Args.FileWriter))
+		}
+		if agg != nil {
+			errs = append(errs, agg.write(cmd.Args.FileWriter))
+		}
+		if shared != nil {
+			errs = append(errs, shared.write(cmd.Args.FileWriter))
+		}
+		if fd != nil {
+			errs = append(errs, fd.write())
+		}
+		if cmd.history != nil && cmd.Args.Watch {
+			// Each batch of changes is recorded as it completes.
+			errs = append(errs, cmd.history.flush(nil))
+		}
+		return errors.Join(errs...)
+	}
+	// runComplete runs once all generation has completed.
+	runComplete := func() error {
+		err := batchComplete()
+		if cmd.Args.SizeReport {
+			sizes.log(cmd.Log)
+		}
+		if detections != nil {
+			err = errors.Join(err, detections.write(cmd.Args.DetectReport))
+		}
+		if formatters != nil {
+			err = errors.Join(err, formatters.write(cmd.Args.FormatterReport))
+		}
+		if links != nil {
+			err = errors.Join(err, links.check(ctx))
+		}
+		return err
+	}
+
+	stats := newRunStats()
 
 	// If we're processing a single file, don't bother setting up the channels/multithreaing.
-	if cmd.Args.FileName != "" {
-		_, _, err = fseh.HandleEvent(ctx, fsnotify.Event{
+	if cmd.Args.FileName != "" && !cmd.Args.Watch {
+		cmd.walkComplete(1)
+		status.started()
+		goUpdated, textUpdated, err := fseh.Load().HandleEvent(ctx, fsnotify.Event{
 			Name: cmd.Args.FileName,
 			Op:   fsnotify.Create,
 		})
-		return err
+		if cmd.tolerated(base, cmd.Args.FileName, err) {
+			err = nil
+		}
+		status.finished(cmd.Args.FileName, err)
+		if err != nil {
+			cmd.fileFailed(cmd.Args.FileName, err)
+		} else {
+			cmd.fileGenerated(cmd.Args.FileName, goUpdated || textUpdated)
+		}
+		if err != nil && cmd.Args.MaxErrors == 0 {
+			return err
+		}
+		stats.processed(goUpdated || textUpdated)
+		if err != nil {
+			cmd.Log.Error("Error", slog.Any("error", err))
+			stats.failed()
+		}
+		if err = runComplete(); err != nil {
+			cmd.fileFailed("", err)
+			return err
+		}
+		if err = cmd.checkErrorBudget(stats); err != nil {
+			return err
+		}
+		if err = cmd.checkBaseline(base); err != nil {
+			return err
+		}
+		stats.logComplete(cmd.Log)
+		return nil
 	}
 
-	// Start timer.
-	start := time.Now()
-
 	// Create channels:
 	// For the initial filesystem walk and subsequent (optional) fsnotify events.
 	events := make(chan fsnotify.Event)
@@ -105,10 +925,11 @@ func (cmd Generate) Run(ctx context.Context) (err error) {
 	var eventHandlerWG sync.WaitGroup
 	// For errs from the watcher.
 	errs := make(chan error)
-	// Tracks whether errors occurred during the generation process.
-	var errorCount atomic.Int64
-	// For triggering actions after generation has completed.
-	postGeneration := make(chan *GenerationEvent, 256)
+	// For triggering actions after generation has completed, coalesced so that
+	// workers never wait for them.
+	postGeneration := newUpdates()
+	// Closed once every event has been handled.
+	eventsHandled := make(chan struct{})
 	// Used to check that the post-generation handler has completed.
 	var postGenerationWG sync.WaitGroup
 	var postGenerationEventsWG sync.WaitGroup
@@ -116,6 +937,73 @@ func (cmd Generate) Run(ctx context.Context) (err error) {
 	// Waitgroup for the push process.
 	var pushHandlerWG sync.WaitGroup
 
+	// walk sends an event for every file to generate, which is only the file
+	// given by -f when watching a single file, or those given by -files. The
+	// files are found before any are sent, so that the largest are sent first,
+	// and only those selected by -include and -exclude are counted.
+	walk := func() (err error) {
+		var walked []fsnotify.Event
+		files := cmd.Args.Files
+		if cmd.Args.FileName != "" {
+			files = []string{cmd.Args.FileName}
+		}
+		for _, fileName := range files {
+			walked = append(walked, fsnotify.Event{
+				Name: fileName,
+				Op:   fsnotify.Create,
+			})
+		}
+		if len(files) == 0 {
+			found := make(chan fsnotify.Event)
+			collected := make(chan struct{})
+			go func() {
+				defer close(collected)
+				for event := range found {
+					walked = append(walked, event)
+				}
+			}()
+			if cmd.Args.FS != nil {
+				err = watcher.WalkFS(ctx, cmd.Args.FS, cmd.Args.Path, found)
+			} else {
+				err = watcher.WalkFiles(ctx, cmd.Args.Path, found)
+			}
+			close(found)
+			<-collected
+			if err != nil {
+				return err
+			}
+		}
+		walked = slices.DeleteFunc(walked, func(event fsnotify.Event) bool {
+			return !sel.selected(event.Name)
+		})
+		largestFirst(src, walked)
+		for _, event := range walked {
+			events <- event
+		}
+		cmd.walkComplete(len(walked))
+		return nil
+	}
+	// batched is closed once changes are no longer being batched.
+	batched := make(chan struct{})
+	// watch sends events for changes to the files to generate, batched over
+	// the -watch-batch window if set.
+	watch := func() (*watcher.RecursiveWatcher, error) {
+		changes := events
+		if cmd.Args.WatchBatch > 0 {
+			changes = make(chan fsnotify.Event)
+			go func(in chan fsnotify.Event) {
+				defer close(batched)
+				watcher.Batch(ctx, in, events, cmd.Args.WatchBatch)
+			}(changes)
+		} else {
+			close(batched)
+		}
+		if cmd.Args.FileName == "" {
+			return watcher.Recursive(ctx, cmd.Args.Path, changes, errs)
+		}
+		return watcher.File(ctx, cmd.Args.FileName, changes, errs)
+	}
+
 	// Start process to push events into the channel.
 	pushHandlerWG.Add(1)
 	go func() {
@@ -126,9 +1014,11 @@ func (cmd Generate) Run(ctx context.Context) (err error) {
 			slog.String("path", cmd.Args.Path),
 			slog.Bool("devMode", cmd.Args.Watch),
 		)
-		if err := watcher.WalkFiles(ctx, cmd.Args.Path, events); err != nil {
+		if err := walk(); err != nil {
 			cmd.Log.Error("WalkFiles failed, exiting", slog.Any("error", err))
-			errs <- FatalError{Err: fmt.Errorf("failed to walk files: %w", err)}
+			fatal := FatalError{Err: fmt.Errorf("failed to walk files: %w", err)}
+			cmd.fileFailed("", fatal)
+			errs <- fatal
 			return
 		}
 		if !cmd.Args.Watch {
@@ -136,18 +1026,30 @@ func (cmd Generate) Run(ctx context.Context) (err error) {
 			return
 		}
 		cmd.Log.Info("Watching files")
-		rw, err := watcher.Recursive(ctx, cmd.Args.Path, events, errs)
+		rw, err := watch()
 		if err != nil {
 			cmd.Log.Error("Recursive watcher setup failed, exiting", slog.Any("error", err))
-			errs <- FatalError{Err: fmt.Errorf("failed to setup recursive watcher: %w", err)}
+			fatal := FatalError{Err: fmt.Errorf("failed to setup recursive watcher: %w", err)}
+			cmd.fileFailed("", fatal)
+			errs <- fatal
 			return
 		}
 		cmd.Log.Debug("Waiting for context to be cancelled to stop watching files")
-		<-ctx.Done()
+		for ctx.Err() == nil {
+			select {
+			case <-ctx.Done():
+			case <-cmd.Args.Rebuild:
+				if err := cmd.rebuild(&fseh, newHandler, walk); err != nil {
+					cmd.fileFailed("", err)
+					errs <- err
+				}
+			}
+		}
 		cmd.Log.Debug("Context cancelled, closing watcher")
 		if err := rw.Close(); err != nil {
 			cmd.Log.Error("Failed to close watcher", slog.Any("error", err))
 		}
+		<-batched
 		cmd.Log.Debug("Waiting for events to be processed")
 		eventsWG.Wait()
 		cmd.Log.Debug(
@@ -156,51 +1058,112 @@ func (cmd Generate) Run(ctx context.Context) (err error) {
 		postGenerationEventsWG.Wait()
 		cmd.Log.Debug(
 			"All post-generation events processed, running walk again, but in production mode",
-			slog.Int64("errorCount", errorCount.Load()),
+			slog.Int("errorCount", stats.passErrors()),
 		)
 		// Reset to reprocess all files in production mode.
-		fseh = NewFSEventHandler(
-			cmd.Log,
-			cmd.Args.Path,
-			false, // Force production mode.
-			opts,
-			cmd.Args.KeepOrphanedFiles,
-			cmd.Args.FileWriter,
-			cmd.Args.Lazy,
-		)
-		errorCount.Store(0)
-		if err := watcher.WalkFiles(ctx, cmd.Args.Path, events); err != nil {
+		production, err := newHandler(false)
+		if err != nil {
+			fatal := FatalError{Err: err}
+			cmd.fileFailed("", fatal)
+			errs <- fatal
+			return
+		}
+		fseh.Store(production)
+		stats.startPass()
+		if base != nil {
+			base.reset()
+		}
+		if err := walk(); err != nil {
 			cmd.Log.Error("Post dev mode WalkFiles failed", slog.Any("error", err))
-			errs <- FatalError{Err: fmt.Errorf("failed to walk files: %w", err)}
+			fatal := FatalError{Err: fmt.Errorf("failed to walk files: %w", err)}
+			cmd.fileFailed("", fatal)
+			errs <- fatal
 			return
 		}
 	}()
 
 	// Start process to handle events.
 	eventHandlerWG.Add(1)
-	sem := make(chan struct{}, cmd.Args.WorkerCount)
+	maxOpenFiles := cmd.maxOpenFiles()
+	workers := concurrency(cmd.Args.WorkerCount, maxOpenFiles)
+	if workers < cmd.Args.WorkerCount {
+		cmd.Log.Debug("Generating fewer snippets at once to stay within the open files limit", slog.Int("workers", workers), slog.Int("maxOpenFiles", maxOpenFiles))
+	}
+	sem := make(chan struct{}, workers)
+	var budget *memoryBudget
+	if memoryLimit > 0 {
+		budget = newMemoryBudget(memoryLimit)
+	}
+	var throttled sync.Once
+	// handle generates the file of an event.
+	handle := func(event fsnotify.Event) {
+		cmd.Log.Debug("Processing file", slog.String("file", event.Name))
+		h := fseh.Load()
+		if budget != nil {
+			var size int64
+			if info, err := src.Stat(event.Name); err == nil {
+				size = info.Size()
+			}
+			// The production pass of watch mode runs once ctx is done, and
+			// must still generate every file.
+			acquireCtx := ctx
+			if !h.DevMode {
+				acquireCtx = context.WithoutCancel(ctx)
+			}
+			waited, err := budget.acquire(acquireCtx, estimate(size))
+			if err != nil {
+				err = fmt.Errorf("%s: failed to wait for the memory budget: %w", event.Name, err)
+				cmd.Log.Error("Event handler failed", slog.Any("error", err))
+				cmd.fileFailed(event.Name, err)
+				stats.failed()
+				return
+			}
+			defer budget.release(estimate(size))
+			if waited {
+				throttled.Do(func() {
+					cmd.Log.Info("Generating fewer snippets at once to stay within the memory budget", slog.String("budget", formatSize(int(memoryLimit))))
+				})
+			}
+		}
+		status.started()
+		goUpdated, textUpdated, err := h.HandleEvent(ctx, event)
+		stats.processed(goUpdated || textUpdated)
+		if cmd.tolerated(base, event.Name, err) {
+			err = nil
+		}
+		status.finished(event.Name, err)
+		if err != nil {
+			cmd.Log.Error("Event handler failed", slog.Any("error", err))
+			cmd.fileFailed(event.Name, err)
+			stats.failed()
+		} else {
+			cmd.fileGenerated(event.Name, goUpdated || textUpdated)
+		}
+		if goUpdated || textUpdated {
+			postGeneration.add(GenerationEvent{
+				Event:       event,
+				GoUpdated:   goUpdated,
+				TextUpdated: textUpdated,
+			})
+		}
+	}
+	flight := newInflight()
 	go func() {
 		defer eventHandlerWG.Done()
-		defer close(postGeneration)
+		defer close(eventsHandled)
 		cmd.Log.Debug("Starting event handler")
 		for event := range events {
+			if !flight.start(event) {
+				// The file is generated again with its latest event once done.
+				continue
+			}
 			eventsWG.Add(1)
 			sem <- struct{}{}
 			go func(event fsnotify.Event) {
-				cmd.Log.Debug("Processing file", slog.String("file", event.Name))
 				defer eventsWG.Done()
 				defer func() { <-sem }()
-				goUpdated, textUpdated, err := fseh.HandleEvent(ctx, event)
-				if err != nil {
-					cmd.Log.Error("Event handler failed", slog.Any("error", err))
-					errs <- err
-				}
-				if goUpdated || textUpdated {
-					postGeneration <- &GenerationEvent{
-						Event:       event,
-						GoUpdated:   goUpdated,
-						TextUpdated: textUpdated,
-					}
+				for ok := true; ok; event, ok = flight.next(event.Name) {
+					handle(event)
 				}
 			}(event)
 		}
@@ -209,7 +1172,6 @@ func (cmd Generate) Run(ctx context.Context) (err error) {
 	}()
 
 	// Start process to handle post-generation events.
-	var updates int
 	postGenerationWG.Add(1)
 	go func() {
 		defer close(errs)
@@ -219,29 +1181,33 @@ func (cmd Generate) Run(ctx context.Context) (err error) {
 		var goUpdated, textUpdated bool
 		for {
 			select {
-			case ge := <-postGeneration:
-				if ge == nil {
-					cmd.Log.Debug("Post-generation event channel closed, exiting")
-					return
-				}
-				goUpdated = goUpdated || ge.GoUpdated
-				textUpdated = textUpdated || ge.TextUpdated
-				if goUpdated || textUpdated {
-					updates++
+			case <-eventsHandled:
+				cmd.Log.Debug("All events handled, exiting")
+				if err := runComplete(); err != nil {
+					cmd.fileFailed("", err)
+					errs <- err
 				}
+				return
+			case <-postGeneration.notify:
 				// Reset timer.
-				if !timeout.Stop() {
-					<-timeout.C
-				}
 				timeout.Reset(time.Millisecond * 100)
 			case <-timeout.C:
+				g, t := postGeneration.take()
+				goUpdated, textUpdated = goUpdated || g, textUpdated || t
 				if !goUpdated && !textUpdated {
 					// Nothing to process, reset timer and wait again.
 					timeout.Reset(time.Hour * 24 * 365)
 					break
 				}
 				postGenerationEventsWG.Add(1)
+				if err := batchComplete(); err != nil {
+					cmd.fileFailed("", err)
+					errs <- err
+				}
 				postGenerationEventsWG.Done()
+				if cmd.Args.Watch {
+					stats.logBatch(cmd.Log)
+				}
 				// Reset timer.
 				timeout.Reset(time.Millisecond * 100)
 				textUpdated = false
@@ -250,7 +1216,8 @@ func (cmd Generate) Run(ctx context.Context) (err error) {
 		}
 	}()
 
-	// Read errors.
+	// Read the errors of the run, such as failing to complete it, which fail
+	// it regardless of MaxErrors. The errors of files are counted by handle.
 	for err := range errs {
 		if err == nil {
 			continue
@@ -260,7 +1227,7 @@ func (cmd Generate) Run(ctx context.Context) (err error) {
 			return err
 		}
 		cmd.Log.Error("Error", slog.Any("error", err))
-		errorCount.Add(1)
+		stats.runFailed(err)
 	}
 
 	// Wait for everything to complete.
@@ -272,14 +1239,62 @@ func (cmd Generate) Run(ctx context.Context) (err error) {
 	postGenerationWG.Wait()
 
 	// Check for errors after everything has completed.
-	if errorCount.Load() > 0 {
-		return fmt.Errorf("generation completed with %d errors", errorCount.Load())
+	if err = stats.passRunErrors(); err != nil {
+		return err
+	}
+	if err = cmd.checkErrorBudget(stats); err != nil {
+		return err
+	}
+	if err = cmd.checkBaseline(base); err != nil {
+		return err
 	}
 
-	cmd.Log.Info(
-		"Complete",
-		slog.Int("updates", updates),
-		slog.Duration("duration", time.Since(start)),
-	)
+	stats.logComplete(cmd.Log)
+	return nil
+}
+
+// checkErrorBudget fails the run if more files failed to generate than
+// MaxErrors allows, and otherwise warns about those that did.
+func (cmd Generate) checkErrorBudget(stats *runStats) error {
+	n := stats.passErrors()
+	if n > 0 && cmd.Args.MaxErrors == 0 {
+		return fmt.Errorf("generation completed with %d errors", n)
+	}
+	if n > cmd.Args.MaxErrors {
+		return fmt.Errorf("generation completed with %d errors, more than the %d allowed by -max-errors", n, cmd.Args.MaxErrors)
+	}
+	if n > 0 {
+		cmd.Log.Warn("Generation completed with errors within the error budget", slog.Int("errors", n), slog.Int("maxErrors", cmd.Args.MaxErrors))
+	}
 	return nil
 }
+
+// tolerated reports whether the error generating a snippet is tolerated by
+// the baseline, if any, warning about it instead.
+func (cmd Generate) tolerated(base *baseline, fileName string, err error) bool {
+	if base == nil || fileName == "" {
+		return false
+	}
+	if !base.record(fileName, err) {
+		return false
+	}
+	cmd.Log.Warn("Ignoring error of snippet in the baseline", slog.String("file", fileName), slog.Any("error", err))
+	return true
+}
+
+// checkBaseline fails the run if snippets in the baseline no longer fail, or
+// records the snippets that failed as the new baseline with UpdateBaseline.
+func (cmd Generate) checkBaseline(base *baseline) error {
+	if base == nil {
+		return nil
+	}
+	if cmd.Args.UpdateBaseline {
+		n, err := base.write()
+		if err != nil {
+			return err
+		}
+		cmd.Log.Info("Updated baseline", slog.String("file", cmd.Args.Baseline), slog.Int("snippets", n))
+		return nil
+	}
+	return base.check(cmd.Args.FileName == "" && len(cmd.Args.Files) == 0)
+}
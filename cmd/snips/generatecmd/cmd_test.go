@@ -0,0 +1,363 @@
+package generatecmd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestCheckHermetic(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    Arguments
+		wantErr bool
+	}{
+		{
+			name: "not hermetic",
+			args: Arguments{Watch: true},
+		},
+		{
+			name: "single file",
+			args: Arguments{Hermetic: true, FileName: "a.code.go"},
+		},
+		{
+			name: "file list",
+			args: Arguments{Hermetic: true, Files: []string{"a.code.go"}},
+		},
+		{
+			name:    "walk",
+			args:    Arguments{Hermetic: true},
+			wantErr: true,
+		},
+		{
+			name:    "watch",
+			args:    Arguments{Hermetic: true, FileName: "a.code.go", Watch: true},
+			wantErr: true,
+		},
+		{
+			name:    "dedupe",
+			args:    Arguments{Hermetic: true, FileName: "a.code.go", SharedDir: "shared"},
+			wantErr: true,
+		},
+		{
+			name:    "otlp endpoint",
+			args:    Arguments{Hermetic: true, FileName: "a.code.go", OTLPEndpoint: "http://127.0.0.1:9/v1/traces"},
+			wantErr: true,
+		},
+		{
+			name:    "http",
+			args:    Arguments{Hermetic: true, FileName: "a.code.go", HTTPAddr: "localhost:7331"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewGenerate(nil, tt.args).checkHermetic()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkHermetic() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckOffline(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    Arguments
+		wantErr bool
+	}{
+		{
+			name: "not offline",
+			args: Arguments{OTLPEndpoint: "http://localhost:4318"},
+		},
+		{
+			name: "file out",
+			args: Arguments{Offline: true, Out: "file:///srv/snippets"},
+		},
+		{
+			name: "directory out",
+			args: Arguments{Offline: true, Out: "generated"},
+		},
+		{
+			name: "loopback http",
+			args: Arguments{Offline: true, HTTPAddr: "localhost:7331"},
+		},
+		{
+			name: "loopback ip http",
+			args: Arguments{Offline: true, HTTPAddr: "[::1]:7331"},
+		},
+		{
+			name:    "every interface http",
+			args:    Arguments{Offline: true, HTTPAddr: ":7331"},
+			wantErr: true,
+		},
+		{
+			name:    "otlp endpoint",
+			args:    Arguments{Offline: true, OTLPEndpoint: "http://localhost:4318"},
+			wantErr: true,
+		},
+		{
+			name:    "remote out",
+			args:    Arguments{Offline: true, Out: "s3://bucket/snippets"},
+			wantErr: true,
+		},
+		{
+			name:    "check links",
+			args:    Arguments{Offline: true, CheckLinks: true},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewGenerate(nil, tt.args).checkOffline()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkOffline() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunFast(t *testing.T) {
+	fsys := fstest.MapFS{"a.code.go": {Data: []byte("package main\n")}}
+	generate := func(fast bool) (written []byte) {
+		t.Helper()
+		args := Arguments{
+			Path:     t.TempDir(),
+			FileName: "a.code.go",
+			FS:       fsys,
+			Fast:     fast,
+			FileWriter: FileWriterFunc(func(_ string, contents []byte) error {
+				written = contents
+				return nil
+			}),
+		}
+		if err := Run(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)), args); err != nil {
+			t.Fatalf("failed to generate: %v", err)
+		}
+		return written
+	}
+	expected := generate(false)
+	if actual := generate(true); !bytes.Equal(actual, expected) {
+		t.Errorf("expected -fast to generate the same file, got:\n%s", actual)
+	}
+	// The unformatted output is used as is when it matches the generated file.
+	fsys["a.code.go_templ.go"] = &fstest.MapFile{Data: expected}
+	if actual := generate(true); !bytes.Equal(actual, expected) {
+		t.Errorf("expected the unchanged file to be written as it was, got:\n%s", actual)
+	}
+
+	for _, args := range []Arguments{
+		{Fast: true},
+		{Fast: true, FileName: "a.code.go", Watch: true},
+		{Fast: true, FileName: "a.code.go", SharedDir: "shared"},
+	} {
+		if err := NewGenerate(nil, args).checkArgs(); err == nil {
+			t.Errorf("expected an error for %+v", args)
+		}
+	}
+}
+
+func TestRunMaxErrors(t *testing.T) {
+	invalid := &fstest.MapFile{Data: []byte("---\nlanguage: unknown\n---\npackage main\n")}
+	tests := []struct {
+		name      string
+		fsys      fstest.MapFS
+		maxErrors int
+		wantErr   bool
+	}{
+		{
+			name:    "no budget",
+			fsys:    fstest.MapFS{"a.code.go": invalid, "b.code.go": {Data: []byte("package main\n")}},
+			wantErr: true,
+		},
+		{
+			name:      "within budget",
+			fsys:      fstest.MapFS{"a.code.go": invalid, "b.code.go": {Data: []byte("package main\n")}},
+			maxErrors: 1,
+		},
+		{
+			name:      "over budget",
+			fsys:      fstest.MapFS{"a.code.go": invalid, "b.code.go": invalid},
+			maxErrors: 1,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := Arguments{
+				Path:        t.TempDir(),
+				FS:          tt.fsys,
+				Out:         t.TempDir(),
+				WorkerCount: 1,
+				MaxErrors:   tt.maxErrors,
+			}
+			err := Run(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)), args)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Run() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("single file", func(t *testing.T) {
+		args := Arguments{
+			Path:       t.TempDir(),
+			FileName:   "a.code.go",
+			FS:         fstest.MapFS{"a.code.go": invalid},
+			FileWriter: FileWriterFunc(func(string, []byte) error { return nil }),
+			MaxErrors:  1,
+		}
+		if err := Run(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)), args); err != nil {
+			t.Errorf("expected the error to be tolerated, got %v", err)
+		}
+	})
+
+	t.Run("run error", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		defer server.Close()
+		args := Arguments{
+			Path:        t.TempDir(),
+			FS:          fstest.MapFS{"a.code.go": {Data: []byte("---\nsource_url: " + server.URL + "/moved\n---\npackage main\n")}},
+			Out:         t.TempDir(),
+			WorkerCount: 1,
+			CheckLinks:  true,
+			MaxErrors:   1,
+		}
+		err := Run(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)), args)
+		if err == nil || !strings.Contains(err.Error(), "404") {
+			t.Errorf("expected the broken link to fail the run regardless of -max-errors, got %v", err)
+		}
+	})
+}
+
+func TestRunRebuild(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "docs")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.code.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// updates reports whether each generation of a.code.go changed its files.
+	updates := make(chan bool, 16)
+	rebuild := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- Run(ctx, slog.New(slog.NewTextHandler(io.Discard, nil)), Arguments{
+			Path:    dir,
+			Watch:   true,
+			NoLock:  true,
+			Rebuild: rebuild,
+			OnFileGenerated: func(fileName string, updated bool) {
+				if filepath.Base(fileName) == "a.code.go" {
+					updates <- updated
+				}
+			},
+		})
+	}()
+	next := func() bool {
+		t.Helper()
+		select {
+		case updated := <-updates:
+			return updated
+		case <-time.After(10 * time.Second):
+			t.Fatal("timed out waiting for a.code.go to be generated")
+			return false
+		}
+	}
+
+	if !next() {
+		t.Error("expected the first generation to write files")
+	}
+	rebuild <- struct{}{}
+	// Without the caches of the first generation, the files are written again.
+	if !next() {
+		t.Error("expected the rebuild to write files again")
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for the run to finish")
+	}
+}
+
+func TestRunPositionComments(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "docs")
+	if err := os.Mkdir(root, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	snippet := "---\nid: main\n---\npackage main\n\nfunc main() {}\n"
+	if err := os.WriteFile(filepath.Join(root, "main.code.go"), []byte(snippet), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	args := Arguments{Path: root, WorkerCount: 1, PositionComments: true}
+	if err := Run(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)), args); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	generated, err := os.ReadFile(filepath.Join(root, "main.code.go_templ.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"/* line 4 */", "/* line 5 */", "/* line 6 */"} {
+		if !bytes.Contains(generated, []byte(line)) {
+			t.Errorf("expected the lines of the snippet file to be commented, missing %s in:\n%s", line, generated)
+		}
+	}
+
+	args.SharedDir = filepath.Join(root, "shared")
+	if err := Run(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)), args); err == nil {
+		t.Error("expected -position-comments to be refused with -dedupe")
+	}
+}
+
+func TestRunIncludeExclude(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "docs")
+	for _, name := range []string{"main.code.go", "guide/intro.code.go", "guide/legacy/old.code.go", "examples/main.code.py"} {
+		fileName := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fileName), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fileName, []byte("package main\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	args := Arguments{Path: root, WorkerCount: 1, Include: []string{"**/*.code.go"}, Exclude: []string{"**/legacy/**"}}
+	if err := Run(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)), args); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for name, want := range map[string]bool{
+		"main.code.go_templ.go":             true,
+		"guide/intro.code.go_templ.go":      true,
+		"guide/legacy/old.code.go_templ.go": false,
+		"examples/main.code.py_templ.go":    false,
+	} {
+		_, err := os.Stat(filepath.Join(root, filepath.FromSlash(name)))
+		if got := err == nil; got != want {
+			t.Errorf("expected %s to be generated: %v, got %v", name, want, got)
+		}
+	}
+
+	for _, invalid := range []Arguments{
+		{Path: root, WorkerCount: 1, Include: []string{"[a"}},
+		{Path: root, WorkerCount: 1, Exclude: []string{"**/legacy/**"}, FileName: filepath.Join(root, "main.code.go")},
+	} {
+		if err := Run(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)), invalid); err == nil {
+			t.Errorf("expected an error for %+v", invalid)
+		}
+	}
+}
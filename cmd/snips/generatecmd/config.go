@@ -0,0 +1,332 @@
+package generatecmd
+
+import (
+	"cmp"
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultConfigFileNames are looked up in the working directory when -config
+// isn't set. At most one of them may exist.
+var DefaultConfigFileNames = []string{"snips.yaml", "snips.toml"}
+
+// Unconfigurable flags describe a single run rather than the project, so
+// can't be set in the config file.
+var Unconfigurable = map[string]bool{
+	"f":                 true,
+	"files":             true,
+	"dir":               true,
+	"only":              true,
+	"since":             true,
+	"staged":            true,
+	"check":             true,
+	"force-overwrite":   true,
+	"position-comments": true,
+	"check-links":       true,
+	"stdout":            true,
+	"format":            true,
+	"fast":              true,
+	"update-baseline":   true,
+	"config":            true,
+	"help":              true,
+}
+
+// lineSettings take a value per line, which the config file may set as a list
+// of strings.
+var lineSettings = map[string]bool{
+	"pragmas": true,
+}
+
+// Sources of setting values, as ApplyConfig returns them, shown by snips config
+// print-effective.
+const (
+	SourceDefault = "default"
+	SourceConfig  = "config"
+	SourceFlag    = "flag"
+	SourceEnv     = "env"
+)
+
+// Config is a parsed config file.
+type Config struct {
+	// FileName of the config file, empty if there isn't one.
+	FileName string
+	// Settings by flag name.
+	Settings map[string]any
+}
+
+// DefaultConfigFile returns the default config file in dir, or the working
+// directory if dir is empty, or an empty string if there isn't one.
+func DefaultConfigFile(dir string) (fileName string, err error) {
+	for _, name := range DefaultConfigFileNames {
+		name = filepath.Join(dir, name)
+		if _, err = os.Stat(name); errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return "", fmt.Errorf("failed to read config: %w", err)
+		}
+		if fileName != "" {
+			return "", fmt.Errorf("both %s and %s exist, remove one or choose one with -config", fileName, name)
+		}
+		fileName = name
+	}
+	return fileName, nil
+}
+
+// LoadConfig loads the config file, or the default config file if fileName is
+// empty and it exists. Files whose name ends in .toml are parsed as TOML, and
+// other files as YAML.
+func LoadConfig(fileName string) (cfg Config, err error) {
+	if fileName == "" {
+		if fileName, err = DefaultConfigFile(""); err != nil || fileName == "" {
+			return cfg, err
+		}
+	}
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return cfg, fmt.Errorf("failed to read config: %w", err)
+	}
+	cfg.FileName = fileName
+	if strings.EqualFold(filepath.Ext(fileName), ".toml") {
+		err = toml.Unmarshal(data, &cfg.Settings)
+	} else {
+		err = yaml.Unmarshal(data, &cfg.Settings)
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("%s: %w", fileName, err)
+	}
+	return cfg, nil
+}
+
+// ApplyConfig sets the flags that weren't set on the command line to their
+// config values, and returns where the value of each flag came from. Mapping
+// flags, such as variables, are merged by key, with their sources keyed as
+// var.NAME.
+func ApplyConfig(fs *flag.FlagSet, cfg Config) (sources map[string]string, err error) {
+	sources = make(map[string]string)
+	fs.VisitAll(func(f *flag.Flag) { sources[f.Name] = SourceDefault })
+	fs.Visit(func(f *flag.Flag) {
+		sources[f.Name] = SourceFlag
+		if m, ok := f.Value.(MapFlag); ok {
+			for key := range m.Entries() {
+				sources[f.Name+"."+key] = SourceFlag
+			}
+		}
+	})
+
+	for _, name := range slices.Sorted(maps.Keys(cfg.Settings)) {
+		f := fs.Lookup(name)
+		if f == nil || Unconfigurable[name] {
+			return nil, fmt.Errorf("unknown setting %q", name)
+		}
+		if _, ok := f.Value.(MapFlag); ok {
+			if err = applyConfigMapping(f, cfg.Settings[name], sources); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if sources[name] == SourceFlag {
+			continue
+		}
+		if _, ok := f.Value.(listFlag); ok {
+			if err = applyConfigList(f, cfg.Settings[name]); err != nil {
+				return nil, err
+			}
+			sources[name] = SourceConfig
+			continue
+		}
+		var value string
+		if list, ok := cfg.Settings[name].([]any); ok && lineSettings[name] {
+			value, err = settingLines(list)
+		} else {
+			value, err = settingString(cfg.Settings[name])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		if err = fs.Set(name, value); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", name, err)
+		}
+		sources[name] = SourceConfig
+	}
+	return sources, nil
+}
+
+// applyConfigMapping adds the keys of a mapping setting that weren't set on
+// the command line. A build-tags string applies to all directories.
+func applyConfigMapping(f *flag.Flag, setting any, sources map[string]string) error {
+	if s, ok := setting.(string); ok && f.Name == "build-tags" {
+		setting = map[string]any{".": s}
+	}
+	mapping, ok := setting.(map[string]any)
+	if !ok {
+		return fmt.Errorf("invalid %s: expected a mapping, got %T", f.Name, setting)
+	}
+	for _, key := range slices.Sorted(maps.Keys(mapping)) {
+		if sources[f.Name+"."+key] == SourceFlag {
+			continue
+		}
+		value, err := settingString(mapping[key])
+		if err != nil {
+			return fmt.Errorf("invalid %s %s: %w", f.Name, key, err)
+		}
+		if err = f.Value.Set(key + "=" + value); err != nil {
+			return fmt.Errorf("invalid %s %s: %w", f.Name, key, err)
+		}
+		sources[f.Name+"."+key] = SourceConfig
+		if sources[f.Name] != SourceFlag {
+			sources[f.Name] = SourceConfig
+		}
+	}
+	return nil
+}
+
+// applyConfigList sets a list flag to each string of a list setting, or to a
+// string setting.
+func applyConfigList(f *flag.Flag, setting any) error {
+	list, ok := setting.([]any)
+	if !ok {
+		list = []any{setting}
+	}
+	for _, item := range list {
+		value, ok := item.(string)
+		if !ok {
+			return fmt.Errorf("invalid %s: expected a string or a list of strings, got %T", f.Name, item)
+		}
+		if err := f.Value.Set(value); err != nil {
+			return fmt.Errorf("invalid %s: %w", f.Name, err)
+		}
+	}
+	return nil
+}
+
+// settingString returns the flag value of a scalar setting.
+func settingString(setting any) (string, error) {
+	switch v := setting.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	}
+	return "", fmt.Errorf("expected a string, integer or boolean, got %T", setting)
+}
+
+// settingLines returns the flag value of a list setting, whose strings are
+// joined with newlines.
+func settingLines(list []any) (string, error) {
+	lines := make([]string, len(list))
+	for i, item := range list {
+		line, ok := item.(string)
+		if !ok {
+			return "", fmt.Errorf("expected a list of strings, got %T", item)
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// commandSettings configure the snips CLI itself rather than the arguments, so
+// are ignored by Run.
+var commandSettings = map[string]bool{
+	"v":                true,
+	"log-level":        true,
+	"detect-report":    true,
+	"formatter-report": true,
+}
+
+// applyConfigFile applies the settings of the config file, see
+// Arguments.Config, to the arguments that the caller didn't set, as if they
+// were flags that weren't set.
+func (args *Arguments) applyConfigFile() error {
+	fileName, err := args.configFile()
+	if err != nil || fileName == "" {
+		return err
+	}
+	cfg, err := LoadConfig(fileName)
+	if err != nil {
+		return err
+	}
+	cfg.Settings = maps.Clone(cfg.Settings)
+	maps.DeleteFunc(cfg.Settings, func(name string, _ any) bool { return commandSettings[name] })
+	var configured, defaults Arguments
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	configured.RegisterFlags(fs)
+	defaults.RegisterFlags(flag.NewFlagSet("defaults", flag.ContinueOnError))
+	if _, err = ApplyConfig(fs, cfg); err != nil {
+		return fmt.Errorf("%s: %w", cfg.FileName, err)
+	}
+	explicit := make(map[string]bool)
+	for _, name := range args.Explicit {
+		explicit[name] = true
+	}
+	mergeConfigured(reflect.ValueOf(args).Elem(), reflect.ValueOf(configured), reflect.ValueOf(defaults), explicit, "")
+	return nil
+}
+
+// configFile returns the config file of the arguments, Config or the default
+// config file of the repository of Path, or an empty string if there isn't
+// one.
+func (args *Arguments) configFile() (fileName string, err error) {
+	if args.Config != "" || args.NoConfig || args.Hermetic || args.FS != nil {
+		return args.Config, nil
+	}
+	root, err := filepath.Abs(cmp.Or(args.Path, "."))
+	if err != nil {
+		return "", fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	return DefaultConfigFile(repositoryRoot(root))
+}
+
+// repositoryRoot returns the root of the git repository containing dir, or dir
+// if it isn't in one.
+func repositoryRoot(dir string) string {
+	for d := dir; ; {
+		if _, err := os.Stat(filepath.Join(d, ".git")); err == nil {
+			return d
+		}
+		parent := filepath.Dir(d)
+		if parent == d {
+			return dir
+		}
+		d = parent
+	}
+}
+
+// mergeConfigured sets the fields of dst that the caller didn't set, those
+// that are zero and not explicit, to the fields of configured that the config
+// file changed from their defaults, and adds the keys of mappings that dst
+// doesn't have. Explicit fields are named by their path from Arguments, e.g.
+// Header.License.
+func mergeConfigured(dst, configured, defaults reflect.Value, explicit map[string]bool, prefix string) {
+	for i := range dst.NumField() {
+		d, c := dst.Field(i), configured.Field(i)
+		name := prefix + dst.Type().Field(i).Name
+		switch {
+		case !d.CanSet() || explicit[name] || reflect.DeepEqual(c.Interface(), defaults.Field(i).Interface()):
+		case d.Kind() == reflect.Struct:
+			mergeConfigured(d, c, defaults.Field(i), explicit, name+".")
+		case d.Kind() == reflect.Map && !d.IsNil():
+			for _, key := range c.MapKeys() {
+				if !d.MapIndex(key).IsValid() {
+					d.SetMapIndex(key, c.MapIndex(key))
+				}
+			}
+		case d.IsZero():
+			d.Set(c)
+		}
+	}
+}
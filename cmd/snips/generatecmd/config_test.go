@@ -0,0 +1,88 @@
+package generatecmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestApplyConfigFile(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "snips.toml")
+	config := `style = "monokai"
+line-numbers = true
+tab-width = 8
+v = true
+
+[var]
+A = "config"
+B = "config"
+`
+	if err := os.WriteFile(fileName, []byte(config), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	args := Arguments{
+		Config:   fileName,
+		TabWidth: 2,
+		Vars:     map[string]string{"B": "caller"},
+		Explicit: []string{"Lines"},
+	}
+	if err := args.applyConfigFile(); err != nil {
+		t.Fatalf("failed to apply config: %v", err)
+	}
+	if args.Style != "monokai" {
+		t.Errorf("expected the config to set the style, got %q", args.Style)
+	}
+	if args.TabWidth != 2 {
+		t.Errorf("expected the caller's tab width to take precedence, got %d", args.TabWidth)
+	}
+	if args.Lines {
+		t.Error("expected the caller's explicit line numbers to take precedence")
+	}
+	if args.Path != "" || args.FileTimeout != 0 {
+		t.Errorf("expected the arguments the config doesn't set to be kept, got %q, %v", args.Path, args.FileTimeout)
+	}
+	if diff := cmp.Diff(map[string]string{"A": "config", "B": "caller"}, args.Vars); diff != "" {
+		t.Errorf("expected variables to be merged by name:\n%s", diff)
+	}
+
+	if err := os.WriteFile(fileName, []byte("stdout = true\n"), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if err := Validate(Arguments{Config: fileName}); err == nil {
+		t.Error("expected an unconfigurable setting to fail validation")
+	}
+}
+
+func TestApplyConfigFileDefault(t *testing.T) {
+	repo := t.TempDir()
+	path := filepath.Join(repo, "docs")
+	for _, dir := range []string{filepath.Join(repo, ".git"), path} {
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(repo, "snips.toml"), []byte("line-numbers = true\n"), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	tests := []struct {
+		name  string
+		args  Arguments
+		lines bool
+	}{
+		{name: "repository root", args: Arguments{Path: path}, lines: true},
+		{name: "no config", args: Arguments{Path: path, NoConfig: true}},
+		{name: "hermetic", args: Arguments{Path: path, Hermetic: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.args.applyConfigFile(); err != nil {
+				t.Fatalf("failed to apply config: %v", err)
+			}
+			if tt.args.Lines != tt.lines {
+				t.Errorf("expected line numbers %v, got %v", tt.lines, tt.args.Lines)
+			}
+		})
+	}
+}
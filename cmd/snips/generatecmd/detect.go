@@ -0,0 +1,81 @@
+package generatecmd
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/garrettladley/snips/generator"
+)
+
+// componentDetection is how the lexer of a component was picked.
+type componentDetection struct {
+	name string
+	generator.Detection
+}
+
+// detectReport tracks the lexer detected for each generated component, so that
+// mis-detections can be found and pinned with the language front matter key.
+type detectReport struct {
+	// root that file names are reported relative to.
+	root string
+
+	mu         sync.Mutex
+	detections map[string][]componentDetection
+}
+
+func newDetectReport(root string) *detectReport {
+	return &detectReport{
+		root:       root,
+		detections: make(map[string][]componentDetection),
+	}
+}
+
+// detectionOpt returns an option that collects the detections of a snippet
+// into pending, to be stored with set once generation succeeds.
+func detectionOpt(pending *[]componentDetection) generator.GenerateOpt {
+	return generator.WithDetectionReport(func(componentName string, d generator.Detection) {
+		*pending = append(*pending, componentDetection{name: componentName, Detection: d})
+	})
+}
+
+// set replaces the detections of a snippet.
+func (r *detectReport) set(fileName string, detections []componentDetection) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.detections[fileName] = detections
+}
+
+// remove forgets the detections of a deleted snippet.
+func (r *detectReport) remove(fileName string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.detections, fileName)
+}
+
+// write a table of the language detected for each component, by file.
+func (r *detectReport) write(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FILE\tCOMPONENT\tLANGUAGE\tCONFIDENCE\tSOURCE")
+	for _, fileName := range slices.Sorted(maps.Keys(r.detections)) {
+		rel, err := filepath.Rel(r.root, fileName)
+		if err != nil {
+			rel = fileName
+		}
+		for _, d := range r.detections[fileName] {
+			confidence := "-"
+			if d.Source == generator.DetectedByAnalysis {
+				confidence = strconv.FormatFloat(float64(d.Confidence), 'f', 2, 32)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", filepath.ToSlash(rel), d.name, d.Lexer, confidence, d.Source)
+		}
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,43 @@
+package generatecmd
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRunDetectReport(t *testing.T) {
+	fsys := &memFS{files: fstest.MapFS{
+		"views/main.code.go":    {Data: []byte("package main\n\nimport \"fmt\"\n\nfunc main() { fmt.Println() }\n")},
+		"views/main.code.snip":  {Data: []byte("package main\n\nimport \"fmt\"\n\nfunc main() { fmt.Println() }\n")},
+		"views/pinned.code.txt": {Data: []byte("---\nlanguage: python\n---\nx = 1\n")},
+	}}
+	var report strings.Builder
+	args := Arguments{
+		Path:         t.TempDir(),
+		FS:           fsys,
+		WorkerCount:  2,
+		DetectReport: &report,
+	}
+	if err := Run(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)), args); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(report.String()), "\n")
+	expected := [][]string{
+		{"FILE", "COMPONENT", "LANGUAGE", "CONFIDENCE", "SOURCE"},
+		{"views/main.code.go", "MainGo", "Go", "-", "filename"},
+		{"views/main.code.snip", "MainSnip", "Go", "0.50", "analysis"},
+		{"views/pinned.code.txt", "PinnedTxt", "Python", "-", "language"},
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("expected %d lines, got:\n%s", len(expected), report.String())
+	}
+	for i, fields := range expected {
+		if got := strings.Fields(lines[i]); strings.Join(got, " ") != strings.Join(fields, " ") {
+			t.Errorf("expected line %d to be %q, got %q", i, fields, lines[i])
+		}
+	}
+}
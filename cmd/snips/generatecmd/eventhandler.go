@@ -2,30 +2,51 @@ package generatecmd
 
 import (
 	"bytes"
+	"cmp"
 	"context"
 	"crypto/sha256"
+	"errors"
 	"fmt"
 	"go/format"
 	"io"
+	"io/fs"
 	"log/slog"
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 	"unicode"
 
+	"github.com/alecthomas/chroma/v2"
 	"github.com/alecthomas/chroma/v2/formatters/html"
 	"github.com/fsnotify/fsnotify"
 	"github.com/garrettladley/snips"
+	"github.com/garrettladley/snips/cmd/snips/generatecmd/notify"
+	"github.com/garrettladley/snips/cmd/snips/generatecmd/tracing"
+	"github.com/garrettladley/snips/extract"
 	"github.com/garrettladley/snips/generator"
 )
 
+// Writer writes generated files.
+type Writer interface {
+	WriteFile(name string, contents []byte) error
+}
+
 type FileWriterFunc func(name string, contents []byte) error
 
+// WriteFile calls f(name, contents).
+func (f FileWriterFunc) WriteFile(name string, contents []byte) error {
+	return f(name, contents)
+}
+
+// FileWriter writes a generated file, replacing it by renaming a temporary
+// file over it, so that readers never see it partially written.
 func FileWriter(fileName string, contents []byte) error {
-	return os.WriteFile(fileName, contents, 0o644)
+	return localWriter{}.WriteFile(fileName, contents)
 }
 
 func WriterFileWriter(w io.Writer) FileWriterFunc {
@@ -35,14 +56,244 @@ func WriterFileWriter(w io.Writer) FileWriterFunc {
 	}
 }
 
+type FSEventHandlerOpt func(h *FSEventHandler)
+
+// WithNotify sends a desktop notification when a file first fails to generate,
+// and when the error clears. Notifications are only sent in dev mode.
+func WithNotify() FSEventHandlerOpt {
+	return func(h *FSEventHandler) {
+		h.notify = true
+	}
+}
+
+// WithGenerateOpts applies the given options to every file generated by the handler.
+func WithGenerateOpts(opts ...generator.GenerateOpt) FSEventHandlerOpt {
+	return func(h *FSEventHandler) {
+		h.generateOpts = append(h.generateOpts, opts...)
+	}
+}
+
+// withStyles resolves the style front matter key with the aliases of styles.
+func withStyles(styles *styleSet) FSEventHandlerOpt {
+	return func(h *FSEventHandler) {
+		h.styles = styles
+	}
+}
+
+// WithSplit generates a component for each definition of .proto and OpenAPI
+// snippets, in addition to the component for the whole snippet.
+func WithSplit() FSEventHandlerOpt {
+	return func(h *FSEventHandler) {
+		h.split = true
+	}
+}
+
+// WithFS reads snippets from fsys instead of the local filesystem, as if it
+// were the handler's directory, e.g. an embed.FS of test fixtures.
+func WithFS(fsys fs.FS) FSEventHandlerOpt {
+	return func(h *FSEventHandler) {
+		h.src.fsys = fsys
+	}
+}
+
+// withUnicodeScan warns about, or fails on, the zero-width and bidi control
+// characters of snippets, see -scan-unicode.
+func withUnicodeScan(mode string) FSEventHandlerOpt {
+	return func(h *FSEventHandler) {
+		h.unicodeMode = mode
+	}
+}
+
+// withSecretCheck fails to generate snippets that look like they contain
+// credentials, see -fail-on-secrets.
+func withSecretCheck() FSEventHandlerOpt {
+	return func(h *FSEventHandler) {
+		h.checkSecrets = true
+	}
+}
+
+// withSharedLiterals references the highlighted HTML of snippets from a shared
+// package, collected by shared.
+func withSharedLiterals(shared *sharedLiterals) FSEventHandlerOpt {
+	return func(h *FSEventHandler) {
+		h.shared = shared
+	}
+}
+
+// withSizeReport records the size of the components of each snippet in sizes.
+func withSizeReport(sizes *sizeReport) FSEventHandlerOpt {
+	return func(h *FSEventHandler) {
+		h.sizes = sizes
+	}
+}
+
+// withFileTimeout reports files that take longer than timeout to generate as
+// errors.
+func withFileTimeout(timeout time.Duration) FSEventHandlerOpt {
+	return func(h *FSEventHandler) {
+		h.fileTimeout = timeout
+	}
+}
+
+func withTrace(span *tracing.Span) FSEventHandlerOpt {
+	return func(h *FSEventHandler) {
+		h.trace = span
+	}
+}
+
+func withDetectReport(detections *detectReport) FSEventHandlerOpt {
+	return func(h *FSEventHandler) {
+		h.detections = detections
+	}
+}
+
+// withHTMLReport calls report with the highlighted HTML of each component.
+func withHTMLReport(report func(fileName, componentName, html string)) FSEventHandlerOpt {
+	return func(h *FSEventHandler) {
+		h.reportHTML = report
+	}
+}
+
+// withTokenReport calls report with the lexer and tokens of each snippet.
+func withTokenReport(report func(fileName, lexer string, tokens []chroma.Token)) FSEventHandlerOpt {
+	return func(h *FSEventHandler) {
+		h.reportTokens = report
+	}
+}
+
+// withBuildTags starts the files generated in each directory with its build
+// constraint, if any.
+func withBuildTags(tags buildConstraints) FSEventHandlerOpt {
+	return func(h *FSEventHandler) {
+		h.buildTags = tags
+	}
+}
+
+// withFast skips formatting generated files that are unchanged from those on
+// disk, see -fast.
+func withFast() FSEventHandlerOpt {
+	return func(h *FSEventHandler) {
+		h.fast = true
+	}
+}
+
+// withShards generates the snippets of large directories into sub-packages,
+// assigned by shards.
+func withShards(shards *shards) FSEventHandlerOpt {
+	return func(h *FSEventHandler) {
+		h.shards = shards
+	}
+}
+
+// withFeed records the changes to snippets in feed.
+func withFeed(feed *feed) FSEventHandlerOpt {
+	return func(h *FSEventHandler) {
+		h.feed = feed
+	}
+}
+
+// withLinkChecks collects the source URLs of snippets, to check them once
+// generation completes.
+func withLinkChecks(links *linkChecks) FSEventHandlerOpt {
+	return func(h *FSEventHandler) {
+		h.links = links
+	}
+}
+
+// withFormatter builds the HTML formatter of each snippet from the options
+// of the run, overridden by its front matter, instead of using the genOpts.
+func withFormatter(opts formatterOptions) FSEventHandlerOpt {
+	return func(h *FSEventHandler) {
+		h.formatter = &opts
+	}
+}
+
+// withTestPackage generates the snippets into the external test package.
+func withTestPackage() FSEventHandlerOpt {
+	return func(h *FSEventHandler) {
+		h.testPackage = true
+	}
+}
+
+// withExamples generates a test file of example functions for each snippet,
+// checking that its components render its text.
+func withExamples() FSEventHandlerOpt {
+	return func(h *FSEventHandler) {
+		h.examples = true
+	}
+}
+
+// withLang highlights snippets with the lexer of the given name, unless their
+// front matter sets their language.
+func withLang(lang string) FSEventHandlerOpt {
+	return func(h *FSEventHandler) {
+		h.lang = lang
+	}
+}
+
+// withTableVariants records the table variants of components generated with
+// generator.WithTableVariants.
+func withTableVariants() FSEventHandlerOpt {
+	return func(h *FSEventHandler) {
+		h.tableVariants = true
+	}
+}
+
+// withPositionComments comments the literal of each line of HTML with the
+// snippet line it came from, see generator.WithPositionComments.
+func withPositionComments() FSEventHandlerOpt {
+	return func(h *FSEventHandler) {
+		h.positionComments = true
+	}
+}
+
+// withForceOverwrite overwrites generated files that were edited by hand,
+// instead of failing their snippets.
+func withForceOverwrite() FSEventHandlerOpt {
+	return func(h *FSEventHandler) {
+		h.forceOverwrite = true
+	}
+}
+
+// withFormatterReport records the effective formatter options of each
+// snippet in formatters.
+func withFormatterReport(formatters *formatterReport) FSEventHandlerOpt {
+	return func(h *FSEventHandler) {
+		h.formatters = formatters
+	}
+}
+
+// withFormatCache reuses the formatted code of generated files from cache.
+func withFormatCache(cache *formatCache) FSEventHandlerOpt {
+	return func(h *FSEventHandler) {
+		h.formatCache = cache
+	}
+}
+
+// withPlugins runs the plugins of each stage over the snippets.
+func withPlugins(plugins *plugins) FSEventHandlerOpt {
+	return func(h *FSEventHandler) {
+		h.plugins = plugins
+	}
+}
+
+// withAggregates generates the snippets of each directory into a single
+// file, collected by aggregates.
+func withAggregates(aggregates *aggregates) FSEventHandlerOpt {
+	return func(h *FSEventHandler) {
+		h.aggregates = aggregates
+	}
+}
+
 func NewFSEventHandler(
 	log *slog.Logger,
 	dir string,
 	devMode bool,
 	genOpts []html.Option,
 	keepOrphanedFiles bool,
-	fileWriter FileWriterFunc,
+	fileWriter Writer,
 	lazy bool,
+	opts ...FSEventHandlerOpt,
 ) *FSEventHandler {
 	if !path.IsAbs(dir) {
 		dir, _ = filepath.Abs(dir)
@@ -50,6 +301,7 @@ func NewFSEventHandler(
 	fseh := &FSEventHandler{
 		Log:                        log,
 		dir:                        dir,
+		src:                        source{root: dir},
 		fileNameToLastModTime:      make(map[string]time.Time),
 		fileNameToLastModTimeMutex: &sync.Mutex{},
 		fileNameToError:            make(map[string]struct{}),
@@ -61,6 +313,10 @@ func NewFSEventHandler(
 		keepOrphanedFiles:          keepOrphanedFiles,
 		writer:                     fileWriter,
 		lazy:                       lazy,
+		styles:                     &styleSet{loaded: make(map[string]*chroma.Style)},
+	}
+	for _, opt := range opts {
+		opt(fseh)
 	}
 	if devMode {
 		// fseh.genOpts = append(fseh.genOpts, generator.WithExtractStrings())
@@ -83,8 +339,52 @@ type FSEventHandler struct {
 	DevMode                    bool
 	Errors                     []error
 	keepOrphanedFiles          bool
-	writer                     func(string, []byte) error
+	writer                     Writer
 	lazy                       bool
+	notify                     bool
+	generateOpts               []generator.GenerateOpt
+	// styles resolves the style front matter key.
+	styles       *styleSet
+	split        bool
+	shared       *sharedLiterals
+	sizes        *sizeReport
+	detections   *detectReport
+	shards       *shards
+	aggregates   *aggregates
+	feed         *feed
+	links        *linkChecks
+	plugins      *plugins
+	formatCache  *formatCache
+	src          source
+	unicodeMode  string
+	invalidUTF8  string
+	checkSecrets bool
+	fileTimeout  time.Duration
+	trace        *tracing.Span
+	buildTags    buildConstraints
+	fast         bool
+	// formatter is the options of the HTML formatter of the run, if snippets
+	// may override them.
+	formatter  *formatterOptions
+	formatters *formatterReport
+	// testPackage generates the snippets into _test.go files of the external
+	// test package.
+	testPackage bool
+	examples    bool
+	// tableVariants generates a table variant of each component.
+	tableVariants bool
+	// positionComments comments the literal of each line of HTML with its
+	// snippet line.
+	positionComments bool
+	// lang is the name of the lexer to highlight snippets with, if set.
+	lang string
+	// forceOverwrite overwrites generated files that were edited by hand.
+	forceOverwrite bool
+	reportHTML     func(fileName, componentName, html string)
+	reportTokens   func(fileName, lexer string, tokens []chroma.Token)
+	text           bool
+	// selection is the snippets selected by -include and -exclude, all if nil.
+	selection *selection
 }
 
 func (h *FSEventHandler) HandleEvent(ctx context.Context, event fsnotify.Event) (goUpdated, textUpdated bool, err error) {
@@ -106,6 +406,16 @@ func (h *FSEventHandler) HandleEvent(ctx context.Context, event fsnotify.Event)
 	if !snips.ContainsDotCodeDot(event.Name) {
 		return false, false, nil
 	}
+	if !h.selection.selected(event.Name) {
+		h.Log.Debug("Skipping snippet not selected by -include and -exclude", slog.String("file", event.Name))
+		return false, false, nil
+	}
+
+	if event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+		if removed, err := h.removeSnippet(event.Name); removed || err != nil {
+			return removed, false, err
+		}
+	}
 
 	// If the file hasn't been updated since the last time we processed it, ignore it.
 	_, updatedModTime := h.UpsertLastModTime(event.Name)
@@ -116,25 +426,125 @@ func (h *FSEventHandler) HandleEvent(ctx context.Context, event fsnotify.Event)
 
 	// Start a processor.
 	start := time.Now()
-	goUpdated, textUpdated, err = h.generate(event.Name)
+	goUpdated, textUpdated, err = h.generateFile(event.Name)
 	if err != nil {
 		h.Log.Error(
 			"Error generating code",
 			slog.String("file", event.Name),
 			slog.Any("error", err),
 		)
-		h.SetError(event.Name, true)
+		if previouslyHadError, _ := h.SetError(event.Name, true); !previouslyHadError {
+			h.sendNotification("Generation failed", fmt.Sprintf("%s: %v", filepath.Base(event.Name), err))
+		}
 		return goUpdated, textUpdated, fmt.Errorf("failed to generate code for %q: %w", event.Name, err)
 	}
 
 	if errorCleared, errorCount := h.SetError(event.Name, false); errorCleared {
 		h.Log.Info("Error cleared", slog.String("file", event.Name), slog.Int("errors", errorCount))
+		h.sendNotification("Error cleared", fmt.Sprintf("%s (%d remaining)", filepath.Base(event.Name), errorCount))
 	}
 	h.Log.Debug("Generated code", slog.String("file", event.Name), slog.Duration("in", time.Since(start)))
 
 	return goUpdated, textUpdated, nil
 }
 
+// removeSnippet forgets a deleted snippet, and removes its orphaned generated
+// files unless they're kept, reporting whether anything changed. The shared
+// package and facades are rebuilt after the batch of changes, so that they
+// never reference the components of deleted snippets.
+func (h *FSEventHandler) removeSnippet(fileName string) (removed bool, err error) {
+	// Editors that save by replacing files remove them first, and the snippet
+	// is generated again instead.
+	if _, err := h.src.Stat(fileName); !errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	aggregated := h.forgetAggregates(fileName)
+	h.fileNameToLastModTimeMutex.Lock()
+	delete(h.fileNameToLastModTime, fileName)
+	h.fileNameToLastModTimeMutex.Unlock()
+	if errorCleared, errorCount := h.SetError(fileName, false); errorCleared {
+		h.Log.Info("Error cleared", slog.String("file", fileName), slog.Int("errors", errorCount))
+	}
+	removed, err = h.removeOrphans(fileName)
+	return aggregated || removed, err
+}
+
+// ignoreSnippet skips a snippet marked as ignored, forgetting it and removing
+// its previously generated files, as if it was deleted, reporting whether
+// anything changed.
+func (h *FSEventHandler) ignoreSnippet(fileName string) (removed bool, err error) {
+	h.Log.Debug("Skipping ignored snippet", slog.String("file", fileName))
+	aggregated := h.forgetAggregates(fileName)
+	removed, err = h.removeOrphans(fileName)
+	return aggregated || removed, err
+}
+
+// forgetAggregates forgets the shared literals, sizes, detections, shards,
+// aggregated code and feed items of a snippet, so that they're dropped from the
+// shared package, reports, facade, directory file and feed, reporting whether
+// there are any.
+func (h *FSEventHandler) forgetAggregates(fileName string) (aggregated bool) {
+	if h.sizes != nil {
+		h.sizes.remove(fileName)
+	}
+	if h.detections != nil {
+		h.detections.remove(fileName)
+	}
+	if h.formatters != nil {
+		h.formatters.remove(fileName)
+	}
+	if h.shards != nil {
+		h.shards.remove(fileName)
+	}
+	if h.shared != nil {
+		h.shared.remove(fileName)
+	}
+	if h.aggregates != nil {
+		h.aggregates.remove(fileName)
+	}
+	if h.feed != nil {
+		h.feed.remove(fileName)
+	}
+	if h.links != nil {
+		h.links.remove(fileName)
+	}
+	return h.shared != nil || h.sizes != nil || h.detections != nil || h.formatters != nil || h.shards != nil || h.aggregates != nil || h.feed != nil
+}
+
+// removeOrphans removes the generated files of a snippet that's deleted or
+// ignored, unless they're kept, reporting whether there were any.
+func (h *FSEventHandler) removeOrphans(fileName string) (removed bool, err error) {
+	if h.keepOrphanedFiles || h.src.fsys != nil {
+		return false, nil
+	}
+	orphans, err := staleTargets(fileName, "")
+	if err != nil {
+		return false, err
+	}
+	for _, suffix := range []string{textSuffix, exampleFileSuffix} {
+		if _, err := os.Stat(fileName + suffix); err == nil {
+			orphans = append(orphans, fileName+suffix)
+		}
+	}
+	if len(orphans) == 0 {
+		return false, nil
+	}
+	for _, orphan := range orphans {
+		h.forgetHash(orphan)
+		h.Log.Debug("Removing orphaned generated file", slog.String("file", orphan))
+	}
+	return true, writeOutputs(h.writer, outputs{removes: orphans})
+}
+
+func (h *FSEventHandler) sendNotification(title, message string) {
+	if !h.notify || !h.DevMode {
+		return
+	}
+	if err := notify.Send("snips: "+title, message); err != nil {
+		h.Log.Warn("Failed to send desktop notification", slog.Any("error", err))
+	}
+}
+
 func (h *FSEventHandler) SetError(fileName string, hasError bool) (previouslyHadError bool, errorCount int) {
 	h.fileNameToErrorMutex.Lock()
 	defer h.fileNameToErrorMutex.Unlock()
@@ -147,21 +557,31 @@ func (h *FSEventHandler) SetError(fileName string, hasError bool) (previouslyHad
 }
 
 func (h *FSEventHandler) UpsertLastModTime(fileName string) (modTime time.Time, updated bool) {
-	fileInfo, err := os.Stat(fileName)
+	fileInfo, err := h.src.Stat(fileName)
 	if err != nil {
 		return modTime, false
 	}
 	h.fileNameToLastModTimeMutex.Lock()
 	defer h.fileNameToLastModTimeMutex.Unlock()
-	previousModTime := h.fileNameToLastModTime[fileName]
+	previousModTime, seen := h.fileNameToLastModTime[fileName]
 	currentModTime := fileInfo.ModTime()
-	if !currentModTime.After(previousModTime) {
+	// Files without a mod time, such as those of an embed.FS, are only
+	// processed once.
+	if seen && !currentModTime.After(previousModTime) {
 		return currentModTime, false
 	}
 	h.fileNameToLastModTime[fileName] = currentModTime
 	return currentModTime, true
 }
 
+// forgetHash forgets the hash of the code written to fileName, e.g. because
+// writing it failed.
+func (h *FSEventHandler) forgetHash(fileName string) {
+	h.hashesMutex.Lock()
+	defer h.hashesMutex.Unlock()
+	delete(h.hashes, fileName)
+}
+
 func (h *FSEventHandler) UpsertHash(fileName string, hash [sha256.Size]byte) (updated bool) {
 	h.hashesMutex.Lock()
 	defer h.hashesMutex.Unlock()
@@ -173,44 +593,351 @@ func (h *FSEventHandler) UpsertHash(fileName string, hash [sha256.Size]byte) (up
 	return true
 }
 
+// formatSource formats generated code with gofmt, reusing the format cache,
+// if set.
+func (h *FSEventHandler) formatSource(code []byte) ([]byte, error) {
+	if h.formatCache != nil {
+		return h.formatCache.source(code)
+	}
+	return format.Source(code)
+}
+
+// generateExamples returns the formatted test file of the examples of a
+// snippet's components.
+func (h *FSEventHandler) generateExamples(packageName string, examples []generator.Example, opts []generator.GenerateOpt) ([]byte, error) {
+	var b bytes.Buffer
+	if err := generator.GenerateExamples(&b, packageName, examples, opts...); err != nil {
+		return nil, fmt.Errorf("example generation error: %w", err)
+	}
+	code, err := h.formatSource(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("example source formatting error: %w", err)
+	}
+	return code, nil
+}
+
+// unchanged reports whether the generated file on disk is code, in which case
+// code is already formatted, since the file was formatted when it was written.
+func (h *FSEventHandler) unchanged(targetFileName string, code []byte) bool {
+	existing, err := h.src.ReadFile(targetFileName)
+	return err == nil && bytes.Equal(existing, code)
+}
+
 // generate Go code for a single template.
 // If a basePath is provided, the filename included in error messages is relative to it.
-func (h *FSEventHandler) generate(fileName string) (goUpdated, textUpdated bool, err error) {
-	pc, err := from(fileName)
+// Each phase of generation is entered on p, if set, so that it can be traced,
+// and reported when it times out.
+func (h *FSEventHandler) generate(fileName string, p *phase) (goUpdated, textUpdated bool, err error) {
+	p.enter("read")
+	pc, err := from(fileName, h.src.packageName)
 	if err != nil {
 		return false, false, fmt.Errorf("failed to parse path %q: %w", fileName, err)
 	}
 
-	f, err := os.ReadFile(fileName)
+	f, err := h.readEventFile(fileName)
 	if err != nil {
 		return false, false, fmt.Errorf("failed to open %q: %w", fileName, err)
 	}
+	if snips.Ignored(f) {
+		goUpdated, err = h.ignoreSnippet(fileName)
+		return goUpdated, false, err
+	}
+	p.enter("parse")
+	if f, err = h.checkUTF8(fileName, f); err != nil {
+		return false, false, fmt.Errorf("%s: %w", fileName, err)
+	}
+	if h.unicodeMode != "" {
+		if err = h.scanUnicode(fileName, f); err != nil {
+			return false, false, fmt.Errorf("%s: %w", fileName, err)
+		}
+	}
+	fm, contents, err := snips.ParseFrontMatter(f)
+	if err != nil {
+		return false, false, fmt.Errorf("%s: %w", fileName, err)
+	}
+	// frontMatterLines precede the code of the snippet in its file.
+	var frontMatterLines int
+	if bytes.HasSuffix(f, contents) {
+		frontMatterLines = bytes.Count(f[:len(f)-len(contents)], []byte("\n"))
+	}
+	if h.links != nil {
+		h.links.set(fileName, fm.SourceURL, contents)
+	}
+
+	// Snippets are redacted first, so that plugins are never given the
+	// redacted content.
+	if contents, err = redact(fm, contents); err != nil {
+		return false, false, fmt.Errorf("%s: %w", fileName, err)
+	}
+	if h.plugins != nil && h.plugins.has(PluginPreHighlight) {
+		code, err := h.plugins.run(pluginRequest{Stage: PluginPreHighlight, File: fileName, Component: pc.componentName, Language: cmp.Or(fm.Language, h.lang), Contents: string(contents)})
+		if err != nil {
+			return false, false, fmt.Errorf("%s: %w", fileName, err)
+		}
+		contents = []byte(code)
+	}
+
+	if h.checkSecrets {
+		if err = checkSecrets(contents); err != nil {
+			return false, false, fmt.Errorf("%s: %w", fileName, err)
+		}
+	}
+
+	fmOpts, err := frontMatterOpts(fm)
+	if err != nil {
+		return false, false, fmt.Errorf("%s: %w", fileName, err)
+	}
 
+	defs, contents, err := extract.Regions(contents)
+	if err != nil {
+		return false, false, fmt.Errorf("%s: %w", fileName, err)
+	}
+	if fm.Split || (h.split && canSplit(fileName)) {
+		split, err := splitDefinitions(fileName, contents)
+		if errors.Is(err, extract.ErrNotOpenAPI) && !fm.Split {
+			// Only OpenAPI documents are split, unless the snippet asks to be.
+			err = nil
+		}
+		if err != nil {
+			return false, false, fmt.Errorf("%s: %w", fileName, err)
+		}
+		defs = append(defs, split...)
+	}
+	components, err := pc.components(defs)
+	if err != nil {
+		return false, false, fmt.Errorf("%s: %w", fileName, err)
+	}
+
+	opts := append(slices.Clone(h.generateOpts), fmOpts...)
+	if h.positionComments {
+		opts = append(opts, generator.WithPositionComments(frontMatterLines))
+	}
+	if source, err := filepath.Rel(h.dir, fileName); err == nil {
+		opts = append(opts, generator.WithSource(filepath.ToSlash(source)))
+	}
+	if fm.Style != "" {
+		style, err := h.styles.get(fm.Style)
+		if err != nil {
+			return false, false, fmt.Errorf("%s: invalid style: %w", fileName, err)
+		}
+		opts = append(opts, generator.WithStyle(style))
+	}
+	if expr := h.buildTags.forDir(filepath.Dir(fileName)); expr != "" {
+		opts = append(opts, generator.WithBuildConstraint(expr))
+	}
+	if p != nil && p.file != nil {
+		opts = append(opts, generator.WithTrace(p.trace))
+	}
+	shared := make(map[string]string)
+	if h.shared != nil {
+		if opt, ok := h.shared.generateOpt(fileName, shared); ok {
+			opts = append(opts, opt)
+		}
+	}
+	var sizes []componentSize
+	if h.sizes != nil {
+		opts = append(opts, h.sizes.generateOpt(&sizes))
+	}
+	var detections []componentDetection
+	opts = append(opts, detectionOpt(&detections))
+	if h.reportHTML != nil {
+		opts = append(opts, generator.WithHTMLReport(func(componentName, html string) {
+			h.reportHTML(fileName, componentName, html)
+		}))
+	}
+	if h.plugins != nil && h.plugins.has(PluginPostHTML) {
+		opts = append(opts, generator.WithHTMLFilter(func(componentName, html string) (string, error) {
+			return h.plugins.run(pluginRequest{Stage: PluginPostHTML, File: fileName, Component: componentName, Language: cmp.Or(fm.Language, h.lang), Contents: html})
+		}))
+	}
+	var examples []generator.Example
+	if h.reportTokens != nil || h.examples {
+		opts = append(opts, generator.WithTokenReport(func(componentName, lexer string, tokens []chroma.Token) {
+			if h.examples {
+				examples = append(examples, generator.Example{ComponentName: componentName, Text: generator.ExampleText(tokens)})
+			}
+			if h.reportTokens != nil && componentName == pc.componentName {
+				h.reportTokens(fileName, lexer, tokens)
+			}
+		}))
+	}
+
+	if h.text {
+		if fm.BaseLine != nil {
+			opts = append(opts, generator.WithBaseLine(*fm.BaseLine))
+		}
+		goUpdated, err = h.generateText(fileName, contents, opts, p)
+		return goUpdated, false, err
+	}
+
+	targetFileName := fileName + "_templ.go"
+	if h.testPackage || fm.TestPackage {
+		if h.shards != nil || h.aggregates != nil {
+			return false, false, fmt.Errorf("%s: test_package can't be used with -max-files-per-package or -aggregate-dirs", fileName)
+		}
+		// Test files are only compiled by go test, and may only refer to the
+		// package under test by importing it.
+		pc.packageName += "_test"
+		targetFileName = fileName + testFileSuffix
+	}
+	// Examples are generated next to the snippet, where the facade of
+	// sub-packages re-exports their components.
+	examplePackage := pc.packageName
+	var shard string
+	if h.shards != nil {
+		if shard, err = h.shards.shardFor(fileName); err != nil {
+			return false, false, fmt.Errorf("%s: %w", fileName, err)
+		}
+		if shard != "" {
+			pc.packageName = shard
+			targetFileName = filepath.Join(filepath.Dir(fileName), shard, filepath.Base(targetFileName))
+		}
+	}
+	if h.aggregates != nil {
+		targetFileName = filepath.Join(filepath.Dir(fileName), aggregateFileName)
+	}
+
+	htmlOpts := h.genOpts
+	var formatter formatterSettings
+	if h.formatter != nil {
+		formatter.formatterOptions, formatter.overrides = h.formatter.override(fm)
+		htmlOpts = formatter.htmlOpts(fm.Slug)
+	}
+
+	p.enter("highlight")
 	var b bytes.Buffer
 	literals, err := generator.Generate(&b,
 		generator.Config{
-			HTMLOpts:      h.genOpts,
-			Style:         "", // TODO: drill down
-			Contents:      f,
+			HTMLOpts:      htmlOpts,
+			Contents:      contents,
 			PackageName:   pc.packageName,
 			ComponentName: pc.componentName,
-		})
+			Components:    components,
+			Lang:          h.lang,
+			FileName:      fileName,
+		},
+		opts...,
+	)
 	if err != nil {
 		return false, false, fmt.Errorf("%s generation error: %w", fileName, err)
 	}
+	for _, d := range detections {
+		switch d.Source {
+		case generator.DetectedByAnalysis:
+			h.Log.Debug("Detected language",
+				slog.String("file", fileName),
+				slog.String("component", d.name),
+				slog.String("lexer", d.Lexer),
+				slog.String("source", d.Source),
+				slog.Float64("confidence", float64(d.Confidence)),
+			)
+		case generator.DetectedByModeline, generator.DetectedByShebang:
+			h.Log.Debug("Detected language",
+				slog.String("file", fileName),
+				slog.String("component", d.name),
+				slog.String("lexer", d.Lexer),
+				slog.String("source", d.Source),
+			)
+		}
+	}
 
-	formattedGoCode, err := format.Source(b.Bytes())
-	if err != nil {
-		return false, false, fmt.Errorf("% source formatting error %w", fileName, err)
+	p.enter("gofmt")
+	formattedGoCode := generator.StampProvenance(b.Bytes())
+	if !h.fast || !h.unchanged(targetFileName, formattedGoCode) {
+		if formattedGoCode, err = h.formatSource(b.Bytes()); err != nil {
+			return false, false, fmt.Errorf("%s source formatting error: %w", fileName, err)
+		}
+		// The hash covers the formatted code.
+		formattedGoCode = generator.StampProvenance(formattedGoCode)
 	}
 
-	targetFileName := fileName + "_templ.go"
-	// Hash output, and write out the file if the codeHash has changed.
+	if !p.enter("write") {
+		return false, false, errAbandoned
+	}
+	// Hash output, and write out the file if the codeHash has changed. The
+	// changes to the snippet's generated files are made together.
+	var changes outputs
 	codeHash := sha256.Sum256(formattedGoCode)
-	if h.UpsertHash(targetFileName, codeHash) {
+	if h.aggregates != nil {
+		// The directory's file is written once the batch completes.
+		goUpdated = h.aggregates.set(fileName, formattedGoCode)
+	} else if h.UpsertHash(targetFileName, codeHash) {
+		if existing, err := h.src.ReadFile(targetFileName); err == nil && !h.forceOverwrite {
+			if err = checkHandEdited(targetFileName, existing); err != nil {
+				h.forgetHash(targetFileName)
+				return false, false, err
+			}
+		}
 		goUpdated = true
-		if err = h.writer(targetFileName, formattedGoCode); err != nil {
-			return false, false, fmt.Errorf("failed to write target file %q: %w", targetFileName, err)
+		if shard != "" {
+			if err = os.MkdirAll(filepath.Dir(targetFileName), 0o755); err != nil {
+				h.forgetHash(targetFileName)
+				return false, false, fmt.Errorf("failed to create sub-package %q: %w", shard, err)
+			}
+		}
+		changes.writes = append(changes.writes, output{name: targetFileName, contents: formattedGoCode})
+	}
+	if h.shards != nil || h.aggregates != nil || h.src.fsys == nil {
+		if changes.removes, err = staleTargets(fileName, targetFileName); err != nil {
+			h.forgetHash(targetFileName)
+			return false, false, err
+		}
+	}
+	exampleFileName := fileName + exampleFileSuffix
+	if h.examples {
+		exampleCode, err := h.generateExamples(examplePackage, examples, opts)
+		if err != nil {
+			h.forgetHash(targetFileName)
+			return false, false, fmt.Errorf("%s: %w", fileName, err)
+		}
+		if h.UpsertHash(exampleFileName, sha256.Sum256(exampleCode)) {
+			goUpdated = true
+			changes.writes = append(changes.writes, output{name: exampleFileName, contents: exampleCode})
+		}
+	} else if _, err := os.Stat(exampleFileName); err == nil && h.src.fsys == nil {
+		changes.removes = append(changes.removes, exampleFileName)
+	}
+	if !changes.empty() {
+		if err = writeOutputs(h.writer, changes); err != nil {
+			// Write the files again on the next change.
+			h.forgetHash(targetFileName)
+			h.forgetHash(exampleFileName)
+			return false, false, err
+		}
+	}
+	if h.shards != nil {
+		names := []string{pc.componentName}
+		for _, c := range components {
+			names = append(names, c.Name)
+		}
+		if h.tableVariants {
+			for _, name := range names {
+				names = append(names, generator.TableVariantName(name))
+			}
+		}
+		h.shards.set(fileName, shard, names)
+	}
+	if h.shared != nil {
+		h.shared.set(fileName, shared)
+	}
+	if h.sizes != nil {
+		h.sizes.set(fileName, sizes)
+	}
+	if h.detections != nil {
+		h.detections.set(fileName, detections)
+	}
+	if h.formatters != nil && h.formatter != nil {
+		h.formatters.set(fileName, formatter)
+	}
+	if h.feed != nil {
+		var language string
+		for _, d := range detections {
+			if d.name == pc.componentName {
+				language = d.Lexer
+			}
+		}
+		if err = h.feed.record(fileName, pc.componentName, language, contents); err != nil {
+			return false, false, err
 		}
 	}
 
@@ -229,12 +956,121 @@ func (h *FSEventHandler) generate(fileName string) (goUpdated, textUpdated bool,
 	return goUpdated, textUpdated, err
 }
 
+// redact redacts the lines marked by snips:redact comments, and the content
+// matching the redact rules of the front matter.
+func redact(fm snips.FrontMatter, contents []byte) ([]byte, error) {
+	var rules []*regexp.Regexp
+	for _, rule := range fm.Redact {
+		re, err := regexp.Compile(rule)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact rule: %w", err)
+		}
+		rules = append(rules, re)
+	}
+	return snips.Redact(contents, rules)
+}
+
+// frontMatterOpts returns the generate options declared by a snippet's front
+// matter, which take precedence over those set for the whole run.
+func frontMatterOpts(fm snips.FrontMatter) (opts []generator.GenerateOpt, err error) {
+	if fm.Class != "" {
+		opts = append(opts, generator.WithClass(fm.Class))
+	}
+	if fm.Slug != "" {
+		if err = snips.ValidSlug(fm.Slug); err != nil {
+			return nil, err
+		}
+		if fm.ID == "" {
+			opts = append(opts, generator.WithID(fm.Slug))
+		}
+	}
+	if fm.ID != "" {
+		opts = append(opts, generator.WithID(fm.ID))
+	}
+	if fm.LineNumbersWidth != 0 {
+		opts = append(opts, generator.WithLineNumbersWidth(fm.LineNumbersWidth))
+	}
+	if fm.LineNumbersClass != "" {
+		opts = append(opts, generator.WithLineNumbersClass(fm.LineNumbersClass))
+	}
+	if fm.Focus != "" {
+		focus, err := snips.ParseLineRanges(fm.Focus)
+		if err != nil {
+			return nil, fmt.Errorf("invalid focus: %w", err)
+		}
+		opts = append(opts, generator.WithFocusLines(focus))
+	}
+	if fm.WordDiff {
+		opts = append(opts, generator.WithWordDiff())
+	}
+	if fm.Language != "" {
+		opts = append(opts, generator.WithLanguage(fm.Language))
+	}
+	if len(fm.Embedded) > 0 {
+		opts = append(opts, generator.WithEmbeddedLanguages(fm.Embedded))
+	}
+	if fm.License != "" || fm.SourceURL != "" {
+		opts = append(opts, generator.WithAttribution(generator.Attribution{License: fm.License, SourceURL: fm.SourceURL}))
+	}
+	if fm.Caption != "" {
+		opts = append(opts, generator.WithCaption(fm.Caption))
+	}
+	if fm.StripComments {
+		opts = append(opts, generator.WithTokenFilter(generator.StripComments))
+	}
+	if fm.CollapseBlankLines {
+		opts = append(opts, generator.WithTokenFilter(generator.CollapseBlankLines))
+	}
+	return opts, nil
+}
+
 type packageComponent struct {
 	packageName   string
 	componentName string
 }
 
-func from(fileName string) (pc packageComponent, err error) {
+// components names each definition after the snippet's component, e.g. the
+// User message of users.code.proto is generated as UsersProtoUser.
+func (pc packageComponent) components(defs []extract.Definition) (components []generator.Component, err error) {
+	names := map[string]struct{}{pc.componentName: {}}
+	for _, def := range defs {
+		name := pc.componentName + sanitze(def.Name)
+		if _, ok := names[name]; ok {
+			return nil, fmt.Errorf("duplicate component name %q for %q", name, def.Name)
+		}
+		names[name] = struct{}{}
+		components = append(components, generator.Component{Name: name, Contents: def.Contents})
+	}
+	return components, nil
+}
+
+func canSplit(fileName string) bool {
+	switch filepath.Ext(stripCode(fileName)) {
+	case ".proto", ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// splitDefinitions extracts the definitions of .proto and OpenAPI snippets.
+func splitDefinitions(fileName string, contents []byte) ([]extract.Definition, error) {
+	switch ext := filepath.Ext(stripCode(fileName)); ext {
+	case ".proto":
+		return extract.Proto(contents), nil
+	case ".yaml", ".yml", ".json":
+		return extract.OpenAPI(contents)
+	default:
+		return nil, fmt.Errorf("cannot split %q snippets, only .proto and OpenAPI snippets are supported", ext)
+	}
+}
+
+// ComponentName returns the name of the component generated from a snippet,
+// e.g. HandlerGo for handler.code.go.
+func ComponentName(fileName string) string {
+	return sanitze(filepath.Base(stripCode(fileName)))
+}
+
+func from(fileName string, packageName func(dir string) string) (pc packageComponent, err error) {
 	fileName = stripCode(fileName)
 	parts := strings.Split(filepath.ToSlash(fileName), "/")
 	if len(parts) == 0 {
@@ -242,7 +1078,7 @@ func from(fileName string) (pc packageComponent, err error) {
 	}
 
 	pc.componentName = sanitze(parts[len(parts)-1])
-	pc.packageName = snips.PackageName(strings.Join(parts[:len(parts)-1], "/"))
+	pc.packageName = packageName(filepath.FromSlash(strings.Join(parts[:len(parts)-1], "/")))
 	return
 }
 
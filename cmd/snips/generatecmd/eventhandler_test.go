@@ -0,0 +1,226 @@
+package generatecmd
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestHandleEventRemovesOrphans(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+	for _, keep := range []bool{false, true} {
+		dir := filepath.Join(t.TempDir(), "docs")
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		fileName := filepath.Join(dir, "main.code.go")
+		if err := os.WriteFile(fileName, []byte("package main\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		h := NewFSEventHandler(log, dir, true, nil, keep, localWriter{}, false)
+		if _, _, err := h.HandleEvent(ctx, fsnotify.Event{Name: fileName, Op: fsnotify.Create}); err != nil {
+			t.Fatal(err)
+		}
+		target := fileName + "_templ.go"
+		if _, err := os.Stat(target); err != nil {
+			t.Fatalf("expected %q to be generated: %v", target, err)
+		}
+
+		if err := os.Remove(fileName); err != nil {
+			t.Fatal(err)
+		}
+		goUpdated, _, err := h.HandleEvent(ctx, fsnotify.Event{Name: fileName, Op: fsnotify.Remove})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, statErr := os.Stat(target)
+		if keep && (goUpdated || statErr != nil) {
+			t.Errorf("expected the orphan to be kept, got update %v and %v", goUpdated, statErr)
+		}
+		if !keep && (!goUpdated || !os.IsNotExist(statErr)) {
+			t.Errorf("expected the orphan to be removed, got update %v and %v", goUpdated, statErr)
+		}
+
+		// The snippet is generated again if it's restored.
+		if err := os.WriteFile(fileName, []byte("package main\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if goUpdated, _, err := h.HandleEvent(ctx, fsnotify.Event{Name: fileName, Op: fsnotify.Create}); err != nil || goUpdated == keep {
+			t.Errorf("expected the restored snippet to be generated if removed, got update %v and %v", goUpdated, err)
+		}
+	}
+}
+
+func TestHandleEventReplaced(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "docs")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	fileName := filepath.Join(dir, "main.code.go")
+	if err := os.WriteFile(fileName, []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sizes := newSizeReport(0)
+	h := NewFSEventHandler(log, dir, true, nil, false, localWriter{}, false, withSizeReport(sizes))
+	if _, _, err := h.HandleEvent(ctx, fsnotify.Event{Name: fileName, Op: fsnotify.Create}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Editors that save by replacing the file report it removed, though it
+	// exists again by the time the event is handled.
+	if err := os.WriteFile(fileName, []byte("package main\n\nfunc main() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Make sure the modification time changes.
+	h.fileNameToLastModTimeMutex.Lock()
+	delete(h.fileNameToLastModTime, fileName)
+	h.fileNameToLastModTimeMutex.Unlock()
+	goUpdated, _, err := h.HandleEvent(ctx, fsnotify.Event{Name: fileName, Op: fsnotify.Remove})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !goUpdated {
+		t.Error("expected the replaced snippet to be generated again")
+	}
+	if _, ok := sizes.sizes[fileName]; !ok {
+		t.Error("expected the replaced snippet to keep its sizes")
+	}
+}
+
+func TestHandleEventIgnored(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "docs")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	fileName := filepath.Join(dir, "main.code.go")
+	target := fileName + "_templ.go"
+	h := NewFSEventHandler(log, dir, true, nil, false, localWriter{}, false)
+	generate := func(contents string) (goUpdated bool) {
+		t.Helper()
+		if err := os.WriteFile(fileName, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		// Make sure the modification time changes.
+		h.fileNameToLastModTimeMutex.Lock()
+		delete(h.fileNameToLastModTime, fileName)
+		h.fileNameToLastModTimeMutex.Unlock()
+		goUpdated, _, err := h.HandleEvent(ctx, fsnotify.Event{Name: fileName, Op: fsnotify.Write})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return goUpdated
+	}
+
+	if !generate("package main\n") {
+		t.Fatal("expected the snippet to be generated")
+	}
+	if _, err := os.Stat(target); err != nil {
+		t.Fatalf("expected %q to be generated: %v", target, err)
+	}
+
+	// Ignored snippets aren't checked, e.g. for invalid front matter.
+	if !generate("// snips:ignore\n---\nunknown: true\n---\npackage main\n") {
+		t.Error("expected ignoring the snippet to remove its generated file")
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Errorf("expected %q to be removed, got %v", target, err)
+	}
+	if generate("---\nignore: true\n---\npackage main\n") {
+		t.Error("expected nothing to change for a snippet that's still ignored")
+	}
+
+	if !generate("package main\n") {
+		t.Error("expected the snippet to be generated once it's no longer ignored")
+	}
+	if _, err := os.Stat(target); err != nil {
+		t.Errorf("expected %q to be generated: %v", target, err)
+	}
+}
+
+func TestRunTestPackage(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "docs")
+	if err := os.Mkdir(root, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	snippet := filepath.Join(root, "main.code.go")
+	run := func(contents string, testPackage bool) {
+		t.Helper()
+		if err := os.WriteFile(snippet, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		args := Arguments{Path: root, WorkerCount: 1, TestPackage: testPackage}
+		if err := Run(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)), args); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	exists := func(name string) bool {
+		_, err := os.Stat(filepath.Join(root, name))
+		return err == nil
+	}
+
+	run("---\ntest_package: true\n---\npackage main\n", false)
+	data, err := os.ReadFile(filepath.Join(root, "main.code.go_templ_test.go"))
+	if err != nil {
+		t.Fatalf("expected the test file to be generated: %v", err)
+	}
+	if !strings.Contains(string(data), "\npackage docs_test\n") {
+		t.Errorf("expected the external test package, got:\n%s", data)
+	}
+	if exists("main.code.go_templ.go") {
+		t.Error("expected no production file")
+	}
+
+	run("package main\n", false)
+	if !exists("main.code.go_templ.go") || exists("main.code.go_templ_test.go") {
+		t.Error("expected the test file to be replaced by a production file")
+	}
+
+	run("package main\n", true)
+	if exists("main.code.go_templ.go") || !exists("main.code.go_templ_test.go") {
+		t.Error("expected -test-package to replace the production file by a test file")
+	}
+}
+
+func TestRunExamples(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "docs")
+	if err := os.Mkdir(root, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "main.code.txt"), []byte("---\nstrip_comments: true\nlanguage: go\n---\n// Comment.\nfunc main() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	run := func(examples bool) {
+		t.Helper()
+		args := Arguments{Path: root, WorkerCount: 1, Examples: examples}
+		if err := Run(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)), args); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	exampleFileName := filepath.Join(root, "main.code.txt_example_test.go")
+
+	run(true)
+	data, err := os.ReadFile(exampleFileName)
+	if err != nil {
+		t.Fatalf("expected the examples to be generated: %v", err)
+	}
+	// The output is the text that's highlighted, after filters.
+	if !strings.Contains(string(data), "func ExampleMainTxt() {") || !strings.Contains(string(data), "\t// Output:\n\t// func main() {}\n}\n") {
+		t.Errorf("unexpected examples:\n%s", data)
+	}
+
+	run(false)
+	if _, err = os.Stat(exampleFileName); err == nil {
+		t.Error("expected the examples to be removed without -examples")
+	}
+}
@@ -0,0 +1,162 @@
+package generatecmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// feedSize is the number of recently changed snippets kept in the feed.
+const feedSize = 50
+
+// feedFile is the JSON feed of recently changed snippets, most recent first,
+// for "recently updated examples" widgets of docs sites.
+type feedFile struct {
+	Updated time.Time  `json:"updated"`
+	Items   []feedItem `json:"items"`
+}
+
+// feedItem is the latest change to a snippet.
+type feedItem struct {
+	Component string `json:"component"`
+	// Snippet is the slash separated path of the snippet, relative to Path.
+	Snippet  string    `json:"snippet"`
+	Language string    `json:"language"`
+	Changed  time.Time `json:"changed"`
+	// Added and Removed are the number of lines added and removed.
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+	// New is set for snippets that were added.
+	New bool `json:"new,omitempty"`
+}
+
+// feed tracks the changes to snippets, comparing their code with the code
+// seen last, which is kept in stateDir so that changes are found across runs.
+// The first run, without a feed file, only records the code of snippets, so
+// that adopting the feed doesn't report every snippet as changed.
+type feed struct {
+	// root that snippet paths are relative to.
+	root     string
+	fileName string
+	stateDir string
+	now      func() time.Time
+
+	mu sync.Mutex
+	// baseline is set while recording the code of snippets without reporting
+	// their changes, until the feed is first written.
+	baseline bool
+	items    []feedItem
+	dirty    bool
+}
+
+// newFeed returns a feed that updates fileName, keeping the code of snippets
+// in stateDir.
+func newFeed(root, fileName, stateDir string) (*feed, error) {
+	f := &feed{root: root, fileName: fileName, stateDir: stateDir, now: time.Now}
+	data, err := os.ReadFile(fileName)
+	if errors.Is(err, os.ErrNotExist) {
+		f.baseline, f.dirty = true, true
+		return f, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feed: %w", err)
+	}
+	var existing feedFile
+	if err = json.Unmarshal(data, &existing); err != nil {
+		return nil, fmt.Errorf("invalid feed %s: %w", fileName, err)
+	}
+	f.items = existing.Items
+	return f, nil
+}
+
+// stateFileName returns the file that the code of the snippet is kept in.
+func (f *feed) stateFileName(rel string) string {
+	h := sha256.Sum256([]byte(rel))
+	return filepath.Join(f.stateDir, hex.EncodeToString(h[:]))
+}
+
+// record compares the code of a snippet with the code seen last, adding an
+// item to the feed if it changed.
+func (f *feed) record(fileName, componentName, language string, code []byte) error {
+	rel, err := filepath.Rel(f.root, fileName)
+	if err != nil {
+		return err
+	}
+	rel = filepath.ToSlash(rel)
+	stateFileName := f.stateFileName(rel)
+	previous, err := os.ReadFile(stateFileName)
+	isNew := errors.Is(err, os.ErrNotExist)
+	if err != nil && !isNew {
+		return fmt.Errorf("failed to read the feed state of %s: %w", rel, err)
+	}
+	if !isNew && bytes.Equal(previous, code) {
+		return nil
+	}
+	if err = os.MkdirAll(f.stateDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create the feed state directory: %w", err)
+	}
+	if err = os.WriteFile(stateFileName, code, 0o644); err != nil {
+		return fmt.Errorf("failed to write the feed state of %s: %w", rel, err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.baseline {
+		return nil
+	}
+	item := feedItem{Component: componentName, Snippet: rel, Language: language, Changed: f.now().UTC().Truncate(time.Second), New: isNew}
+	for _, op := range diffLines(splitLines(previous), splitLines(code)) {
+		switch op.kind {
+		case '+':
+			item.Added++
+		case '-':
+			item.Removed++
+		}
+	}
+	f.items = slices.DeleteFunc(f.items, func(i feedItem) bool { return i.Snippet == rel })
+	f.items = slices.Insert(f.items, 0, item)
+	f.items = f.items[:min(len(f.items), feedSize)]
+	f.dirty = true
+	return nil
+}
+
+// remove drops a deleted snippet from the feed.
+func (f *feed) remove(fileName string) {
+	rel, err := filepath.Rel(f.root, fileName)
+	if err != nil {
+		return
+	}
+	rel = filepath.ToSlash(rel)
+	_ = os.Remove(f.stateFileName(rel))
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := len(f.items)
+	f.items = slices.DeleteFunc(f.items, func(i feedItem) bool { return i.Snippet == rel })
+	f.dirty = f.dirty || len(f.items) != n
+}
+
+// write writes the feed file, if it changed.
+func (f *feed) write() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.dirty {
+		return nil
+	}
+	data, err := json.MarshalIndent(feedFile{Updated: f.now().UTC().Truncate(time.Second), Items: append([]feedItem{}, f.items...)}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.WriteFile(f.fileName, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write feed: %w", err)
+	}
+	f.baseline, f.dirty = false, false
+	return nil
+}
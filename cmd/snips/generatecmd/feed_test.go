@@ -0,0 +1,118 @@
+package generatecmd
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestRunFeed(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "docs")
+	if err := os.Mkdir(root, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	feedFileName := filepath.Join(t.TempDir(), "snippets.json")
+	writeSnippet := func(name, code string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(root, name), []byte(code), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run := func() feedFile {
+		t.Helper()
+		args := Arguments{Path: root, WorkerCount: 1, Feed: feedFileName}
+		if err := Run(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)), args); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		data, err := os.ReadFile(feedFileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var f feedFile
+		if err = json.Unmarshal(data, &f); err != nil {
+			t.Fatal(err)
+		}
+		return f
+	}
+
+	writeSnippet("main.code.go", "package main\n\nfunc main() {}\n")
+	if f := run(); len(f.Items) != 0 {
+		t.Fatalf("expected the first run to only record snippets, got %+v", f.Items)
+	}
+
+	writeSnippet("main.code.go", "package main\n\nimport \"fmt\"\n\nfunc main() { fmt.Println() }\n")
+	writeSnippet("hello.code.py", "---\nlanguage: python\n---\nprint(\"hello\")\n")
+	f := run()
+	expected := []feedItem{
+		{Component: "MainGo", Snippet: "main.code.go", Language: "Go", Added: 3, Removed: 1},
+		{Component: "HelloPy", Snippet: "hello.code.py", Language: "Python", Added: 1, New: true},
+	}
+	ignoreChanged := cmpopts.IgnoreFields(feedItem{}, "Changed")
+	sortItems := cmpopts.SortSlices(func(a, b feedItem) bool { return a.Snippet < b.Snippet })
+	if diff := cmp.Diff(expected, f.Items, ignoreChanged, sortItems); diff != "" {
+		t.Errorf("unexpected feed (-want +got):\n%s", diff)
+	}
+	for _, item := range f.Items {
+		if time.Since(item.Changed) > time.Minute {
+			t.Errorf("expected %s to have changed now, got %v", item.Snippet, item.Changed)
+		}
+	}
+
+	if f = run(); len(f.Items) != 2 {
+		t.Errorf("expected unchanged snippets to keep their items, got %+v", f.Items)
+	}
+}
+
+func TestFeedRecord(t *testing.T) {
+	root := t.TempDir()
+	fd, err := newFeed(root, filepath.Join(root, "snippets.json"), filepath.Join(root, ".snips", "feed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fd.baseline = false
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	fd.now = func() time.Time { return now }
+
+	for i := range feedSize + 1 {
+		fileName := filepath.Join(root, "views", string(rune('a'+i%26))+string(rune('a'+i/26))+".code.go")
+		if err = fd.record(fileName, "Component", "Go", []byte("package main\n")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(fd.items) != feedSize {
+		t.Fatalf("expected the feed to be capped at %d items, got %d", feedSize, len(fd.items))
+	}
+
+	fileName := filepath.Join(root, "views", "ab.code.go")
+	if err = fd.record(fileName, "Component", "Go", []byte("package main\n\nfunc f() {}\n")); err != nil {
+		t.Fatal(err)
+	}
+	expected := feedItem{Component: "Component", Snippet: "views/ab.code.go", Language: "Go", Changed: now, Added: 2}
+	if diff := cmp.Diff(expected, fd.items[0]); diff != "" {
+		t.Errorf("expected the latest change first (-want +got):\n%s", diff)
+	}
+	if len(fd.items) != feedSize {
+		t.Errorf("expected a single item per snippet, got %d items", len(fd.items))
+	}
+
+	fd.remove(fileName)
+	for _, item := range fd.items {
+		if item.Snippet == "views/ab.code.go" {
+			t.Fatalf("expected the removed snippet to be dropped from the feed")
+		}
+	}
+	if err = fd.record(fileName, "Component", "Go", []byte("package main\n")); err != nil {
+		t.Fatal(err)
+	}
+	if !fd.items[0].New {
+		t.Errorf("expected a snippet added again to be new")
+	}
+}
@@ -0,0 +1,75 @@
+package generatecmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/garrettladley/snips"
+)
+
+// ReadFileList reads a newline delimited list of files to generate, as given
+// to -files by build systems that compute the set of snippets themselves.
+// Blank lines are ignored.
+func ReadFileList(r io.Reader) (files []string, err error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			files = append(files, line)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read file list: %w", err)
+	}
+	return files, nil
+}
+
+// checkSnippet checks that a file given to -files is an existing snippet of
+// src, and returns its absolute path.
+func checkSnippet(src source, fileName string) (string, error) {
+	if !IsSnippet(fileName) {
+		return "", fmt.Errorf("%q is not a snippet, expected a .code. file", fileName)
+	}
+	fileName, err := src.abs(fileName)
+	if err != nil {
+		return "", fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	info, err := src.Stat(fileName)
+	if err != nil {
+		return "", fmt.Errorf("failed to read snippet: %w", err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("%q is a directory, expected a snippet", fileName)
+	}
+	return fileName, nil
+}
+
+// dirSnippets returns the snippets directly in dir.
+func dirSnippets(dir string) (fileNames []string, err error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && IsSnippet(entry.Name()) {
+			fileNames = append(fileNames, filepath.Join(dir, entry.Name()))
+		}
+	}
+	return fileNames, nil
+}
+
+// IsSnippet reports whether the file is a snippet, rather than code generated
+// from one.
+func IsSnippet(name string) bool {
+	return snips.ContainsDotCodeDot(name) && !strings.HasSuffix(name, "_templ.go") && !strings.HasSuffix(name, testFileSuffix) && !strings.HasSuffix(name, exampleFileSuffix) && !strings.HasSuffix(name, textSuffix)
+}
+
+// testFileSuffix is that of the code generated into the external test
+// package, see Arguments.TestPackage.
+const testFileSuffix = "_templ_test.go"
+
+// exampleFileSuffix is that of the examples of -examples.
+const exampleFileSuffix = "_example_test.go"
@@ -0,0 +1,70 @@
+package generatecmd
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReadFileList(t *testing.T) {
+	files, err := ReadFileList(strings.NewReader("a.code.go\n\n  b/c.code.rs  \r\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]string{"a.code.go", "b/c.code.rs"}, files); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestCheckSnippet(t *testing.T) {
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "a.code.go")
+	if err := os.WriteFile(fileName, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := checkSnippet(source{}, fileName); err != nil || got != fileName {
+		t.Errorf("checkSnippet(%q) = %q, %v", fileName, got, err)
+	}
+	for _, invalid := range []string{
+		filepath.Join(dir, "missing.code.go"),
+		filepath.Join(dir, "a.code.go_templ.go"),
+		filepath.Join(dir, "main.go"),
+	} {
+		if _, err := checkSnippet(source{}, invalid); err == nil {
+			t.Errorf("checkSnippet(%q) expected an error", invalid)
+		}
+	}
+}
+
+func TestRunDir(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "docs")
+	if err := os.MkdirAll(filepath.Join(root, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.code.go", filepath.Join("sub", "b.code.go")} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("package main\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	args := Arguments{Path: root, Dir: root, WorkerCount: 1}
+	if err := Run(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)), args); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "a.code.go_templ.go")); err != nil {
+		t.Errorf("expected the snippet in the directory to be generated: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "sub", "b.code.go_templ.go")); err == nil {
+		t.Error("expected the snippet in the subdirectory not to be generated")
+	}
+
+	if err := NewGenerate(nil, Arguments{Dir: root, FileName: "a.code.go"}).checkArgs(); err == nil {
+		t.Error("expected an error for -dir with -f")
+	}
+}
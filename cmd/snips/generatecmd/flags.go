@@ -0,0 +1,251 @@
+package generatecmd
+
+import (
+	"flag"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"time"
+)
+
+// RegisterFlags defines the flags of snips generate that set the arguments in
+// fs, with their defaults, e.g. -style for Style.
+func (args *Arguments) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&args.FileName, "f", "", "")
+	fs.StringVar(&args.Path, "path", ".", "")
+	fs.StringVar(&args.Dir, "dir", "", "")
+	fs.Func("only", "", func(s string) error {
+		args.Only = splitList(s)
+		return nil
+	})
+	fs.Var(listFlag{&args.Include}, "include", "")
+	fs.Var(listFlag{&args.Exclude}, "exclude", "")
+	fs.StringVar(&args.Since, "since", "", "")
+	fs.BoolVar(&args.Staged, "staged", false, "")
+	fs.BoolVar(&args.Check, "check", false, "")
+	fs.BoolVar(&args.CheckLinks, "check-links", false, "")
+	fs.BoolVar(&args.History, "history", false, "")
+	fs.BoolVar(&args.Hermetic, "hermetic", false, "")
+	fs.BoolVar(&args.Offline, "offline", false, "")
+	fs.BoolVar(&args.Fast, "fast", false, "")
+	fs.StringVar(&args.Out, "out", "", "")
+	fs.BoolVar(&args.Watch, "watch", false, "")
+	fs.DurationVar(&args.WatchBatch, "watch-batch", 0, "")
+	fs.DurationVar(&args.FileTimeout, "file-timeout", 30*time.Second, "")
+	fs.IntVar(&args.MaxErrors, "max-errors", 0, "")
+	fs.StringVar(&args.MaxMemory, "max-memory", "", "")
+	fs.IntVar(&args.MaxOpenFiles, "max-open-files", 0, "")
+	fs.StringVar(&args.CacheDir, "cache-dir", "", "")
+	fs.StringVar(&args.Baseline, "baseline", "", "")
+	fs.StringVar(&args.HTTPAddr, "http", "", "")
+	fs.BoolVar(&args.Wait, "wait", false, "")
+	fs.BoolVar(&args.NoLock, "no-lock", false, "")
+	fs.StringVar(&args.ChromaVersion, "chroma-version", "", "")
+	fs.StringVar(&args.Compat, "compat", "", "")
+	fs.BoolVar(&args.UpdateBaseline, "update-baseline", false, "")
+	fs.BoolVar(&args.ForceOverwrite, "force-overwrite", false, "")
+	fs.StringVar(&args.OTLPEndpoint, "otlp-endpoint", "", "")
+	fs.BoolVar(&args.Notify, "notify", false, "")
+	fs.StringVar(&args.Style, "style", "swapoff", "")
+	fs.StringVar(&args.Lang, "lang", "", "")
+	args.StyleAliases = make(map[string]string)
+	fs.Var(styleAliasFlag(args.StyleAliases), "style-alias", "")
+	args.Plugins = make(map[string]string)
+	fs.Var(pluginFlag(args.Plugins), "plugin", "")
+	fs.IntVar(&args.TabWidth, "tab-width", 8, "")
+	fs.BoolVar(&args.Lines, "line-numbers", false, "")
+	fs.BoolVar(&args.LinesTable, "line-numbers-table", false, "")
+	fs.BoolVar(&args.TableVariants, "table-variants", false, "")
+	fs.BoolVar(&args.PositionComments, "position-comments", false, "")
+	fs.IntVar(&args.LinesWidth, "line-numbers-width", 0, "")
+	fs.StringVar(&args.LinesClass, "line-numbers-class", "", "")
+	fs.StringVar(&args.Focus, "focus", "", "")
+	fs.BoolVar(&args.WordDiff, "word-diff", false, "")
+	fs.BoolVar(&args.LanguageBadge, "language-badge", false, "")
+	fs.BoolVar(&args.AnalyticsAttrs, "analytics-attrs", false, "")
+	fs.BoolVar(&args.ContentHash, "content-hash", false, "")
+	fs.BoolVar(&args.AttributionFooter, "attribution-footer", false, "")
+	args.Messages = make(map[string]string)
+	fs.Var(messagesFlag(args.Messages), "message", "")
+	fs.BoolVar(&args.Runtime, "runtime", false, "")
+	fs.StringVar(&args.Themes, "themes", "", "")
+	fs.BoolVar(&args.BidiSafe, "bidi-safe", false, "")
+	fs.StringVar(&args.ScanUnicode, "scan-unicode", "", "")
+	fs.StringVar(&args.InvalidUTF8, "invalid-utf8", "replace", "")
+	fs.BoolVar(&args.FailOnSecrets, "fail-on-secrets", false, "")
+	fs.IntVar(&args.BaseLine, "base-line", 0, "")
+	fs.BoolVar(&args.LinkableLines, "linkable-lines", false, "")
+	fs.IntVar(&args.WorkerCount, "w", runtime.NumCPU(), "")
+	fs.BoolVar(&args.Split, "split", false, "")
+	fs.StringVar(&args.SharedDir, "dedupe", "", "")
+	fs.BoolVar(&args.SizeReport, "size-report", false, "")
+	fs.StringVar(&args.Feed, "feed", "", "")
+	fs.StringVar(&args.StreamThreshold, "stream-threshold", "", "")
+	fs.IntVar(&args.MaxFilesPerPackage, "max-files-per-package", 0, "")
+	fs.BoolVar(&args.AggregateDirs, "aggregate-dirs", false, "")
+	fs.BoolVar(&args.TestPackage, "test-package", false, "")
+	fs.BoolVar(&args.Examples, "examples", false, "")
+	fs.StringVar(&args.SizeBudget, "size-budget", "", "")
+	fs.StringVar(&args.WrapperClass, "wrapper-class", "", "")
+	fs.StringVar(&args.Header.Text, "header", "", "")
+	fs.StringVar(&args.Header.CodeGenerated, "generated-comment", "", "")
+	fs.StringVar(&args.Header.LintIgnore, "lint-ignore", "", "")
+	fs.StringVar(&args.Header.Pragmas, "pragmas", "", "")
+	fs.StringVar(&args.SymbolsFile, "symbols", "", "")
+	args.Vars = make(map[string]string)
+	fs.Var(varsFlag(args.Vars), "var", "")
+	args.BuildTags = make(map[string]string)
+	fs.Var(buildTagsFlag(args.BuildTags), "build-tags", "")
+	fs.BoolVar(&args.Lazy, "lazy", false, "")
+	fs.BoolVar(&args.KeepOrphanedFiles, "keep-orphaned-files", false, "")
+}
+
+// MapFlag is implemented by flags that collect repeated KEY=value flags into
+// a mapping, which the config file's mapping is merged into by key.
+type MapFlag interface {
+	flag.Value
+	Entries() map[string]string
+}
+
+// listFlag collects the values of a repeated flag, e.g. -include, which the
+// config file may set as a list.
+type listFlag struct {
+	values *[]string
+}
+
+func (l listFlag) String() string {
+	if l.values == nil {
+		return ""
+	}
+	return strings.Join(*l.values, ",")
+}
+
+func (l listFlag) Set(s string) error {
+	*l.values = append(*l.values, s)
+	return nil
+}
+
+func (l listFlag) Get() any {
+	return *l.values
+}
+
+// varsFlag collects repeated -var NAME=value flags.
+type varsFlag map[string]string
+
+func (v varsFlag) Entries() map[string]string { return v }
+
+func (v varsFlag) String() string {
+	var sb strings.Builder
+	for i, name := range slices.Sorted(maps.Keys(v)) {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString(name + "=" + v[name])
+	}
+	return sb.String()
+}
+
+func (v varsFlag) Set(s string) error {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("expected NAME=value, got %q", s)
+	}
+	v[name] = value
+	return nil
+}
+
+// styleAliasFlag collects repeated -style-alias alias=style flags.
+type styleAliasFlag map[string]string
+
+func (a styleAliasFlag) Entries() map[string]string { return a }
+
+func (a styleAliasFlag) String() string {
+	return varsFlag(a).String()
+}
+
+func (a styleAliasFlag) Set(s string) error {
+	alias, style, ok := strings.Cut(s, "=")
+	if !ok || alias == "" || style == "" {
+		return fmt.Errorf("expected alias=style, got %q", s)
+	}
+	a[alias] = style
+	return nil
+}
+
+// messagesFlag collects repeated -message id=text flags.
+type messagesFlag map[string]string
+
+func (m messagesFlag) Entries() map[string]string { return m }
+
+func (m messagesFlag) String() string {
+	return varsFlag(m).String()
+}
+
+func (m messagesFlag) Set(s string) error {
+	id, text, ok := strings.Cut(s, "=")
+	if !ok || id == "" {
+		return fmt.Errorf("expected id=text, got %q", s)
+	}
+	m[id] = text
+	return nil
+}
+
+// pluginFlag collects repeated -plugin stage=command flags.
+type pluginFlag map[string]string
+
+func (p pluginFlag) Entries() map[string]string { return p }
+
+func (p pluginFlag) String() string {
+	return varsFlag(p).String()
+}
+
+func (p pluginFlag) Set(s string) error {
+	stage, command, ok := strings.Cut(s, "=")
+	if !ok || stage == "" || strings.TrimSpace(command) == "" {
+		return fmt.Errorf("expected stage=command, got %q", s)
+	}
+	p[stage] = command
+	return nil
+}
+
+// buildTagsFlag collects repeated -build-tags [dir=]constraint flags, by
+// directory, "." if none is given.
+type buildTagsFlag map[string]string
+
+func (b buildTagsFlag) Entries() map[string]string { return b }
+
+func (b buildTagsFlag) String() string {
+	var sb strings.Builder
+	for i, dir := range slices.Sorted(maps.Keys(b)) {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString(dir + "=" + b[dir])
+	}
+	return sb.String()
+}
+
+func (b buildTagsFlag) Set(s string) error {
+	dir, expr, ok := strings.Cut(s, "=")
+	if !ok {
+		dir, expr = ".", s
+	}
+	if dir == "" {
+		return fmt.Errorf("expected [dir=]constraint, got %q", s)
+	}
+	b[filepath.ToSlash(filepath.Clean(dir))] = expr
+	return nil
+}
+
+// splitList splits a comma separated list, dropping blank entries.
+func splitList(s string) (list []string) {
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
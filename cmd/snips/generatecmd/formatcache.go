@@ -0,0 +1,81 @@
+package generatecmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"go/format"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// formatCacheSize is the number of formatted files kept in memory, after which
+// the cache starts over, so that long watch sessions don't grow it unbounded.
+const formatCacheSize = 1024
+
+// formatCache stores generated files formatted by gofmt, keyed by the hash of
+// the unformatted code, in memory, and in a directory across runs if set, so
+// that snippets generating the same code don't pay for formatting it again.
+// Failing to read or write the directory is the same as a miss. It's safe for
+// concurrent use.
+type formatCache struct {
+	// dir persists the formatted files across runs, if set.
+	dir string
+	// version is of Go, whose go/format updates may format differently.
+	version string
+
+	mu        sync.Mutex
+	formatted map[[sha256.Size]byte][]byte
+}
+
+func newFormatCache(dir string) *formatCache {
+	return &formatCache{
+		dir:       dir,
+		version:   runtime.Version(),
+		formatted: make(map[[sha256.Size]byte][]byte),
+	}
+}
+
+// source returns the code formatted by format.Source, from the cache if it
+// was formatted before. Errors aren't cached.
+func (c *formatCache) source(code []byte) ([]byte, error) {
+	key := sha256.Sum256(code)
+	c.mu.Lock()
+	formatted, ok := c.formatted[key]
+	c.mu.Unlock()
+	if ok {
+		return formatted, nil
+	}
+	if c.dir != "" {
+		if formatted, err := os.ReadFile(c.fileName(key)); err == nil {
+			c.remember(key, formatted)
+			return formatted, nil
+		}
+	}
+	formatted, err := format.Source(code)
+	if err != nil {
+		return nil, err
+	}
+	c.remember(key, formatted)
+	if c.dir != "" {
+		_ = writeCacheFile(c.fileName(key), formatted)
+	}
+	return formatted, nil
+}
+
+func (c *formatCache) remember(key [sha256.Size]byte, formatted []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.formatted) >= formatCacheSize {
+		clear(c.formatted)
+	}
+	c.formatted[key] = formatted
+}
+
+// fileName returns the file of the formatted code of key in dir, which
+// depends on the Go version.
+func (c *formatCache) fileName(key [sha256.Size]byte) string {
+	h := sha256.Sum256(append([]byte(c.version+"\x00"), key[:]...))
+	return filepath.Join(c.dir, "gofmt", hex.EncodeToString(h[:]))
+}
@@ -0,0 +1,44 @@
+package generatecmd
+
+import (
+	"crypto/sha256"
+	"os"
+	"testing"
+)
+
+func TestFormatCache(t *testing.T) {
+	dir := t.TempDir()
+	code := []byte("package main\nfunc  main( ) {}\n")
+	formatted, err := newFormatCache(dir).source(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "package main\n\nfunc main() {}\n"; string(formatted) != expected {
+		t.Errorf("expected %q, got %q", expected, formatted)
+	}
+
+	// Runs reuse the formatted code of earlier runs.
+	c := newFormatCache(dir)
+	if err = os.WriteFile(c.fileName(sha256.Sum256(code)), []byte("cached\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if formatted, err = c.source(code); err != nil || string(formatted) != "cached\n" {
+		t.Errorf("expected the persisted code, got %q and %v", formatted, err)
+	}
+	other := newFormatCache(dir)
+	other.version += "-next"
+	if formatted, err = other.source(code); err != nil || string(formatted) == "cached\n" {
+		t.Errorf("expected other Go versions to miss, got %q and %v", formatted, err)
+	}
+
+	memory := newFormatCache("")
+	if _, err = memory.source([]byte("package")); err == nil {
+		t.Error("expected invalid code to fail")
+	}
+	if len(memory.formatted) != 0 {
+		t.Errorf("expected errors not to be cached, got %d entries", len(memory.formatted))
+	}
+	if _, err = memory.source(code); err != nil || len(memory.formatted) != 1 {
+		t.Errorf("expected the formatted code to be kept in memory, got %d entries and %v", len(memory.formatted), err)
+	}
+}
@@ -0,0 +1,125 @@
+package generatecmd
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/garrettladley/snips"
+)
+
+// formatterOptions are the settings of the HTML formatter, set for the run by
+// flags, and overridden for each snippet by its front matter.
+type formatterOptions struct {
+	TabWidth      int
+	BaseLine      int
+	Lines         bool
+	LinesTable    bool
+	LinkableLines bool
+}
+
+// override returns the options with those set by the front matter of a
+// snippet, and the front matter keys that set them.
+func (o formatterOptions) override(fm snips.FrontMatter) (formatterOptions, []string) {
+	var keys []string
+	if fm.LineNumbers != nil {
+		o.Lines = *fm.LineNumbers
+		keys = append(keys, "line_numbers")
+	}
+	if fm.LineNumbersTable != nil {
+		o.LinesTable = *fm.LineNumbersTable
+		keys = append(keys, "line_numbers_table")
+	}
+	if fm.BaseLine != nil {
+		o.BaseLine = *fm.BaseLine
+		keys = append(keys, "base_line")
+	}
+	if fm.LinkableLines != nil {
+		o.LinkableLines = *fm.LinkableLines
+		keys = append(keys, "linkable_lines")
+	}
+	return o, keys
+}
+
+// htmlOpts returns the formatter options. Line anchors are prefixed by the
+// slug, if any, so that those of snippets on the same page are apart, e.g.
+// #http-mux-L3.
+func (o formatterOptions) htmlOpts(slug string) []html.Option {
+	prefix := "L"
+	if slug != "" {
+		prefix = slug + "-L"
+	}
+	return []html.Option{
+		html.TabWidth(o.TabWidth),
+		html.BaseLineNumber(o.BaseLine),
+		html.WithLineNumbers(o.Lines),
+		html.LineNumbersInTable(o.LinesTable),
+		html.WithLinkableLineNumbers(o.LinkableLines, prefix),
+	}
+}
+
+// formatterSettings are the effective formatter options of a snippet.
+type formatterSettings struct {
+	formatterOptions
+	// overrides are the front matter keys that overrode the options of the
+	// run.
+	overrides []string
+}
+
+// formatterReport tracks the effective formatter options of each snippet, so
+// that the front matter overriding those of the run can be reviewed.
+type formatterReport struct {
+	// root that file names are reported relative to.
+	root string
+
+	mu       sync.Mutex
+	settings map[string]formatterSettings
+}
+
+func newFormatterReport(root string) *formatterReport {
+	return &formatterReport{
+		root:     root,
+		settings: make(map[string]formatterSettings),
+	}
+}
+
+// set replaces the formatter options of a snippet.
+func (r *formatterReport) set(fileName string, settings formatterSettings) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.settings[fileName] = settings
+}
+
+// remove forgets the formatter options of a deleted snippet.
+func (r *formatterReport) remove(fileName string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.settings, fileName)
+}
+
+// write a table of the effective formatter options of each snippet.
+func (r *formatterReport) write(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FILE\tLINES\tTABLE\tBASE\tLINKABLE\tOVERRIDES")
+	for _, fileName := range slices.Sorted(maps.Keys(r.settings)) {
+		rel, err := filepath.Rel(r.root, fileName)
+		if err != nil {
+			rel = fileName
+		}
+		s := r.settings[fileName]
+		overrides := "-"
+		if len(s.overrides) > 0 {
+			overrides = strings.Join(s.overrides, ",")
+		}
+		fmt.Fprintf(tw, "%s\t%t\t%t\t%d\t%t\t%s\n", filepath.ToSlash(rel), s.Lines, s.LinesTable, s.BaseLine, s.LinkableLines, overrides)
+	}
+	return tw.Flush()
+}
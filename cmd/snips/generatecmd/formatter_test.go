@@ -0,0 +1,60 @@
+package generatecmd
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRunFormatterOverrides(t *testing.T) {
+	fsys := &memFS{files: fstest.MapFS{
+		"views/main.code.go":    {Data: []byte("package main\n")},
+		"views/excerpt.code.go": {Data: []byte("---\nslug: excerpt\nbase_line: 42\n---\npackage main\n")},
+		"views/plain.code.go":   {Data: []byte("---\nline_numbers: false\nlinkable_lines: false\n---\npackage main\n")},
+	}}
+	var report strings.Builder
+	args := Arguments{
+		Path:            t.TempDir(),
+		FS:              fsys,
+		WorkerCount:     2,
+		Lines:           true,
+		LinkableLines:   true,
+		BaseLine:        1,
+		FormatterReport: &report,
+	}
+	if err := Run(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)), args); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for fileName, expected := range map[string]string{
+		"views/main.code.go_templ.go":    `id=\"L1\"`,
+		"views/excerpt.code.go_templ.go": `id=\"excerpt-L42\"`,
+		"views/plain.code.go_templ.go":   "<pre",
+	} {
+		generated := string(fsys.files[fileName].Data)
+		if !strings.Contains(generated, expected) {
+			t.Errorf("expected %s to contain %s, got:\n%s", fileName, expected, generated)
+		}
+	}
+	if generated := string(fsys.files["views/plain.code.go_templ.go"].Data); strings.Contains(generated, "href=") {
+		t.Errorf("expected no line numbers, got:\n%s", generated)
+	}
+
+	lines := strings.Split(strings.TrimSpace(report.String()), "\n")
+	expected := [][]string{
+		{"FILE", "LINES", "TABLE", "BASE", "LINKABLE", "OVERRIDES"},
+		{"views/excerpt.code.go", "true", "false", "42", "true", "base_line"},
+		{"views/main.code.go", "true", "false", "1", "true", "-"},
+		{"views/plain.code.go", "false", "false", "1", "false", "line_numbers,linkable_lines"},
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("expected %d lines, got:\n%s", len(expected), report.String())
+	}
+	for i, fields := range expected {
+		if got := strings.Fields(lines[i]); strings.Join(got, " ") != strings.Join(fields, " ") {
+			t.Errorf("expected line %d to be %q, got %q", i, fields, lines[i])
+		}
+	}
+}
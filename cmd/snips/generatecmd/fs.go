@@ -0,0 +1,78 @@
+package generatecmd
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/garrettladley/snips"
+)
+
+// WriteFS is a filesystem that generated files can be written back to, e.g.
+// an in-memory filesystem in tests. Names are slash separated and relative to
+// its root, as with fs.FS.
+type WriteFS interface {
+	fs.FS
+	Writer
+}
+
+// source reads snippets from fsys, as if it were the directory root, or from
+// the local filesystem if fsys is nil.
+type source struct {
+	root string
+	fsys fs.FS
+}
+
+// name returns the name in fsys of the file fileName.
+func (s source) name(fileName string) (string, error) {
+	rel, err := filepath.Rel(s.root, fileName)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%q is outside of %q", fileName, s.root)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// abs returns the absolute path of fileName, which is relative to the root of
+// fsys, or the working directory on the local filesystem.
+func (s source) abs(fileName string) (string, error) {
+	if s.fsys != nil && !filepath.IsAbs(fileName) {
+		return filepath.Join(s.root, fileName), nil
+	}
+	return filepath.Abs(fileName)
+}
+
+func (s source) ReadFile(fileName string) ([]byte, error) {
+	if s.fsys == nil {
+		return os.ReadFile(fileName)
+	}
+	name, err := s.name(fileName)
+	if err != nil {
+		return nil, err
+	}
+	return fs.ReadFile(s.fsys, name)
+}
+
+func (s source) Stat(fileName string) (fs.FileInfo, error) {
+	if s.fsys == nil {
+		return os.Stat(fileName)
+	}
+	name, err := s.name(fileName)
+	if err != nil {
+		return nil, err
+	}
+	return fs.Stat(s.fsys, name)
+}
+
+// packageName returns the package name of the directory dir.
+func (s source) packageName(dir string) string {
+	if s.fsys == nil {
+		return snips.PackageName(dir)
+	}
+	name, err := s.name(dir)
+	if err != nil {
+		return filepath.Base(dir)
+	}
+	return snips.PackageNameFS(s.fsys, name)
+}
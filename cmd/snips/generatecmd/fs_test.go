@@ -0,0 +1,69 @@
+package generatecmd
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+// memFS is an in-memory WriteFS.
+type memFS struct {
+	mu    sync.Mutex
+	files fstest.MapFS
+}
+
+func (m *memFS) Open(name string) (fs.File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.files.Open(name)
+}
+
+func (m *memFS) WriteFile(name string, contents []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files[name] = &fstest.MapFile{Data: contents}
+	return nil
+}
+
+func TestRunFS(t *testing.T) {
+	fsys := &memFS{files: fstest.MapFS{
+		"views/hello.code.go": {Data: []byte("package main\n")},
+		"views/page.templ":    {Data: []byte("package pages\n")},
+		"docs/intro.code.txt": {Data: []byte("Hello\n")},
+		"vendor/x/y.code.go":  {Data: []byte("package y\n")},
+		"views/notsnippet.go": {Data: []byte("package views\n")},
+	}}
+	// The root doesn't exist, so nothing can be read from or written to disk.
+	root := filepath.Join(t.TempDir(), "missing")
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	if err := Run(context.Background(), log, Arguments{Path: root, FS: fsys, WorkerCount: 2}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for name, expected := range map[string]string{
+		"views/hello.code.go_templ.go": "package pages",
+		"docs/intro.code.txt_templ.go": "package docs",
+	} {
+		f, ok := fsys.files[name]
+		if !ok {
+			t.Errorf("expected %q to be generated", name)
+			continue
+		}
+		if !strings.Contains(string(f.Data), expected) {
+			t.Errorf("expected %q to contain %q, got:\n%s", name, expected, f.Data)
+		}
+	}
+	if _, ok := fsys.files["views/notsnippet.go_templ.go"]; ok {
+		t.Error("expected only snippets to be generated")
+	}
+
+	if err := Run(context.Background(), log, Arguments{Path: root, FS: fstest.MapFS{}}); err == nil {
+		t.Error("expected an error for a read-only FS without an output")
+	}
+}
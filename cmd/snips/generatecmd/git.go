@@ -0,0 +1,116 @@
+package generatecmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/garrettladley/snips/cmd/snips/generatecmd/watcher"
+)
+
+// git runs git in dir, returning its output.
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	c := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	c.Stdout, c.Stderr = &stdout, &stderr
+	if err := c.Run(); err != nil {
+		return "", fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// changedFiles returns the absolute paths of the files of the git repository
+// containing dir that changed since the commit where ref branched off,
+// including uncommitted and untracked files.
+func changedFiles(ctx context.Context, dir, ref string) (files []string, err error) {
+	base, err := git(ctx, dir, "merge-base", ref, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	diff, err := gitFiles(ctx, dir, "diff", "--name-only", "-z", strings.TrimSpace(base))
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := gitFiles(ctx, dir, "ls-files", "--others", "--exclude-standard", "--full-name", "-z")
+	if err != nil {
+		return nil, err
+	}
+	files = append(diff, untracked...)
+	slices.Sort(files)
+	return slices.Compact(files), nil
+}
+
+// stagedFiles returns the absolute paths of the files staged for commit in the
+// git repository containing dir, other than deleted files.
+func stagedFiles(ctx context.Context, dir string) (files []string, err error) {
+	return gitFiles(ctx, dir, "diff", "--cached", "--name-only", "--diff-filter=d", "-z")
+}
+
+// gitFiles runs a git command that lists NUL separated paths relative to the
+// root of the repository containing dir, returning their absolute paths.
+func gitFiles(ctx context.Context, dir string, args ...string) (files []string, err error) {
+	// The root is found relative to dir, rather than with --show-toplevel, so
+	// that paths match dir even if it's reached through a symlink.
+	cdup, err := git(ctx, dir, "rev-parse", "--show-cdup")
+	if err != nil {
+		return nil, err
+	}
+	root := filepath.Join(dir, strings.TrimSpace(cdup))
+	out, err := git(ctx, dir, args...)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range strings.Split(out, "\x00") {
+		if name != "" {
+			files = append(files, filepath.Join(root, filepath.FromSlash(name)))
+		}
+	}
+	return files, nil
+}
+
+// affectedSnippets returns the snippets under dir affected by changes to the
+// given files: changed snippets, and the snippets of directories whose .templ
+// files changed, since they may have changed package. all reports whether
+// inputs of every snippet changed, such as the -symbols file, so that every
+// snippet needs generating.
+func affectedSnippets(dir string, changed, inputs []string) (files []string, all bool, err error) {
+	for _, fileName := range changed {
+		if slices.Contains(inputs, fileName) {
+			return nil, true, nil
+		}
+		rel, err := filepath.Rel(dir, fileName)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) || inSkippedDir(rel) {
+			continue
+		}
+		switch {
+		case IsSnippet(fileName):
+			if _, err := os.Stat(fileName); err == nil {
+				files = append(files, fileName)
+			}
+		case strings.HasSuffix(fileName, ".templ"):
+			entries, err := os.ReadDir(filepath.Dir(fileName))
+			if err != nil && !os.IsNotExist(err) {
+				return nil, false, fmt.Errorf("failed to read snippet directory: %w", err)
+			}
+			for _, entry := range entries {
+				if !entry.IsDir() && IsSnippet(entry.Name()) {
+					files = append(files, filepath.Join(filepath.Dir(fileName), entry.Name()))
+				}
+			}
+		}
+	}
+	slices.Sort(files)
+	return slices.Compact(files), false, nil
+}
+
+// inSkippedDir reports whether the relative path is in a directory that isn't
+// walked, e.g. vendor.
+func inSkippedDir(rel string) bool {
+	dirs := strings.Split(filepath.ToSlash(filepath.Dir(rel)), "/")
+	return slices.ContainsFunc(dirs, watcher.SkipDir)
+}
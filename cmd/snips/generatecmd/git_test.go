@@ -0,0 +1,75 @@
+package generatecmd
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestChangedSnippets(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	dir := t.TempDir()
+	write := func(name, contents string) {
+		t.Helper()
+		fileName := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(fileName), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fileName, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run := func(args ...string) {
+		t.Helper()
+		if _, err := git(context.Background(), dir, append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("a/x.code.go", "package main\n")
+	write("b/y.code.go", "package main\n")
+	write("b/z.code.txt", "hello\n")
+	write("c/w.code.go", "package main\n")
+	write("symbols.json", "{}\n")
+	run("init", "-q", "-b", "main")
+	run("add", ".")
+	run("commit", "-q", "-m", "initial")
+	run("checkout", "-q", "-b", "feature")
+
+	write("a/x.code.go", "package main // changed\n")
+	run("commit", "-q", "-am", "change a")
+	write("b/page.templ", "package pages\n")
+	write("vendor/v.code.go", "package v\n")
+
+	changed, err := changedFiles(context.Background(), dir, "main")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	files, all, err := affectedSnippets(dir, changed, []string{filepath.Join(dir, "symbols.json")})
+	if err != nil || all {
+		t.Fatalf("unexpected result all=%v, err=%v", all, err)
+	}
+	want := []string{
+		filepath.Join(dir, "a", "x.code.go"),
+		filepath.Join(dir, "b", "y.code.go"),
+		filepath.Join(dir, "b", "z.code.txt"),
+	}
+	if diff := cmp.Diff(want, files); diff != "" {
+		t.Errorf("unexpected snippets (-want +got):\n%s", diff)
+	}
+
+	write("symbols.json", `{"main": "https://example.com"}`+"\n")
+	changed, err = changedFiles(context.Background(), dir, "main")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, all, _ = affectedSnippets(dir, changed, []string{filepath.Join(dir, "symbols.json")}); !all {
+		t.Error("expected a change to the symbols file to affect every snippet")
+	}
+}
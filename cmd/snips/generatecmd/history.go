@@ -0,0 +1,175 @@
+package generatecmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/garrettladley/snips"
+)
+
+// historyFileName is the append-only log of generation runs, relative to the
+// path.
+var historyFileName = filepath.Join(".snips", "history.jsonl")
+
+// historyRecord is a line of the history log, describing a run, or a batch of
+// changes in watch mode.
+type historyRecord struct {
+	Time    time.Time `json:"time"`
+	Version string    `json:"version"`
+	Chroma  string    `json:"chroma"`
+	// Options is the fingerprint of the options of the run, which changes
+	// when any option that affects the generated files does.
+	Options string `json:"options"`
+	Watch   bool   `json:"watch,omitempty"`
+	// Duration is in milliseconds.
+	Duration int64 `json:"duration"`
+	// Changed are the generated files that changed, or were removed,
+	// relative to the path.
+	Changed []string       `json:"changed"`
+	Errors  []historyError `json:"errors,omitempty"`
+}
+
+type historyError struct {
+	// File is the snippet that failed, relative to the path, or "" for the
+	// errors of the run.
+	File  string `json:"file,omitempty"`
+	Error string `json:"error"`
+}
+
+// history collects the changes and errors of a run, appending them to the
+// history log. It's safe for concurrent use.
+type history struct {
+	root     string
+	fileName string
+	options  string
+	watch    bool
+	now      func() time.Time
+
+	mu      sync.Mutex
+	start   time.Time
+	changed []string
+	errors  []historyError
+}
+
+// newHistory returns the history of a run, fingerprinting its options, e.g.
+// -style=github.
+func newHistory(root string, options []string, watch bool) *history {
+	h := sha256.Sum256([]byte(strings.Join(slices.Sorted(slices.Values(options)), "\x00")))
+	return &history{
+		root:     root,
+		fileName: filepath.Join(root, historyFileName),
+		options:  hex.EncodeToString(h[:8]),
+		watch:    watch,
+		now:      time.Now,
+		start:    time.Now(),
+	}
+}
+
+// rel returns the path of the file relative to the root.
+func (h *history) rel(fileName string) string {
+	if rel, err := filepath.Rel(h.root, fileName); err == nil && filepath.IsAbs(fileName) {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.ToSlash(fileName)
+}
+
+// writer returns a writer that records the generated files that w changes.
+func (h *history) writer(w Writer) *historyWriter {
+	return &historyWriter{h: h, w: w}
+}
+
+// historyWriter records the generated files whose contents change, or that are
+// removed, comparing them with the files on disk, so that rewriting a file
+// with the same contents isn't recorded.
+type historyWriter struct {
+	h *history
+	w Writer
+}
+
+func (hw *historyWriter) WriteFile(name string, contents []byte) error {
+	return hw.WriteFiles(outputs{writes: []output{{name: name, contents: contents}}})
+}
+
+func (hw *historyWriter) WriteFiles(o outputs) error {
+	var changed []string
+	for _, out := range o.writes {
+		if existing, err := os.ReadFile(out.name); err != nil || !bytes.Equal(existing, out.contents) {
+			changed = append(changed, hw.h.rel(out.name))
+		}
+	}
+	for _, name := range o.removes {
+		if _, err := os.Stat(name); err == nil {
+			changed = append(changed, hw.h.rel(name))
+		}
+	}
+	if err := writeOutputs(hw.w, o); err != nil {
+		return err
+	}
+	hw.h.mu.Lock()
+	defer hw.h.mu.Unlock()
+	hw.h.changed = append(hw.h.changed, changed...)
+	return nil
+}
+
+func (h *history) fileFailed(fileName string, err error) {
+	if fileName != "" {
+		fileName = h.rel(fileName)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.errors = append(h.errors, historyError{File: fileName, Error: err.Error()})
+}
+
+// flush appends a record of the changes and errors since the last flush to
+// the log, with the error of the run, if any. Batches of watch mode without
+// changes or errors aren't recorded.
+func (h *history) flush(err error) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err != nil && !slices.ContainsFunc(h.errors, func(e historyError) bool { return e.Error == err.Error() }) {
+		h.errors = append(h.errors, historyError{Error: err.Error()})
+	}
+	now := h.now()
+	defer func() {
+		h.start, h.changed, h.errors = now, nil, nil
+	}()
+	if h.watch && len(h.changed) == 0 && len(h.errors) == 0 {
+		return nil
+	}
+	slices.Sort(h.changed)
+	record := historyRecord{
+		Time:     h.start.UTC(),
+		Version:  snips.Version(),
+		Chroma:   ChromaVersion(),
+		Options:  h.options,
+		Watch:    h.watch,
+		Duration: now.Sub(h.start).Milliseconds(),
+		Changed:  slices.Compact(append([]string{}, h.changed...)),
+		Errors:   h.errors,
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(h.fileName), 0o755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	f, err := os.OpenFile(h.fileName, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+	if _, err = f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to append to history: %w", err)
+	}
+	return f.Close()
+}
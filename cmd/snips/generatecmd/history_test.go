@@ -0,0 +1,72 @@
+package generatecmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRunHistory(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "docs")
+	if err := os.Mkdir(root, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeSnippet := func(name, code string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(root, name), []byte(code), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run := func(options ...string) error {
+		args := Arguments{Path: root, WorkerCount: 1, History: true, Options: options}
+		return Run(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)), args)
+	}
+
+	writeSnippet("main.code.go", "package main\n")
+	if err := run("-style=github"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := run("-style=github"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	writeSnippet("broken.code.go", "---\nlanguage: unknown\n---\n")
+	if err := run("-style=monokai"); err == nil {
+		t.Fatal("expected the broken snippet to fail the run")
+	}
+
+	f, err := os.Open(filepath.Join(root, historyFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var records []historyRecord
+	for s := bufio.NewScanner(f); s.Scan(); {
+		var r historyRecord
+		if err = json.Unmarshal(s.Bytes(), &r); err != nil {
+			t.Fatalf("invalid record %q: %v", s.Text(), err)
+		}
+		records = append(records, r)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected a record per run, got %d", len(records))
+	}
+	if diff := cmp.Diff([]string{"main.code.go_templ.go"}, records[0].Changed); diff != "" {
+		t.Errorf("unexpected changes of the first run (-want +got):\n%s", diff)
+	}
+	if len(records[1].Changed) != 0 || len(records[1].Errors) != 0 {
+		t.Errorf("expected the second run to change nothing, got %+v", records[1])
+	}
+	if records[0].Options != records[1].Options || records[1].Options == records[2].Options {
+		t.Errorf("expected the options fingerprint to change with the options, got %q, %q and %q", records[0].Options, records[1].Options, records[2].Options)
+	}
+	if len(records[2].Errors) == 0 || records[2].Errors[0].File != "broken.code.go" {
+		t.Errorf("expected the error of the broken snippet to be recorded, got %+v", records[2].Errors)
+	}
+}
@@ -0,0 +1,25 @@
+package generatecmd
+
+// walkComplete calls OnWalkComplete, if set.
+func (cmd Generate) walkComplete(snippets int) {
+	if cmd.Args.OnWalkComplete != nil {
+		cmd.Args.OnWalkComplete(snippets)
+	}
+}
+
+// fileGenerated calls OnFileGenerated, if set.
+func (cmd Generate) fileGenerated(fileName string, updated bool) {
+	if cmd.Args.OnFileGenerated != nil {
+		cmd.Args.OnFileGenerated(fileName, updated)
+	}
+}
+
+// fileFailed records the error in the history, and calls OnError, if set.
+func (cmd Generate) fileFailed(fileName string, err error) {
+	if cmd.history != nil {
+		cmd.history.fileFailed(fileName, err)
+	}
+	if cmd.Args.OnError != nil {
+		cmd.Args.OnError(fileName, err)
+	}
+}
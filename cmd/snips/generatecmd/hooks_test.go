@@ -0,0 +1,91 @@
+package generatecmd
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// hookRecorder records the calls of the lifecycle hooks.
+type hookRecorder struct {
+	mu        sync.Mutex
+	walked    []int
+	generated []string
+	failed    []string
+}
+
+func (r *hookRecorder) hook(args *Arguments) {
+	args.OnWalkComplete = func(snippets int) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.walked = append(r.walked, snippets)
+	}
+	args.OnFileGenerated = func(fileName string, updated bool) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if updated {
+			r.generated = append(r.generated, filepath.Base(fileName))
+		}
+	}
+	args.OnError = func(fileName string, err error) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.failed = append(r.failed, filepath.Base(fileName))
+	}
+}
+
+func TestLifecycleHooks(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "docs")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, contents := range map[string]string{
+		"a.code.go":       "package main\n",
+		"b.code.go":       "package main\n",
+		"invalid.code.go": "---\nlanguage: unknown\n---\npackage main\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	var r hookRecorder
+	args := Arguments{Path: dir, MaxErrors: 1}
+	r.hook(&args)
+	if err := Run(context.Background(), log, args); err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(r.generated)
+	if diff := cmp.Diff([]int{3}, r.walked); diff != "" {
+		t.Errorf("unexpected walks:\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"a.code.go", "b.code.go"}, r.generated); diff != "" {
+		t.Errorf("unexpected generated files:\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"invalid.code.go"}, r.failed); diff != "" {
+		t.Errorf("unexpected failed files:\n%s", diff)
+	}
+
+	// A single file is walked too.
+	r = hookRecorder{}
+	args.FileName = filepath.Join(dir, "invalid.code.go")
+	args.MaxErrors = 0
+	r.hook(&args)
+	if err := Run(context.Background(), log, args); err == nil {
+		t.Fatal("expected an error")
+	}
+	if diff := cmp.Diff([]int{1}, r.walked); diff != "" {
+		t.Errorf("unexpected walks:\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"invalid.code.go"}, r.failed); diff != "" {
+		t.Errorf("unexpected failed files:\n%s", diff)
+	}
+}
@@ -0,0 +1,87 @@
+package generatecmd
+
+import (
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// inflight coalesces the events of files that are being generated, so that a
+// file that changes while it's generated is generated once more afterwards,
+// with its latest event, rather than concurrently, however many times it
+// changed. It's safe for concurrent use.
+type inflight struct {
+	mu sync.Mutex
+	// pending are the latest events of the files being generated, by name, or
+	// nil if they haven't changed since.
+	pending map[string]*fsnotify.Event
+}
+
+func newInflight() *inflight {
+	return &inflight{pending: make(map[string]*fsnotify.Event)}
+}
+
+// start reports whether the file of the event isn't being generated, marking
+// it as being generated. Otherwise, the event replaces the file's pending
+// event, which next returns once the file is generated.
+func (f *inflight) start(event fsnotify.Event) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.pending[event.Name]; ok {
+		f.pending[event.Name] = &event
+		return false
+	}
+	f.pending[event.Name] = nil
+	return true
+}
+
+// next returns the pending event of a file that was generated, if it changed
+// while it was, keeping it marked as being generated. Otherwise, it marks the
+// file as done.
+func (f *inflight) next(name string) (event fsnotify.Event, ok bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if pending := f.pending[name]; pending != nil {
+		f.pending[name] = nil
+		return *pending, true
+	}
+	delete(f.pending, name)
+	return event, false
+}
+
+// updates coalesces the generation events of files into whether Go code or
+// text was updated, notifying the post-generation handler without waiting for
+// it, so that workers aren't held up while it completes a batch. It's safe for
+// concurrent use.
+type updates struct {
+	mu          sync.Mutex
+	goUpdated   bool
+	textUpdated bool
+	// notify is signalled when there are updates to take.
+	notify chan struct{}
+}
+
+func newUpdates() *updates {
+	return &updates{notify: make(chan struct{}, 1)}
+}
+
+// add records the updates of a generation event.
+func (u *updates) add(ge GenerationEvent) {
+	u.mu.Lock()
+	u.goUpdated = u.goUpdated || ge.GoUpdated
+	u.textUpdated = u.textUpdated || ge.TextUpdated
+	u.mu.Unlock()
+	select {
+	case u.notify <- struct{}{}:
+	default:
+	}
+}
+
+// take returns the updates recorded since the last take, and resets them.
+func (u *updates) take() (goUpdated, textUpdated bool) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	goUpdated, textUpdated = u.goUpdated, u.textUpdated
+	u.goUpdated, u.textUpdated = false, false
+	return goUpdated, textUpdated
+}
@@ -0,0 +1,98 @@
+package generatecmd
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestInflight(t *testing.T) {
+	f := newInflight()
+	if !f.start(fsnotify.Event{Name: "a.code.go", Op: fsnotify.Create}) {
+		t.Fatal("expected the first event to start generating the file")
+	}
+	for _, op := range []fsnotify.Op{fsnotify.Write, fsnotify.Remove, fsnotify.Create} {
+		if f.start(fsnotify.Event{Name: "a.code.go", Op: op}) {
+			t.Fatalf("expected %v to be coalesced while the file is generated", op)
+		}
+	}
+	if !f.start(fsnotify.Event{Name: "b.code.go", Op: fsnotify.Write}) {
+		t.Error("expected other files to be generated concurrently")
+	}
+	event, ok := f.next("a.code.go")
+	if !ok || event.Op != fsnotify.Create {
+		t.Fatalf("expected the latest event to be generated next, got %v, %v", event, ok)
+	}
+	if _, ok = f.next("a.code.go"); ok {
+		t.Fatal("expected no more events")
+	}
+	if !f.start(fsnotify.Event{Name: "a.code.go", Op: fsnotify.Write}) {
+		t.Error("expected the file to be generated again once done")
+	}
+}
+
+// TestInflightChurn checks that a file changing many times while it's being
+// generated is never generated concurrently, and is generated after its last
+// change.
+func TestInflightChurn(t *testing.T) {
+	f := newInflight()
+	var running, overlaps, generated atomic.Int32
+	var last atomic.Int64
+	var wg sync.WaitGroup
+	generate := func(event fsnotify.Event) {
+		defer wg.Done()
+		for ok := true; ok; event, ok = f.next(event.Name) {
+			if running.Add(1) > 1 {
+				overlaps.Add(1)
+			}
+			time.Sleep(time.Millisecond)
+			last.Store(int64(event.Op))
+			generated.Add(1)
+			running.Add(-1)
+		}
+	}
+	const changes = 10_000
+	for i := range changes {
+		op := fsnotify.Write
+		if i == changes-1 {
+			op = fsnotify.Chmod
+		}
+		if event := (fsnotify.Event{Name: "a.code.go", Op: op}); f.start(event) {
+			wg.Add(1)
+			go generate(event)
+		}
+	}
+	wg.Wait()
+	if n := overlaps.Load(); n > 0 {
+		t.Errorf("expected the file never to be generated concurrently, got %d overlaps", n)
+	}
+	if n := generated.Load(); n >= changes {
+		t.Errorf("expected changes to be coalesced, generated %d times", n)
+	}
+	if op := fsnotify.Op(last.Load()); op != fsnotify.Chmod {
+		t.Errorf("expected the last change to be generated last, got %v", op)
+	}
+}
+
+func TestUpdates(t *testing.T) {
+	u := newUpdates()
+	for range 1000 {
+		// Adding never waits for the updates to be taken.
+		u.add(GenerationEvent{GoUpdated: true})
+	}
+	u.add(GenerationEvent{TextUpdated: true})
+	select {
+	case <-u.notify:
+	default:
+		t.Fatal("expected a notification")
+	}
+	if goUpdated, textUpdated := u.take(); !goUpdated || !textUpdated {
+		t.Errorf("expected both updates, got %v, %v", goUpdated, textUpdated)
+	}
+	if goUpdated, textUpdated := u.take(); goUpdated || textUpdated {
+		t.Errorf("expected the updates to be reset, got %v, %v", goUpdated, textUpdated)
+	}
+}
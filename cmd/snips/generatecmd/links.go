@@ -0,0 +1,192 @@
+package generatecmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// linkCheckConcurrency is the number of source URLs checked at once.
+const linkCheckConcurrency = 8
+
+// githubPermalink matches the URLs of files on GitHub pinned to a commit, and
+// their optional line range, e.g.
+// https://github.com/owner/repo/blob/<sha>/main.go#L10-L20.
+var githubPermalink = regexp.MustCompile(`^https://github\.com/([^/]+)/([^/]+)/blob/([0-9a-f]{40})/([^#?]+)(?:#L(\d+)(?:-L(\d+))?)?$`)
+
+// sourceLink is the source_url of a snippet, and its code, as written.
+type sourceLink struct {
+	url  string
+	code []byte
+}
+
+// linkChecks collects the source URLs of snippets, to check that they still
+// resolve once generation completes, and that the code of the lines of
+// pinned GitHub permalinks still matches the snippet. It's safe for
+// concurrent use.
+type linkChecks struct {
+	// root that snippet paths are reported relative to.
+	root   string
+	client *http.Client
+	// rawURL returns the URL of the raw contents of a file of a GitHub
+	// repository, at a commit.
+	rawURL func(owner, repo, sha, path string) string
+
+	mu    sync.Mutex
+	links map[string]sourceLink
+}
+
+func newLinkChecks(root string) *linkChecks {
+	return &linkChecks{
+		root:   root,
+		client: &http.Client{Timeout: 30 * time.Second},
+		rawURL: func(owner, repo, sha, path string) string {
+			return "https://raw.githubusercontent.com/" + owner + "/" + repo + "/" + sha + "/" + path
+		},
+		links: make(map[string]sourceLink),
+	}
+}
+
+// set records the source URL of a snippet, if any.
+func (l *linkChecks) set(fileName, sourceURL string, code []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if sourceURL == "" {
+		delete(l.links, fileName)
+		return
+	}
+	l.links[fileName] = sourceLink{url: sourceURL, code: code}
+}
+
+func (l *linkChecks) remove(fileName string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.links, fileName)
+}
+
+// check checks the source URLs of all snippets, returning the errors of those
+// that no longer resolve, or whose code drifted, sorted by snippet.
+func (l *linkChecks) check(ctx context.Context) error {
+	l.mu.Lock()
+	links := maps.Clone(l.links)
+	l.mu.Unlock()
+
+	fileNames := slices.Sorted(maps.Keys(links))
+	errs := make([]error, len(fileNames))
+	sem := make(chan struct{}, linkCheckConcurrency)
+	var wg sync.WaitGroup
+	for i, fileName := range fileNames {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			if err := l.checkLink(ctx, links[fileName]); err != nil {
+				rel, relErr := filepath.Rel(l.root, fileName)
+				if relErr != nil {
+					rel = fileName
+				}
+				errs[i] = fmt.Errorf("%s: source_url %s: %w", filepath.ToSlash(rel), links[fileName].url, err)
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// checkLink checks that the source URL resolves, and for GitHub permalinks
+// with a line range, that the lines match the code of the snippet.
+func (l *linkChecks) checkLink(ctx context.Context, link sourceLink) error {
+	m := githubPermalink.FindStringSubmatch(link.url)
+	if m == nil || m[5] == "" {
+		_, err := l.get(ctx, link.url)
+		return err
+	}
+	start, _ := strconv.Atoi(m[5])
+	end := start
+	if m[6] != "" {
+		end, _ = strconv.Atoi(m[6])
+	}
+	if start < 1 || end < start {
+		return fmt.Errorf("invalid line range L%s-L%s", m[5], m[6])
+	}
+	path, err := url.PathUnescape(m[4])
+	if err != nil {
+		return err
+	}
+	upstream, err := l.get(ctx, l.rawURL(m[1], m[2], m[3], path))
+	if err != nil {
+		return err
+	}
+	lines := strings.Split(strings.ReplaceAll(string(upstream), "\r\n", "\n"), "\n")
+	if end > len(lines) {
+		return fmt.Errorf("the file has %d lines, the link is to lines %d-%d", len(lines), start, end)
+	}
+	if normalizeCode(strings.Join(lines[start-1:end], "\n")) != normalizeCode(string(link.code)) {
+		return fmt.Errorf("lines %d-%d no longer match the snippet", start, end)
+	}
+	return nil
+}
+
+// get returns the body of the URL, failing unless it responds with a 2xx
+// status, after redirects.
+func (l *linkChecks) get(ctx context.Context, rawURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := l.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("%s responded %s", rawURL, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// normalizeCode returns code without the differences that snippets usually
+// make to the code they're copied from: line endings, trailing whitespace,
+// surrounding blank lines and the indentation common to all lines.
+func normalizeCode(code string) string {
+	lines := strings.Split(strings.ReplaceAll(code, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	for len(lines) > 0 && lines[0] == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	indent := -1
+	for _, line := range lines {
+		if line == "" {
+			continue
+		}
+		n := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent == -1 || n < indent {
+			indent = n
+		}
+	}
+	var b bytes.Buffer
+	for _, line := range lines {
+		if line != "" {
+			line = line[indent:]
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
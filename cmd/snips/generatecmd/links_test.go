@@ -0,0 +1,93 @@
+package generatecmd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLinkChecks(t *testing.T) {
+	const sha = "0123456789abcdef0123456789abcdef01234567"
+	upstream := "package main\n\nfunc main() {\n\tfmt.Println(\"hello\")\n}\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/raw/owner/repo/" + sha + "/cmd/main.go":
+			w.Write([]byte(upstream))
+		case "/ok":
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	links := newLinkChecks("/docs")
+	links.client = server.Client()
+	links.rawURL = func(owner, repo, sha, path string) string {
+		return server.URL + "/raw/" + owner + "/" + repo + "/" + sha + "/" + path
+	}
+	permalink := "https://github.com/owner/repo/blob/" + sha + "/cmd/main.go"
+
+	tests := []struct {
+		name    string
+		url     string
+		code    string
+		wantErr string
+	}{
+		{
+			name: "resolves",
+			url:  server.URL + "/ok",
+		},
+		{
+			name:    "not found",
+			url:     server.URL + "/moved",
+			wantErr: "404 Not Found",
+		},
+		{
+			name: "matching lines",
+			url:  permalink + "#L3-L5",
+			code: "\n\tfunc main() {\n\t\tfmt.Println(\"hello\")   \n\t}\n",
+		},
+		{
+			name: "matching indented line",
+			url:  permalink + "#L4",
+			code: "fmt.Println(\"hello\")",
+		},
+		{
+			name:    "drifted lines",
+			url:     permalink + "#L3-L5",
+			code:    "func main() {\n\tfmt.Println(\"goodbye\")\n}\n",
+			wantErr: "lines 3-5 no longer match the snippet",
+		},
+		{
+			name:    "lines past the end",
+			url:     permalink + "#L5-L9",
+			wantErr: "the file has 6 lines",
+		},
+		{
+			name:    "moved file",
+			url:     "https://github.com/owner/repo/blob/" + sha + "/main.go#L1",
+			wantErr: "404 Not Found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			links.set("/docs/views/main.code.go", tt.url, []byte(tt.code))
+			defer links.remove("/docs/views/main.code.go")
+			err := links.check(context.Background())
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+			if !strings.HasPrefix(err.Error(), "views/main.code.go: source_url "+tt.url+": ") {
+				t.Errorf("expected the error to name the snippet and link, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package generatecmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// lockFileName is the advisory lock of a target path, relative to it.
+var lockFileName = filepath.Join(".snips", "lock")
+
+// lockRetry is how often a lock held by another process is retried.
+const lockRetry = 100 * time.Millisecond
+
+// fileLock is an advisory lock of a target path, held by the process whose
+// PID is written to its lock file, so that concurrent snips processes, e.g.
+// an editor plugin and a terminal watch, don't clobber each other's outputs.
+// Locks left behind by processes that exited are taken over.
+type fileLock struct {
+	fileName string
+	log      *slog.Logger
+	// wait for the lock to be released, instead of failing.
+	wait bool
+}
+
+func newFileLock(log *slog.Logger, dir string, wait bool) *fileLock {
+	return &fileLock{fileName: filepath.Join(dir, lockFileName), log: log, wait: wait}
+}
+
+// lock takes the lock, waiting for it if it's held by another process and
+// wait is set.
+func (l *fileLock) lock(ctx context.Context) error {
+	if err := os.MkdirAll(filepath.Dir(l.fileName), 0o755); err != nil {
+		return fmt.Errorf("failed to create lock: %w", err)
+	}
+	waiting := false
+	for {
+		pid, err := l.tryLock()
+		if err != nil || pid == 0 {
+			return err
+		}
+		if !l.wait {
+			holder := fmt.Sprintf("snips process %d", pid)
+			if pid < 0 {
+				holder = "another snips process"
+			}
+			return fmt.Errorf("%s is locked by %s, wait for it with -wait, or disable locking with -no-lock", filepath.Dir(filepath.Dir(l.fileName)), holder)
+		}
+		if !waiting {
+			l.log.Info("Waiting for lock held by another snips process", slog.String("lock", l.fileName), slog.Int("pid", pid))
+			waiting = true
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(lockRetry):
+		}
+	}
+}
+
+// tryLock takes the lock if it's free, returning the PID of the process that
+// holds it otherwise, or -1 if it's unknown yet.
+func (l *fileLock) tryLock() (pid int, err error) {
+	for {
+		f, err := os.OpenFile(l.fileName, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err == nil {
+			_, err = f.WriteString(strconv.Itoa(os.Getpid()) + "\n")
+			return 0, errors.Join(err, f.Close())
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return 0, fmt.Errorf("failed to create lock: %w", err)
+		}
+		data, err := os.ReadFile(l.fileName)
+		if errors.Is(err, os.ErrNotExist) {
+			// Released since.
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to read lock: %w", err)
+		}
+		pid, err = strconv.Atoi(strings.TrimSpace(string(data)))
+		if err != nil {
+			// The holder hasn't written its PID yet.
+			return -1, nil
+		}
+		if processRunning(pid) {
+			return pid, nil
+		}
+		l.log.Debug("Taking over lock of exited process", slog.String("lock", l.fileName), slog.Int("pid", pid))
+		if err = os.Remove(l.fileName); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return 0, fmt.Errorf("failed to remove stale lock: %w", err)
+		}
+	}
+}
+
+// unlock releases the lock.
+func (l *fileLock) unlock() error {
+	if err := os.Remove(l.fileName); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to release lock: %w", err)
+	}
+	return nil
+}
+
+// processRunning reports whether the process is running. Processes whose state
+// can't be checked, e.g. on platforms without signal 0, are assumed to be.
+func processRunning(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return !errors.Is(p.Signal(syscall.Signal(0)), os.ErrProcessDone)
+}
+
+// lockingWriter holds the lock while each file is written, so that watch mode
+// doesn't hold it between changes.
+type lockingWriter struct {
+	ctx  context.Context
+	lock *fileLock
+	w    Writer
+	// mu serialises the writes of the process, which share the lock.
+	mu sync.Mutex
+}
+
+func (lw *lockingWriter) WriteFile(name string, contents []byte) (err error) {
+	return lw.WriteFiles(outputs{writes: []output{{name: name, contents: contents}}})
+}
+
+// WriteFiles makes the changes to the generated files of a snippet while
+// holding the lock.
+func (lw *lockingWriter) WriteFiles(o outputs) (err error) {
+	lw.mu.Lock()
+	defer lw.mu.Unlock()
+	if err = lw.lock.lock(lw.ctx); err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, lw.lock.unlock())
+	}()
+	return writeOutputs(lw.w, o)
+}
+
+// lockDir returns the local directory that generated files are written to,
+// which is locked while they're written, unless -no-lock is set.
+func (cmd Generate) lockDir() (dir string, ok bool) {
+	if cmd.Args.NoLock || cmd.Args.Check || cmd.Args.FileWriter != nil || cmd.Args.FS != nil {
+		return "", false
+	}
+	if cmd.Args.Out == "" {
+		return cmd.Args.Path, true
+	}
+	u := parseOut(cmd.Args.Out)
+	if !strings.EqualFold(u.Scheme, "file") {
+		return "", false
+	}
+	return filepath.FromSlash(u.Host + u.Path), true
+}
@@ -0,0 +1,56 @@
+package generatecmd
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFileLock(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	held := newFileLock(log, dir, false)
+	if err := held.lock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := newFileLock(log, dir, false).lock(ctx); err == nil || !strings.Contains(err.Error(), "-wait") {
+		t.Errorf("expected the held lock to fail, got %v", err)
+	}
+
+	waited := make(chan error)
+	go func() {
+		waited <- newFileLock(log, dir, true).lock(ctx)
+	}()
+	time.Sleep(2 * lockRetry)
+	if err := held.unlock(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-waited:
+		if err != nil {
+			t.Fatalf("expected to get the lock once released, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected to get the lock once released")
+	}
+	if err := held.unlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("exited process", func(t *testing.T) {
+		// PIDs are below 2^22 on Linux, so no process has this one.
+		if err := os.WriteFile(filepath.Join(dir, lockFileName), []byte("1073741824\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := newFileLock(log, dir, false).lock(ctx); err != nil {
+			t.Errorf("expected to take over the lock, got %v", err)
+		}
+	})
+}
@@ -3,14 +3,20 @@ package generatecmd
 import (
 	"context"
 	_ "embed"
+	"io"
+	"io/fs"
 	"log/slog"
+	"time"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/garrettladley/snips/generator"
 
 	_ "net/http/pprof"
 )
 
 type Arguments struct {
 	FileName          string
-	FileWriter        FileWriterFunc
+	FileWriter        Writer
 	Path              string
 	Watch             bool
 	Style             string
@@ -22,8 +28,305 @@ type Arguments struct {
 	WorkerCount       int
 	KeepOrphanedFiles bool
 	Lazy              bool
+	Notify            bool
+	SymbolsFile       string
+	WrapperClass      string
+	LinesWidth        int
+	LinesClass        string
+	Focus             string
+	WordDiff          bool
+	Split             bool
+	// TableVariants generates each component twice, with line numbers inline,
+	// e.g. MainGo, and in a table, e.g. MainGoTable, so that sites can pick
+	// the layout per page.
+	TableVariants bool
+	// PositionComments writes the HTML of each line of a snippet as its own
+	// literal in the generated code, commented with the snippet line, to debug
+	// escaping issues.
+	PositionComments bool
+	// Lang is the name or alias of the chroma lexer to highlight snippets
+	// with, e.g. "go", instead of detecting it from their file names and
+	// contents. Snippets may override it with the language front matter key.
+	Lang string
+	// LanguageBadge shows the language of each snippet in a badge.
+	LanguageBadge bool
+	// LanguageLabel returns the text of the language badge of the chroma lexer
+	// with the given name, defaulting to generator.LanguageLabel.
+	LanguageLabel func(lexerName string) string
+	// Themes are the light and dark chroma styles, e.g. "github,monokai", to
+	// highlight with CSS classes, so that readers can switch between them
+	// with runtime.ThemeSwitcher.
+	Themes string
+	// Runtime renders the styles of wrappers with the snips runtime package,
+	// once per page, instead of inlining them into every component.
+	Runtime bool
+	// Fast generates the single file given by FileName with as little work as
+	// possible, for editors that generate on save: the templ version check is
+	// skipped, and so is formatting the generated file if it's unchanged.
+	Fast bool
+	// AnalyticsAttrs adds data-snips-name and data-snips-lang attributes to the
+	// wrapper of each component, for product analytics.
+	AnalyticsAttrs bool
+	// ContentHash adds a data-snips-hash attribute to the wrapper of each
+	// component, the hash of its HTML, for use in cache keys.
+	ContentHash bool
+	// AttributionFooter renders the source and license of snippets that set
+	// them in front matter in a footer below the code.
+	AttributionFooter bool
+	// Messages localize the UI text rendered into generated components, by
+	// message ID, see runtime.Messages.
+	Messages map[string]string
+	// BidiSafe isolates right-to-left text and shows bidi control characters,
+	// so that they can't reorder the rendered code.
+	BidiSafe bool
+	// ScanUnicode warns about, or with "fail" fails on, the zero-width and bidi
+	// control characters of snippets, if set to "warn" or "fail".
+	ScanUnicode string
+	// InvalidUTF8 is the policy for bytes of snippets that aren't valid UTF-8:
+	// "replace" them with U+FFFD, the default, "fail", or keep them "raw",
+	// escaped in the generated code.
+	InvalidUTF8 string
+	// FailOnSecrets fails to generate snippets that look like they contain
+	// credentials, such as AWS keys, private keys or bearer tokens.
+	FailOnSecrets bool
+	// SharedDir is the directory of the package to write deduplicated
+	// highlighted HTML to, if set.
+	SharedDir string
+	// Vars are substituted for {{NAME}} placeholders in snippets.
+	Vars map[string]string
+	// StyleAliases are style names, by alias, that -style, -themes and the
+	// style front matter key may refer to. Targets are chroma style names, or
+	// paths to XML style files.
+	StyleAliases map[string]string
+	// Plugins are the commands of external processes that transform snippets,
+	// by stage, e.g. {"pre-highlight": "./sanitize.sh"}, see
+	// PluginPreHighlight and PluginPostHTML. They're run in Path once per
+	// snippet, or component, given a JSON request on stdin, and responding
+	// with JSON on stdout.
+	Plugins map[string]string
+	// SizeReport logs the size of the highlighted HTML of each component and
+	// package once generation completes.
+	SizeReport bool
+	// SizeBudget is the maximum total size of the highlighted HTML of a package,
+	// e.g. 512KB. Packages over budget are warned about.
+	SizeBudget string
+	// StreamThreshold is the size of highlighted HTML, e.g. 64KB, above which
+	// components are rendered from a compressed blob instead of a constant.
+	StreamThreshold string
+	// MaxFilesPerPackage shards the generated code of directories with more
+	// snippets than this into sub-packages, behind a facade. 0 disables it.
+	MaxFilesPerPackage int
+	// AggregateDirs generates the snippets of each directory into a single
+	// snips_generated_templ.go, with a function per snippet, instead of a file
+	// per snippet.
+	AggregateDirs bool
+	// TestPackage generates each snippet into <snippet>_templ_test.go, in the
+	// external test package of its directory, e.g. views_test, so that its
+	// components are only compiled by go test, and kept out of production
+	// binaries. Snippets may opt in alone with the test_package front matter
+	// key.
+	TestPackage bool
+	// Examples also generates <snippet>_example_test.go, with an example
+	// function for each component, e.g. ExampleMainGo, whose output comment is
+	// the plain text of the snippet, so that go test checks that components
+	// render their snippets, and godoc shows them as examples. The module of
+	// the generated code must require github.com/garrettladley/snips.
+	Examples bool
+	// Text writes the line-numbered plain text of each snippet to
+	// <snippet>_snips.txt, with control characters escaped, instead of
+	// generating code, for consumers that don't need HTML, e.g. man pages.
+	Text bool
+	// ForceOverwrite overwrites generated files that were edited by hand since
+	// they were generated, which are otherwise refused, failing their snippets.
+	// Generated files record the hash of their contents in their header.
+	ForceOverwrite bool
+	// Files to generate, instead of walking Path.
+	Files []string
+	// Dir generates only the snippets directly in the directory, without
+	// walking its subdirectories, e.g. for a go:generate directive in the
+	// package, see snips annotate.
+	Dir string
+	// Only are the names of the components to generate, e.g. HelloWorldGo.
+	// The snippets that generate them are found by walking Path, and only they
+	// are generated.
+	Only []string
+	// Include are globs of the slash-separated paths of snippets relative to
+	// Path, e.g. docs/**/*.code.go, whose ** segments match any number of
+	// directories. Only the snippets matching one of them are generated, or
+	// all if there are none.
+	Include []string
+	// Exclude are globs, like Include, of the snippets to skip even if
+	// included, e.g. **/legacy/**.
+	Exclude []string
+	// Since is a git ref, e.g. origin/main. Only the snippets affected by the
+	// changes since it branched off are generated, instead of walking Path.
+	Since string
+	// Staged only generates the snippets affected by the changes staged for
+	// commit, like Since.
+	Staged bool
+	// Check compares the generated code with the existing generated files
+	// instead of writing it, failing if any are out of date.
+	Check bool
+	// History appends a record of the run, its version, options fingerprint,
+	// duration, the generated files that changed and the errors, to
+	// .snips/history.jsonl in Path, to find out when and why generated files
+	// changed. Watch mode records each batch of changes.
+	History bool
+	// Options are the options of the run, e.g. -style=github, fingerprinted
+	// in History records.
+	Options []string
+	// CheckLinks checks that the source_url of each snippet still resolves
+	// once generation completes, and that the lines of GitHub permalinks
+	// pinned to a commit, e.g. .../blob/<sha>/main.go#L10-L20, still match the
+	// snippet, failing the run with the links that don't.
+	CheckLinks bool
+	// Out is where generated files are written instead of beside their
+	// snippets, a directory or a URL with a registered scheme, see RegisterWriter.
+	Out string
+	// FS is read instead of the local filesystem, as if it were the directory
+	// Path, e.g. an embed.FS or fstest.MapFS. Generated files are written back
+	// to it if it's a WriteFS, otherwise to Out or FileWriter.
+	FS fs.FS
+	// WatchBatch coalesces the changes made within this window into a single
+	// generation pass in watch mode, 0 processes each change as it's made.
+	WatchBatch time.Duration
+	// Rebuild regenerates every snippet in watch mode each time it receives,
+	// with the caches of previous generations invalidated and styles loaded
+	// again, so that scripts can poke a long-running watcher, e.g. with
+	// SIGHUP after a git pull or a theme change, instead of restarting it.
+	Rebuild <-chan struct{}
+	// Header customizes the comments at the top of generated files, e.g. to
+	// add a license.
+	Header generator.Header
+	// BuildTags are the //go:build constraints of the generated files, by
+	// directory relative to Path, "." for all of them, e.g. {"docs": "docs"}.
+	// The constraint of the closest directory applies, and "" clears it.
+	BuildTags map[string]string
+	// DetectReport is written a table of the language detected for each
+	// component, and how it was detected, once generation completes, if set.
+	DetectReport io.Writer
+	// FormatterReport is written a table of the effective HTML formatter
+	// options of each snippet, and the front matter keys overriding those of
+	// the run, once generation completes, if set.
+	FormatterReport io.Writer
+	// Feed maintains a JSON feed of the snippets changed most recently at the
+	// given path, with their language, change time and the number of lines
+	// added and removed, e.g. for a "recently updated examples" widget. The
+	// code of snippets is kept in .snips/feed in Path to find changes across
+	// runs, and the first run, without a feed, only records it.
+	Feed string
+	// HTMLReport is called with the highlighted HTML of each generated
+	// component, and the snippet it was generated from, if set. It may be
+	// called concurrently.
+	HTMLReport func(fileName, componentName, html string)
+	// TokenReport is called with the name of the lexer and the tokens of each
+	// snippet, as it's highlighted, if set. The tokens of the components of
+	// its regions and split definitions aren't reported, since they're part
+	// of the snippet. It may be called concurrently, and mustn't modify the
+	// tokens.
+	TokenReport func(fileName, lexer string, tokens []chroma.Token)
+	// OnWalkComplete is called once the snippets to generate have been found,
+	// with their number, e.g. to show the progress of the run, if set. In
+	// watch mode, it's called again on each Rebuild, and when the snippets
+	// are generated for the last time, once the context is done.
+	OnWalkComplete func(snippets int)
+	// OnFileGenerated is called after each snippet is generated, reporting
+	// whether its generated files changed, if set. It may be called
+	// concurrently.
+	OnFileGenerated func(fileName string, updated bool)
+	// OnError is called with the error of each snippet that fails to generate,
+	// unless the baseline tolerates it, and with a fileName of "" for the
+	// errors of the run, such as failing to walk Path, if set. It may be
+	// called concurrently.
+	OnError func(fileName string, err error)
+	// FileTimeout is how long a single file may take to generate before it's
+	// reported as an error, so that a pathological snippet can't hang the run.
+	// 0 disables it.
+	FileTimeout time.Duration
+	// MaxMemory is the memory that the snippets generated at once may use,
+	// estimated from their sizes, e.g. 512MB, so that runs in memory
+	// constrained containers aren't killed. It defaults to GOMEMLIMIT, if set.
+	MaxMemory string
+	// MaxOpenFiles is the number of files that the snippets generated at once
+	// may hold open, independently of WorkerCount, so that big runs don't fail
+	// with "too many open files". It defaults to the soft limit of open files
+	// of the process, less those reserved for the rest of it.
+	MaxOpenFiles int
+	// CacheDir persists the tokens of snippets across runs, keyed on their
+	// contents and lexers, so that runs that only change presentation options,
+	// such as Style or TabWidth, skip tokenizing. Watch mode always keeps them
+	// in memory for rebuilds. It also persists the generated files formatted
+	// by gofmt, keyed on the unformatted code, which are always kept in memory
+	// for the run.
+	CacheDir string
+	// MaxErrors is the number of files that may fail to generate, which are
+	// reported, before the run fails, e.g. while adopting snips in a large
+	// snippet tree. Errors of the run itself, such as broken links with
+	// CheckLinks, always fail it.
+	MaxErrors int
+	// HTTPAddr serves /healthz and /statusz, reporting the last generation
+	// time, pending events and current errors as JSON, and the /debug/pprof/
+	// profiles, on the given address, e.g. localhost:7331.
+	HTTPAddr string
+	// Wait for the lock of the target path held by another snips process,
+	// instead of failing. Watch mode always waits.
+	Wait bool
+	// NoLock writes generated files without taking the lock of the target
+	// path, .snips/lock, which keeps concurrent snips processes from
+	// clobbering each other's outputs.
+	NoLock bool
+	// ChromaVersion pins the version of chroma, e.g. "v2.14.0", failing the
+	// run if snips was built with another, since they highlight differently.
+	ChromaVersion string
+	// Compat lays generated files out as the given snips version did, e.g.
+	// "v0.1", so that upgrades of large generated trees don't churn them.
+	Compat string
+	// Baseline is a JSON file of the snippets known to fail, whose errors are
+	// tolerated. The run fails if other snippets fail, or if snippets in the
+	// baseline no longer do.
+	Baseline string
+	// UpdateBaseline records the snippets that fail in Baseline, instead of
+	// checking them.
+	UpdateBaseline bool
+	// OTLPEndpoint is the OTLP/HTTP collector, e.g. http://localhost:4318, to
+	// export a trace of the run to, with a span for each file and its phases.
+	// The OTEL_EXPORTER_OTLP_HEADERS environment variable sets its headers,
+	// unless Hermetic is set.
+	OTLPEndpoint string
+	// Hermetic only reads declared inputs, skipping the templ version check and
+	// SNIPS_ environment variables, for sandboxed and reproducible builds.
+	Hermetic bool
+	// Offline refuses the features that would access the network, such as
+	// OTLPEndpoint, Out writers other than file:// and HTTPAddr on addresses
+	// other than loopback ones, for air-gapped build environments.
+	Offline bool
+	// Config is a config file, see LoadConfig, whose settings apply to the
+	// arguments that the caller didn't set, as the snips CLI applies them to
+	// the flags that weren't set. It defaults to the snips.yaml or snips.toml
+	// at the root of the git repository of Path, or in Path if it isn't in
+	// one, unless Hermetic or FS is set. Arguments are set if they're not
+	// zero, or Explicit.
+	Config string
+	// Explicit are the names of the arguments that the caller set, even to
+	// their zero value, e.g. "Lines" or "Header.License", so that Config
+	// doesn't override them.
+	Explicit []string
+	// NoConfig doesn't apply a config file, e.g. for callers that apply it
+	// themselves, as the snips CLI does.
+	NoConfig bool
 }
 
 func Run(ctx context.Context, log *slog.Logger, args Arguments) (err error) {
+	if err = args.applyConfigFile(); err != nil {
+		return err
+	}
 	return NewGenerate(log, args).Run(ctx)
 }
+
+// Validate checks the arguments without generating anything.
+func Validate(args Arguments) error {
+	if err := args.applyConfigFile(); err != nil {
+		return err
+	}
+	return NewGenerate(nil, args).Validate()
+}
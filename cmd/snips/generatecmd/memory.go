@@ -0,0 +1,84 @@
+package generatecmd
+
+import (
+	"context"
+	"math"
+	"runtime/debug"
+	"sync"
+)
+
+const (
+	// memoryPerByte estimates the memory used to generate a snippet per byte of
+	// it, which holds its tokens, highlighted HTML and generated code at once.
+	memoryPerByte = 40
+	// memoryPerFile estimates the memory used to generate any snippet.
+	memoryPerFile = 64 << 10
+)
+
+// memoryBudget limits the snippets generated at once by the estimated memory
+// that generating them uses, so that runs of snippet-heavy repositories
+// aren't killed in memory constrained containers. It's safe for concurrent
+// use.
+type memoryBudget struct {
+	limit int64
+
+	mu    sync.Mutex
+	inUse int64
+	// released is closed, and replaced, whenever memory is released.
+	released chan struct{}
+}
+
+func newMemoryBudget(limit int64) *memoryBudget {
+	return &memoryBudget{limit: limit, released: make(chan struct{})}
+}
+
+// memoryLimit returns the memory budget of a run, which is MaxMemory, or the
+// GOMEMLIMIT of the process, or 0 if there's neither.
+func (cmd Generate) memoryLimit() (int64, error) {
+	limit, err := parseSize(cmd.Args.MaxMemory)
+	if err != nil || limit > 0 {
+		return int64(limit), err
+	}
+	// A negative limit reads it without changing it.
+	if goMemLimit := debug.SetMemoryLimit(-1); goMemLimit < math.MaxInt64 {
+		return goMemLimit, nil
+	}
+	return 0, nil
+}
+
+// estimate returns the estimated memory used to generate a snippet of size
+// bytes.
+func estimate(size int64) int64 {
+	return memoryPerFile + size*memoryPerByte
+}
+
+// acquire waits until generating a snippet using n bytes fits the budget, or
+// until no other snippets are being generated, so that snippets larger than
+// the budget are generated on their own. It returns whether it had to wait.
+func (b *memoryBudget) acquire(ctx context.Context, n int64) (waited bool, err error) {
+	for {
+		b.mu.Lock()
+		if b.inUse == 0 || b.inUse+n <= b.limit {
+			b.inUse += n
+			b.mu.Unlock()
+			return waited, nil
+		}
+		released := b.released
+		b.mu.Unlock()
+		waited = true
+		select {
+		case <-ctx.Done():
+			return waited, ctx.Err()
+		case <-released:
+		}
+	}
+}
+
+// release returns the memory acquired for a snippet to the budget.
+func (b *memoryBudget) release(n int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.inUse -= n
+	close(b.released)
+	b.released = make(chan struct{})
+}
@@ -0,0 +1,124 @@
+package generatecmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMemoryBudget(t *testing.T) {
+	ctx := context.Background()
+	b := newMemoryBudget(100)
+	if waited, err := b.acquire(ctx, 60); waited || err != nil {
+		t.Fatalf("expected the first snippet to fit, got %v, %v", waited, err)
+	}
+
+	acquired := make(chan bool)
+	go func() {
+		waited, err := b.acquire(ctx, 60)
+		if err != nil {
+			t.Error(err)
+		}
+		acquired <- waited
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("expected a snippet over the budget to wait")
+	case <-time.After(50 * time.Millisecond):
+	}
+	b.release(60)
+	if waited := <-acquired; !waited {
+		t.Error("expected the snippet to report that it waited")
+	}
+
+	// Snippets larger than the budget are generated on their own.
+	b.release(60)
+	if waited, err := b.acquire(ctx, 500); waited || err != nil {
+		t.Fatalf("expected a snippet larger than the budget to be generated alone, got %v, %v", waited, err)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := b.acquire(ctx, 1); err == nil {
+		t.Error("expected waiting to stop when the context is done")
+	}
+}
+
+func TestMemoryLimit(t *testing.T) {
+	limit, err := Generate{Args: &Arguments{MaxMemory: "512MB"}}.memoryLimit()
+	if err != nil || limit != 512<<20 {
+		t.Errorf("expected 512MB, got %d, %v", limit, err)
+	}
+	if _, err := (Generate{Args: &Arguments{MaxMemory: "lots"}}).memoryLimit(); err == nil {
+		t.Error("expected an error for an invalid size")
+	}
+}
+
+func TestRunWithinMemoryBudget(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "docs")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.code.go", "b.code.go", "c.code.go"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("package main\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	// Every snippet is over the budget, so they're generated one at a time.
+	if err := Run(context.Background(), log, Arguments{Path: dir, MaxMemory: "1KB", WorkerCount: 3}); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.code.go", "b.code.go", "c.code.go"} {
+		if _, err := os.Stat(filepath.Join(dir, name+"_templ.go")); err != nil {
+			t.Errorf("expected %s to be generated: %v", name, err)
+		}
+	}
+}
+
+func TestRunWithinMemoryBudgetOnceWatchEnds(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "docs")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for i := range 32 {
+		names = append(names, fmt.Sprintf("s%d.code.go", i))
+	}
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("package main\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	// The production pass runs once the context is done, and snippets waiting
+	// for the budget must still be generated.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var generated atomic.Int64
+	args := Arguments{
+		Path:        dir,
+		MaxMemory:   "1KB",
+		WorkerCount: 8,
+		Watch:       true,
+		NoLock:      true,
+		OnFileGenerated: func(string, bool) {
+			if generated.Add(1) == int64(len(names)) {
+				cancel()
+			}
+		},
+	}
+	if err := Run(ctx, log, args); err != nil {
+		t.Fatal(err)
+	}
+	// Each snippet is generated by the development pass, then by the
+	// production pass.
+	if got, want := generated.Load(), int64(2*len(names)); got != want {
+		t.Errorf("expected %d snippets to be generated, got %d", want, got)
+	}
+}
@@ -3,6 +3,7 @@ package modcheck
 import (
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 
 	"github.com/a-h/templ"
@@ -80,3 +81,32 @@ func Check(dir string) error {
 	}
 	return fmt.Errorf("templ not found in go.mod file, run `go get github.com/a-h/templ` to install it")
 }
+
+// ImportPath returns the import path of the package in dir, using the module
+// path of the nearest go.mod file.
+func ImportPath(dir string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	root, err := WalkUp(dir)
+	if err != nil {
+		return "", err
+	}
+	m, err := os.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return "", fmt.Errorf("failed to read go.mod file: %w", err)
+	}
+	modulePath := modfile.ModulePath(m)
+	if modulePath == "" {
+		return "", fmt.Errorf("failed to find the module path in %s", filepath.Join(root, "go.mod"))
+	}
+	rel, err := filepath.Rel(root, dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to get path relative to module root: %w", err)
+	}
+	if rel == "." {
+		return modulePath, nil
+	}
+	return path.Join(modulePath, filepath.ToSlash(rel)), nil
+}
@@ -0,0 +1,54 @@
+package notify
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Send displays a native desktop notification with the given title and message.
+func Send(title, message string) error {
+	cmd, err := command(runtime.GOOS, title, message)
+	if err != nil {
+		return err
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to send notification: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func command(goos, title, message string) (*exec.Cmd, error) {
+	switch goos {
+	case "darwin":
+		script := "display notification " + appleScriptQuote(message) + " with title " + appleScriptQuote(title)
+		return exec.Command("osascript", "-e", script), nil
+	case "linux", "freebsd", "openbsd", "netbsd":
+		return exec.Command("notify-send", title, message), nil
+	case "windows":
+		script := "[void][System.Reflection.Assembly]::LoadWithPartialName('System.Windows.Forms');" +
+			"$n = New-Object System.Windows.Forms.NotifyIcon;" +
+			"$n.Icon = [System.Drawing.SystemIcons]::Information;" +
+			"$n.BalloonTipTitle = " + powerShellQuote(title) + ";" +
+			"$n.BalloonTipText = " + powerShellQuote(message) + ";" +
+			"$n.Visible = $true;" +
+			"$n.ShowBalloonTip(5000);" +
+			"Start-Sleep -Seconds 5;" +
+			"$n.Dispose()"
+		return exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script), nil
+	}
+	return nil, fmt.Errorf("desktop notifications are not supported on %s", goos)
+}
+
+// appleScriptQuote returns s as a double quoted AppleScript string literal.
+func appleScriptQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
+
+// powerShellQuote returns s as a single quoted PowerShell string literal.
+func powerShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
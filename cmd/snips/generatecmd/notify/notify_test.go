@@ -0,0 +1,47 @@
+package notify
+
+import "testing"
+
+func TestAppleScriptQuote(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "plain",
+			in:   "hello world",
+			want: `"hello world"`,
+		},
+		{
+			name: "double quotes",
+			in:   `failed to parse "x.code.go"`,
+			want: `"failed to parse \"x.code.go\""`,
+		},
+		{
+			name: "backslashes",
+			in:   `C:\snippets`,
+			want: `"C:\\snippets"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := appleScriptQuote(tt.in); got != tt.want {
+				t.Errorf("appleScriptQuote(%q) = %s, want %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPowerShellQuote(t *testing.T) {
+	if got, want := powerShellQuote("it's broken"), "'it''s broken'"; got != want {
+		t.Errorf("powerShellQuote() = %s, want %s", got, want)
+	}
+}
+
+func TestCommandUnsupported(t *testing.T) {
+	if _, err := command("plan9", "title", "message"); err == nil {
+		t.Error("expected an error for an unsupported platform")
+	}
+}
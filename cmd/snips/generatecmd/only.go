@@ -0,0 +1,69 @@
+package generatecmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/garrettladley/snips/cmd/snips/generatecmd/watcher"
+)
+
+// onlySnippets returns the snippets that generate the components named by
+// -only, found by walking Path.
+func (cmd Generate) onlySnippets(ctx context.Context) (files []string, err error) {
+	found := make(chan fsnotify.Event)
+	var snippets []string
+	collected := make(chan struct{})
+	go func() {
+		defer close(collected)
+		for event := range found {
+			snippets = append(snippets, event.Name)
+		}
+	}()
+	if cmd.Args.FS != nil {
+		err = watcher.WalkFS(ctx, cmd.Args.FS, cmd.Args.Path, found)
+	} else {
+		err = watcher.WalkFiles(ctx, cmd.Args.Path, found)
+	}
+	close(found)
+	<-collected
+	if err != nil {
+		return nil, fmt.Errorf("failed to find snippets: %w", err)
+	}
+	if files, err = snippetsOf(snippets, cmd.Args.Only); err != nil {
+		return nil, err
+	}
+	cmd.Log.Debug("Snippets of components", slog.Any("components", cmd.Args.Only), slog.Int("count", len(files)))
+	return files, nil
+}
+
+// snippetsOf returns the snippets that generate the named components. The
+// components of regions and split definitions, e.g. UsersProtoUser, are
+// generated by the snippet whose component is the longest prefix of their
+// name, e.g. users.code.proto. Components of the same name in different
+// packages are all generated.
+func snippetsOf(snippets, components []string) (files []string, err error) {
+	for _, name := range components {
+		var matches []string
+		longest := 0
+		for _, snippet := range snippets {
+			component := ComponentName(snippet)
+			if component == "" || len(component) < longest || !strings.HasPrefix(name, component) {
+				continue
+			}
+			if len(component) > longest {
+				matches, longest = nil, len(component)
+			}
+			matches = append(matches, snippet)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no snippet generates component %q", name)
+		}
+		files = append(files, matches...)
+	}
+	slices.Sort(files)
+	return slices.Compact(files), nil
+}
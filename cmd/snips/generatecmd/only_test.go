@@ -0,0 +1,94 @@
+package generatecmd
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSnippetsOf(t *testing.T) {
+	snippets := []string{
+		"/docs/hello_world.code.go",
+		"/docs/users.code.proto",
+		"/docs/users.code.go",
+		"/blog/hello_world.code.go",
+		"/blog/auth.code.sh",
+	}
+	tests := []struct {
+		name       string
+		components []string
+		want       []string
+		wantErr    string
+	}{
+		{
+			name:       "components of the same name in different packages",
+			components: []string{"HelloWorldGo"},
+			want:       []string{"/blog/hello_world.code.go", "/docs/hello_world.code.go"},
+		},
+		{
+			name:       "several components",
+			components: []string{"AuthSh", "UsersGo"},
+			want:       []string{"/blog/auth.code.sh", "/docs/users.code.go"},
+		},
+		{
+			name:       "split definition",
+			components: []string{"UsersProtoUser"},
+			want:       []string{"/docs/users.code.proto"},
+		},
+		{
+			name:       "unknown component",
+			components: []string{"AuthSh", "Missing"},
+			wantErr:    `no snippet generates component "Missing"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := snippetsOf(snippets, tt.components)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unexpected snippets (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestGenerateOnly(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "docs")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.code.go", "b.code.go", "c.code.go"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("package main\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	if err := Run(context.Background(), log, Arguments{Path: dir, Only: []string{"AGo", "CGo"}, NoLock: true}); err != nil {
+		t.Fatal(err)
+	}
+	for name, generated := range map[string]bool{"a.code.go": true, "b.code.go": false, "c.code.go": true} {
+		_, err := os.Stat(filepath.Join(dir, name+"_templ.go"))
+		if generated != (err == nil) {
+			t.Errorf("expected %s to be generated: %v, got error %v", name, generated, err)
+		}
+	}
+
+	err := Run(context.Background(), log, Arguments{Path: dir, Only: []string{"AGo"}, Watch: true})
+	if err == nil || !strings.Contains(err.Error(), "-only") {
+		t.Errorf("expected -watch to be refused, got %v", err)
+	}
+}
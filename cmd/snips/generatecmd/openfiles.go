@@ -0,0 +1,35 @@
+package generatecmd
+
+const (
+	// filesPerSnippet is the most files that generating a snippet holds open at
+	// once: the snippet, its generated file, the temporary file it's written
+	// to, and its examples file.
+	filesPerSnippet = 4
+	// reservedFiles are left to the rest of the process, e.g. stdio, the
+	// watcher, the lock, caches, history and plugins.
+	reservedFiles = 64
+)
+
+// maxOpenFiles returns the number of files that the snippets generated at once
+// may hold open, which is MaxOpenFiles, or the soft limit of open files of the
+// process, less the reserved files, or 0 if there's neither.
+func (cmd Generate) maxOpenFiles() int {
+	if cmd.Args.MaxOpenFiles > 0 {
+		return cmd.Args.MaxOpenFiles
+	}
+	if limit := openFilesLimit(); limit > 0 {
+		return max(limit-reservedFiles, filesPerSnippet)
+	}
+	return 0
+}
+
+// concurrency returns the number of snippets to generate at once, the number
+// of workers, capped so that they hold at most maxOpenFiles open, so that runs
+// don't fail with "too many open files", e.g. with the default limit of 256
+// on macOS. A maxOpenFiles of 0 doesn't cap it.
+func concurrency(workers, maxOpenFiles int) int {
+	if maxOpenFiles <= 0 {
+		return workers
+	}
+	return max(min(workers, maxOpenFiles/filesPerSnippet), 1)
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package generatecmd
+
+// openFilesLimit returns 0, since the platform has no limit of open files to
+// read, e.g. Windows, whose handles are only limited by memory.
+func openFilesLimit() int {
+	return 0
+}
@@ -0,0 +1,30 @@
+package generatecmd
+
+import "testing"
+
+func TestConcurrency(t *testing.T) {
+	tests := []struct {
+		workers, maxOpenFiles, expected int
+	}{
+		{workers: 8, maxOpenFiles: 0, expected: 8},
+		{workers: 8, maxOpenFiles: 1024, expected: 8},
+		{workers: 64, maxOpenFiles: 256 - reservedFiles, expected: (256 - reservedFiles) / filesPerSnippet},
+		{workers: 8, maxOpenFiles: 1, expected: 1},
+	}
+	for _, tt := range tests {
+		if got := concurrency(tt.workers, tt.maxOpenFiles); got != tt.expected {
+			t.Errorf("concurrency(%d, %d) = %d, expected %d", tt.workers, tt.maxOpenFiles, got, tt.expected)
+		}
+	}
+}
+
+func TestMaxOpenFiles(t *testing.T) {
+	if got := (Generate{Args: &Arguments{MaxOpenFiles: 12}}).maxOpenFiles(); got != 12 {
+		t.Errorf("expected -max-open-files to override the limit, got %d", got)
+	}
+	limit := openFilesLimit()
+	got := Generate{Args: &Arguments{}}.maxOpenFiles()
+	if limit > 0 && got != max(limit-reservedFiles, filesPerSnippet) || limit == 0 && got != 0 {
+		t.Errorf("expected the soft limit %d less the reserved files, got %d", limit, got)
+	}
+}
@@ -0,0 +1,21 @@
+//go:build unix
+
+package generatecmd
+
+import (
+	"math"
+	"syscall"
+)
+
+// openFilesLimit returns the soft limit of open files of the process, or 0 if
+// it's unlimited or unknown.
+func openFilesLimit() int {
+	var limit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &limit); err != nil {
+		return 0
+	}
+	if cur := uint64(limit.Cur); cur > 0 && cur < math.MaxInt32 {
+		return int(cur)
+	}
+	return 0
+}
@@ -0,0 +1,178 @@
+package generatecmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// patchContext is the number of unchanged lines around each hunk of a patch.
+const patchContext = 3
+
+// PatchWriter returns a Writer that writes a unified diff of the changes to
+// each generated file to w, instead of writing the file, so that editors can
+// apply them as a workspace edit. Nothing is written for files that are up to
+// date.
+func PatchWriter(w io.Writer) FileWriterFunc {
+	return func(name string, contents []byte) error {
+		existing, err := os.ReadFile(name)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		oldName := name
+		if err != nil {
+			oldName = "/dev/null"
+		}
+		_, err = io.WriteString(w, unifiedDiff(oldName, name, existing, contents))
+		return err
+	}
+}
+
+// diffOp is an operation of a line diff: ' ' to keep a line, '-' to delete it,
+// or '+' to insert it.
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// unifiedDiff returns the unified diff from a to b, or an empty string if they
+// are equal.
+func unifiedDiff(oldName, newName string, a, b []byte) string {
+	if bytes.Equal(a, b) {
+		return ""
+	}
+	ops := diffLines(splitLines(a), splitLines(b))
+
+	var sb strings.Builder
+	sb.WriteString("--- " + oldName + "\n")
+	sb.WriteString("+++ " + newName + "\n")
+	// oldLine and newLine are the 1-based line numbers of ops[i].
+	oldLine, newLine := 1, 1
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			oldLine, newLine = oldLine+1, newLine+1
+			i++
+			continue
+		}
+		// Start the hunk with the context before the change.
+		start := max(i-patchContext, 0)
+		oldStart, newStart := oldLine-(i-start), newLine-(i-start)
+		// End the hunk once there are more than twice the context lines
+		// of unchanged lines before the next change.
+		end, unchanged := i, 0
+		for ; end < len(ops) && unchanged <= 2*patchContext; end++ {
+			if ops[end].kind == ' ' {
+				unchanged++
+			} else {
+				unchanged = 0
+			}
+		}
+		end -= max(unchanged-patchContext, 0)
+
+		var oldCount, newCount int
+		for _, op := range ops[start:end] {
+			if op.kind != '+' {
+				oldCount++
+			}
+			if op.kind != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(oldStart, oldCount), hunkRange(newStart, newCount))
+		for _, op := range ops[start:end] {
+			sb.WriteString(string(op.kind) + op.line)
+			if !strings.HasSuffix(op.line, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		for _, op := range ops[i:end] {
+			if op.kind != '+' {
+				oldLine++
+			}
+			if op.kind != '-' {
+				newLine++
+			}
+		}
+		i = end
+	}
+	return sb.String()
+}
+
+// hunkRange returns the range of lines of a hunk header, e.g. "3,4", where
+// empty ranges start at the line before.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+// splitLines splits s after each newline.
+func splitLines(s []byte) (lines []string) {
+	if len(s) == 0 {
+		return nil
+	}
+	lines = strings.SplitAfter(string(s), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns the operations that turn a into b, from the longest
+// common subsequence of their lines, after trimming their common prefix and
+// suffix.
+func diffLines(a, b []string) (ops []diffOp) {
+	var prefix int
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	var suffix int
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{kind: ' ', line: line})
+	}
+
+	x, y := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	// lcs[i][j] is the length of the longest common subsequence of x[i:] and
+	// y[j:].
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			ops = append(ops, diffOp{kind: ' ', line: x[i]})
+			i, j = i+1, j+1
+		case j == len(y) || (i < len(x) && lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{kind: '-', line: x[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{kind: '+', line: y[j]})
+			j++
+		}
+	}
+
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{kind: ' ', line: line})
+	}
+	return ops
+}
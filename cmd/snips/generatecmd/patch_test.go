@@ -0,0 +1,98 @@
+package generatecmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	lines := func(from, to int) (s string) {
+		for i := from; i <= to; i++ {
+			s += "line " + string(rune('a'+i-1)) + "\n"
+		}
+		return s
+	}
+	tests := []struct {
+		name     string
+		a, b     string
+		expected string
+	}{
+		{
+			name: "equal",
+			a:    "a\n",
+			b:    "a\n",
+		},
+		{
+			name:     "new file",
+			a:        "",
+			b:        "a\nb\n",
+			expected: "--- old\n+++ new\n@@ -0,0 +1,2 @@\n+a\n+b\n",
+		},
+		{
+			name:     "changed line",
+			a:        lines(1, 10),
+			b:        strings.Replace(lines(1, 10), "line e\n", "line E\n", 1),
+			expected: "--- old\n+++ new\n@@ -2,7 +2,7 @@\n line b\n line c\n line d\n-line e\n+line E\n line f\n line g\n line h\n",
+		},
+		{
+			name: "separate hunks",
+			a:    lines(1, 20),
+			b:    "line A\n" + lines(2, 19) + "line T\n",
+			expected: "--- old\n+++ new\n@@ -1,4 +1,4 @@\n-line a\n+line A\n line b\n line c\n line d\n" +
+				"@@ -17,4 +17,4 @@\n line q\n line r\n line s\n-line t\n+line T\n",
+		},
+		{
+			name:     "deleted lines",
+			a:        lines(1, 3),
+			b:        "line a\n",
+			expected: "--- old\n+++ new\n@@ -1,3 +1 @@\n line a\n-line b\n-line c\n",
+		},
+		{
+			name:     "no newline at end of file",
+			a:        "a\nb",
+			b:        "a\nb\n",
+			expected: "--- old\n+++ new\n@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := unifiedDiff("old", "new", []byte(tt.a), []byte(tt.b))
+			if diff := cmp.Diff(tt.expected, actual); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestPatchWriter(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "existing.go")
+	if err := os.WriteFile(existing, []byte("package a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "missing.go")
+
+	var b bytes.Buffer
+	w := PatchWriter(&b)
+	for name, contents := range map[string]string{existing: "package b\n", missing: "package a\n"} {
+		if err := w.WriteFile(name, []byte(contents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, expected := range []string{
+		"--- " + existing + "\n+++ " + existing + "\n@@ -1 +1 @@\n-package a\n+package b\n",
+		"--- /dev/null\n+++ " + missing + "\n@@ -0,0 +1 @@\n+package a\n",
+	} {
+		if !strings.Contains(b.String(), expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, b.String())
+		}
+	}
+	if contents, _ := os.ReadFile(existing); string(contents) != "package a\n" {
+		t.Errorf("expected the file to be left as it was, got %q", contents)
+	}
+}
@@ -0,0 +1,139 @@
+package generatecmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// The stages that plugins run at.
+const (
+	// PluginPreHighlight plugins transform the code of a snippet, without its
+	// front matter and once redacted, before it's highlighted, e.g. to
+	// sanitize it.
+	PluginPreHighlight = "pre-highlight"
+	// PluginPostHTML plugins transform the highlighted HTML of each
+	// component, before it's wrapped, e.g. to add notes after the code.
+	PluginPostHTML = "post-html"
+)
+
+var pluginStages = []string{PluginPreHighlight, PluginPostHTML}
+
+// pluginTimeout is how long a plugin may take to process a snippet.
+const pluginTimeout = 30 * time.Second
+
+// pluginProtocolVersion is incremented on incompatible changes to
+// pluginRequest and pluginResponse.
+const pluginProtocolVersion = 1
+
+// pluginRequest is written as JSON to the stdin of a plugin, e.g.
+//
+//	{"version": 1, "stage": "pre-highlight", "file": "docs/main.code.go", "component": "MainGo", "contents": "package main\n"}
+type pluginRequest struct {
+	Version int    `json:"version"`
+	Stage   string `json:"stage"`
+	// File is the slash separated path of the snippet, relative to the path.
+	File      string `json:"file"`
+	Component string `json:"component"`
+	// Language is the language front matter of the snippet, if any.
+	Language string `json:"language,omitempty"`
+	// Contents is the code of the snippet for pre-highlight plugins, and the
+	// highlighted HTML of the component for post-html plugins.
+	Contents string `json:"contents"`
+}
+
+// pluginResponse is read as JSON from the stdout of a plugin, e.g.
+//
+//	{"contents": "package main\n"}
+//
+// Plugins fail the snippet by setting Error, or exiting with a non-zero status.
+type pluginResponse struct {
+	// Contents replaces the contents of the request.
+	Contents *string `json:"contents"`
+	Error    string  `json:"error"`
+}
+
+// plugins are external processes that transform snippets, by stage, speaking
+// a JSON protocol over stdin and stdout, so that snips can be extended in any
+// language.
+type plugins struct {
+	// root is the directory plugins run in, and that files are relative to.
+	root string
+	// commands by stage, split into the program and its arguments.
+	commands map[string][]string
+}
+
+// checkPlugins checks that plugins are configured for known stages.
+func checkPlugins(commands map[string]string) error {
+	for _, stage := range slices.Sorted(maps.Keys(commands)) {
+		if !slices.Contains(pluginStages, stage) {
+			return fmt.Errorf("unknown plugin stage %q, expected one of %s", stage, strings.Join(pluginStages, ", "))
+		}
+		if len(strings.Fields(commands[stage])) == 0 {
+			return fmt.Errorf("empty %s plugin command", stage)
+		}
+	}
+	return nil
+}
+
+func newPlugins(root string, commands map[string]string) *plugins {
+	p := &plugins{root: root, commands: make(map[string][]string)}
+	for stage, command := range commands {
+		p.commands[stage] = strings.Fields(command)
+	}
+	return p
+}
+
+// has reports whether a plugin is configured for the stage.
+func (p *plugins) has(stage string) bool {
+	return len(p.commands[stage]) > 0
+}
+
+// run runs the plugin of the stage with req, returning the contents of its
+// response.
+func (p *plugins) run(req pluginRequest) (string, error) {
+	command := p.commands[req.Stage]
+	req.Version = pluginProtocolVersion
+	if rel, err := filepath.Rel(p.root, req.File); err == nil {
+		req.File = filepath.ToSlash(rel)
+	}
+	in, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), pluginTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Dir = p.root
+	cmd.Stdin = bytes.NewReader(in)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err = cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("timed out after %v", pluginTimeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", fmt.Errorf("%s plugin %s failed: %w", req.Stage, command[0], err)
+	}
+	var resp pluginResponse
+	if err = json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return "", fmt.Errorf("%s plugin %s responded with invalid JSON: %w", req.Stage, command[0], err)
+	}
+	if resp.Error != "" {
+		return "", fmt.Errorf("%s plugin %s failed: %s", req.Stage, command[0], resp.Error)
+	}
+	if resp.Contents == nil {
+		return "", fmt.Errorf("%s plugin %s responded without contents", req.Stage, command[0])
+	}
+	return *resp.Contents, nil
+}
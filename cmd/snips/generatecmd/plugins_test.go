@@ -0,0 +1,82 @@
+package generatecmd
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestPluginProcess is run as a plugin by TestRunPlugins, redacting SECRET
+// before highlighting, and adding a note after the highlighted code. It fails
+// snippets whose lines marked with snips:redact it's given.
+func TestPluginProcess(t *testing.T) {
+	if os.Getenv("SNIPS_TEST_PLUGIN") == "" {
+		return
+	}
+	var req pluginRequest
+	if err := json.NewDecoder(os.Stdin).Decode(&req); err != nil {
+		os.Exit(2)
+	}
+	resp := map[string]string{}
+	switch {
+	case strings.Contains(req.Contents, "FAIL"):
+		resp["error"] = "refused " + req.File
+	case strings.Contains(req.Contents, "hunter2"):
+		resp["error"] = "given redacted content of " + req.File
+	case req.Stage == PluginPreHighlight:
+		resp["contents"] = strings.ReplaceAll(req.Contents, "SECRET", "REDACTED")
+	case req.Stage == PluginPostHTML:
+		resp["contents"] = req.Contents + "<aside>Note for " + req.Component + "</aside>"
+	}
+	json.NewEncoder(os.Stdout).Encode(resp)
+	os.Exit(0)
+}
+
+func TestRunPlugins(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "docs")
+	if err := os.Mkdir(root, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "main.code.go"), []byte("---\nlanguage: go\n---\npackage main\n\nconst key = \"SECRET\"\n\n// snips:redact\nconst password = \"hunter2\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("SNIPS_TEST_PLUGIN", "1")
+	plugin := os.Args[0] + " -test.run=^TestPluginProcess$"
+	var fileErr error
+	run := func(plugins map[string]string) error {
+		args := Arguments{Path: root, WorkerCount: 1, Plugins: plugins, OnError: func(_ string, err error) { fileErr = err }}
+		return Run(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)), args)
+	}
+
+	if err := run(map[string]string{PluginPreHighlight: plugin, PluginPostHTML: plugin}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(root, "main.code.go_templ.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "SECRET") || !strings.Contains(string(data), "REDACTED") {
+		t.Errorf("expected the pre-highlight plugin to redact the snippet, got:\n%s", data)
+	}
+	if strings.Contains(string(data), "hunter2") {
+		t.Errorf("expected the snips:redact line to be redacted, got:\n%s", data)
+	}
+	if !strings.Contains(string(data), "<aside>Note for MainGo</aside>") {
+		t.Errorf("expected the post-html plugin to add a note, got:\n%s", data)
+	}
+
+	if err = os.WriteFile(filepath.Join(root, "main.code.go"), []byte("package FAIL\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err = run(map[string]string{PluginPreHighlight: plugin}); err == nil || fileErr == nil || !strings.Contains(fileErr.Error(), "pre-highlight plugin") || !strings.Contains(fileErr.Error(), "refused main.code.go") {
+		t.Errorf("expected the plugin to fail the snippet, got %v and %v", err, fileErr)
+	}
+	if err = run(map[string]string{"post-build": plugin}); err == nil || !strings.Contains(err.Error(), `unknown plugin stage "post-build"`) {
+		t.Errorf("expected an unknown stage to be refused, got %v", err)
+	}
+}
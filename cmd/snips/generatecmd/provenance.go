@@ -0,0 +1,17 @@
+package generatecmd
+
+import (
+	"fmt"
+
+	"github.com/garrettladley/snips/generator"
+)
+
+// checkHandEdited returns an error if the existing contents of a generated
+// file don't match the hash recorded in its header, so that edits made to it,
+// rather than to its snippet, aren't silently lost when it's regenerated.
+func checkHandEdited(fileName string, existing []byte) error {
+	if !generator.HandEdited(existing) {
+		return nil
+	}
+	return fmt.Errorf("%s was edited by hand since it was generated, move the edits to its snippet, or use -force-overwrite to discard them", fileName)
+}
@@ -0,0 +1,58 @@
+package generatecmd
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunHandEdited(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "docs")
+	if err := os.Mkdir(root, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "main.code.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var log bytes.Buffer
+	run := func(force bool) error {
+		t.Helper()
+		log.Reset()
+		args := Arguments{Path: root, WorkerCount: 1, ForceOverwrite: force}
+		return Run(context.Background(), slog.New(slog.NewTextHandler(&log, nil)), args)
+	}
+	if err := run(false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Regenerating an untouched file is fine.
+	if err := run(false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	target := filepath.Join(root, "main.code.go_templ.go")
+	generated, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := append(generated, "\n// A tweak.\n"...)
+	if err = os.WriteFile(target, edited, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err = run(false); err == nil || !strings.Contains(log.String(), "was edited by hand") {
+		t.Fatalf("expected the hand-edited file to be refused, got %v:\n%s", err, log.String())
+	}
+	if contents, _ := os.ReadFile(target); string(contents) != string(edited) {
+		t.Error("expected the hand-edited file to be kept")
+	}
+
+	if err = run(true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if contents, _ := os.ReadFile(target); string(contents) != string(generated) {
+		t.Errorf("expected -force-overwrite to regenerate the file, got:\n%s", contents)
+	}
+}
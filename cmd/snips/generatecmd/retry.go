@@ -0,0 +1,59 @@
+package generatecmd
+
+import (
+	"errors"
+	"io/fs"
+	"log/slog"
+	"time"
+)
+
+// readBackoff are the delays between attempts to read the file of a watch
+// mode event that's still being written.
+var readBackoff = []time.Duration{
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	200 * time.Millisecond,
+}
+
+// recentlyModified is how recently an empty file must have been modified for
+// it to be treated as still being written.
+const recentlyModified = time.Second
+
+// readEventFile reads a snippet. In watch mode, reads are retried with backoff
+// while the file is missing, or still being written, since editors emit events
+// before they've finished saving, e.g. by truncating the file first.
+func (h *FSEventHandler) readEventFile(fileName string) ([]byte, error) {
+	if !h.DevMode {
+		return h.src.ReadFile(fileName)
+	}
+	for attempt := 0; ; attempt++ {
+		data, err := h.src.ReadFile(fileName)
+		if attempt == len(readBackoff) || !h.partialRead(fileName, data, err) {
+			return data, err
+		}
+		h.Log.Debug("Retrying read of file being written", slog.String("file", fileName), slog.Int("attempt", attempt+1))
+		time.Sleep(readBackoff[attempt])
+	}
+}
+
+// partialRead reports whether a read may have seen the file before it was
+// fully written: it was missing, its size has changed since, or it was empty
+// and has just been modified.
+func (h *FSEventHandler) partialRead(fileName string, data []byte, err error) bool {
+	if errors.Is(err, fs.ErrNotExist) {
+		return true
+	}
+	if err != nil {
+		return false
+	}
+	info, err := h.src.Stat(fileName)
+	if err != nil {
+		return true
+	}
+	if info.Size() != int64(len(data)) {
+		return true
+	}
+	return len(data) == 0 && time.Since(info.ModTime()) < recentlyModified
+}
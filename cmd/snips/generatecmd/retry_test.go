@@ -0,0 +1,51 @@
+package generatecmd
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReadEventFile(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "main.code.go")
+
+	t.Run("written after the event", func(t *testing.T) {
+		h := NewFSEventHandler(log, dir, true, nil, false, nil, false)
+		go func() {
+			time.Sleep(readBackoff[0])
+			_ = os.WriteFile(fileName, []byte("package main\n"), 0o644)
+		}()
+		data, err := h.readEventFile(fileName)
+		if err != nil {
+			t.Fatalf("expected the read to be retried, got %v", err)
+		}
+		if string(data) != "package main\n" {
+			t.Errorf("unexpected contents %q", data)
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		h := NewFSEventHandler(log, dir, true, nil, false, nil, false)
+		if _, err := h.readEventFile(filepath.Join(dir, "missing.code.go")); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("expected the error once retries run out, got %v", err)
+		}
+	})
+
+	t.Run("production mode", func(t *testing.T) {
+		h := NewFSEventHandler(log, dir, false, nil, false, nil, false)
+		start := time.Now()
+		if _, err := h.readEventFile(filepath.Join(dir, "missing.code.go")); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("expected a not found error, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed >= readBackoff[0] {
+			t.Errorf("expected no retries, took %v", elapsed)
+		}
+	})
+}
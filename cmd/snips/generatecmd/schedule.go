@@ -0,0 +1,26 @@
+package generatecmd
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// largestFirst orders the events of a walk by the size of their files, largest
+// first, so that the workers start on the snippets that take longest to
+// generate, rather than a giant snippet found last serializing the end of the
+// run. Files of the same size keep the order of the walk, and files that can't
+// be stat'ed go last, failing when they're generated.
+func largestFirst(src source, events []fsnotify.Event) {
+	sizes := make(map[string]int64, len(events))
+	for _, event := range events {
+		sizes[event.Name] = -1
+		if info, err := src.Stat(event.Name); err == nil {
+			sizes[event.Name] = info.Size()
+		}
+	}
+	slices.SortStableFunc(events, func(a, b fsnotify.Event) int {
+		return cmp.Compare(sizes[b.Name], sizes[a.Name])
+	})
+}
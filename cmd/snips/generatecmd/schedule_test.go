@@ -0,0 +1,35 @@
+package generatecmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLargestFirst(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "docs")
+	fsys := fstest.MapFS{
+		"small.code.go":  {Data: []byte("x")},
+		"large.code.go":  {Data: []byte(strings.Repeat("x", 100))},
+		"medium.code.go": {Data: []byte(strings.Repeat("x", 10))},
+		"same.code.go":   {Data: []byte(strings.Repeat("x", 10))},
+	}
+	var events []fsnotify.Event
+	for _, name := range []string{"small.code.go", "missing.code.go", "medium.code.go", "large.code.go", "same.code.go"} {
+		events = append(events, fsnotify.Event{Name: filepath.Join(root, name), Op: fsnotify.Create})
+	}
+	largestFirst(source{root: root, fsys: fsys}, events)
+
+	var actual []string
+	for _, event := range events {
+		actual = append(actual, filepath.Base(event.Name))
+	}
+	expected := []string{"large.code.go", "medium.code.go", "same.code.go", "small.code.go", "missing.code.go"}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("unexpected order (-want +got):\n%s", diff)
+	}
+}
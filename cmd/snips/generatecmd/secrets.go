@@ -0,0 +1,22 @@
+package generatecmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/garrettladley/snips"
+)
+
+// checkSecrets fails if a snippet looks like it contains credentials, once
+// redacted. Line numbers are of the redacted snippet, without its front matter.
+func checkSecrets(contents []byte) error {
+	found := snips.ScanSecrets(contents)
+	if len(found) == 0 {
+		return nil
+	}
+	var secrets []string
+	for _, s := range found {
+		secrets = append(secrets, fmt.Sprintf("%s on line %d", s.Kind, s.Line))
+	}
+	return fmt.Errorf("found possible secrets, remove or redact them: %s", strings.Join(secrets, ", "))
+}
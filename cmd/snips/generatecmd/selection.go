@@ -0,0 +1,74 @@
+package generatecmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/garrettladley/snips/cmd/snips/generatecmd/watcher"
+)
+
+// selection is the snippets selected by -include and -exclude, by their
+// slash-separated paths relative to root: those matching an include glob, or
+// every snippet if there are none, unless they match an exclude glob.
+type selection struct {
+	root    string
+	include []watcher.Glob
+	exclude []watcher.Glob
+}
+
+// newSelection returns the selection of the include and exclude globs, or nil
+// if there are none, which selects every snippet.
+func newSelection(root string, include, exclude []string) (s *selection, err error) {
+	if len(include) == 0 && len(exclude) == 0 {
+		return nil, nil
+	}
+	s = &selection{root: root}
+	if s.include, err = parseGlobs(include); err != nil {
+		return nil, fmt.Errorf("invalid include: %w", err)
+	}
+	if s.exclude, err = parseGlobs(exclude); err != nil {
+		return nil, fmt.Errorf("invalid exclude: %w", err)
+	}
+	return s, nil
+}
+
+func parseGlobs(patterns []string) (globs []watcher.Glob, err error) {
+	globs = make([]watcher.Glob, len(patterns))
+	for i, pattern := range patterns {
+		if globs[i], err = watcher.ParseGlob(pattern); err != nil {
+			return nil, err
+		}
+	}
+	return globs, nil
+}
+
+// selected reports whether the snippet is selected. Snippets outside of root
+// are only selected if there's no include glob.
+func (s *selection) selected(fileName string) bool {
+	if s == nil {
+		return true
+	}
+	rel, err := filepath.Rel(s.root, fileName)
+	if err != nil {
+		return len(s.include) == 0
+	}
+	rel = filepath.ToSlash(rel)
+	return (len(s.include) == 0 || matchAny(s.include, rel)) && !matchAny(s.exclude, rel)
+}
+
+func matchAny(globs []watcher.Glob, name string) bool {
+	for _, g := range globs {
+		if g.Match(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// withSelection only generates the selected snippets, skipping the changes to
+// others when watching.
+func withSelection(s *selection) FSEventHandlerOpt {
+	return func(h *FSEventHandler) {
+		h.selection = s
+	}
+}
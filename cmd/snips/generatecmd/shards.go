@@ -0,0 +1,206 @@
+package generatecmd
+
+import (
+	"errors"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/garrettladley/snips"
+	"github.com/garrettladley/snips/cmd/snips/generatecmd/modcheck"
+	"github.com/garrettladley/snips/generator"
+)
+
+// facadeFileName is the name of the file that re-exports the components of
+// sharded sub-packages.
+const facadeFileName = "snips_facade.go"
+
+// shardPrefix is the name prefix of sharded sub-packages, e.g. gen01.
+const shardPrefix = "gen"
+
+// shardedFile is the sub-package a snippet was generated into, and the
+// components it defines.
+type shardedFile struct {
+	shard      string
+	components []string
+}
+
+// shards splits the snippets of directories with more than maxFiles snippets
+// into sub-packages of at most maxFiles snippets each, so that no package of
+// literals grows too large for gopls and the compiler. A facade in the
+// original package re-exports the components, so that callers are unaffected.
+type shards struct {
+	maxFiles int
+	// header is the comments at the top of facades.
+	header generator.Header
+	// buildTags are the build constraints of facades, by directory.
+	buildTags buildConstraints
+
+	mu sync.Mutex
+	// files by directory, then snippet file name.
+	files map[string]map[string]shardedFile
+	// dirty directories, whose facade needs to be written.
+	dirty map[string]struct{}
+}
+
+func newShards(maxFiles int) *shards {
+	return &shards{
+		maxFiles: maxFiles,
+		files:    make(map[string]map[string]shardedFile),
+		dirty:    make(map[string]struct{}),
+	}
+}
+
+// shardFor returns the sub-package to generate a snippet into, or an empty
+// string if its directory doesn't need sharding. Snippets are assigned to
+// sub-packages in file name order.
+func (s *shards) shardFor(fileName string) (shard string, err error) {
+	entries, err := os.ReadDir(filepath.Dir(fileName))
+	if err != nil {
+		return "", fmt.Errorf("failed to read snippet directory: %w", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && IsSnippet(entry.Name()) {
+			names = append(names, entry.Name())
+		}
+	}
+	if len(names) <= s.maxFiles {
+		return "", nil
+	}
+	index, ok := slices.BinarySearch(names, filepath.Base(fileName))
+	if !ok {
+		return "", fmt.Errorf("snippet %q not found in its directory", fileName)
+	}
+	return fmt.Sprintf("%s%02d", shardPrefix, index/s.maxFiles+1), nil
+}
+
+// set records the sub-package a snippet was generated into, and its components.
+func (s *shards) set(fileName, shard string, components []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	dir := filepath.Dir(fileName)
+	files, ok := s.files[dir]
+	if !ok {
+		files = make(map[string]shardedFile)
+		s.files[dir] = files
+	}
+	sf := shardedFile{shard: shard, components: components}
+	if prev, ok := files[fileName]; ok && prev.shard == sf.shard && slices.Equal(prev.components, sf.components) {
+		return
+	}
+	files[fileName] = sf
+	s.dirty[dir] = struct{}{}
+}
+
+// remove forgets a deleted snippet.
+func (s *shards) remove(fileName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	dir := filepath.Dir(fileName)
+	if _, ok := s.files[dir][fileName]; ok {
+		delete(s.files[dir], fileName)
+		s.dirty[dir] = struct{}{}
+	}
+}
+
+// write the facade of each directory that has changed since the last write.
+// The facade is removed from directories that are no longer sharded.
+func (s *shards) write(writer Writer) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var errs []error
+	for dir := range s.dirty {
+		if err := s.writeFacade(dir, writer); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		delete(s.dirty, dir)
+	}
+	return errors.Join(errs...)
+}
+
+func (s *shards) writeFacade(dir string, writer Writer) error {
+	fileName := filepath.Join(dir, facadeFileName)
+
+	type export struct{ shard, component string }
+	var exports []export
+	for _, sf := range s.files[dir] {
+		if sf.shard == "" {
+			continue
+		}
+		for _, component := range sf.components {
+			exports = append(exports, export{shard: sf.shard, component: component})
+		}
+	}
+	if len(exports) == 0 {
+		if err := os.Remove(fileName); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove facade %q: %w", fileName, err)
+		}
+		return nil
+	}
+	slices.SortFunc(exports, func(a, b export) int {
+		return strings.Compare(a.component, b.component)
+	})
+
+	importPath, err := modcheck.ImportPath(dir)
+	if err != nil {
+		return fmt.Errorf("failed to get import path of %q: %w", dir, err)
+	}
+	var shardNames []string
+	for _, e := range exports {
+		shardNames = append(shardNames, e.shard)
+	}
+	slices.Sort(shardNames)
+	shardNames = slices.Compact(shardNames)
+
+	var sb strings.Builder
+	sb.WriteString(generator.BuildConstraintComment(s.buildTags.forDir(dir)))
+	sb.WriteString(s.header.Comment())
+	sb.WriteString(s.header.PragmaComment())
+	sb.WriteString("package " + snips.PackageName(dir) + "\n\n")
+	sb.WriteString("import (\n\t\"github.com/a-h/templ\"\n\n")
+	for _, shard := range shardNames {
+		sb.WriteString("\t" + shard + " \"" + importPath + "/" + shard + "\"\n")
+	}
+	sb.WriteString(")\n")
+	for _, e := range exports {
+		sb.WriteString("\n// " + e.component + " is generated in the " + e.shard + " sub-package.\n")
+		sb.WriteString("func " + e.component + "() templ.Component { return " + e.shard + "." + e.component + "() }\n")
+	}
+
+	formatted, err := format.Source([]byte(sb.String()))
+	if err != nil {
+		return fmt.Errorf("facade source formatting error: %w", err)
+	}
+	if err = writer.WriteFile(fileName, formatted); err != nil {
+		return fmt.Errorf("failed to write facade %q: %w", fileName, err)
+	}
+	return nil
+}
+
+// staleTargets returns the code generated for a snippet anywhere other than
+// target, left behind when it moved between sub-packages, or in or out of the
+// test package.
+func staleTargets(fileName, target string) (stale []string, err error) {
+	base := filepath.Base(fileName) + "_templ.go"
+	dir := filepath.Dir(fileName)
+	candidates, err := filepath.Glob(filepath.Join(dir, shardPrefix+"[0-9]*", base))
+	if err != nil {
+		return nil, err
+	}
+	candidates = append(candidates, filepath.Join(dir, base), filepath.Join(dir, filepath.Base(fileName)+testFileSuffix))
+	for _, candidate := range candidates {
+		if candidate == target {
+			continue
+		}
+		if _, err := os.Stat(candidate); err == nil {
+			stale = append(stale, candidate)
+		}
+	}
+	return stale, nil
+}
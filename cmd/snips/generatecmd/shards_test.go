@@ -0,0 +1,125 @@
+package generatecmd
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestShardFor(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.code.go", "b.code.go", "c.code.go", "a.code.go_templ.go", "main.go"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		maxFiles int
+		file     string
+		want     string
+	}{
+		{maxFiles: 3, file: "c.code.go", want: ""},
+		{maxFiles: 2, file: "a.code.go", want: "gen01"},
+		{maxFiles: 2, file: "b.code.go", want: "gen01"},
+		{maxFiles: 2, file: "c.code.go", want: "gen02"},
+	}
+	for _, tt := range tests {
+		got, err := newShards(tt.maxFiles).shardFor(filepath.Join(dir, tt.file))
+		if err != nil {
+			t.Fatalf("shardFor(%q) unexpected error: %v", tt.file, err)
+		}
+		if got != tt.want {
+			t.Errorf("shardFor(%q) with max %d = %q, want %q", tt.file, tt.maxFiles, got, tt.want)
+		}
+	}
+}
+
+func TestShardsWriteFacade(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module example.com/site\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Join(root, "views")
+
+	written := make(map[string]string)
+	writer := FileWriterFunc(func(name string, contents []byte) error {
+		written[name] = string(contents)
+		return nil
+	})
+
+	s := newShards(1)
+	s.set(filepath.Join(dir, "a.code.go"), "gen01", []string{"AGo"})
+	s.set(filepath.Join(dir, "b.code.proto"), "gen02", []string{"BProto", "BProtoUser"})
+	if err := s.write(writer); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+
+	facade, ok := written[filepath.Join(dir, facadeFileName)]
+	if !ok {
+		t.Fatalf("expected a facade to be written, got %v", written)
+	}
+	for _, expected := range []string{
+		"package views",
+		`gen01 "example.com/site/views/gen01"`,
+		`gen02 "example.com/site/views/gen02"`,
+		"func AGo() templ.Component { return gen01.AGo() }",
+		"func BProtoUser() templ.Component { return gen02.BProtoUser() }",
+	} {
+		if !strings.Contains(facade, expected) {
+			t.Errorf("expected facade to contain %q, got:\n%s", expected, facade)
+		}
+	}
+
+	// Unchanged directories aren't written again.
+	clear(written)
+	s.set(filepath.Join(dir, "a.code.go"), "gen01", []string{"AGo"})
+	if err := s.write(writer); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+	if len(written) != 0 {
+		t.Errorf("expected nothing to be written, got %v", written)
+	}
+}
+
+func TestStaleTargets(t *testing.T) {
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "a.code.go")
+	stale := []string{
+		filepath.Join(dir, "a.code.go_templ.go"),
+		filepath.Join(dir, "gen01", "a.code.go_templ.go"),
+	}
+	target := filepath.Join(dir, "gen02", "a.code.go_templ.go")
+	for _, name := range append(stale, target) {
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := staleTargets(fileName, target)
+	if err != nil {
+		t.Fatalf("failed to find stale targets: %v", err)
+	}
+	slices.Sort(got)
+	if diff := cmp.Diff(stale, got); diff != "" {
+		t.Errorf("unexpected stale targets (-want +got):\n%s", diff)
+	}
+	if err = writeOutputs(localWriter{}, outputs{removes: got}); err != nil {
+		t.Fatalf("failed to remove stale targets: %v", err)
+	}
+	for _, name := range stale {
+		if _, err := os.Stat(name); !os.IsNotExist(err) {
+			t.Errorf("expected %q to be removed", name)
+		}
+	}
+	if _, err := os.Stat(target); err != nil {
+		t.Errorf("expected %q to be kept: %v", target, err)
+	}
+}
@@ -0,0 +1,155 @@
+package generatecmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/garrettladley/snips"
+	"github.com/garrettladley/snips/cmd/snips/generatecmd/modcheck"
+	"github.com/garrettladley/snips/generator"
+)
+
+// sharedFileName is the name of the file holding the shared literals.
+const sharedFileName = "snips_shared.go"
+
+// sharedLiterals collects the highlighted HTML of all snippets, deduplicated by
+// content, so that it can be written once to a shared package that the
+// generated components reference.
+type sharedLiterals struct {
+	dir         string
+	packageName string
+	importPath  string
+
+	mu sync.Mutex
+	// names of the literals used by each snippet.
+	names map[string][]string
+	// literals by name.
+	literals map[string]string
+	dirty    bool
+	// header is the comments at the top of the shared package.
+	header generator.Header
+	// buildConstraint is the //go:build expression of the shared package.
+	buildConstraint string
+}
+
+func newSharedLiterals(dir string) (*sharedLiterals, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	importPath, err := modcheck.ImportPath(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get import path of shared package %q: %w", dir, err)
+	}
+	return &sharedLiterals{
+		dir:         dir,
+		packageName: snips.PackageName(dir),
+		importPath:  importPath,
+		names:       make(map[string][]string),
+		literals:    make(map[string]string),
+	}, nil
+}
+
+// generateOpt returns an option that collects the literals of a snippet, to be
+// stored with set once generation succeeds. Snippets in the shared package
+// itself are not shared, since they can't import their own package.
+func (s *sharedLiterals) generateOpt(fileName string, pending map[string]string) (opt generator.GenerateOpt, ok bool) {
+	if filepath.Dir(fileName) == s.dir {
+		return nil, false
+	}
+	return generator.WithSharedLiterals(s.importPath, func(literal string) string {
+		hash := sha256.Sum256([]byte(literal))
+		name := "Snippet" + hex.EncodeToString(hash[:8])
+		pending[name] = literal
+		return name
+	}), true
+}
+
+// set replaces the literals used by a snippet.
+func (s *sharedLiterals) set(fileName string, literals map[string]string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := make([]string, 0, len(literals))
+	for name, literal := range literals {
+		names = append(names, name)
+		if _, ok := s.literals[name]; !ok {
+			s.literals[name] = literal
+			s.dirty = true
+		}
+	}
+	slices.Sort(names)
+	if !slices.Equal(s.names[fileName], names) {
+		s.names[fileName] = names
+		s.dirty = true
+	}
+}
+
+// remove forgets the literals used by a deleted snippet.
+func (s *sharedLiterals) remove(fileName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.names[fileName]; ok {
+		delete(s.names, fileName)
+		s.dirty = true
+	}
+}
+
+// write the shared package, if the literals have changed since the last write.
+// Literals no longer used by any snippet are dropped.
+func (s *sharedLiterals) write(writer Writer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.dirty {
+		return nil
+	}
+
+	used := make(map[string]struct{})
+	for _, names := range s.names {
+		for _, name := range names {
+			used[name] = struct{}{}
+		}
+	}
+	for name := range s.literals {
+		if _, ok := used[name]; !ok {
+			delete(s.literals, name)
+		}
+	}
+	names := make([]string, 0, len(s.literals))
+	for name := range s.literals {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var sb strings.Builder
+	sb.WriteString(generator.BuildConstraintComment(s.buildConstraint))
+	sb.WriteString(s.header.Comment())
+	sb.WriteString(s.header.PragmaComment())
+	sb.WriteString("package " + s.packageName + "\n\n")
+	sb.WriteString("// Highlighted HTML shared by the snippet components, deduplicated by content.\n")
+	sb.WriteString("const (\n")
+	for _, name := range names {
+		sb.WriteString("\t" + name + " = \"" + s.literals[name] + "\"\n")
+	}
+	sb.WriteString(")\n")
+
+	formatted, err := format.Source([]byte(sb.String()))
+	if err != nil {
+		return fmt.Errorf("shared package source formatting error: %w", err)
+	}
+	if err = os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create shared package directory %q: %w", s.dir, err)
+	}
+	fileName := filepath.Join(s.dir, sharedFileName)
+	if err = writer.WriteFile(fileName, formatted); err != nil {
+		return fmt.Errorf("failed to write shared package %q: %w", fileName, err)
+	}
+	s.dirty = false
+	return nil
+}
@@ -0,0 +1,182 @@
+package generatecmd
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/garrettladley/snips/generator"
+)
+
+// componentSize is the size of the escaped string literal of a component.
+type componentSize struct {
+	name string
+	size int
+}
+
+// sizeReport tracks the size each generated component contributes to its
+// package, so that packages exceeding the budget can be warned about.
+type sizeReport struct {
+	// budget is the maximum total size of a package in bytes, 0 for no budget.
+	budget int
+
+	mu sync.Mutex
+	// sizes of the components of each snippet.
+	sizes map[string][]componentSize
+	// changed packages, since the last budget check.
+	changed map[string]struct{}
+}
+
+func newSizeReport(budget int) *sizeReport {
+	return &sizeReport{
+		budget:  budget,
+		sizes:   make(map[string][]componentSize),
+		changed: make(map[string]struct{}),
+	}
+}
+
+// generateOpt returns an option that collects the component sizes of a snippet
+// into pending, to be stored with set once generation succeeds.
+func (r *sizeReport) generateOpt(pending *[]componentSize) generator.GenerateOpt {
+	return generator.WithLiteralSizes(func(componentName string, size int) {
+		*pending = append(*pending, componentSize{name: componentName, size: size})
+	})
+}
+
+// set replaces the component sizes of a snippet.
+func (r *sizeReport) set(fileName string, sizes []componentSize) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if slices.Equal(r.sizes[fileName], sizes) {
+		return
+	}
+	r.sizes[fileName] = sizes
+	r.changed[filepath.Dir(fileName)] = struct{}{}
+}
+
+// remove forgets the component sizes of a deleted snippet.
+func (r *sizeReport) remove(fileName string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.sizes[fileName]; ok {
+		delete(r.sizes, fileName)
+		r.changed[filepath.Dir(fileName)] = struct{}{}
+	}
+}
+
+// packageTotals returns the total size of each package.
+func (r *sizeReport) packageTotals() map[string]int {
+	totals := make(map[string]int)
+	for fileName, sizes := range r.sizes {
+		dir := filepath.Dir(fileName)
+		for _, cs := range sizes {
+			totals[dir] += cs.size
+		}
+	}
+	return totals
+}
+
+// checkBudget warns about packages that have changed since the last check and
+// exceed the budget.
+func (r *sizeReport) checkBudget(log *slog.Logger) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.budget <= 0 || len(r.changed) == 0 {
+		return
+	}
+	totals := r.packageTotals()
+	for _, dir := range slices.Sorted(maps.Keys(r.changed)) {
+		if total := totals[dir]; total > r.budget {
+			log.Warn("Package exceeds size budget",
+				slog.String("package", dir),
+				slog.String("size", formatSize(total)),
+				slog.String("budget", formatSize(r.budget)),
+			)
+		}
+	}
+	clear(r.changed)
+}
+
+// log the size of each component, largest first, and the total of each package.
+func (r *sizeReport) log(log *slog.Logger) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	byPackage := make(map[string][]componentSize)
+	for fileName, sizes := range r.sizes {
+		dir := filepath.Dir(fileName)
+		byPackage[dir] = append(byPackage[dir], sizes...)
+	}
+	totals := r.packageTotals()
+	for _, dir := range slices.Sorted(maps.Keys(byPackage)) {
+		sizes := byPackage[dir]
+		slices.SortFunc(sizes, func(a, b componentSize) int {
+			if a.size != b.size {
+				return b.size - a.size
+			}
+			return strings.Compare(a.name, b.name)
+		})
+		for _, cs := range sizes {
+			log.Info("Component size",
+				slog.String("package", dir),
+				slog.String("component", cs.name),
+				slog.String("size", formatSize(cs.size)),
+			)
+		}
+		attrs := []any{
+			slog.String("package", dir),
+			slog.Int("components", len(sizes)),
+			slog.String("size", formatSize(totals[dir])),
+		}
+		if r.budget > 0 {
+			attrs = append(attrs, slog.String("budget", formatSize(r.budget)))
+		}
+		log.Info("Package size", attrs...)
+	}
+}
+
+var sizeUnits = []struct {
+	suffix string
+	bytes  int
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+// parseSize parses a size in bytes, with an optional B, KB, MB or GB suffix,
+// e.g. 512KB. Units are powers of 1024. An empty string is 0.
+func parseSize(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	upper := strings.ToUpper(s)
+	multiplier := 1
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(upper, unit.suffix) {
+			upper, multiplier = strings.TrimSpace(strings.TrimSuffix(upper, unit.suffix)), unit.bytes
+			break
+		}
+	}
+	n, err := strconv.Atoi(upper)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%q is not a size, e.g. 512KB", s)
+	}
+	return n * multiplier, nil
+}
+
+// formatSize formats a size in bytes using the largest unit it's at least one of.
+func formatSize(n int) string {
+	for _, unit := range sizeUnits {
+		if n >= unit.bytes && unit.bytes > 1 {
+			return strconv.FormatFloat(float64(n)/float64(unit.bytes), 'f', 1, 64) + unit.suffix
+		}
+	}
+	return strconv.Itoa(n) + "B"
+}
@@ -0,0 +1,86 @@
+package generatecmd
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int
+	}{
+		{in: "", want: 0},
+		{in: "100", want: 100},
+		{in: "100B", want: 100},
+		{in: "512KB", want: 512 << 10},
+		{in: "2mb", want: 2 << 20},
+		{in: "1 GB", want: 1 << 30},
+	}
+	for _, tt := range tests {
+		got, err := parseSize(tt.in)
+		if err != nil {
+			t.Errorf("parseSize(%q) unexpected error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseSize(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+	for _, in := range []string{"KB", "-1KB", "1.5MB", "big"} {
+		if _, err := parseSize(in); err == nil {
+			t.Errorf("parseSize(%q) expected an error", in)
+		}
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		in   int
+		want string
+	}{
+		{in: 0, want: "0B"},
+		{in: 1023, want: "1023B"},
+		{in: 1536, want: "1.5KB"},
+		{in: 3 << 20, want: "3.0MB"},
+	}
+	for _, tt := range tests {
+		if got := formatSize(tt.in); got != tt.want {
+			t.Errorf("formatSize(%d) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSizeReportCheckBudget(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(slog.NewTextHandler(&buf, nil))
+
+	r := newSizeReport(100)
+	r.set("/views/a.code.go", []componentSize{{name: "A", size: 60}})
+	r.set("/views/b.code.go", []componentSize{{name: "B", size: 50}})
+	r.set("/other/c.code.go", []componentSize{{name: "C", size: 10}})
+	r.checkBudget(log)
+	if got := strings.Count(buf.String(), "exceeds size budget"); got != 1 {
+		t.Fatalf("expected 1 warning, got %d:\n%s", got, buf.String())
+	}
+	if !strings.Contains(buf.String(), "package=/views") {
+		t.Errorf("expected a warning for /views, got:\n%s", buf.String())
+	}
+
+	// Unchanged packages aren't warned about again.
+	buf.Reset()
+	r.set("/views/a.code.go", []componentSize{{name: "A", size: 60}})
+	r.checkBudget(log)
+	if buf.Len() != 0 {
+		t.Errorf("expected no warnings, got:\n%s", buf.String())
+	}
+
+	// Removing a snippet brings the package back under budget.
+	r.remove("/views/b.code.go")
+	r.checkBudget(log)
+	if buf.Len() != 0 {
+		t.Errorf("expected no warnings, got:\n%s", buf.String())
+	}
+}
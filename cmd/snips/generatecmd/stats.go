@@ -0,0 +1,118 @@
+package generatecmd
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// runStats collects the statistics of a run, which are logged when it
+// completes, and after each batch of changes in watch mode. It's safe for
+// concurrent use.
+type runStats struct {
+	mu    sync.Mutex
+	start time.Time
+	// total counts since the run started.
+	total passStats
+	// pass counts since the current pass over the files started. Watch mode
+	// ends with a production mode pass, whose errors decide whether the run
+	// failed.
+	pass passStats
+	// batch counts since the last batch was logged.
+	batch passStats
+	// runErrs are the errors of the current pass that aren't those of a file,
+	// such as failing to write an aggregate or check links.
+	runErrs []error
+}
+
+type passStats struct {
+	// files processed.
+	files int
+	// updates is the number of files whose generated code changed.
+	updates int
+	errors  int
+}
+
+func (p *passStats) add(updated bool) {
+	p.files++
+	if updated {
+		p.updates++
+	}
+}
+
+func newRunStats() *runStats {
+	return &runStats{start: time.Now()}
+}
+
+// processed records that a file was processed.
+func (s *runStats) processed(updated bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.total.add(updated)
+	s.pass.add(updated)
+	s.batch.add(updated)
+}
+
+// failed records an error.
+func (s *runStats) failed() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.total.errors++
+	s.pass.errors++
+	s.batch.errors++
+}
+
+// runFailed records an error of the run, rather than of a file.
+func (s *runStats) runFailed(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runErrs = append(s.runErrs, err)
+}
+
+// startPass starts a new pass over the files.
+func (s *runStats) startPass() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pass = passStats{}
+	s.runErrs = nil
+}
+
+// passRunErrors returns the errors of the run in the current pass, joined.
+func (s *runStats) passRunErrors() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return errors.Join(s.runErrs...)
+}
+
+// passErrors returns the number of errors in the current pass.
+func (s *runStats) passErrors() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.pass.errors
+}
+
+// logBatch logs the statistics of the batch since the last call.
+func (s *runStats) logBatch(log *slog.Logger) {
+	s.mu.Lock()
+	batch := s.batch
+	s.batch = passStats{}
+	s.mu.Unlock()
+	log.Info("Generated", batch.attrs()...)
+}
+
+// logComplete logs the statistics of the whole run.
+func (s *runStats) logComplete(log *slog.Logger) {
+	s.mu.Lock()
+	total := s.total
+	s.mu.Unlock()
+	log.Info("Complete", append(total.attrs(), slog.Duration("duration", time.Since(s.start)))...)
+}
+
+func (p passStats) attrs() []any {
+	return []any{
+		slog.Int("files", p.files),
+		slog.Int("updates", p.updates),
+		slog.Int("errors", p.errors),
+	}
+}
@@ -0,0 +1,48 @@
+package generatecmd
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRunStats(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(slog.NewTextHandler(&buf, nil))
+
+	s := newRunStats()
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.processed(i%2 == 0)
+		}()
+	}
+	wg.Wait()
+	s.failed()
+	s.logBatch(log)
+	if !strings.Contains(buf.String(), "msg=Generated files=10 updates=5 errors=1") {
+		t.Errorf("unexpected batch log: %s", buf.String())
+	}
+
+	// Errors are counted per pass, but reported for the whole run.
+	buf.Reset()
+	s.startPass()
+	s.processed(true)
+	if n := s.passErrors(); n != 0 {
+		t.Errorf("expected no errors in the new pass, got %d", n)
+	}
+	s.logBatch(log)
+	s.logComplete(log)
+	for _, expected := range []string{
+		"msg=Generated files=1 updates=1 errors=0",
+		"msg=Complete files=11 updates=6 errors=1",
+	} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("expected log to contain %q, got: %s", expected, buf.String())
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package generatecmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// minStallTimeout is the least time that events may be pending without any
+// completing before /healthz reports the run as wedged.
+const minStallTimeout = time.Minute
+
+// runStatus is the state of a run, served as JSON by /statusz, and checked by
+// /healthz, so that dev environment orchestrators can restart a wedged
+// watcher. It's safe for concurrent use.
+type runStatus struct {
+	root  string
+	watch bool
+	// stallTimeout is how long events may be pending without any completing
+	// before the run is unhealthy.
+	stallTimeout time.Duration
+
+	mu sync.Mutex
+	// lastGeneration is when the last batch of generation completed.
+	lastGeneration time.Time
+	// lastProgress is when an event last completed, or when events started
+	// pending.
+	lastProgress time.Time
+	pending      int
+	// errors of the files that currently fail, by path relative to root.
+	errors map[string]string
+}
+
+func newRunStatus(root string, watch bool, fileTimeout time.Duration) *runStatus {
+	return &runStatus{
+		root:         root,
+		watch:        watch,
+		stallTimeout: max(minStallTimeout, 2*fileTimeout),
+		lastProgress: time.Now(),
+		errors:       make(map[string]string),
+	}
+}
+
+// started records that an event started processing.
+func (s *runStatus) started() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pending == 0 {
+		s.lastProgress = time.Now()
+	}
+	s.pending++
+}
+
+// finished records the result of processing an event for the file.
+func (s *runStatus) finished(fileName string, err error) {
+	rel, relErr := filepath.Rel(s.root, fileName)
+	if relErr != nil {
+		rel = fileName
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending--
+	s.lastProgress = time.Now()
+	if err != nil {
+		s.errors[filepath.ToSlash(rel)] = err.Error()
+	} else {
+		delete(s.errors, filepath.ToSlash(rel))
+	}
+}
+
+// generated records that a batch of generation completed.
+func (s *runStatus) generated() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastGeneration = time.Now()
+}
+
+// statusJSON is the JSON of /statusz.
+type statusJSON struct {
+	Watch bool `json:"watch"`
+	// LastGeneration is omitted until the first batch completes.
+	LastGeneration *time.Time  `json:"lastGeneration,omitempty"`
+	PendingEvents  int         `json:"pendingEvents"`
+	Errors         []fileError `json:"errors"`
+	Healthy        bool        `json:"healthy"`
+}
+
+type fileError struct {
+	File  string `json:"file"`
+	Error string `json:"error"`
+}
+
+// healthJSON is the JSON of /healthz.
+type healthJSON struct {
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// unhealthy returns why the run is wedged, or "" if it's healthy. The caller
+// must hold s.mu.
+func (s *runStatus) unhealthy(now time.Time) string {
+	if s.pending > 0 && now.Sub(s.lastProgress) > s.stallTimeout {
+		return fmt.Sprintf("%d events pending with none completing for %v", s.pending, now.Sub(s.lastProgress).Round(time.Second))
+	}
+	return ""
+}
+
+func (s *runStatus) status(now time.Time) statusJSON {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := statusJSON{
+		Watch:         s.watch,
+		PendingEvents: s.pending,
+		Errors:        []fileError{},
+		Healthy:       s.unhealthy(now) == "",
+	}
+	if !s.lastGeneration.IsZero() {
+		lastGeneration := s.lastGeneration
+		st.LastGeneration = &lastGeneration
+	}
+	for _, file := range slices.Sorted(maps.Keys(s.errors)) {
+		st.Errors = append(st.Errors, fileError{File: file, Error: s.errors[file]})
+	}
+	return st
+}
+
+// handler serves /healthz, /statusz, and the /debug/pprof/ profiles.
+func (s *runStatus) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		reason := s.unhealthy(time.Now())
+		s.mu.Unlock()
+		if reason != "" {
+			writeJSON(w, http.StatusServiceUnavailable, healthJSON{Status: "unhealthy", Reason: reason})
+			return
+		}
+		writeJSON(w, http.StatusOK, healthJSON{Status: "ok"})
+	})
+	mux.HandleFunc("GET /statusz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, s.status(time.Now()))
+	})
+	// net/http/pprof registers its handlers with the default mux.
+	mux.Handle("/debug/pprof/", http.DefaultServeMux)
+	return mux
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// serveStatus serves the status of the run on HTTPAddr until the returned
+// function is called.
+func (cmd Generate) serveStatus(status *runStatus) (stop func(), err error) {
+	ln, err := net.Listen("tcp", cmd.Args.HTTPAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", cmd.Args.HTTPAddr, err)
+	}
+	srv := &http.Server{Handler: status.handler(), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+			cmd.Log.Error("Status server failed", slog.Any("error", err))
+		}
+	}()
+	cmd.Log.Info("Serving status", slog.String("url", "http://"+ln.Addr().String()+"/statusz"))
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_ = srv.Shutdown(ctx)
+	}, nil
+}
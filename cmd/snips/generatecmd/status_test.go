@@ -0,0 +1,72 @@
+package generatecmd
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRunStatus(t *testing.T) {
+	root := t.TempDir()
+	status := newRunStatus(root, true, 0)
+	srv := httptest.NewServer(status.handler())
+	defer srv.Close()
+
+	get := func(path string, v any) int {
+		t.Helper()
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if err = json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode
+	}
+
+	status.started()
+	status.started()
+	status.finished(filepath.Join(root, "a.code.go"), errors.New("invalid front matter"))
+	status.finished(filepath.Join(root, "views", "b.code.go"), nil)
+	status.generated()
+	var st statusJSON
+	if code := get("/statusz", &st); code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", code)
+	}
+	if st.LastGeneration == nil {
+		t.Error("expected the last generation time")
+	}
+	st.LastGeneration = nil
+	expected := statusJSON{
+		Watch:   true,
+		Errors:  []fileError{{File: "a.code.go", Error: "invalid front matter"}},
+		Healthy: true,
+	}
+	if diff := cmp.Diff(expected, st); diff != "" {
+		t.Errorf("unexpected status (-want +got):\n%s", diff)
+	}
+
+	var health healthJSON
+	if code := get("/healthz", &health); code != http.StatusOK || health.Status != "ok" {
+		t.Errorf("expected healthy, got %d %+v", code, health)
+	}
+	status.started()
+	status.mu.Lock()
+	status.lastProgress = time.Now().Add(-2 * minStallTimeout)
+	status.mu.Unlock()
+	if code := get("/healthz", &health); code != http.StatusServiceUnavailable || health.Status != "unhealthy" {
+		t.Errorf("expected a stalled event to be unhealthy, got %d %+v", code, health)
+	}
+
+	status.finished(filepath.Join(root, "a.code.go"), nil)
+	if get("/statusz", &st); len(st.Errors) != 0 {
+		t.Errorf("expected the error to clear, got %+v", st.Errors)
+	}
+}
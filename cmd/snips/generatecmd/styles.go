@@ -0,0 +1,94 @@
+package generatecmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+// styleSet resolves the style names of flags and front matter, which are
+// chroma style names, paths to XML style files, or aliases of either defined
+// by -style-alias, so that snippets don't hardcode paths, and themes are
+// swapped in one place. It's safe for concurrent use.
+type styleSet struct {
+	aliases map[string]string
+
+	mu sync.Mutex
+	// loaded are the styles loaded from XML files, by path.
+	loaded map[string]*chroma.Style
+}
+
+// newStyleSet returns a set with the given aliases, checking that each
+// resolves to a style.
+func newStyleSet(aliases map[string]string) (*styleSet, error) {
+	s := &styleSet{aliases: aliases, loaded: make(map[string]*chroma.Style)}
+	for alias, target := range aliases {
+		if _, ok := aliases[target]; ok {
+			return nil, fmt.Errorf("invalid style alias %q: aliases can't refer to aliases", alias)
+		}
+		if _, err := s.get(alias); err != nil {
+			return nil, fmt.Errorf("invalid style alias %q: %w", alias, err)
+		}
+	}
+	return s, nil
+}
+
+// resolve returns the style name or XML path that name is an alias of, or
+// name itself.
+func (s *styleSet) resolve(name string) string {
+	if target, ok := s.aliases[name]; ok {
+		return target
+	}
+	return name
+}
+
+// get returns the named style.
+func (s *styleSet) get(name string) (*chroma.Style, error) {
+	name = s.resolve(name)
+	if strings.HasSuffix(name, ".xml") {
+		return s.load(name)
+	}
+	style, ok := styles.Registry[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown style %q", name)
+	}
+	return style, nil
+}
+
+// registered returns the chroma style name that name resolves to, for uses
+// that need a registered style, such as -themes, whose CSS is generated by
+// the runtime package.
+func (s *styleSet) registered(name string) (string, error) {
+	target := s.resolve(name)
+	if strings.HasSuffix(target, ".xml") {
+		return "", fmt.Errorf("style %q is an XML file, expected a chroma style", name)
+	}
+	if _, ok := styles.Registry[target]; !ok {
+		return "", fmt.Errorf("unknown style %q", target)
+	}
+	return target, nil
+}
+
+// load returns the style of the XML file, loading it once.
+func (s *styleSet) load(fileName string) (*chroma.Style, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if style, ok := s.loaded[fileName]; ok {
+		return style, nil
+	}
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load style: %w", err)
+	}
+	defer f.Close()
+	style, err := chroma.NewXMLStyle(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load style %q: %w", fileName, err)
+	}
+	s.loaded[fileName] = style
+	return style, nil
+}
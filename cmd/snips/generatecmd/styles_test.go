@@ -0,0 +1,149 @@
+package generatecmd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const brandStyle = `<style name="brand">
+  <entry type="Background" style="#abcdef bg:#123456"/>
+</style>
+`
+
+func TestStyleSet(t *testing.T) {
+	brand := filepath.Join(t.TempDir(), "brand.xml")
+	if err := os.WriteFile(brand, []byte(brandStyle), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	styles, err := newStyleSet(map[string]string{"brand-dark": brand, "brand-light": "dracula"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		expected string
+		wantErr  string
+	}{
+		{name: "monokai", expected: "monokai"},
+		{name: "brand-light", expected: "dracula"},
+		{name: "brand-dark", expected: "brand"},
+		{name: brand, expected: "brand"},
+		{name: "unknown", wantErr: `unknown style "unknown"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			style, err := styles.get(tt.name)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if style.Name != tt.expected {
+				t.Errorf("expected style %q, got %q", tt.expected, style.Name)
+			}
+		})
+	}
+
+	if _, err := styles.registered("brand-dark"); err == nil {
+		t.Error("expected an error for a registered style that is an XML file")
+	}
+	if name, err := styles.registered("brand-light"); err != nil || name != "dracula" {
+		t.Errorf("expected the registered style dracula, got %q, %v", name, err)
+	}
+}
+
+func TestNewStyleSetInvalidAliases(t *testing.T) {
+	tests := []struct {
+		name    string
+		aliases map[string]string
+		wantErr string
+	}{
+		{
+			name:    "unknown style",
+			aliases: map[string]string{"brand": "unknown"},
+			wantErr: `invalid style alias "brand": unknown style "unknown"`,
+		},
+		{
+			name:    "missing file",
+			aliases: map[string]string{"brand": filepath.Join(t.TempDir(), "missing.xml")},
+			wantErr: `invalid style alias "brand": failed to load style`,
+		},
+		{
+			name:    "alias of an alias",
+			aliases: map[string]string{"brand": "dark", "dark": "monokai"},
+			wantErr: `invalid style alias "brand": aliases can't refer to aliases`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newStyleSet(tt.aliases)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("expected an error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestStyleAliases(t *testing.T) {
+	brand := filepath.Join(t.TempDir(), "brand.xml")
+	if err := os.WriteFile(brand, []byte(brandStyle), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fsys := fstest.MapFS{
+		"flag.code.txt":        {Data: []byte("x = 1\n")},
+		"frontmatter.code.txt": {Data: []byte("---\nstyle: brand-dark\n---\nx = 1\n")},
+		"unknown.code.txt":     {Data: []byte("---\nstyle: unknown\n---\nx = 1\n")},
+	}
+	tests := []struct {
+		fileName string
+		style    string
+		expected string
+		wantErr  string
+	}{
+		{fileName: "flag.code.txt", style: "brand-light", expected: "background-color:#282a36"},
+		{fileName: "flag.code.txt", style: "brand-dark", expected: "background-color:#123456"},
+		{fileName: "frontmatter.code.txt", style: "brand-light", expected: "background-color:#123456"},
+		{fileName: "unknown.code.txt", style: "brand-light", wantErr: `invalid style: unknown style "unknown"`},
+		{fileName: "flag.code.txt", style: "unknown", wantErr: `invalid style: unknown style "unknown"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.fileName+"/"+tt.style, func(t *testing.T) {
+			var code []byte
+			args := Arguments{
+				Path:     filepath.Join(t.TempDir(), "docs"),
+				FS:       fsys,
+				FileName: tt.fileName,
+				FileWriter: FileWriterFunc(func(_ string, contents []byte) error {
+					code = contents
+					return nil
+				}),
+				Style:        tt.style,
+				StyleAliases: map[string]string{"brand-dark": brand, "brand-light": "dracula"},
+			}
+			err := Run(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)), args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Contains(code, []byte(tt.expected)) {
+				t.Errorf("expected the generated code to contain %q, got:\n%s", tt.expected, code)
+			}
+		})
+	}
+}
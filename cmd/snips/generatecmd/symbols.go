@@ -0,0 +1,19 @@
+package generatecmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// readSymbolLinks reads a JSON object mapping identifiers to URLs.
+func readSymbolLinks(fileName string) (links map[string]string, err error) {
+	f, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read symbols file %q: %w", fileName, err)
+	}
+	if err = json.Unmarshal(f, &links); err != nil {
+		return nil, fmt.Errorf("failed to parse symbols file %q: %w", fileName, err)
+	}
+	return links, nil
+}
@@ -0,0 +1,43 @@
+package generatecmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/garrettladley/snips/generator"
+)
+
+// textSuffix is the suffix of the plain text files of snippets written with
+// -format text, e.g. hello.code.go_snips.txt.
+const textSuffix = "_snips.txt"
+
+// withText writes the plain text of snippets instead of generating code.
+func withText() FSEventHandlerOpt {
+	return func(h *FSEventHandler) {
+		h.text = true
+	}
+}
+
+// generateText writes the line-numbered plain text of a snippet beside it,
+// reporting whether it changed.
+func (h *FSEventHandler) generateText(fileName string, contents []byte, opts []generator.GenerateOpt, p *phase) (updated bool, err error) {
+	p.enter("highlight")
+	var b bytes.Buffer
+	if err = generator.GenerateText(&b, generator.Config{Contents: contents}, opts...); err != nil {
+		return false, fmt.Errorf("%s generation error: %w", fileName, err)
+	}
+	if !p.enter("write") {
+		return false, errAbandoned
+	}
+	targetFileName := fileName + textSuffix
+	if !h.UpsertHash(targetFileName, sha256.Sum256(b.Bytes())) {
+		return false, nil
+	}
+	if err = writeOutputs(h.writer, outputs{writes: []output{{name: targetFileName, contents: b.Bytes()}}}); err != nil {
+		// Write the file again on the next change.
+		h.forgetHash(targetFileName)
+		return false, err
+	}
+	return true, nil
+}
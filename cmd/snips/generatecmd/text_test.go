@@ -0,0 +1,61 @@
+package generatecmd
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestGenerateTextFormat(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "docs")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.code.go"), []byte("---\nredact: [secret]\n---\npackage main\n\nvar key = \"secret\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	for range 2 {
+		// The text files aren't snippets, so the second run leaves them alone.
+		if err := Run(context.Background(), log, Arguments{Path: dir, Text: true, NoLock: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	text, err := os.ReadFile(filepath.Join(dir, "a.code.go"+textSuffix))
+	if err != nil {
+		t.Fatalf("expected the text file to be written: %v", err)
+	}
+	if expected := "1  package main\n2\n3  var key = \"•••\"\n"; string(text) != expected {
+		t.Errorf("expected:\n%q\ngot:\n%q", expected, text)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	if strings.Join(names, ",") != "a.code.go,a.code.go_snips.txt" {
+		t.Errorf("expected only the snippet and its text, got %v", names)
+	}
+
+	// The text file is removed with its snippet.
+	if err = os.Remove(filepath.Join(dir, "a.code.go")); err != nil {
+		t.Fatal(err)
+	}
+	h := NewFSEventHandler(log, dir, false, nil, false, localWriter{}, false, withText())
+	if _, _, err = h.HandleEvent(context.Background(), fsnotify.Event{Name: filepath.Join(dir, "a.code.go"), Op: fsnotify.Remove}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "a.code.go"+textSuffix)); !os.IsNotExist(err) {
+		t.Errorf("expected the text file to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+package generatecmd
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/garrettladley/snips/cmd/snips/generatecmd/tracing"
+)
+
+// errAbandoned is returned by generation that carried on after its file timed
+// out, once it reaches the write phase.
+var errAbandoned = errors.New("abandoned after timing out")
+
+// phase is the phase of a file's generation, e.g. "highlight", which is
+// reported when it times out, and traced as a span of the file's span. A nil
+// phase never times out.
+type phase struct {
+	mu        sync.Mutex
+	name      string
+	abandoned bool
+	// file is the span of the file's generation, and span that of the phase.
+	file *tracing.Span
+	span *tracing.Span
+}
+
+// enter starts the named phase, returning false if the generation has been
+// abandoned, in which case it must stop without writing anything.
+func (p *phase) enter(name string) bool {
+	if p == nil {
+		return true
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.abandoned {
+		return false
+	}
+	p.name = name
+	p.span.End()
+	p.span = p.file.Start(name)
+	return true
+}
+
+// trace starts a span of the current phase, for generator.WithTrace.
+func (p *phase) trace(name string) (end func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.span.Start(name).End
+}
+
+// end ends the file's generation with err.
+func (p *phase) end(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.span.End()
+	p.file.SetError(err)
+	p.file.End()
+}
+
+// abandon abandons the generation, returning the phase it was in.
+func (p *phase) abandon() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.abandoned = true
+	return p.name
+}
+
+// generateFile generates fileName, failing if it takes longer than the file
+// timeout. Highlighting can't be interrupted, so a timed out generation
+// carries on in the background, but is stopped before it writes anything.
+func (h *FSEventHandler) generateFile(fileName string) (goUpdated, textUpdated bool, err error) {
+	if h.fileTimeout <= 0 && h.trace == nil {
+		return h.generate(fileName, nil)
+	}
+	p := &phase{name: "start"}
+	if h.trace != nil {
+		rel, err := filepath.Rel(h.dir, fileName)
+		if err != nil {
+			rel = fileName
+		}
+		p.file = h.trace.Start("generate", tracing.String("file", filepath.ToSlash(rel)))
+	}
+	if h.fileTimeout <= 0 {
+		goUpdated, textUpdated, err = h.generate(fileName, p)
+		p.end(err)
+		return goUpdated, textUpdated, err
+	}
+	type result struct {
+		goUpdated, textUpdated bool
+		err                    error
+	}
+	done := make(chan result, 1)
+	start := time.Now()
+	go func() {
+		var r result
+		r.goUpdated, r.textUpdated, r.err = h.generate(fileName, p)
+		done <- r
+	}()
+	timer := time.NewTimer(h.fileTimeout)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		p.end(r.err)
+		return r.goUpdated, r.textUpdated, r.err
+	case <-timer.C:
+		name := p.abandon()
+		select {
+		case r := <-done:
+			// It completed as it timed out.
+			p.end(r.err)
+			return r.goUpdated, r.textUpdated, r.err
+		default:
+		}
+		err = fmt.Errorf("timed out after %v in the %s phase", time.Since(start).Round(time.Millisecond), name)
+		p.end(err)
+		return false, false, err
+	}
+}
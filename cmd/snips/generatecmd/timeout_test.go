@@ -0,0 +1,109 @@
+package generatecmd
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// slowFS is an FS whose files can't be read until release is closed.
+type slowFS struct {
+	fstest.MapFS
+	release chan struct{}
+}
+
+func (s slowFS) ReadFile(name string) ([]byte, error) {
+	<-s.release
+	return s.MapFS.ReadFile(name)
+}
+
+func TestRunFileTimeout(t *testing.T) {
+	fsys := slowFS{
+		MapFS:   fstest.MapFS{"views/slow.code.go": {Data: []byte("package main\n")}},
+		release: make(chan struct{}),
+	}
+	defer close(fsys.release)
+	written := make(chan string, 1)
+	args := Arguments{
+		Path:     t.TempDir(),
+		FileName: "views/slow.code.go",
+		FS:       fsys,
+		FileWriter: FileWriterFunc(func(name string, contents []byte) error {
+			written <- name
+			return nil
+		}),
+		FileTimeout: 10 * time.Millisecond,
+	}
+	err := Run(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)), args)
+	if err == nil || !strings.Contains(err.Error(), "timed out") || !strings.Contains(err.Error(), "in the read phase") {
+		t.Fatalf("expected a timeout in the read phase, got %v", err)
+	}
+	select {
+	case name := <-written:
+		t.Errorf("expected nothing to be written, got %q", filepath.Base(name))
+	default:
+	}
+}
+
+func TestPhaseAbandon(t *testing.T) {
+	p := &phase{}
+	if !p.enter("highlight") {
+		t.Fatal("expected the phase to be entered")
+	}
+	if name := p.abandon(); name != "highlight" {
+		t.Errorf("expected to abandon in the highlight phase, got %q", name)
+	}
+	if p.enter("write") {
+		t.Error("expected an abandoned generation not to write")
+	}
+	var none *phase
+	if !none.enter("write") {
+		t.Error("expected a nil phase never to be abandoned")
+	}
+}
+
+func TestRunTrace(t *testing.T) {
+	var names []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []struct{ Name string }
+				}
+			}
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		for _, s := range req.ResourceSpans[0].ScopeSpans[0].Spans {
+			names = append(names, s.Name)
+		}
+	}))
+	defer srv.Close()
+
+	fsys := &memFS{files: fstest.MapFS{"views/a.code.go": {Data: []byte("package main\n")}}}
+	args := Arguments{
+		Path:         t.TempDir(),
+		FileName:     "views/a.code.go",
+		FS:           fsys,
+		OTLPEndpoint: srv.URL,
+	}
+	if err := Run(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)), args); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Spans are exported in the order they end.
+	expected := []string{"read", "parse", "tokenize", "format", "highlight", "gofmt", "write", "generate", "snips generate"}
+	if diff := cmp.Diff(expected, names); diff != "" {
+		t.Errorf("unexpected spans:\n%s", diff)
+	}
+}
@@ -0,0 +1,111 @@
+package generatecmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/garrettladley/snips"
+)
+
+// tokenCache stores the tokens of snippets, in memory for the rebuilds of
+// watch mode, and in a directory across runs, so that runs that only change
+// presentation options skip tokenizing. Failing to read or write the
+// directory is the same as a miss, since the tokens can always be recomputed.
+// It's safe for concurrent use.
+type tokenCache struct {
+	// dir persists the tokens across runs, if set.
+	dir string
+	// version is of snips and chroma, whose updates change the tokens.
+	version string
+
+	mu sync.Mutex
+	// tokens by key, if they're kept in memory.
+	tokens map[string][]chroma.Token
+}
+
+// newTokenCache returns a cache persisting tokens in dir, if set, and keeping
+// them in memory if inMemory is set.
+func newTokenCache(dir string, inMemory bool) *tokenCache {
+	c := &tokenCache{dir: dir, version: snips.Version() + " " + ChromaVersion()}
+	if inMemory {
+		c.tokens = make(map[string][]chroma.Token)
+	}
+	return c
+}
+
+func (c *tokenCache) Get(key string) (tokens []chroma.Token, ok bool) {
+	c.mu.Lock()
+	tokens, ok = c.tokens[key]
+	c.mu.Unlock()
+	if ok {
+		return slices.Clone(tokens), true
+	}
+	if c.dir == "" {
+		return nil, false
+	}
+	data, err := os.ReadFile(c.fileName(key))
+	if err != nil {
+		return nil, false
+	}
+	if err = gob.NewDecoder(bytes.NewReader(data)).Decode(&tokens); err != nil {
+		return nil, false
+	}
+	c.remember(key, tokens)
+	return slices.Clone(tokens), true
+}
+
+func (c *tokenCache) Put(key string, tokens []chroma.Token) {
+	c.remember(key, tokens)
+	if c.dir == "" {
+		return
+	}
+	var b bytes.Buffer
+	if err := gob.NewEncoder(&b).Encode(tokens); err != nil {
+		return
+	}
+	_ = writeCacheFile(c.fileName(key), b.Bytes())
+}
+
+// remember keeps the tokens in memory, if enabled.
+func (c *tokenCache) remember(key string, tokens []chroma.Token) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.tokens != nil {
+		c.tokens[key] = tokens
+	}
+}
+
+// fileName returns the file of the tokens of key in dir, which depends on the
+// version, so that updates don't reuse the tokens of another lexer version.
+func (c *tokenCache) fileName(key string) string {
+	h := sha256.Sum256([]byte(c.version + "\x00" + key))
+	return filepath.Join(c.dir, "tokens", hex.EncodeToString(h[:]))
+}
+
+// writeCacheFile writes a file of a cache directory via a temporary file, so
+// that concurrent runs never read partial entries.
+func writeCacheFile(fileName string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(fileName), 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(fileName), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err = f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), fileName)
+}
@@ -0,0 +1,44 @@
+package generatecmd
+
+import (
+	"testing"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTokenCache(t *testing.T) {
+	dir := t.TempDir()
+	tokens := []chroma.Token{{Type: chroma.Keyword, Value: "package"}, {Type: chroma.Text, Value: " "}, {Type: chroma.NameOther, Value: "main"}}
+
+	newTokenCache(dir, false).Put("key", tokens)
+	got, ok := newTokenCache(dir, false).Get("key")
+	if !ok {
+		t.Fatal("expected the tokens to be persisted")
+	}
+	if diff := cmp.Diff(tokens, got); diff != "" {
+		t.Errorf("unexpected tokens (-want +got):\n%s", diff)
+	}
+	if _, ok = newTokenCache(dir, false).Get("other"); ok {
+		t.Error("expected other keys to miss")
+	}
+	other := newTokenCache(dir, false)
+	other.version += "-next"
+	if _, ok = other.Get("key"); ok {
+		t.Error("expected other versions to miss")
+	}
+
+	memory := newTokenCache("", true)
+	memory.Put("key", tokens)
+	got, ok = memory.Get("key")
+	if !ok {
+		t.Fatal("expected the tokens to be kept in memory")
+	}
+	got[0].Value = "changed"
+	if got, _ = memory.Get("key"); got[0].Value != "package" {
+		t.Errorf("expected the cached tokens not to change, got %q", got[0].Value)
+	}
+	if _, ok = newTokenCache("", false).Get("key"); ok {
+		t.Error("expected a cache without memory or dir to miss")
+	}
+}
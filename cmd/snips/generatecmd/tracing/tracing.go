@@ -0,0 +1,274 @@
+// Package tracing records the spans of a run and exports them as an
+// OpenTelemetry trace, using OTLP over HTTP with the JSON encoding, so that
+// snips shows up alongside other build telemetry without pulling in the
+// OpenTelemetry SDK.
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tracer records the spans of a single trace. A nil Tracer records nothing.
+type Tracer struct {
+	mu      sync.Mutex
+	traceID [16]byte
+	spans   []*Span
+	// resource attributes, such as the service name.
+	resource []Attr
+}
+
+// New returns a Tracer for a new trace of the named service.
+func New(service, version string) *Tracer {
+	t := &Tracer{resource: []Attr{
+		String("service.name", service),
+		String("service.version", version),
+	}}
+	_, _ = rand.Read(t.traceID[:])
+	return t
+}
+
+// Start starts a root span.
+func (t *Tracer) Start(name string, attrs ...Attr) *Span {
+	if t == nil {
+		return nil
+	}
+	return t.start(name, [8]byte{}, attrs)
+}
+
+func (t *Tracer) start(name string, parent [8]byte, attrs []Attr) *Span {
+	s := &Span{t: t, parent: parent, name: name, start: time.Now(), attrs: attrs}
+	_, _ = rand.Read(s.id[:])
+	return s
+}
+
+// Span is a timed operation of a trace. Its methods must not be called
+// concurrently, but its children may be started and ended concurrently. A nil
+// Span records nothing.
+type Span struct {
+	t      *Tracer
+	id     [8]byte
+	parent [8]byte
+	name   string
+	start  time.Time
+	end    time.Time
+	attrs  []Attr
+	err    string
+}
+
+// Start starts a child span.
+func (s *Span) Start(name string, attrs ...Attr) *Span {
+	if s == nil {
+		return nil
+	}
+	return s.t.start(name, s.id, attrs)
+}
+
+// SetAttributes adds attributes to the span.
+func (s *Span) SetAttributes(attrs ...Attr) {
+	if s == nil {
+		return
+	}
+	s.attrs = append(s.attrs, attrs...)
+}
+
+// SetError marks the span as failed, if err isn't nil.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.err = err.Error()
+}
+
+// End ends the span, recording it in its trace. Ending a span again does
+// nothing.
+func (s *Span) End() {
+	if s == nil || !s.end.IsZero() {
+		return
+	}
+	s.end = time.Now()
+	s.t.mu.Lock()
+	defer s.t.mu.Unlock()
+	s.t.spans = append(s.t.spans, s)
+}
+
+// Attr is an attribute of a span.
+type Attr struct {
+	Key   string
+	Value any
+}
+
+func String(key, value string) Attr    { return Attr{Key: key, Value: value} }
+func Int(key string, value int) Attr   { return Attr{Key: key, Value: value} }
+func Bool(key string, value bool) Attr { return Attr{Key: key, Value: value} }
+
+// Export sends the ended spans to the OTLP/HTTP collector at endpoint, e.g.
+// http://localhost:4318. The /v1/traces path is added to endpoints without a
+// path.
+func (t *Tracer) Export(ctx context.Context, endpoint string, headers map[string]string) error {
+	if t == nil {
+		return nil
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid endpoint: %w", err)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/v1/traces"
+	}
+	body, err := json.Marshal(t.request())
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export trace: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("failed to export trace: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// ParseHeaders parses headers in the format of the
+// OTEL_EXPORTER_OTLP_HEADERS environment variable, e.g. "api-key=abc,x=y".
+func ParseHeaders(s string) (map[string]string, error) {
+	headers := make(map[string]string)
+	for _, kv := range strings.Split(s, ",") {
+		if strings.TrimSpace(kv) == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid header %q, expected key=value", kv)
+		}
+		v, err := url.QueryUnescape(strings.TrimSpace(v))
+		if err != nil {
+			return nil, fmt.Errorf("invalid header %q: %w", kv, err)
+		}
+		headers[strings.TrimSpace(k)] = v
+	}
+	return headers, nil
+}
+
+// The OTLP/JSON encoding of traces, see
+// https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding.
+type (
+	exportRequest struct {
+		ResourceSpans []resourceSpans `json:"resourceSpans"`
+	}
+	resourceSpans struct {
+		Resource   resource     `json:"resource"`
+		ScopeSpans []scopeSpans `json:"scopeSpans"`
+	}
+	resource struct {
+		Attributes []keyValue `json:"attributes"`
+	}
+	scopeSpans struct {
+		Scope scope  `json:"scope"`
+		Spans []span `json:"spans"`
+	}
+	scope struct {
+		Name string `json:"name"`
+	}
+	span struct {
+		TraceID           string     `json:"traceId"`
+		SpanID            string     `json:"spanId"`
+		ParentSpanID      string     `json:"parentSpanId,omitempty"`
+		Name              string     `json:"name"`
+		Kind              int        `json:"kind"`
+		StartTimeUnixNano string     `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+		Attributes        []keyValue `json:"attributes,omitempty"`
+		Status            *status    `json:"status,omitempty"`
+	}
+	status struct {
+		Code    int    `json:"code"`
+		Message string `json:"message,omitempty"`
+	}
+	keyValue struct {
+		Key   string   `json:"key"`
+		Value anyValue `json:"value"`
+	}
+	anyValue struct {
+		StringValue *string `json:"stringValue,omitempty"`
+		IntValue    *string `json:"intValue,omitempty"`
+		BoolValue   *bool   `json:"boolValue,omitempty"`
+	}
+)
+
+const (
+	spanKindInternal = 1
+	statusCodeError  = 2
+)
+
+func (t *Tracer) request() exportRequest {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	spans := make([]span, len(t.spans))
+	for i, s := range t.spans {
+		spans[i] = span{
+			TraceID:           hex.EncodeToString(t.traceID[:]),
+			SpanID:            hex.EncodeToString(s.id[:]),
+			Name:              s.name,
+			Kind:              spanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+			Attributes:        keyValues(s.attrs),
+		}
+		if s.parent != [8]byte{} {
+			spans[i].ParentSpanID = hex.EncodeToString(s.parent[:])
+		}
+		if s.err != "" {
+			spans[i].Status = &status{Code: statusCodeError, Message: s.err}
+		}
+	}
+	return exportRequest{ResourceSpans: []resourceSpans{{
+		Resource: resource{Attributes: keyValues(t.resource)},
+		ScopeSpans: []scopeSpans{{
+			Scope: scope{Name: "github.com/garrettladley/snips"},
+			Spans: spans,
+		}},
+	}}}
+}
+
+func keyValues(attrs []Attr) []keyValue {
+	kvs := make([]keyValue, len(attrs))
+	for i, a := range attrs {
+		kvs[i].Key = a.Key
+		switch v := a.Value.(type) {
+		case string:
+			kvs[i].Value.StringValue = &v
+		case int:
+			s := strconv.Itoa(v)
+			kvs[i].Value.IntValue = &s
+		case bool:
+			kvs[i].Value.BoolValue = &v
+		default:
+			s := fmt.Sprint(v)
+			kvs[i].Value.StringValue = &s
+		}
+	}
+	return kvs
+}
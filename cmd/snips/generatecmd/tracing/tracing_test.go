@@ -0,0 +1,80 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExport(t *testing.T) {
+	var got exportRequest
+	var path, apiKey string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, apiKey = r.URL.Path, r.Header.Get("api-key")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	tr := New("snips", "v1.0.0")
+	run := tr.Start("snips generate")
+	file := run.Start("generate", String("file", "views/a.code.go"))
+	file.SetError(errors.New("failed"))
+	file.End()
+	run.End()
+	// Spans that haven't ended aren't exported.
+	run.Start("write")
+
+	if err := tr.Export(context.Background(), srv.URL, map[string]string{"api-key": "abc"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if path != "/v1/traces" || apiKey != "abc" {
+		t.Errorf("expected a request to /v1/traces with the api-key header, got %q and %q", path, apiKey)
+	}
+	spans := got.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+	if spans[0].ParentSpanID != spans[1].SpanID || spans[1].ParentSpanID != "" {
+		t.Errorf("expected the file span to be a child of the root span, got %+v", spans)
+	}
+	if spans[0].TraceID != spans[1].TraceID || len(spans[0].TraceID) != 32 {
+		t.Errorf("expected the spans to share a trace ID, got %q and %q", spans[0].TraceID, spans[1].TraceID)
+	}
+	if diff := cmp.Diff(&status{Code: statusCodeError, Message: "failed"}, spans[0].Status); diff != "" {
+		t.Errorf("unexpected status:\n%s", diff)
+	}
+	if v := spans[0].Attributes[0].Value.StringValue; v == nil || *v != "views/a.code.go" {
+		t.Errorf("expected the file attribute, got %+v", spans[0].Attributes)
+	}
+}
+
+func TestNil(t *testing.T) {
+	var tr *Tracer
+	s := tr.Start("run")
+	s.Start("child").End()
+	s.SetError(errors.New("failed"))
+	s.End()
+	if err := tr.Export(context.Background(), "http://localhost:0", nil); err != nil {
+		t.Errorf("expected a nil tracer not to export, got %v", err)
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	got, err := ParseHeaders("api-key=abc%3D, x-team = docs,")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := cmp.Diff(map[string]string{"api-key": "abc=", "x-team": "docs"}, got); diff != "" {
+		t.Errorf("unexpected headers:\n%s", diff)
+	}
+	if _, err = ParseHeaders("api-key"); err == nil {
+		t.Error("expected an error for a header without a value")
+	}
+}
@@ -0,0 +1,53 @@
+package generatecmd
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/garrettladley/snips"
+)
+
+// Modes of -scan-unicode.
+const (
+	scanUnicodeWarn = "warn"
+	scanUnicodeFail = "fail"
+)
+
+// maxReportedChars is the number of suspicious characters listed in an error.
+const maxReportedChars = 5
+
+// checkScanUnicode checks the -scan-unicode mode.
+func checkScanUnicode(mode string) error {
+	switch mode {
+	case "", scanUnicodeWarn, scanUnicodeFail:
+		return nil
+	}
+	return fmt.Errorf("invalid -scan-unicode mode %q, expected %q or %q", mode, scanUnicodeWarn, scanUnicodeFail)
+}
+
+// scanUnicode warns about, or fails on, the zero-width and bidi control
+// characters of a snippet.
+func (h *FSEventHandler) scanUnicode(fileName string, contents []byte) error {
+	found := snips.ScanUnicode(contents)
+	if len(found) == 0 {
+		return nil
+	}
+	if h.unicodeMode == scanUnicodeFail {
+		var positions []string
+		for _, c := range found[:min(len(found), maxReportedChars)] {
+			positions = append(positions, c.String())
+		}
+		if len(found) > maxReportedChars {
+			positions = append(positions, fmt.Sprintf("and %d more", len(found)-maxReportedChars))
+		}
+		return fmt.Errorf("found invisible or bidi control characters: %s", strings.Join(positions, ", "))
+	}
+	for _, c := range found {
+		h.Log.Warn("Invisible or bidi control character",
+			slog.String("file", fileName),
+			slog.String("at", c.String()),
+		)
+	}
+	return nil
+}
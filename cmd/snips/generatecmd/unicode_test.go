@@ -0,0 +1,45 @@
+package generatecmd
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestScanUnicode(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.code.go": {Data: []byte("package main\n\nvar a = \"user\u202E // admin\"\n")},
+	}
+	root := filepath.Join(t.TempDir(), "missing")
+
+	var buf bytes.Buffer
+	log := slog.New(slog.NewTextHandler(&buf, nil))
+	written := make(map[string]bool)
+	args := Arguments{Path: root, FS: fsys, FileName: "a.code.go", FileWriter: FileWriterFunc(func(name string, _ []byte) error {
+		written[name] = true
+		return nil
+	})}
+
+	args.ScanUnicode = scanUnicodeWarn
+	if err := Run(context.Background(), log, args); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), `at="3:14: U+202E RLO"`) || len(written) != 1 {
+		t.Errorf("expected a warning and the file to be generated, got %v and log:\n%s", written, buf.String())
+	}
+
+	args.ScanUnicode = scanUnicodeFail
+	err := Run(context.Background(), log, args)
+	if err == nil || !strings.Contains(err.Error(), "3:14: U+202E RLO") {
+		t.Errorf("expected an error naming the character, got %v", err)
+	}
+
+	args.ScanUnicode = "strict"
+	if err := Run(context.Background(), log, args); err == nil {
+		t.Error("expected an invalid mode to be an error")
+	}
+}
@@ -0,0 +1,51 @@
+package generatecmd
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+
+	"github.com/garrettladley/snips"
+)
+
+// Policies of -invalid-utf8.
+const (
+	invalidUTF8Replace = "replace"
+	invalidUTF8Fail    = "fail"
+	invalidUTF8Raw     = "raw"
+)
+
+// checkInvalidUTF8 checks the -invalid-utf8 policy.
+func checkInvalidUTF8(policy string) error {
+	switch policy {
+	case "", invalidUTF8Replace, invalidUTF8Fail, invalidUTF8Raw:
+		return nil
+	}
+	return fmt.Errorf("invalid -invalid-utf8 policy %q, expected %q, %q or %q", policy, invalidUTF8Replace, invalidUTF8Fail, invalidUTF8Raw)
+}
+
+// withInvalidUTF8 sets the policy for snippets that aren't valid UTF-8, see
+// -invalid-utf8.
+func withInvalidUTF8(policy string) FSEventHandlerOpt {
+	return func(h *FSEventHandler) {
+		h.invalidUTF8 = policy
+	}
+}
+
+// checkUTF8 applies the -invalid-utf8 policy to a snippet: its invalid bytes
+// are replaced with U+FFFD, which is reported, or it fails, or they're kept.
+func (h *FSEventHandler) checkUTF8(fileName string, contents []byte) ([]byte, error) {
+	if h.invalidUTF8 == invalidUTF8Raw {
+		return contents, nil
+	}
+	line, column, found := snips.InvalidUTF8(contents)
+	if !found {
+		return contents, nil
+	}
+	at := fmt.Sprintf("%d:%d", line, column)
+	if h.invalidUTF8 == invalidUTF8Fail {
+		return nil, fmt.Errorf("invalid UTF-8 at %s", at)
+	}
+	h.Log.Warn("Replacing invalid UTF-8 with U+FFFD", slog.String("file", fileName), slog.String("at", at))
+	return bytes.ToValidUTF8(contents, []byte("\uFFFD")), nil
+}
@@ -0,0 +1,62 @@
+package generatecmd
+
+import (
+	"bytes"
+	"context"
+	"go/parser"
+	"go/token"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestInvalidUTF8(t *testing.T) {
+	fsys := fstest.MapFS{
+		"a.code.txt": {Data: []byte("hello \xff world\n")},
+	}
+	tests := []struct {
+		policy   string
+		expected string
+		wantErr  string
+	}{
+		{policy: "", expected: "hello \uFFFD world"},
+		{policy: invalidUTF8Replace, expected: "hello \uFFFD world"},
+		{policy: invalidUTF8Raw, expected: `hello \xff world`},
+		{policy: invalidUTF8Fail, wantErr: "invalid UTF-8 at 1:7"},
+		{policy: "ignore", wantErr: "invalid -invalid-utf8 policy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			var code []byte
+			args := Arguments{
+				Path:     filepath.Join(t.TempDir(), "docs"),
+				FS:       fsys,
+				FileName: "a.code.txt",
+				FileWriter: FileWriterFunc(func(_ string, contents []byte) error {
+					code = contents
+					return nil
+				}),
+				InvalidUTF8: tt.policy,
+			}
+			err := Run(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)), args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("expected an error containing %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Contains(code, []byte(tt.expected)) {
+				t.Errorf("expected the generated code to contain %q, got:\n%s", tt.expected, code)
+			}
+			if _, err := parser.ParseFile(token.NewFileSet(), "a.code.txt_templ.go", code, 0); err != nil {
+				t.Errorf("expected the generated code to be valid Go, got %v", err)
+			}
+		})
+	}
+}
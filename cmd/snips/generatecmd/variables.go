@@ -0,0 +1,22 @@
+package generatecmd
+
+import "strings"
+
+// variablesEnvPrefix is the prefix of environment variables available as
+// snippet variables, e.g. SNIPS_VERSION is available as {{SNIPS_VERSION}}.
+const variablesEnvPrefix = "SNIPS_"
+
+// variables merges the SNIPS_ prefixed variables of environ, e.g. os.Environ(),
+// with vars, which take precedence.
+func variables(vars map[string]string, environ []string) map[string]string {
+	merged := make(map[string]string)
+	for _, kv := range environ {
+		if k, v, ok := strings.Cut(kv, "="); ok && strings.HasPrefix(k, variablesEnvPrefix) {
+			merged[k] = v
+		}
+	}
+	for k, v := range vars {
+		merged[k] = v
+	}
+	return merged
+}
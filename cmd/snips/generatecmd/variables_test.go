@@ -0,0 +1,23 @@
+package generatecmd
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestVariables(t *testing.T) {
+	got := variables(
+		map[string]string{"SNIPS_VERSION": "v2", "NAME": "snips"},
+		[]string{"SNIPS_VERSION=v1", "SNIPS_ENV=prod", "HOME=/root", "SNIPS_EQ=a=b"},
+	)
+	want := map[string]string{
+		"SNIPS_VERSION": "v2",
+		"SNIPS_ENV":     "prod",
+		"SNIPS_EQ":      "a=b",
+		"NAME":          "snips",
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -0,0 +1,52 @@
+package watcher
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Batch coalesces the events from in over a window into a single batch, which
+// is sent to out once the window has passed, so that a burst of changes, e.g.
+// from switching git branches, is processed in one pass. Only the latest event
+// of each file in a window is sent. The window starts at the first event after
+// the previous batch, so batches are at least window apart. Batch returns when
+// ctx is done or in is closed, dropping any pending events.
+func Batch(ctx context.Context, in <-chan fsnotify.Event, out chan<- fsnotify.Event, window time.Duration) {
+	pending := make(map[string]fsnotify.Event)
+	timer := time.NewTimer(window)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-in:
+			if !ok {
+				return
+			}
+			if len(pending) == 0 {
+				timer.Reset(window)
+			}
+			pending[event.Name] = event
+		case <-timer.C:
+			batch := make([]fsnotify.Event, 0, len(pending))
+			for _, event := range pending {
+				batch = append(batch, event)
+			}
+			clear(pending)
+			slices.SortFunc(batch, func(a, b fsnotify.Event) int {
+				return strings.Compare(a.Name, b.Name)
+			})
+			for _, event := range batch {
+				select {
+				case <-ctx.Done():
+					return
+				case out <- event:
+				}
+			}
+		}
+	}
+}
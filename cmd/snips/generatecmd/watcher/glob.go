@@ -0,0 +1,28 @@
+package watcher
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Glob is a pattern of slash-separated paths, e.g. docs/**/*.code.go, whose
+// ** segments match any number of directories, and whose other segments are
+// matched by path.Match, as in .snipsignore files. Unlike theirs, it's always
+// anchored to the directory paths are relative to.
+type Glob struct {
+	segments []string
+}
+
+// ParseGlob parses a glob pattern, failing if it's malformed.
+func ParseGlob(pattern string) (Glob, error) {
+	segments := strings.Split(strings.TrimPrefix(pattern, "/"), "/")
+	if pattern == "" || !validSegments(segments) {
+		return Glob{}, fmt.Errorf("invalid glob %q", pattern)
+	}
+	return Glob{segments: segments}, nil
+}
+
+// Match reports whether the glob matches the slash-separated path.
+func (g Glob) Match(name string) bool {
+	return matchSegments(g.segments, strings.Split(name, "/"))
+}
@@ -0,0 +1,37 @@
+package watcher
+
+import "testing"
+
+func TestGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		want    bool
+	}{
+		{pattern: "docs/**/*.code.go", name: "docs/main.code.go", want: true},
+		{pattern: "docs/**/*.code.go", name: "docs/a/b/main.code.go", want: true},
+		{pattern: "docs/**/*.code.go", name: "docs/main.code.py", want: false},
+		{pattern: "docs/**/*.code.go", name: "examples/docs/main.code.go", want: false},
+		{pattern: "**/legacy/**", name: "legacy/old.code.go", want: true},
+		{pattern: "**/legacy/**", name: "docs/legacy/v1/old.code.go", want: true},
+		{pattern: "**/legacy/**", name: "docs/legacy.code.go", want: false},
+		{pattern: "*.code.go", name: "main.code.go", want: true},
+		{pattern: "*.code.go", name: "docs/main.code.go", want: false},
+		{pattern: "/docs/*", name: "docs/main.code.go", want: true},
+	}
+	for _, tt := range tests {
+		g, err := ParseGlob(tt.pattern)
+		if err != nil {
+			t.Fatalf("failed to parse %q: %v", tt.pattern, err)
+		}
+		if got := g.Match(tt.name); got != tt.want {
+			t.Errorf("%q.Match(%q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+
+	for _, invalid := range []string{"", "docs/[a"} {
+		if _, err := ParseGlob(invalid); err == nil {
+			t.Errorf("expected %q to be invalid", invalid)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package watcher
+
+import (
+	"bufio"
+	"bytes"
+	"io/fs"
+	"path"
+	"strings"
+	"sync"
+)
+
+// IgnoreFileName is the name of the files that exclude snippets from walking
+// and watching, in gitignore syntax. Its patterns are relative to the
+// directory it's in, and apply to that directory and its subdirectories, with
+// those of subdirectories taking precedence. As with gitignore, the files of
+// an ignored directory can't be included again.
+const IgnoreFileName = ".snipsignore"
+
+// ignorer matches paths against the ignore files of a file tree. Ignore files
+// are read once, when first needed, unless they're forgotten. It's safe for
+// concurrent use.
+type ignorer struct {
+	fsys fs.FS
+
+	mu sync.Mutex
+	// rules are the patterns of the ignore file of each directory, by its
+	// slash-separated path in fsys.
+	rules map[string][]ignoreRule
+}
+
+// ignoreRule is a pattern of an ignore file.
+type ignoreRule struct {
+	// segments of the pattern, relative to the directory of the ignore file,
+	// matched against the segments of paths. A ** segment matches any number
+	// of path segments.
+	segments []string
+	// negate re-includes the paths the pattern matches, from a leading !.
+	negate bool
+	// dirOnly only matches directories, from a trailing /.
+	dirOnly bool
+}
+
+func newIgnorer(fsys fs.FS) *ignorer {
+	return &ignorer{fsys: fsys, rules: make(map[string][]ignoreRule)}
+}
+
+// ignored reports whether the slash-separated path in fsys is ignored, either
+// itself or by an ignored parent directory.
+func (ig *ignorer) ignored(name string, isDir bool) bool {
+	name = path.Clean(name)
+	if name == "." || name == ".." || strings.HasPrefix(name, "../") || path.IsAbs(name) {
+		return false
+	}
+	segments := strings.Split(name, "/")
+	for i := range segments {
+		if ig.match(segments[:i+1], isDir || i < len(segments)-1) {
+			return true
+		}
+	}
+	return false
+}
+
+// match reports whether the last pattern of the ignore files of the parent
+// directories of the path that matches it, if any, ignores it.
+func (ig *ignorer) match(segments []string, isDir bool) (ignored bool) {
+	for i := range segments {
+		dir := path.Join(segments[:i]...)
+		if dir == "" {
+			dir = "."
+		}
+		for _, rule := range ig.rulesOf(dir) {
+			if rule.match(segments[i:], isDir) {
+				ignored = !rule.negate
+			}
+		}
+	}
+	return ignored
+}
+
+// rulesOf returns the patterns of the ignore file in dir, reading it if it
+// hasn't been read. Directories without a readable ignore file have none.
+func (ig *ignorer) rulesOf(dir string) []ignoreRule {
+	ig.mu.Lock()
+	defer ig.mu.Unlock()
+	if rules, ok := ig.rules[dir]; ok {
+		return rules
+	}
+	data, err := fs.ReadFile(ig.fsys, path.Join(dir, IgnoreFileName))
+	var rules []ignoreRule
+	if err == nil {
+		rules = parseIgnore(data)
+	}
+	ig.rules[dir] = rules
+	return rules
+}
+
+// forget drops the patterns of the ignore file in dir, so that they're read
+// again when next needed, e.g. after it changed.
+func (ig *ignorer) forget(dir string) {
+	ig.mu.Lock()
+	defer ig.mu.Unlock()
+	delete(ig.rules, path.Clean(dir))
+}
+
+// parseIgnore parses the patterns of an ignore file. As with gitignore, blank
+// lines and lines starting with # are skipped, as are invalid patterns.
+func parseIgnore(data []byte) (rules []ignoreRule) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		// Trailing spaces are trimmed unless escaped.
+		if trimmed := strings.TrimRight(line, " "); strings.HasSuffix(trimmed, `\`) && len(trimmed) < len(line) {
+			line = trimmed[:len(trimmed)-1] + " "
+		} else {
+			line = trimmed
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var rule ignoreRule
+		if rule.negate = strings.HasPrefix(line, "!"); rule.negate {
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+			line = line[1:]
+		}
+		if rule.dirOnly = strings.HasSuffix(line, "/"); rule.dirOnly {
+			line = strings.TrimSuffix(line, "/")
+		}
+		// Patterns with a separator, other than a trailing one, are anchored to
+		// the directory of the ignore file. Others match at any depth.
+		anchored := strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+		if line == "" {
+			continue
+		}
+		rule.segments = strings.Split(line, "/")
+		if !anchored && rule.segments[0] != "**" {
+			rule.segments = append([]string{"**"}, rule.segments...)
+		}
+		if !validSegments(rule.segments) {
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+func validSegments(segments []string) bool {
+	for _, segment := range segments {
+		if _, err := path.Match(segment, ""); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// match reports whether the rule matches the path, given as its segments
+// relative to the directory of the ignore file.
+func (r ignoreRule) match(segments []string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	return matchSegments(r.segments, segments)
+}
+
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		// A trailing ** matches everything inside, but not the directory
+		// itself.
+		if len(pattern) == 1 {
+			return len(segments) > 0
+		}
+		for i := range len(segments) + 1 {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], segments[1:])
+}
@@ -0,0 +1,125 @@
+package watcher
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestIgnored(t *testing.T) {
+	ig := newIgnorer(fstest.MapFS{
+		".snipsignore": {Data: []byte(`# Fixtures are huge.
+fixtures/
+/generated
+*.code.sql
+!keep.code.sql
+docs/**/legacy
+third_party/**
+\#hash.code.go
+`)},
+		"docs/.snipsignore": {Data: []byte("!fixtures/\ndraft.code.go\n")},
+	})
+	tests := []struct {
+		name  string
+		isDir bool
+		want  bool
+	}{
+		{name: "main.code.go", want: false},
+		{name: "fixtures", isDir: true, want: true},
+		{name: "fixtures", want: false},
+		{name: "a/b/fixtures/x.code.go", want: true},
+		{name: "generated/x.code.go", want: true},
+		{name: "a/generated/x.code.go", want: false},
+		{name: "a/query.code.sql", want: true},
+		{name: "a/keep.code.sql", want: false},
+		{name: "docs/v1/legacy/x.code.go", want: true},
+		{name: "docs/legacy/x.code.go", want: true},
+		{name: "third_party", isDir: true, want: false},
+		{name: "third_party/x/y.code.go", want: true},
+		{name: "#hash.code.go", want: true},
+		{name: "docs/fixtures/x.code.go", want: false},
+		{name: "docs/a/draft.code.go", want: true},
+		{name: "draft.code.go", want: false},
+		{name: "../outside.code.go", want: false},
+	}
+	for _, tt := range tests {
+		if got := ig.ignored(tt.name, tt.isDir); got != tt.want {
+			t.Errorf("ignored(%q, %v) = %v, want %v", tt.name, tt.isDir, got, tt.want)
+		}
+	}
+}
+
+func TestWalkFSIgnore(t *testing.T) {
+	fsys := fstest.MapFS{
+		".snipsignore":            {Data: []byte("vendored/\n*.skip.code.go\n")},
+		"a.code.go":               {},
+		"b.skip.code.go":          {},
+		"vendored/c.code.go":      {},
+		"docs/d.code.go":          {},
+		"docs/.snipsignore":       {Data: []byte("e.code.go\n")},
+		"docs/e.code.go":          {},
+		"docs/vendored/f.code.go": {},
+	}
+	events := make(chan fsnotify.Event, len(fsys))
+	if err := WalkFS(context.Background(), fsys, "/root", events); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	close(events)
+	var got []string
+	for event := range events {
+		got = append(got, filepath.ToSlash(event.Name))
+	}
+	slices.Sort(got)
+	if diff := cmp.Diff([]string{"/root/a.code.go", "/root/docs/d.code.go"}, got); diff != "" {
+		t.Errorf("unexpected files walked (-want +got):\n%s", diff)
+	}
+}
+
+func TestRecursiveIgnore(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "fixtures"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, IgnoreFileName), []byte("fixtures/\n*.skip.code.go\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := make(chan fsnotify.Event, 16)
+	errs := make(chan error, 16)
+	w, err := Recursive(ctx, dir, events, errs)
+	if err != nil {
+		t.Fatalf("failed to watch: %v", err)
+	}
+	defer w.Close()
+
+	for _, name := range []string{"fixtures/a.code.go", "b.skip.code.go", "c.code.go"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("package main\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := filepath.Join(dir, "c.code.go")
+	select {
+	case event := <-events:
+		if event.Name != want {
+			t.Errorf("expected an event for %q only, got %v", want, event)
+		}
+	case err := <-errs:
+		t.Fatalf("unexpected error: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for an event")
+	}
+	select {
+	case event := <-events:
+		t.Errorf("expected ignored files to have no events, got %v", event)
+	case <-time.After(3 * queueDelay):
+	}
+}
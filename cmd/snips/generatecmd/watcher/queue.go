@@ -0,0 +1,141 @@
+package watcher
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	// queueDelay is how long a file must go without changing before its
+	// latest event is sent, so that the writes of a save are sent once.
+	queueDelay = 100 * time.Millisecond
+	// queueCapacity is the most files with pending events, beyond which the
+	// watcher waits for events to be sent before reading more.
+	queueCapacity = 1 << 16
+)
+
+// queue coalesces the events of each file, sending the latest once the file
+// hasn't changed for delay, in the order the files last changed. It holds
+// the events of at most capacity files, push waiting for events to be sent
+// beyond that, so that a storm of changes, e.g. a git checkout of 50k files,
+// applies backpressure to the watcher, rather than piling up timers and
+// goroutines blocked on sending. It's safe for concurrent use.
+type queue struct {
+	delay    time.Duration
+	capacity int
+
+	mu sync.Mutex
+	// files are the pending events, least recently changed first.
+	files *list.List
+	// byName are the elements of files, by file name.
+	byName map[string]*list.Element
+	// pushed is signalled when an event is pushed.
+	pushed chan struct{}
+	// sent is closed, and replaced, whenever an event is taken to be sent.
+	sent chan struct{}
+}
+
+// queued is a pending event, and when it's due to be sent.
+type queued struct {
+	event fsnotify.Event
+	due   time.Time
+}
+
+func newQueue(delay time.Duration, capacity int) *queue {
+	return &queue{
+		delay:    delay,
+		capacity: capacity,
+		files:    list.New(),
+		byName:   make(map[string]*list.Element),
+		pushed:   make(chan struct{}, 1),
+		sent:     make(chan struct{}),
+	}
+}
+
+// push replaces the pending event of the file, if any, by event, waiting for
+// space if the queue is full, until ctx is done.
+func (q *queue) push(ctx context.Context, event fsnotify.Event) error {
+	q.mu.Lock()
+	for {
+		if e, ok := q.byName[event.Name]; ok {
+			e.Value = queued{event: event, due: time.Now().Add(q.delay)}
+			q.files.MoveToBack(e)
+			break
+		}
+		if q.files.Len() < q.capacity {
+			q.byName[event.Name] = q.files.PushBack(queued{event: event, due: time.Now().Add(q.delay)})
+			break
+		}
+		sent := q.sent
+		q.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-sent:
+		}
+		q.mu.Lock()
+	}
+	q.mu.Unlock()
+	select {
+	case q.pushed <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// len returns the number of files with pending events.
+func (q *queue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.files.Len()
+}
+
+// take returns the least recently changed pending event if it's due, or how
+// long until it is, or a negative duration if there's none.
+func (q *queue) take() (event fsnotify.Event, wait time.Duration, ok bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	e := q.files.Front()
+	if e == nil {
+		return event, -1, false
+	}
+	next := e.Value.(queued)
+	if wait = time.Until(next.due); wait > 0 {
+		return event, wait, false
+	}
+	q.files.Remove(e)
+	delete(q.byName, next.event.Name)
+	close(q.sent)
+	q.sent = make(chan struct{})
+	return next.event, 0, true
+}
+
+// send sends the events to out as they're due, until ctx is done. Since files
+// are ordered by when they last changed, events are due in order.
+func (q *queue) send(ctx context.Context, out chan<- fsnotify.Event) {
+	for {
+		event, wait, ok := q.take()
+		if ok {
+			select {
+			case <-ctx.Done():
+				return
+			case out <- event:
+			}
+			continue
+		}
+		var due <-chan time.Time
+		if wait > 0 {
+			due = time.After(wait)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.pushed:
+		case <-due:
+		}
+	}
+}
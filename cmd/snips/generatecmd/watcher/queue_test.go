@@ -0,0 +1,136 @@
+package watcher
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestQueue(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q := newQueue(20*time.Millisecond, 2)
+	for _, op := range []fsnotify.Op{fsnotify.Create, fsnotify.Write, fsnotify.Write, fsnotify.Remove} {
+		if err := q.push(ctx, fsnotify.Event{Name: "a.code.go", Op: op}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := q.push(ctx, fsnotify.Event{Name: "b.code.go", Op: fsnotify.Write}); err != nil {
+		t.Fatal(err)
+	}
+	if n := q.len(); n != 2 {
+		t.Fatalf("expected the events of each file to be coalesced, got %d pending", n)
+	}
+
+	// The queue is full, so events of other files wait for space.
+	full, cancelFull := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancelFull()
+	if err := q.push(full, fsnotify.Event{Name: "c.code.go", Op: fsnotify.Write}); err == nil {
+		t.Fatal("expected pushing to a full queue to wait")
+	}
+
+	out := make(chan fsnotify.Event)
+	go q.send(ctx, out)
+	want := []fsnotify.Event{
+		{Name: "a.code.go", Op: fsnotify.Remove},
+		{Name: "b.code.go", Op: fsnotify.Write},
+	}
+	for _, w := range want {
+		select {
+		case got := <-out:
+			if got != w {
+				t.Errorf("expected %v, got %v", w, got)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for %v", w)
+		}
+	}
+	select {
+	case event := <-out:
+		t.Errorf("expected each file's latest event only, got %v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+// TestQueueStorm simulates a git checkout changing every file of a large tree
+// several times, checking that each file's latest event is sent, while the
+// queue and the goroutines of the process stay bounded.
+func TestQueueStorm(t *testing.T) {
+	files, changes, writers := 50_000, 3, 4
+	if testing.Short() {
+		files = 5_000
+	}
+	const capacity = 1024
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	q := newQueue(time.Millisecond, capacity)
+	out := make(chan fsnotify.Event)
+	go q.send(ctx, out)
+	goroutines := runtime.NumGoroutine()
+
+	var wg sync.WaitGroup
+	for w := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range changes {
+				op := fsnotify.Write
+				if c == changes-1 {
+					op = fsnotify.Create
+				}
+				for i := w; i < files; i += writers {
+					if err := q.push(ctx, fsnotify.Event{Name: fmt.Sprintf("f%05d.code.go", i), Op: op}); err != nil {
+						t.Error(err)
+						return
+					}
+				}
+			}
+		}()
+	}
+	pushed := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(pushed)
+	}()
+
+	latest := make(map[string]fsnotify.Op, files)
+	var sent, maxGoroutines int
+	for done := false; !done; {
+		select {
+		case event := <-out:
+			latest[event.Name] = event.Op
+			sent++
+			if n := q.len(); n > capacity {
+				t.Fatalf("expected at most %d pending files, got %d", capacity, n)
+			}
+			maxGoroutines = max(maxGoroutines, runtime.NumGoroutine())
+		case <-pushed:
+			pushed = nil
+		case <-time.After(time.Second):
+			if pushed != nil {
+				t.Fatal("timed out waiting for events")
+			}
+			done = true
+		}
+	}
+	if len(latest) != files {
+		t.Errorf("expected events for %d files, got %d", files, len(latest))
+	}
+	for name, op := range latest {
+		if op != fsnotify.Create {
+			t.Errorf("expected the latest event of %s to be sent last, got %v", name, op)
+			break
+		}
+	}
+	if sent > files*changes {
+		t.Errorf("expected at most %d events, got %d", files*changes, sent)
+	}
+	if extra := maxGoroutines - goroutines; extra > writers+2 {
+		t.Errorf("expected the goroutines to stay bounded, got %d more", extra)
+	}
+}
@@ -7,8 +7,6 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
-	"sync"
-	"time"
 
 	"github.com/fsnotify/fsnotify"
 	"github.com/garrettladley/snips"
@@ -20,6 +18,10 @@ func Recursive(
 	out chan fsnotify.Event,
 	errors chan error,
 ) (w *RecursiveWatcher, err error) {
+	root, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
 	fsnw, err := fsnotify.NewWatcher()
 	if err != nil {
 		return nil, err
@@ -29,17 +31,52 @@ func Recursive(
 		w:      fsnw,
 		Events: out,
 		Errors: errors,
-		timers: make(map[timerKey]*time.Timer),
+		queue:  newQueue(queueDelay, queueCapacity),
+		root:   root,
+		ignore: newIgnorer(os.DirFS(root)),
 	}
+	go w.queue.send(ctx, out)
 	go w.loop()
 	return w, w.Add(path)
 }
 
+// File watches a single file, sending its events to out. The file's directory
+// is watched rather than the file itself, so that the watch survives editors
+// that save by renaming a new file over the old one, e.g. vim.
+func File(
+	ctx context.Context,
+	fileName string,
+	out chan fsnotify.Event,
+	errors chan error,
+) (w *RecursiveWatcher, err error) {
+	fsnw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w = &RecursiveWatcher{
+		ctx:    ctx,
+		w:      fsnw,
+		file:   filepath.Clean(fileName),
+		Events: out,
+		Errors: errors,
+		queue:  newQueue(queueDelay, queueCapacity),
+	}
+	go w.queue.send(ctx, out)
+	go w.loop()
+	return w, fsnw.Add(filepath.Dir(w.file))
+}
+
 // WalkFiles walks the file tree rooted at path, sending a Create event for each
-// file it encounters.
+// file it encounters. Files excluded by .snipsignore files are skipped.
 func WalkFiles(ctx context.Context, path string, out chan fsnotify.Event) (err error) {
-	rootPath := path
-	fileSystem := os.DirFS(rootPath)
+	return WalkFS(ctx, os.DirFS(path), path, out)
+}
+
+// WalkFS walks fileSystem, sending a Create event for each file it encounters,
+// named as if fileSystem were the directory rootPath. Files excluded by
+// .snipsignore files are skipped.
+func WalkFS(ctx context.Context, fileSystem fs.FS, rootPath string, out chan fsnotify.Event) (err error) {
+	ignore := newIgnorer(fileSystem)
 	return fs.WalkDir(fileSystem, ".", func(path string, info os.DirEntry, err error) error {
 		if err != nil {
 			return nil
@@ -48,10 +85,10 @@ func WalkFiles(ctx context.Context, path string, out chan fsnotify.Event) (err e
 		if err != nil {
 			return nil
 		}
-		if info.IsDir() && shouldSkipDir(absPath) {
+		if info.IsDir() && (shouldSkipDir(absPath) || ignore.ignored(path, true)) {
 			return filepath.SkipDir
 		}
-		if !shouldIncludeFile(absPath) {
+		if !shouldIncludeFile(absPath) || ignore.ignored(path, false) {
 			return nil
 		}
 		out <- fsnotify.Event{
@@ -63,28 +100,26 @@ func WalkFiles(ctx context.Context, path string, out chan fsnotify.Event) (err e
 }
 
 type RecursiveWatcher struct {
-	ctx     context.Context
-	w       *fsnotify.Watcher
-	Events  chan fsnotify.Event
-	Errors  chan error
-	timerMu sync.Mutex
-	timers  map[timerKey]*time.Timer
-}
-
-func shouldIncludeFile(name string) bool {
-	return snips.ContainsDotCodeDot(name)
-}
+	ctx    context.Context
+	w      *fsnotify.Watcher
+	Events chan fsnotify.Event
+	Errors chan error
+	// queue coalesces the events of each file before they're sent to Events.
+	queue *queue
 
-type timerKey struct {
-	name string
-	op   fsnotify.Op
+	// file is the only file to send events for, when watching a single file.
+	file string
+	// root is the absolute path of the watched directory, whose .snipsignore
+	// files ignore, when watching recursively.
+	root   string
+	ignore *ignorer
 }
 
-func timerKeyFromEvent(event fsnotify.Event) timerKey {
-	return timerKey{
-		name: event.Name,
-		op:   event.Op,
-	}
+func shouldIncludeFile(name string) bool {
+	// Files generated from snippets also contain .code., e.g. x.code.go_templ.go,
+	// x.code.go_templ_test.go of -test-package, x.code.go_example_test.go of
+	// -examples, or the plain text of -format text, x.code.go_snips.txt.
+	return snips.ContainsDotCodeDot(name) && !strings.HasSuffix(name, "_templ.go") && !strings.HasSuffix(name, "_templ_test.go") && !strings.HasSuffix(name, "_example_test.go") && !strings.HasSuffix(name, "_snips.txt")
 }
 
 func (w *RecursiveWatcher) Close() error {
@@ -100,29 +135,31 @@ func (w *RecursiveWatcher) loop() {
 			if !ok {
 				return
 			}
-			if event.Has(fsnotify.Create) {
-				if err := w.Add(event.Name); err != nil {
-					w.Errors <- err
+			if w.file != "" {
+				if filepath.Clean(event.Name) != w.file {
+					continue
+				}
+			} else {
+				if filepath.Base(event.Name) == IgnoreFileName {
+					// Directories ignored since they were watched stay watched,
+					// but their events are ignored below.
+					if rel, ok := w.rel(filepath.Dir(event.Name)); ok {
+						w.ignore.forget(rel)
+					}
+				}
+				if event.Has(fsnotify.Create) {
+					if err := w.Add(event.Name); err != nil {
+						w.Errors <- err
+					}
+				}
+				// Only notify on .code.* related files.
+				if !shouldIncludeFile(event.Name) || w.ignored(event.Name, false) {
+					continue
 				}
 			}
-			// Only notify on .code.* related files.
-			if !shouldIncludeFile(event.Name) {
-				continue
-			}
-			tk := timerKeyFromEvent(event)
-			w.timerMu.Lock()
-			t, ok := w.timers[tk]
-			w.timerMu.Unlock()
-			if !ok {
-				t = time.AfterFunc(100*time.Millisecond, func() {
-					w.Events <- event
-				})
-				w.timerMu.Lock()
-				w.timers[tk] = t
-				w.timerMu.Unlock()
-				continue
+			if err := w.queue.push(w.ctx, event); err != nil {
+				return
 			}
-			t.Reset(100 * time.Millisecond)
 		case err, ok := <-w.w.Errors:
 			if !ok {
 				return
@@ -140,13 +177,42 @@ func (w *RecursiveWatcher) Add(dir string) error {
 		if !info.IsDir() {
 			return nil
 		}
-		if shouldSkipDir(dir) {
+		if shouldSkipDir(dir) || w.ignored(dir, true) {
 			return filepath.SkipDir
 		}
 		return w.w.Add(dir)
 	})
 }
 
+// rel returns the slash-separated path of name relative to the watched
+// directory, if it's inside it.
+func (w *RecursiveWatcher) rel(name string) (rel string, ok bool) {
+	if w.root == "" {
+		return "", false
+	}
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return "", false
+	}
+	if rel, err = filepath.Rel(w.root, abs); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// ignored reports whether a file or directory in the watched directory is
+// excluded by its .snipsignore files.
+func (w *RecursiveWatcher) ignored(name string, isDir bool) bool {
+	rel, ok := w.rel(name)
+	return ok && w.ignore.ignored(rel, isDir)
+}
+
+// SkipDir reports whether files in the directory are skipped when walking,
+// as the Go tool skips vendor, and directories starting with . or _.
+func SkipDir(dir string) bool {
+	return shouldSkipDir(dir)
+}
+
 func shouldSkipDir(dir string) bool {
 	if dir == "." {
 		return false
@@ -1,6 +1,14 @@
 package watcher
 
-import "testing"
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
 
 func TestShouldIncludeFile(t *testing.T) {
 	tests := []struct {
@@ -23,6 +31,11 @@ func TestShouldIncludeFile(t *testing.T) {
 			path: "foo.bar.code.rs",
 			want: true,
 		},
+		{
+			name: "generated false",
+			path: "snippet_0.code.go_templ.go",
+			want: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -33,3 +46,84 @@ func TestShouldIncludeFile(t *testing.T) {
 		})
 	}
 }
+
+func TestFile(t *testing.T) {
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "snippet.code.go")
+	if err := os.WriteFile(fileName, []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := make(chan fsnotify.Event, 16)
+	errs := make(chan error, 16)
+	w, err := File(ctx, fileName, events, errs)
+	if err != nil {
+		t.Fatalf("failed to watch file: %v", err)
+	}
+	defer w.Close()
+
+	// Changes to other snippets in the directory are ignored.
+	if err := os.WriteFile(filepath.Join(dir, "other.code.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Replacing the file by renaming, as vim does, is seen.
+	tmp := filepath.Join(dir, "snippet.code.go.tmp")
+	if err := os.WriteFile(tmp, []byte("package main\n\nfunc main() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, fileName); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case event := <-events:
+		if event.Name != fileName {
+			t.Errorf("expected an event for %q, got %v", fileName, event)
+		}
+	case err := <-errs:
+		t.Fatalf("unexpected error: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for an event")
+	}
+}
+
+func TestBatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	in := make(chan fsnotify.Event)
+	out := make(chan fsnotify.Event, 16)
+	go Batch(ctx, in, out, 50*time.Millisecond)
+
+	in <- fsnotify.Event{Name: "b.code.go", Op: fsnotify.Write}
+	in <- fsnotify.Event{Name: "a.code.go", Op: fsnotify.Create}
+	in <- fsnotify.Event{Name: "b.code.go", Op: fsnotify.Remove}
+	select {
+	case event := <-out:
+		t.Fatalf("expected events to be held until the window has passed, got %v", event)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	var got []fsnotify.Event
+	for range 2 {
+		select {
+		case event := <-out:
+			got = append(got, event)
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for the batch, got %v", got)
+		}
+	}
+	want := []fsnotify.Event{
+		{Name: "a.code.go", Op: fsnotify.Create},
+		{Name: "b.code.go", Op: fsnotify.Remove},
+	}
+	if got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	select {
+	case event := <-out:
+		t.Errorf("expected each file's latest event only, got %v", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
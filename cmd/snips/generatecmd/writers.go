@@ -0,0 +1,155 @@
+package generatecmd
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// WriterOpener opens the Writer for an -out URL, e.g. s3://bucket/prefix. The
+// Writer is given file names relative to the generated path, with forward
+// slashes, e.g. views/snippet.code.go_templ.go. Writers that are Removers
+// also remove stale generated files.
+type WriterOpener func(u *url.URL) (Writer, error)
+
+var (
+	writersMu sync.RWMutex
+	writers   = map[string]WriterOpener{
+		"file": openFileWriter,
+	}
+)
+
+// RegisterWriter makes a Writer available for -out URLs with the given scheme,
+// e.g. "s3" or "gs", so that generated files can be published straight to
+// object storage. Registering a scheme again replaces its opener.
+func RegisterWriter(scheme string, open WriterOpener) {
+	writersMu.Lock()
+	defer writersMu.Unlock()
+	writers[strings.ToLower(scheme)] = open
+}
+
+// OpenWriter opens the Writer registered for the scheme of out, which is
+// written to instead of root. URLs without a scheme are local directories.
+func OpenWriter(out, root string) (Writer, error) {
+	u := parseOut(out)
+	writersMu.RLock()
+	open, ok := writers[strings.ToLower(u.Scheme)]
+	writersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("no writer registered for %s:// output", u.Scheme)
+	}
+	w, err := open(u)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", out, err)
+	}
+	return relativeWriter{root: root, w: w}, nil
+}
+
+// parseOut parses an -out URL.
+func parseOut(out string) *url.URL {
+	u, err := url.Parse(out)
+	if err != nil || u.Scheme == "" || len(u.Scheme) == 1 {
+		// Plain paths, including Windows paths such as C:\out.
+		return &url.URL{Scheme: "file", Path: filepath.ToSlash(out)}
+	}
+	return u
+}
+
+// relativeWriter passes names relative to root to w.
+type relativeWriter struct {
+	root string
+	w    Writer
+}
+
+func (rw relativeWriter) WriteFile(name string, contents []byte) error {
+	rel, err := rw.rel(name)
+	if err != nil {
+		return err
+	}
+	return rw.w.WriteFile(rel, contents)
+}
+
+// WriteFiles passes the changes to w, as a transaction if it supports it.
+func (rw relativeWriter) WriteFiles(o outputs) (err error) {
+	rel := outputs{writes: make([]output, len(o.writes)), removes: make([]string, len(o.removes))}
+	for i, out := range o.writes {
+		if rel.writes[i].name, err = rw.rel(out.name); err != nil {
+			return err
+		}
+		rel.writes[i].contents = out.contents
+	}
+	for i, name := range o.removes {
+		if rel.removes[i], err = rw.rel(name); err != nil {
+			return err
+		}
+	}
+	return writeOutputs(rw.w, rel)
+}
+
+// Remove removes the file from w, if it's a Remover.
+func (rw relativeWriter) Remove(name string) error {
+	rel, err := rw.rel(name)
+	if err != nil {
+		return err
+	}
+	if r, ok := rw.w.(Remover); ok {
+		return r.Remove(rel)
+	}
+	return nil
+}
+
+// rel returns the slash-separated name of name relative to root.
+func (rw relativeWriter) rel(name string) (string, error) {
+	rel, err := filepath.Rel(rw.root, name)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("cannot write %q, it's outside of %q", name, rw.root)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// openFileWriter writes to the local directory of a file:// URL.
+func openFileWriter(u *url.URL) (Writer, error) {
+	dir := filepath.FromSlash(u.Host + u.Path)
+	if dir == "" {
+		return nil, fmt.Errorf("missing directory")
+	}
+	return dirWriter{dir: dir}, nil
+}
+
+// dirWriter writes generated files to a local directory, given their
+// slash-separated names relative to it, as localWriter does.
+type dirWriter struct {
+	dir string
+}
+
+func (dw dirWriter) WriteFile(name string, contents []byte) error {
+	return dw.WriteFiles(outputs{writes: []output{{name: name, contents: contents}}})
+}
+
+// WriteFiles creates the directories of the files to write, then makes the
+// changes as a transaction.
+func (dw dirWriter) WriteFiles(o outputs) error {
+	local := outputs{writes: make([]output, len(o.writes)), removes: make([]string, len(o.removes))}
+	for i, out := range o.writes {
+		local.writes[i] = output{name: dw.path(out.name), contents: out.contents}
+		if err := os.MkdirAll(filepath.Dir(local.writes[i].name), 0o755); err != nil {
+			return fmt.Errorf("failed to write target file %q: %w", out.name, err)
+		}
+	}
+	for i, name := range o.removes {
+		local.removes[i] = dw.path(name)
+	}
+	return localWriter{}.WriteFiles(local)
+}
+
+func (dw dirWriter) Remove(name string) error {
+	return os.Remove(dw.path(name))
+}
+
+// path returns the local path of the file name.
+func (dw dirWriter) path(name string) string {
+	return filepath.Join(dw.dir, filepath.FromSlash(name))
+}
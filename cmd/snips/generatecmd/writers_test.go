@@ -0,0 +1,128 @@
+package generatecmd
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestOpenWriter(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "src")
+	for _, out := range []string{
+		filepath.Join(t.TempDir(), "out"),
+		"file://" + filepath.ToSlash(filepath.Join(t.TempDir(), "out")),
+	} {
+		w, err := OpenWriter(out, root)
+		if err != nil {
+			t.Fatalf("OpenWriter(%q) unexpected error: %v", out, err)
+		}
+		if err = w.WriteFile(filepath.Join(root, "views", "a.code.go_templ.go"), []byte("package views\n")); err != nil {
+			t.Fatalf("failed to write: %v", err)
+		}
+		dir := filepath.FromSlash(parseOut(out).Path)
+		if _, err = os.Stat(filepath.Join(dir, "views", "a.code.go_templ.go")); err != nil {
+			t.Errorf("expected the file to be written to %q: %v", dir, err)
+		}
+		if err = w.WriteFile(filepath.Join(filepath.Dir(root), "other.go"), nil); err == nil {
+			t.Errorf("expected an error writing outside of the root")
+		}
+
+		// Stale files are removed from the output, not the source tree.
+		local := filepath.Join(root, "views", "a.code.go_templ.go")
+		if err = os.MkdirAll(filepath.Dir(local), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err = os.WriteFile(local, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err = writeOutputs(w, outputs{removes: []string{local}}); err != nil {
+			t.Fatalf("failed to remove: %v", err)
+		}
+		if _, err = os.Stat(filepath.Join(dir, "views", "a.code.go_templ.go")); !os.IsNotExist(err) {
+			t.Errorf("expected the file to be removed from %q, got %v", dir, err)
+		}
+		if _, err = os.Stat(local); err != nil {
+			t.Errorf("expected the file of the source tree to be kept: %v", err)
+		}
+		if err = os.RemoveAll(root); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestOpenWriterTransaction(t *testing.T) {
+	root := t.TempDir()
+	dir := t.TempDir()
+	w, err := OpenWriter(dir, root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	a := filepath.Join(root, "a.code.go_templ.go")
+	if err = w.WriteFile(a, []byte("package a\n")); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+	// A file where the directory of b should be fails to write b.
+	if err = os.WriteFile(filepath.Join(dir, "views"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	err = writeOutputs(w, outputs{writes: []output{
+		{name: a, contents: []byte("package b\n")},
+		{name: filepath.Join(root, "views", "b.code.go_templ.go"), contents: []byte("package views\n")},
+	}})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "a.code.go_templ.go")); string(got) != "package a\n" {
+		t.Errorf("expected a failed transaction to keep the files, got %q", got)
+	}
+}
+
+func TestOpenWriterScheme(t *testing.T) {
+	if _, err := OpenWriter("bucket://snippets/prefix", "/src"); err == nil {
+		t.Fatal("expected an error for an unregistered scheme")
+	}
+
+	written := make(map[string]string)
+	RegisterWriter("bucket", func(u *url.URL) (Writer, error) {
+		return FileWriterFunc(func(name string, contents []byte) error {
+			written[u.Host+u.Path+"/"+name] = string(contents)
+			return nil
+		}), nil
+	})
+	defer func() {
+		writersMu.Lock()
+		delete(writers, "bucket")
+		writersMu.Unlock()
+	}()
+
+	root := t.TempDir()
+	w, err := OpenWriter("bucket://snippets/prefix", root)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err = w.WriteFile(filepath.Join(root, "views", "a.code.go_templ.go"), []byte("package views\n")); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+	if diff := cmp.Diff(map[string]string{"snippets/prefix/views/a.code.go_templ.go": "package views\n"}, written); diff != "" {
+		t.Errorf("unexpected files written:\n%s", diff)
+	}
+
+	// Writers that can't remove files leave stale files in place, and the
+	// files of the source tree alone.
+	local := filepath.Join(root, "views", "a.code.go_templ.go")
+	if err = os.MkdirAll(filepath.Dir(local), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(local, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err = writeOutputs(w, outputs{removes: []string{local}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err = os.Stat(local); err != nil {
+		t.Errorf("expected the file of the source tree to be kept: %v", err)
+	}
+}
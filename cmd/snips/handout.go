@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+	"github.com/garrettladley/snips/cmd/snips/generatecmd"
+	"github.com/garrettladley/snips/runtime"
+	"gopkg.in/yaml.v3"
+)
+
+const handoutUsageText = `usage: snips handout -manifest <file> -o <file> [<args>...]
+
+Generates the snippets listed in a manifest into a single paged HTML document, with a table of
+contents and a page per snippet, for PDF renderers and printing, e.g. training handouts.
+Accepts the args of snips generate, and reads the config file, so that the snippets are
+highlighted as generate does. Nothing is written but the document.
+
+The manifest is a YAML file listing the components to include, in order:
+  title: Go fundamentals
+  snippets:
+    - component: HelloWorldGo
+      title: Hello, world
+    - component: UsersProtoUser
+      snippet: api/users.code.proto
+The title of a snippet defaults to its component name. The snippet path, relative to -path,
+is only needed when several snippets generate components of the same name.
+
+Args:
+  -manifest <file>
+    The manifest listing the snippets of the document.
+  -o <file>
+    Writes the document to file, or to stdout with -.
+  -help
+    Print help and exit.
+`
+
+// handoutManifest lists the snippets of a handout.
+type handoutManifest struct {
+	// Title of the document, "Snippets" if unset.
+	Title    string           `yaml:"title"`
+	Snippets []handoutSnippet `yaml:"snippets"`
+}
+
+// handoutSnippet is a page of a handout.
+type handoutSnippet struct {
+	// Component is the name of the component to include.
+	Component string `yaml:"component"`
+	// Title of the page, the component name if unset.
+	Title string `yaml:"title"`
+	// Snippet is the path of the snippet generating the component, relative
+	// to -path, for components whose name isn't unique.
+	Snippet string `yaml:"snippet"`
+}
+
+// loadHandoutManifest reads and checks the manifest.
+func loadHandoutManifest(fileName string) (m handoutManifest, err error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return m, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err = dec.Decode(&m); err != nil && err != io.EOF {
+		return m, fmt.Errorf("invalid manifest %s: %w", fileName, err)
+	}
+	if len(m.Snippets) == 0 {
+		return m, fmt.Errorf("invalid manifest %s: no snippets listed", fileName)
+	}
+	for i, s := range m.Snippets {
+		if s.Component == "" {
+			return m, fmt.Errorf("invalid manifest %s: snippet %d has no component", fileName, i+1)
+		}
+	}
+	return m, nil
+}
+
+// components returns the names of the components of the manifest.
+func (m handoutManifest) components() (names []string) {
+	for _, s := range m.Snippets {
+		names = append(names, s.Component)
+	}
+	return names
+}
+
+// handoutHTML collects the highlighted HTML of components, by name, then
+// snippet path relative to root.
+type handoutHTML struct {
+	root string
+
+	mu   sync.Mutex
+	html map[string]map[string]string
+}
+
+func (h *handoutHTML) add(fileName, componentName, html string) {
+	snippet, err := filepath.Rel(h.root, fileName)
+	if err != nil {
+		// Snippets are always found within the root.
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.html[componentName] == nil {
+		h.html[componentName] = make(map[string]string)
+	}
+	h.html[componentName][filepath.ToSlash(snippet)] = html
+}
+
+// get returns the HTML of the manifest's snippet.
+func (h *handoutHTML) get(s handoutSnippet) (string, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	bySnippet := h.html[s.Component]
+	if s.Snippet != "" {
+		html, ok := bySnippet[filepath.ToSlash(filepath.Clean(s.Snippet))]
+		if !ok {
+			return "", fmt.Errorf("snippet %q doesn't generate component %q", s.Snippet, s.Component)
+		}
+		return html, nil
+	}
+	if len(bySnippet) > 1 {
+		return "", fmt.Errorf("component %q is generated by %d snippets, set the snippet of its manifest entry", s.Component, len(bySnippet))
+	}
+	for _, html := range bySnippet {
+		return html, nil
+	}
+	return "", fmt.Errorf("no snippet generates component %q", s.Component)
+}
+
+// handoutCSS breaks the document into a page per snippet, wrapping long lines
+// rather than clipping them.
+const handoutCSS = `@page { margin: 2cm; }
+.snips-handout-page { break-before: page; page-break-before: always; }
+.snips-handout-page h2 { break-after: avoid; page-break-after: avoid; }
+.snips-handout-page pre { white-space: pre-wrap; overflow-wrap: anywhere; }
+@media print { .snips-handout-toc a { color: inherit; text-decoration: none; } }
+`
+
+// renderHandout returns the paged HTML document of the manifest's snippets.
+func renderHandout(m handoutManifest, h *handoutHTML, css string) (string, error) {
+	title := m.Title
+	if title == "" {
+		title = "Snippets"
+	}
+	var sb strings.Builder
+	sb.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	sb.WriteString("<title>" + html.EscapeString(title) + "</title>\n")
+	sb.WriteString("<style>\n" + css + handoutCSS + "</style>\n</head>\n<body>\n")
+	sb.WriteString("<h1>" + html.EscapeString(title) + "</h1>\n")
+	sb.WriteString("<nav class=\"snips-handout-toc\">\n<h2>Contents</h2>\n<ol>\n")
+	for i, s := range m.Snippets {
+		fmt.Fprintf(&sb, "<li><a href=\"#snippet-%d\">%s</a></li>\n", i+1, html.EscapeString(cmp.Or(s.Title, s.Component)))
+	}
+	sb.WriteString("</ol>\n</nav>\n")
+	for i, s := range m.Snippets {
+		code, err := h.get(s)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&sb, "<section class=\"snips-handout-page\" id=\"snippet-%d\">\n<h2>%s</h2>\n%s\n</section>\n", i+1, html.EscapeString(cmp.Or(s.Title, s.Component)), code)
+	}
+	sb.WriteString("</body>\n</html>\n")
+	return sb.String(), nil
+}
+
+func handoutCmd(stdout, stderr io.Writer, args []string) (code int) {
+	f := &generateFlags{}
+	f.flagSet = newGenerateFlagSet(f, flag.ContinueOnError)
+	f.flagSet.SetOutput(io.Discard)
+	manifest := f.flagSet.String("manifest", "", "")
+	out := f.flagSet.String("o", "", "")
+	if err := f.flagSet.Parse(args); err != nil || f.flagSet.NArg() > 0 {
+		fmt.Fprint(stderr, handoutUsageText)
+		return 64 // EX_USAGE
+	}
+	if f.help {
+		fmt.Fprint(stdout, handoutUsageText)
+		return 0
+	}
+	fail := func(err error) int {
+		color.New(color.FgRed).Fprint(stderr, "(✗) ")
+		fmt.Fprintln(stderr, "Command failed: "+err.Error())
+		return 1
+	}
+	if *manifest == "" || *out == "" {
+		fmt.Fprint(stderr, handoutUsageText)
+		return 64 // EX_USAGE
+	}
+	if len(f.args.Only) > 0 || f.args.FileName != "" || f.files != "" {
+		return fail(fmt.Errorf("cannot use -only, -f or -files with snips handout, the manifest selects the snippets"))
+	}
+	m, err := loadHandoutManifest(*manifest)
+	if err != nil {
+		return fail(err)
+	}
+	if err = f.applyConfigFile(); err != nil {
+		return fail(err)
+	}
+	root, err := filepath.Abs(f.args.Path)
+	if err != nil {
+		return fail(fmt.Errorf("failed to get absolute path: %w", err))
+	}
+	f.args.Path = root
+	f.args.Only = m.components()
+	highlighted := &handoutHTML{root: root, html: make(map[string]map[string]string)}
+	f.args.HTMLReport = highlighted.add
+	discard := generatecmd.FileWriterFunc(func(string, []byte) error { return nil })
+	if err = generateInto(f, "handout", discard, stderr); err != nil {
+		return fail(err)
+	}
+
+	css := runtime.CSS() + "\n"
+	if f.args.Themes != "" {
+		light, dark, err := generatecmd.NewGenerate(nil, f.args).Themes()
+		if err != nil {
+			return fail(err)
+		}
+		themes, err := runtime.ThemeCSS(light, dark)
+		if err != nil {
+			return fail(fmt.Errorf("invalid themes: %w", err))
+		}
+		css += themes + "\n"
+	}
+
+	document, err := renderHandout(m, highlighted, css)
+	if err != nil {
+		return fail(err)
+	}
+	if *out == "-" {
+		_, err = io.WriteString(stdout, document)
+	} else {
+		err = os.WriteFile(*out, []byte(document), 0o644)
+	}
+	if err != nil {
+		return fail(fmt.Errorf("failed to write handout: %w", err))
+	}
+	return 0
+}
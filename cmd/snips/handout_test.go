@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHandoutCmd(t *testing.T) {
+	dir := writeSnippets(t, map[string]string{
+		"main.code.go":          "package main\n",
+		"views/query.code.sql":  "SELECT 1;\n",
+		"views/unused.code.sql": "SELECT 2;\n",
+		"other/main.code.go":    "package other\n",
+	})
+	manifest := filepath.Join(t.TempDir(), "handout.yaml")
+	writeManifest := func(contents string) {
+		t.Helper()
+		if err := os.WriteFile(manifest, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	handout := func() (stdout, stderr string, code int) {
+		t.Helper()
+		var out, errOut bytes.Buffer
+		code = run(&out, &errOut, []string{"snips", "handout", "-path", dir, "-manifest", manifest, "-o", "-"})
+		return out.String(), errOut.String(), code
+	}
+
+	writeManifest("title: Queries & programs\nsnippets:\n  - component: QuerySql\n    title: A query\n  - component: MainGo\n    snippet: main.code.go\n")
+	document, stderr, code := handout()
+	if code != 0 {
+		t.Fatalf("handout failed with code %d: %s", code, stderr)
+	}
+	for _, expected := range []string{
+		"<title>Queries &amp; programs</title>",
+		`<li><a href="#snippet-1">A query</a></li>`,
+		`<li><a href="#snippet-2">MainGo</a></li>`,
+		`<section class="snips-handout-page" id="snippet-1">`,
+		"break-before: page",
+		"SELECT",
+	} {
+		if !strings.Contains(document, expected) {
+			t.Errorf("expected the document to contain %q, got:\n%s", expected, document)
+		}
+	}
+	if strings.Index(document, "SELECT") > strings.Index(document, `id="snippet-2"`) {
+		t.Error("expected the snippets in the order of the manifest")
+	}
+	if n := strings.Count(document, `class="snips-handout-page"`); n != 2 {
+		t.Errorf("expected only the snippets of the manifest, got %d pages", n)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "main.code.go_templ.go")); !os.IsNotExist(err) {
+		t.Errorf("expected no generated files to be written, got %v", err)
+	}
+
+	writeManifest("snippets:\n  - component: MainGo\n")
+	if _, stderr, code = handout(); code != 1 || !strings.Contains(stderr, "generated by 2 snippets") {
+		t.Errorf("expected an ambiguous component to fail, got code %d: %s", code, stderr)
+	}
+	writeManifest("snippets:\n  - component: MissingGo\n")
+	if _, stderr, code = handout(); code != 1 || !strings.Contains(stderr, `no snippet generates component "MissingGo"`) {
+		t.Errorf("expected a missing component to fail, got code %d: %s", code, stderr)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+const hookUsageText = `usage: snips hook <command> [<args>...]
+
+Checks that the code generated from staged snippets is up to date before each commit, by
+running snips generate -check -staged.
+
+commands:
+  install        Installs the git pre-commit hook of the repository in the working directory
+  print-config   Prints the hook config for the pre-commit framework, https://pre-commit.com
+
+Args:
+  -force
+    Replace an existing pre-commit hook. (default false)
+`
+
+// hookCommand is run by the pre-commit hook.
+const hookCommand = "snips generate -check -staged"
+
+// hookScript is the git pre-commit hook.
+const hookScript = `#!/bin/sh
+# Installed by snips hook install.
+# Checks that the code generated from staged snippets is up to date.
+exec ` + hookCommand + `
+`
+
+// preCommitConfig is the hook config for the pre-commit framework.
+const preCommitConfig = `repos:
+  - repo: local
+    hooks:
+      - id: snips
+        name: snips
+        entry: ` + hookCommand + `
+        language: system
+        files: '\.code\.'
+        pass_filenames: false
+`
+
+func hookCmd(stdout, stderr io.Writer, args []string) (code int) {
+	if len(args) < 1 {
+		fmt.Fprint(stderr, hookUsageText)
+		return 64 // EX_USAGE
+	}
+	switch args[0] {
+	case "install":
+	case "print-config":
+		fmt.Fprint(stdout, preCommitConfig)
+		return 0
+	case "help", "-help", "--help", "-h":
+		fmt.Fprint(stdout, hookUsageText)
+		return 0
+	default:
+		fmt.Fprint(stderr, hookUsageText)
+		return 64 // EX_USAGE
+	}
+
+	cmd := flag.NewFlagSet("hook install", flag.ContinueOnError)
+	cmd.SetOutput(io.Discard)
+	force := cmd.Bool("force", false, "")
+	if err := cmd.Parse(args[1:]); err != nil {
+		fmt.Fprint(stderr, hookUsageText)
+		return 64 // EX_USAGE
+	}
+
+	fileName, err := installHook(".", *force)
+	if err != nil {
+		color.New(color.FgRed).Fprint(stderr, "(✗) ")
+		fmt.Fprintln(stderr, "Command failed: "+err.Error())
+		return 1
+	}
+	color.New(color.FgGreen).Fprint(stdout, "(✓) ")
+	fmt.Fprintln(stdout, "Installed "+fileName)
+	return 0
+}
+
+// installHook writes the pre-commit hook of the git repository containing dir,
+// returning its file name. An existing hook is only replaced if force is set.
+func installHook(dir string, force bool) (fileName string, err error) {
+	var stdout, stderr bytes.Buffer
+	git := exec.Command("git", "-C", dir, "rev-parse", "--git-path", "hooks")
+	git.Stdout, git.Stderr = &stdout, &stderr
+	if err = git.Run(); err != nil {
+		return "", fmt.Errorf("failed to find git hooks: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	hooksDir := strings.TrimSpace(stdout.String())
+	if !filepath.IsAbs(hooksDir) {
+		hooksDir = filepath.Join(dir, hooksDir)
+	}
+	fileName = filepath.Join(hooksDir, "pre-commit")
+
+	if _, err = os.Stat(fileName); err == nil && !force {
+		return "", fmt.Errorf("%s already exists, add -force to replace it, or run %q from it", fileName, hookCommand)
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	if err = os.MkdirAll(hooksDir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create hooks directory: %w", err)
+	}
+	if err = os.WriteFile(fileName, []byte(hookScript), 0o755); err != nil {
+		return "", fmt.Errorf("failed to write hook: %w", err)
+	}
+	return fileName, nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestInstallHook(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not found")
+	}
+	dir := t.TempDir()
+	if out, err := exec.Command("git", "-C", dir, "init", "-q").CombinedOutput(); err != nil {
+		t.Fatalf("git init failed: %v: %s", err, out)
+	}
+
+	fileName, err := installHook(dir, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := filepath.Join(dir, ".git", "hooks", "pre-commit"); fileName != want {
+		t.Errorf("expected the hook to be installed to %q, got %q", want, fileName)
+	}
+	contents, err := os.ReadFile(fileName)
+	if err != nil || string(contents) != hookScript {
+		t.Errorf("expected the hook script, got %q, %v", contents, err)
+	}
+
+	if _, err = installHook(dir, false); err == nil {
+		t.Error("expected an existing hook not to be replaced")
+	}
+	if _, err = installHook(dir, true); err != nil {
+		t.Errorf("expected -force to replace the hook, got %v", err)
+	}
+}
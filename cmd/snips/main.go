@@ -6,9 +6,10 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"net/url"
 	"os"
 	"os/signal"
-	"runtime"
+	"syscall"
 
 	"github.com/fatih/color"
 	"github.com/garrettladley/snips"
@@ -29,8 +30,19 @@ snips - generate syntax highlighted templ components from code snippets
 
 
 commands:
-  generate   Generates syntax highlighted templ files from source code
-  version    Prints the version
+  generate       Generates syntax highlighted templ files from source code
+  config         Validates and prints the config file
+  hook           Installs a git pre-commit hook that checks generated files are up to date
+  fmt            Formats snippet front matter, whitespace and directives
+  annotate       Adds go:generate directives to packages with snippets, for go generate ./...
+  migrate        Moves the code blocks hand-written in .templ files into snippets
+  export         Archives the generated code, HTML and CSS of snippets with a manifest
+  handout        Generates a paged HTML document of the snippets listed in a manifest, for printing
+  drift          Lists the generated files that would change, grouped by cause
+  stats          Reports the languages, lines and token types of snippets, and the style entries used
+  verify-build   Builds the packages with generated files, attributing errors to snippets
+  tui            Walks through the snippets that failed to generate, to edit, retry or ignore them
+  version        Prints the version
 `
 
 func run(stdout, stderr io.Writer, args []string) (code int) {
@@ -41,6 +53,28 @@ func run(stdout, stderr io.Writer, args []string) (code int) {
 	switch args[1] {
 	case "generate":
 		return generateCmd(stdout, stderr, args[2:])
+	case "config":
+		return configCmd(stdout, stderr, args[2:])
+	case "hook":
+		return hookCmd(stdout, stderr, args[2:])
+	case "fmt":
+		return fmtCmd(stdout, stderr, args[2:])
+	case "annotate":
+		return annotateCmd(stdout, stderr, args[2:])
+	case "migrate":
+		return migrateCmd(stdout, stderr, args[2:])
+	case "export":
+		return exportCmd(stdout, stderr, args[2:])
+	case "handout":
+		return handoutCmd(stdout, stderr, args[2:])
+	case "drift":
+		return driftCmd(stdout, stderr, args[2:])
+	case "stats":
+		return statsCmd(stdout, stderr, args[2:])
+	case "verify-build":
+		return verifyBuildCmd(stdout, stderr, args[2:])
+	case "tui":
+		return tuiCmd(os.Stdin, stdout, stderr, args[2:])
 	case "version", "--version":
 		fmt.Fprintln(stdout, snips.Version())
 		return 0
@@ -54,34 +88,359 @@ func run(stdout, stderr io.Writer, args []string) (code int) {
 
 const generateUsageText = `usage: snips generate [<args>...]
 
-Generates syntax highlighted templ components from code snippets.
+Generates syntax highlighted templ components from code snippets. Snippets whose first line
+is a snips:ignore comment, or whose front matter sets ignore: true, are skipped, e.g. while
+they're a work in progress, and their previously generated files removed. Walking and
+watching path skips the files excluded by .snipsignore files, in gitignore syntax, e.g. to
+exclude fixtures or vendored snippets.
 
 Args:
   -path <path>
   	Generates code for all files in path. (default .)
   -f <file>
     Optionally generates code for a single file, e.g. -f snippet.code.go
+  -files <file>
+    Generates code for exactly the snippets listed in the file, one per line, without walking
+    the path. Use - to read the list from stdin, e.g. find . -name '*.code.*' | snips generate -files -
+  -dir <dir>
+    Generates code for only the snippets directly in dir, without walking its subdirectories,
+    e.g. -dir . in the go:generate directives written by snips annotate.
+  -only <components>
+    Generates code for only the snippets of the comma separated components, e.g.
+    -only HelloWorldGo,AuthExampleGo. The components of regions and split definitions select
+    their snippet. Handy when iterating on a few snippets of a large tree without -watch.
+  -include <glob>
+    Generates code for only the snippets whose path relative to -path matches the glob, in which
+    ** matches any number of directories, e.g. -include "docs/**/*.code.go". Repeat it to
+    include the snippets matching any of the globs. Applies to walking and watching the path,
+    and to -dir, -only, -since and -staged. Can't be used with -f or -files.
+  -exclude <glob>
+    Skips the snippets whose path relative to -path matches the glob, even if included, e.g.
+    -exclude "**/legacy/**". Can be repeated.
+  -since <ref>
+    Generates code for only the snippets affected by the changes since the git ref branched off,
+    e.g. -since origin/main, including uncommitted and untracked files. Snippets are affected by
+    changes to themselves and to .templ files in their directory. Every snippet is generated if
+    the -symbols file changed.
+  -staged
+    Like -since, but for the changes staged for commit, e.g. in a pre-commit hook, see snips hook.
+  -check
+    Checks that the generated files are up to date instead of writing them, failing with the
+    list of files that are missing or out of date.
+  -force-overwrite
+    Overwrite generated files that were edited by hand since they were generated, discarding the
+    edits. Without it, snips refuses to, failing the snippets whose generated files don't match
+    the hash recorded in their header. (default false)
+  -history
+    Append a record of each run to .snips/history.jsonl in the path: the snips and chroma
+    versions, a fingerprint of the options, the duration, the generated files that changed and
+    the errors, to find out when and why generated files changed in long-lived docs repos. Watch
+    mode records each batch of changes. (default false)
+  -check-links
+    Check that the source_url front matter of each snippet still resolves, and that the lines
+    of GitHub permalinks pinned to a commit, e.g. .../blob/<sha>/main.go#L10-L20, still match
+    the snippet, ignoring indentation and trailing whitespace, failing with the links that
+    don't, to catch drift between docs and upstream code. Can't be used with -watch.
+    (default false)
+  -hermetic
+    Only read declared inputs: the snippets given by -f or -files, and files named by flags.
+    Skips the templ version check and SNIPS_ environment variables, and refuses -watch,
+    -notify, -dedupe, -max-files-per-package, -cache-dir, -feed, -history, -check-links,
+    -plugin, -otlp-endpoint and -http. For sandboxed build systems. (default false)
+  -offline
+    Guarantee that no network access is attempted, failing instead of running features that
+    would need it: -otlp-endpoint, -check-links, -out writers other than file://, and -http on
+    addresses other than loopback ones, e.g. localhost:7331. For air-gapped build environments.
+    (default false)
+  -out <dir or url>
+    Write generated files to the given directory, or URL such as file:///srv/snippets, keeping
+    their paths relative to -path, instead of beside their snippets. Other schemes, such as s3://
+    or gs://, are available when registered with generatecmd.RegisterWriter.
   -stdout
     Prints to stdout instead of writing generated files to the filesystem.
     Only applicable when -f is used.
+  -fast
+    Generate the file given by -f as fast as possible, for editors that generate on save. Skips
+    the templ version check, formatting the generated file if it's unchanged, and logging, so
+    only errors are printed. Can't be used with -watch, -dedupe, -max-files-per-package or
+    -otlp-endpoint. (default false)
+  -format <format>
+    Format of the output of -stdout. With patch, prints a unified diff of the changes to the
+    generated file instead of its contents, and nothing if it's up to date, so that editors can
+    apply them as a workspace edit. With text, writes the line-numbered plain text of each
+    snippet, with control characters escaped, to <snippet>_snips.txt instead of generating code,
+    for consumers that don't need HTML, such as man pages. (default the generated file, options:
+    "patch", "text")
   -watch
     Set to true to watch the path for changes and regenerate code.
+    With -f, only the given file is watched. Send the process SIGHUP to regenerate every snippet
+    with caches invalidated, e.g. after a git pull or a theme change, instead of restarting it.
+  -watch-batch <duration>
+    Coalesce the changes made within the given window into a single generation pass, e.g.
+    -watch-batch 2s, lowering CPU and battery use when many files change at once, such as
+    when switching git branches. (default 0, each change is processed as it's made)
+  -file-timeout <duration>
+    Fail files that take longer than the given duration to generate, reporting the phase they
+    were in, so that a pathological snippet, e.g. minified input that a lexer backtracks on,
+    can't hang the run. 0 disables it. (default 30s)
+  -max-memory <size>
+    Generate fewer snippets at once when the memory they're estimated to use, from their sizes,
+    would exceed the given size, e.g. -max-memory 512MB, so that runs in memory constrained
+    containers aren't killed. (default GOMEMLIMIT, if set)
+  -max-open-files <n>
+    Generate fewer snippets at once when they would hold more than n files open, whatever the
+    number of workers, so that big runs don't fail with "too many open files", e.g. with the
+    default ulimit of macOS. (default the soft limit of open files of the process, less 64)
+  -cache-dir <dir>
+    Cache the tokens of snippets in the given directory, e.g. .snips/cache, keyed on their
+    contents and lexers, so that runs that only change presentation options, such as -style or
+    -tab-width, skip tokenizing and only run the formatter. Watch mode always caches them in
+    memory, for rebuilds. Generated files formatted by gofmt are cached too, keyed on the
+    unformatted code, so that unchanged files skip formatting.
+  -max-errors <n>
+    Tolerate up to n files failing to generate, reporting them, before failing the run, so that
+    large snippet trees can be adopted incrementally while CI still fails on new errors beyond the
+    current number. Errors of the run itself, such as broken links with -check-links, always
+    fail it. (default 0)
+  -baseline <file>
+    Tolerate the errors of the snippets recorded as failing in the given JSON file, e.g.
+    snips-baseline.json, failing the run when other snippets fail, or when snippets in the
+    baseline no longer do, so that it's cleaned up as they're fixed.
+  -update-baseline
+    Record the snippets that fail in the -baseline file, instead of checking them. Can't be used
+    with -f, -files, -since, -staged or -watch. (default false)
+  -wait
+    Wait for other snips processes writing to the same path to finish, instead of failing. Writes
+    are locked with .snips/lock in the path, or -out directory, so that concurrent processes, e.g.
+    an editor plugin and a terminal watch, don't clobber each other's outputs. Watch mode only
+    locks while writing, and always waits. (default false)
+  -no-lock
+    Write generated files without locking the path. (default false)
+  -http <addr>
+    Serve the status of the run on the given address, e.g. localhost:7331: /statusz reports the
+    last generation time, pending events and current errors as JSON, and /healthz fails once
+    events have been pending without progress, so that dev environment orchestrators can restart
+    a wedged watcher. Go profiles are served at /debug/pprof/. Can't be used with -hermetic.
+  -otlp-endpoint <url>
+    Export a trace of the run to the OTLP/HTTP collector at the given URL, e.g.
+    http://localhost:4318, with a span for each file and its read, parse, tokenize, format,
+    gofmt and write phases. Headers, such as API keys, are read from the
+    OTEL_EXPORTER_OTLP_HEADERS environment variable. Can't be used with -hermetic.
+  -notify
+    Send a desktop notification when generation fails or recovers in watch mode. (default false)
   -style
-  	Style to use for formatting or path to an XML file to load.
+  	Style to use for formatting, path to an XML file to load, or a -style-alias.
+    Snippets may override this with the style front matter key. (default swapoff)
+  -lang <language>
+    Highlight snippets with the chroma lexer of the given name or alias, e.g. -lang go, instead
+    of detecting it from a modeline, shebang, their file name, e.g. main.go for main.code.go, or
+    their contents. Snippets may override this with the language front matter key.
+  -style-alias <alias=style>
+    Define an alias of a chroma style name or XML style file path, that -style, -themes and
+    the style front matter key may refer to, can be repeated, e.g. brand-dark=./brand.xml.
+  -plugin <stage=command>
+    Run an external process over each snippet at a stage, can be repeated for each stage, e.g.
+    -plugin pre-highlight=./sanitize.sh. The command is run in the path, given a JSON request
+    on stdin, {"version": 1, "stage": ..., "file": ..., "component": ..., "language": ...,
+    "contents": ...}, and responds on stdout with {"contents": ...} to replace the contents, or
+    {"error": ...} to fail the snippet. Stages:
+      pre-highlight   the redacted code of the snippet, without its front matter, before
+                      highlighting
+      post-html       the highlighted HTML of each component, before it's wrapped
+    Can't be used with -hermetic.
   -tab-width
   	Set the HTML tab width. (default 8)
   -line-numbers
   	Include line numbers in output.
+    Snippets may override this with the line_numbers front matter key.
   -line-numbers-table
   	Split line numbers and code in a HTML table.
+    Snippets may override this with the line_numbers_table front matter key.
+  -table-variants
+    Generate each component twice, with line numbers inline, e.g. MainGo(), and in a HTML table,
+    e.g. MainGoTable(), so that sites can use the table layout on wide pages and the inline
+    layout in narrow cards. Both have line numbers, whatever -line-numbers and
+    -line-numbers-table are set to. (default false)
+  -position-comments
+    Write the HTML of each line of a snippet as its own string literal in the generated code,
+    preceded by a /* line N */ comment with its line in the snippet file, to trace a rendering
+    glitch, e.g. an escaping issue, back to the snippet line and token it came from. For
+    debugging, since it makes generated files larger. Can't be used with -format text or
+    -dedupe. (default false)
+  -line-numbers-width <n>
+    Right align line numbers in a column at least n characters wide.
+    Snippets may override this with the line_numbers_width front matter key.
+  -line-numbers-class <class>
+    Add a class to line number elements.
+    Snippets may override this with the line_numbers_class front matter key.
+  -focus <lines>
+    Dim all but the given lines until the snippet is hovered or clicked, e.g. -focus 3-5,8
+    Snippets may override this with the focus front matter key.
+  -word-diff
+    Highlight the changed words within changed lines of diff snippets. (default false)
+    Snippets may enable this with the word_diff front matter key.
   -base-line
   	Base line number. (default 1)
+    Snippets may override this with the base_line front matter key.
   -linkable-lines
   	Make the line numbers linkable and be a link to themselves.
+    Snippets with slug front matter prefix their anchors with it, e.g. #http-mux-L3.
+    Snippets may override this with the linkable_lines front matter key.
+  -split
+    Also generate a component for each message, enum and service of .proto snippets,
+    and for each operation of OpenAPI snippets, e.g. UsersProtoUser. (default false)
+    Snippets may enable this with the split front matter key.
+    Regions marked with "snips:region <Name>" and "snips:endregion" comments are always
+    generated as their own components.
+  -dedupe <dir>
+    Write the highlighted HTML of all snippets to a shared package in dir, deduplicated by
+    content, and reference it from the generated components. Reduces binary size when the
+    same snippet is used in many places.
+  -max-files-per-package <n>
+    Generate the snippets of directories with more than n snippets into sub-packages
+    (gen01, gen02, ...) of at most n snippets each, and re-export their components from a
+    generated facade in the original package. (default 0, disabled)
+  -aggregate-dirs
+    Generate the snippets of each directory into a single snips_generated_templ.go, with a
+    function per snippet, instead of a file per snippet, to reduce the file count and compile
+    overhead of folders with many small snippets. Can't be used with -f, -files, -since,
+    -staged, -include, -exclude or -max-files-per-package. (default false)
+  -test-package
+    Generate each snippet into <snippet>_templ_test.go, in the external test package of its
+    directory, e.g. views_test, so that its components are only compiled by go test, for
+    snippets only used by example tests. Can't be used with -max-files-per-package or
+    -aggregate-dirs. Snippets may enable this with the test_package front matter key. (default false)
+  -examples
+    Also generate <snippet>_example_test.go, with an example function for each component, e.g.
+    ExampleMainGo, whose output comment is the plain text of the snippet, so that go test checks
+    that components render their snippets, and godoc shows them as examples. The module must
+    require github.com/garrettladley/snips. (default false)
+  -stream-threshold <size>
+    Render components whose highlighted HTML is larger than the given size from an embedded
+    gzip blob, decompressed as they render, e.g. -stream-threshold 64KB. Trades a little CPU
+    for smaller binaries and faster compiles.
+  -size-report
+    Log the size of the highlighted HTML of each component and package once generation completes. (default false)
+  -detect-report
+    Print a table of the language detected for each component, its confidence, and how it was
+    detected, once generation completes, to find snippets to pin with the language front matter
+    key. Printed to stderr with -stdout. (default false)
+  -formatter-report
+    Print a table of the line numbers, table, base line and linkable line settings of each snippet,
+    and the front matter keys that override those of the flags, once generation completes.
+    Printed to stderr with -stdout. (default false)
+  -feed <file>
+    Maintain a JSON feed of the 50 snippets changed most recently in the given file, e.g.
+    snippets.json, with their component, language, change time and the number of lines added
+    and removed, that docs sites can show as recently updated examples. The code of snippets is
+    kept in .snips/feed in the path to find changes across runs, and the first run only records it.
+  -size-budget <size>
+    Warn when the highlighted HTML of a package exceeds the given size, e.g. -size-budget 512KB
+  -bidi-safe
+    Render snippets left-to-right, isolate right-to-left text such as Arabic or Hebrew comments,
+    and show bidi control characters, e.g. RLO, instead of letting them reorder the code.
+    (default false)
+  -scan-unicode <warn|fail>
+    Warn about, or fail on, zero-width and bidi control characters in snippets, which can hide
+    or reorder code in Trojan Source style attacks. (default disabled)
+  -invalid-utf8 <replace|fail|raw>
+    What to do with the bytes of snippets that aren't valid UTF-8, which Go string literals can't
+    hold: replace them with U+FFFD, reporting where, fail naming the line and column, or keep
+    them raw in the highlighted HTML, escaped in the generated code. (default replace)
+  -fail-on-secrets
+    Fail to generate snippets that look like they contain credentials, such as AWS keys,
+    private keys or bearer tokens. (default false)
+    Snippets may redact values with a snips:redact comment on the line before, or with
+    regular expressions in front matter, e.g.
+      ---
+      redact: ['token=(\w+)']
+      ---
+  -language-badge
+    Show the language of each snippet, e.g. "Go" or "Protobuf", in a badge in the top right
+    corner of the snippet. (default false)
+  -analytics-attrs
+    Add data-snips-name and data-snips-lang attributes to the wrapper of each snippet, naming its
+    component and language, e.g. data-snips-lang="go", so that product analytics can track which
+    snippets readers view or copy. (default false)
+  -content-hash
+    Add a data-snips-hash attribute to the wrapper of each snippet, the hash of its HTML, so that
+    HTTP caches and edge renderers can use it as a cache key or ETag for snippet partials.
+    (default false)
+  -attribution-footer
+    Show the source and license of third-party snippets in a footer below the code, linking to
+    the source. They're always written as comments in the generated file. (default false)
+    Snippets set them in front matter, e.g.
+      ---
+      license: MIT
+      source_url: https://github.com/example/repo/blob/main/main.go
+      ---
+  -message <id=text>
+    Localize UI text rendered into the generated components, can be repeated, e.g.
+    -message source=Quelle: for the attribution footer. IDs: source, license. The copy, copied,
+    copy-label, theme and theme-label messages of the runtime package's components are set
+    when rendering, with runtime.WithMessages. (default English)
+  -runtime
+    Render the styles of snippet wrappers, such as language badges, once per page with the
+    github.com/garrettladley/snips/runtime package, instead of inlining them into every
+    component. The module must require github.com/garrettladley/snips. The package also has
+    CopyButton, Caption, Tabs and LineHighlighter components for wrappers. (default false)
+  -chroma-version <version>
+    Fail if snips was built with another version of chroma, e.g. v2.14.0, since chroma updates
+    change highlighted output. Review the changes to generated files with snips drift before
+    updating the pin.
+  -compat <version>
+    Lay generated files out as the given snips version did, e.g. -compat v0.1, so that an
+    upgrade of a large generated tree doesn't also reorder its imports and components, or add
+    their doc comments. Drop the flag in a separate change to move to the current layout.
+  -themes <light>,<dark>
+    Highlight with CSS classes instead of inline styles, with the CSS of both chroma styles, e.g.
+    -themes github,monokai, rendered once per page by the runtime package. The dark style is used
+    when the system prefers dark colors, and readers can switch between them with
+    runtime.ThemeSwitcher(). The module must require github.com/garrettladley/snips. Line
+    number widths aren't supported with classes.
+  -wrapper-class <class>
+    Wraps the highlighted code in a div with the given class.
+    Snippets may set their own class and id in front matter, e.g.
+      ---
+      class: example
+      id: example-handler
+      ---
+  -var <NAME=value>
+    Replace {{NAME}} placeholders in snippets with value before highlighting, can be repeated.
+    Environment variables prefixed with SNIPS_ are also available, e.g. {{SNIPS_VERSION}}.
+  -header <text>
+    Add comments above the code generated comment of generated files, e.g. a license header.
+    Lines that aren't already comments are commented out. Set it in the config file for
+    multi-line headers.
+  -generated-comment <text>
+    Replace the "Code generated by snips - DO NOT EDIT." comment of generated files. Go tools
+    only recognise generated files by comments like "Code generated <by> DO NOT EDIT.", so the
+    text must match it.
+  -lint-ignore <pragmas>
+    Replace the //lint:file-ignore pragma written after the package clause of generated files,
+    one pragma per line, e.g. //nolint:all, or omit it with none.
+  -pragmas <pragmas>
+    Add pragmas directly above the package clause of generated files, one per line, e.g.
+    //nolint:all or //coverage:ignore, for the linters and coverage tools that flag generated
+    files. Set it in the config file as a list for multiple pragmas.
+  -build-tags <[dir=]constraint>
+    Start generated files with a //go:build constraint, e.g. docs, so that the components are
+    only built with those tags. Prefix it with a directory relative to -path to only apply it to
+    the snippets in that directory and below, where the closest directory's constraint wins, and
+    an empty constraint clears it. Can be repeated.
+  -symbols <file>
+    Path to a JSON file mapping identifiers to URLs, e.g. {"http.Handler": "https://pkg.go.dev/net/http#Handler"}.
+    Matching identifiers are wrapped in links.
   -lazy
     Only generate .go files if the source *.code.* file is newer. // needed?
   -keep-orphaned-files
-    Keeps orphaned generated .go files. (default false)
+    Keeps the generated .go files of snippets deleted in watch mode, or ignored, which are
+    otherwise removed.
+    The -dedupe package and -max-files-per-package facades drop their components either way.
+    (default false)
+  -config <file>
+    Load settings from the given config file, see snips config. Flags take precedence over
+    the config file. (default snips.yaml or snips.toml, whichever exists)
   -v
     Set log verbosity level to "debug". (default "info")
   -log-level
@@ -94,35 +453,158 @@ Examples:
   // TODO
 `
 
+// generateFlags are the values of the generate command's flags.
+type generateFlags struct {
+	args     generatecmd.Arguments
+	files    string
+	toStdout bool
+	format   string
+	verbose  bool
+	logLevel string
+	config   string
+	help     bool
+
+	// detectReport writes the detect report to stdout, or stderr with -stdout.
+	detectReport bool
+	// formatterReport writes the formatter report to stdout, or stderr with
+	// -stdout.
+	formatterReport bool
+	// flagSet the flags were parsed by.
+	flagSet *flag.FlagSet
+	// sources of each flag's value, see generatecmd.ApplyConfig.
+	sources map[string]string
+}
+
+// newGenerateFlagSet returns the generate command's flags, bound to f.
+func newGenerateFlagSet(f *generateFlags, errorHandling flag.ErrorHandling) *flag.FlagSet {
+	cmd := flag.NewFlagSet("generate", errorHandling)
+	f.args.RegisterFlags(cmd)
+	// The config file is applied to the flags that weren't set, see
+	// applyConfigFile, rather than by generatecmd.
+	f.args.NoConfig = true
+	cmd.StringVar(&f.files, "files", "", "")
+	cmd.BoolVar(&f.toStdout, "stdout", false, "")
+	cmd.StringVar(&f.format, "format", "", "")
+	cmd.BoolVar(&f.verbose, "v", false, "")
+	cmd.StringVar(&f.logLevel, "log-level", "info", "")
+	cmd.BoolVar(&f.detectReport, "detect-report", false, "")
+	cmd.BoolVar(&f.formatterReport, "formatter-report", false, "")
+	cmd.StringVar(&f.config, "config", "", "")
+	cmd.BoolVar(&f.help, "help", false, "")
+	return cmd
+}
+
+// parseGenerateFlags parses the generate command's flags, and applies the
+// config file to those not set on the command line.
+func parseGenerateFlags(stdout io.Writer, args []string, errorHandling flag.ErrorHandling) (f *generateFlags, err error) {
+	f = &generateFlags{}
+	f.flagSet = newGenerateFlagSet(f, errorHandling)
+	if err = f.flagSet.Parse(args); err != nil {
+		return nil, err
+	}
+	if f.help {
+		return f, nil
+	}
+	if err = f.applyConfigFile(); err != nil {
+		return nil, err
+	}
+	f.args.Options = runOptions(f.flagSet)
+	switch f.format {
+	case "":
+	case "patch":
+		if !f.toStdout {
+			return nil, fmt.Errorf("-format patch requires -stdout")
+		}
+	case "text":
+		f.args.Text = true
+	default:
+		return nil, fmt.Errorf("invalid -format %q, expected patch or text", f.format)
+	}
+	if f.toStdout {
+		f.args.FileWriter = generatecmd.WriterFileWriter(stdout)
+		if f.format == "patch" {
+			f.args.FileWriter = generatecmd.PatchWriter(stdout)
+		}
+	}
+	return f, nil
+}
+
+// runOptions returns the configurable flags that aren't set to their default,
+// e.g. -style=github, which describe how the snippets are generated.
+func runOptions(cmd *flag.FlagSet) (options []string) {
+	cmd.VisitAll(func(f *flag.Flag) {
+		if !generatecmd.Unconfigurable[f.Name] && f.Value.String() != f.DefValue {
+			options = append(options, "-"+f.Name+"="+f.Value.String())
+		}
+	})
+	return options
+}
+
+// applyConfigFile applies the config file to the flags not set on the command
+// line.
+func (f *generateFlags) applyConfigFile() (err error) {
+	cfg, err := generatecmd.LoadConfig(f.config)
+	if err != nil {
+		return err
+	}
+	f.config = cfg.FileName
+	if f.sources, err = generatecmd.ApplyConfig(f.flagSet, cfg); err != nil {
+		return fmt.Errorf("%s: %w", cfg.FileName, err)
+	}
+	return nil
+}
+
+// generateInto generates the snippets selected by f, passing the generated
+// files to w by their paths relative to -path instead of writing them, for
+// commands that process generated files, such as export. The flags that write
+// generated files elsewhere are refused.
+func generateInto(f *generateFlags, command string, w generatecmd.Writer, stderr io.Writer) (err error) {
+	for _, refused := range []struct {
+		flag string
+		set  bool
+	}{
+		{flag: "-stdout", set: f.toStdout},
+		{flag: "-out", set: f.args.Out != ""},
+		{flag: "-watch", set: f.args.Watch},
+		{flag: "-check", set: f.args.Check},
+		{flag: "-format text", set: f.args.Text},
+	} {
+		if refused.set {
+			return fmt.Errorf("cannot use %s with snips %s", refused.flag, command)
+		}
+	}
+	if f.files != "" {
+		if f.args.Files, err = readFileList(f.files); err != nil {
+			return err
+		}
+	}
+	// Generated files are passed to w as if it were an -out URL.
+	scheme := "snips-" + command
+	generatecmd.RegisterWriter(scheme, func(*url.URL) (generatecmd.Writer, error) {
+		return w, nil
+	})
+	f.args.Out = scheme + ":"
+	// Only snips generate updates the files describing the generated tree.
+	f.args.History, f.args.Feed = false, ""
+	return generatecmd.Run(context.Background(), newLogger(f.logLevel, f.verbose, stderr), f.args)
+}
+
 func generateCmd(stdout, stderr io.Writer, args []string) (code int) {
-	cmd := flag.NewFlagSet("generate", flag.ExitOnError)
-	fileNameFlag := cmd.String("f", "", "")
-	pathFlag := cmd.String("path", ".", "")
-	toStdoutFlag := cmd.Bool("stdout", false, "")
-	watchFlag := cmd.Bool("watch", false, "")
-	styleFlag := cmd.String("style", "swapoff", "")
-	tabWidthFlag := cmd.Int("tab-width", 8, "")
-	linesFlag := cmd.Bool("line-numbers", false, "")
-	linesTableFlag := cmd.Bool("line-numbers-table", false, "")
-	baseLineFlag := cmd.Int("base-line", 0, "")
-	linkableLinesFlag := cmd.Bool("linkable-lines", false, "")
-	workerCountFlag := cmd.Int("w", runtime.NumCPU(), "")
-	verboseFlag := cmd.Bool("v", false, "")
-	logLevelFlag := cmd.String("log-level", "info", "")
-	lazyFlag := cmd.Bool("lazy", false, "")
-	keepOrphanedFilesFlag := cmd.Bool("keep-orphaned-files", false, "")
-	helpFlag := cmd.Bool("help", false, "")
-	err := cmd.Parse(args)
+	f, err := parseGenerateFlags(stdout, args, flag.ExitOnError)
 	if err != nil {
-		fmt.Fprint(stderr, generateUsageText)
+		color.New(color.FgRed).Fprint(stderr, "(✗) ")
+		fmt.Fprintln(stderr, "Command failed: "+err.Error())
 		return 64 // EX_USAGE
 	}
-	if *helpFlag {
+	if f.help {
 		fmt.Fprint(stdout, generateUsageText)
 		return
 	}
 
-	log := newLogger(*logLevelFlag, *verboseFlag, stderr)
+	log := newLogger(f.logLevel, f.verbose, stderr)
+	if f.args.Fast {
+		log = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	signalChan := make(chan os.Signal, 1)
@@ -132,27 +614,30 @@ func generateCmd(stdout, stderr io.Writer, args []string) (code int) {
 		fmt.Fprintln(stderr, "Stopping...")
 		cancel()
 	}()
+	if f.args.Watch {
+		f.args.Rebuild = notifyRebuild(ctx)
+	}
 
-	var fw generatecmd.FileWriterFunc
-	if *toStdoutFlag {
-		fw = generatecmd.WriterFileWriter(stdout)
-	}
-
-	err = generatecmd.Run(ctx, log, generatecmd.Arguments{
-		FileName:          *fileNameFlag,
-		Path:              *pathFlag,
-		FileWriter:        fw,
-		Watch:             *watchFlag,
-		Style:             *styleFlag,
-		TabWidth:          *tabWidthFlag,
-		Lines:             *linesFlag,
-		LinesTable:        *linesTableFlag,
-		BaseLine:          *baseLineFlag,
-		LinkableLines:     *linkableLinesFlag,
-		WorkerCount:       *workerCountFlag,
-		KeepOrphanedFiles: *keepOrphanedFilesFlag,
-		Lazy:              *lazyFlag,
-	})
+	if f.files != "" {
+		if f.args.Files, err = readFileList(f.files); err != nil {
+			color.New(color.FgRed).Fprint(stderr, "(✗) ")
+			fmt.Fprintln(stderr, "Command failed: "+err.Error())
+			return 1
+		}
+	}
+	if f.detectReport {
+		f.args.DetectReport = stdout
+		if f.toStdout {
+			f.args.DetectReport = stderr
+		}
+	}
+	if f.formatterReport {
+		f.args.FormatterReport = stdout
+		if f.toStdout {
+			f.args.FormatterReport = stderr
+		}
+	}
+	err = generatecmd.Run(ctx, log, f.args)
 	if err != nil {
 		color.New(color.FgRed).Fprint(stderr, "(✗) ")
 		fmt.Fprintln(stderr, "Command failed: "+err.Error())
@@ -161,6 +646,43 @@ func generateCmd(stdout, stderr io.Writer, args []string) (code int) {
 	return 0
 }
 
+// notifyRebuild returns a channel that receives on SIGHUP until ctx is done.
+// Signals received while a rebuild is pending are coalesced into it.
+func notifyRebuild(ctx context.Context) <-chan struct{} {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	rebuild := make(chan struct{}, 1)
+	go func() {
+		defer signal.Stop(hup)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				select {
+				case rebuild <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+	return rebuild
+}
+
+// readFileList reads the list of files to generate from fileName, or stdin
+// if it's "-".
+func readFileList(fileName string) ([]string, error) {
+	if fileName == "-" {
+		return generatecmd.ReadFileList(os.Stdin)
+	}
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file list: %w", err)
+	}
+	defer f.Close()
+	return generatecmd.ReadFileList(f)
+}
+
 func newLogger(logLevel string, verbose bool, stderr io.Writer) *slog.Logger {
 	if verbose {
 		logLevel = "debug"
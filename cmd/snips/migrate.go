@@ -0,0 +1,289 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/fatih/color"
+	"github.com/garrettladley/snips/cmd/snips/generatecmd"
+	"github.com/garrettladley/snips/cmd/snips/generatecmd/watcher"
+	"gopkg.in/yaml.v3"
+)
+
+const migrateUsageText = `usage: snips migrate [<args>...]
+
+Moves the code blocks hand-written in .templ files into snippets, so that snips highlights
+them, replacing each block with a call of the component generated from its snippet. Blocks
+are <pre><code> elements, and @templ.Raw calls of a <pre> element with escaped code, e.g.
+  @templ.Raw("<pre><code class=\"language-go\">fmt.Println(&#34;hi&#34;)</code></pre>")
+The snippets of index.templ are written beside it as index_1.code.go, index_2.code.sql, and
+so on, with the language of the block's language-* class pinned in front matter. Blocks with
+templ expressions, e.g. { name }, are skipped. Run snips generate afterwards.
+
+Args:
+  -path <path>
+    Migrates the .templ files in path. (default .)
+  -f <file>
+    Optionally migrates a single .templ file, e.g. -f index.templ
+  -dry-run
+    Lists the snippets that would be written, without changing any files. (default false)
+  -help
+    Print help and exit.
+`
+
+var (
+	// preCodeBlock matches a <pre> element, with an optional <code> element,
+	// capturing the attributes of each and the escaped code.
+	preCodeBlock = regexp.MustCompile(`(?s)<pre(\s[^>]*)?>(?:\s*<code(\s[^>]*)?>)?(.*?)(?:</code>\s*)?</pre>`)
+	// rawCall matches @templ.Raw calls of a string literal.
+	rawCall = regexp.MustCompile("@templ\\.Raw\\((`[^`]*`|\"(?:[^\"\\\\\\n]|\\\\.)*\")\\)")
+	// languageClass matches the classes that name the language of code blocks,
+	// e.g. language-go, as used by highlight.js and Prism.
+	languageClass = regexp.MustCompile(`\b(?:language|lang)-([\w+#.-]+)`)
+	// tag matches the tags of markup left by other highlighters.
+	tag = regexp.MustCompile(`<[^>]*>`)
+)
+
+// migratedBlock is a code block of a .templ file moved into a snippet.
+type migratedBlock struct {
+	// start and end are the offsets of the block in the .templ file.
+	start, end int
+	// language is the chroma lexer of the block's language class, if any.
+	language string
+	// code is the unescaped code.
+	code string
+}
+
+func migrateCmd(stdout, stderr io.Writer, args []string) (code int) {
+	cmd := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	cmd.SetOutput(io.Discard)
+	path := cmd.String("path", ".", "")
+	fileName := cmd.String("f", "", "")
+	dryRun := cmd.Bool("dry-run", false, "")
+	help := cmd.Bool("help", false, "")
+	if err := cmd.Parse(args); err != nil || cmd.NArg() > 0 {
+		fmt.Fprint(stderr, migrateUsageText)
+		return 64 // EX_USAGE
+	}
+	if *help {
+		fmt.Fprint(stdout, migrateUsageText)
+		return 0
+	}
+
+	fileNames := []string{*fileName}
+	if *fileName == "" {
+		var err error
+		if fileNames, err = templFiles(*path); err != nil {
+			color.New(color.FgRed).Fprint(stderr, "(✗) ")
+			fmt.Fprintln(stderr, "Command failed: "+err.Error())
+			return 1
+		}
+	}
+	var snippets int
+	for _, fileName := range fileNames {
+		written, skipped, err := migrateFile(fileName, !*dryRun)
+		if err != nil {
+			color.New(color.FgRed).Fprint(stderr, "(✗) ")
+			fmt.Fprintln(stderr, "Command failed: "+err.Error())
+			return 1
+		}
+		for _, name := range written {
+			fmt.Fprintln(stdout, name)
+		}
+		if skipped > 0 {
+			color.New(color.FgYellow).Fprint(stderr, "(!) ")
+			fmt.Fprintf(stderr, "%s: skipped %d code blocks with templ expressions\n", fileName, skipped)
+		}
+		snippets += len(written)
+	}
+	if snippets > 0 && !*dryRun {
+		color.New(color.FgGreen).Fprint(stdout, "(✓) ")
+		fmt.Fprintf(stdout, "Migrated %d code blocks, run snips generate to generate their components\n", snippets)
+	}
+	return 0
+}
+
+// templFiles returns the .templ files in path, skipping the directories that
+// generate skips.
+func templFiles(path string) (fileNames []string, err error) {
+	err = filepath.WalkDir(path, func(fileName string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			rel, err := filepath.Rel(path, fileName)
+			if err != nil {
+				return err
+			}
+			if watcher.SkipDir(filepath.ToSlash(rel)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasSuffix(d.Name(), ".templ") {
+			fileNames = append(fileNames, fileName)
+		}
+		return nil
+	})
+	return fileNames, err
+}
+
+// migrateFile moves the code blocks of a .templ file into snippets beside it,
+// returning their file names, and the number of blocks skipped because they
+// contain templ expressions. Nothing is written unless write is set.
+func migrateFile(fileName string, write bool) (snippets []string, skipped int, err error) {
+	contents, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read %q: %w", fileName, err)
+	}
+	blocks, skipped := findCodeBlocks(string(contents))
+	if len(blocks) == 0 {
+		return nil, skipped, nil
+	}
+
+	base := strings.TrimSuffix(fileName, ".templ")
+	var sb strings.Builder
+	var offset, n int
+	for _, b := range blocks {
+		var snippet string
+		for {
+			n++
+			snippet = base + "_" + strconv.Itoa(n) + ".code" + extension(b.language)
+			if _, err := os.Stat(snippet); errors.Is(err, os.ErrNotExist) {
+				break
+			} else if err != nil {
+				return nil, 0, err
+			}
+		}
+		if write {
+			data, err := snippetContents(b)
+			if err != nil {
+				return nil, 0, err
+			}
+			if err = os.WriteFile(snippet, []byte(data), 0o644); err != nil {
+				return nil, 0, fmt.Errorf("failed to write %q: %w", snippet, err)
+			}
+		}
+		snippets = append(snippets, snippet)
+		sb.WriteString(string(contents[offset:b.start]))
+		sb.WriteString("@" + generatecmd.ComponentName(snippet) + "()")
+		offset = b.end
+	}
+	sb.WriteString(string(contents[offset:]))
+	if !write {
+		return snippets, skipped, nil
+	}
+	info, err := os.Stat(fileName)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err = os.WriteFile(fileName, []byte(sb.String()), info.Mode().Perm()); err != nil {
+		return nil, 0, fmt.Errorf("failed to write %q: %w", fileName, err)
+	}
+	return snippets, skipped, nil
+}
+
+// findCodeBlocks returns the code blocks of a .templ file, in order, and the
+// number of blocks skipped because they contain templ expressions, whose
+// output can't be known until they're rendered.
+func findCodeBlocks(templ string) (blocks []migratedBlock, skipped int) {
+	raw := rawCall.FindAllStringSubmatchIndex(templ, -1)
+	for _, m := range raw {
+		literal := templ[m[2]:m[3]]
+		value, err := strconv.Unquote(literal)
+		if err != nil {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		pre := preCodeBlock.FindStringSubmatchIndex(value)
+		if pre == nil || pre[0] != 0 || pre[1] != len(value) {
+			// Only calls of a single code block are migrated.
+			continue
+		}
+		blocks = append(blocks, codeBlock(value, pre, m[0], m[1]))
+	}
+	for _, m := range preCodeBlock.FindAllStringSubmatchIndex(templ, -1) {
+		if inRaw(raw, m[0]) {
+			continue
+		}
+		if strings.ContainsAny(templ[m[6]:m[7]], "{}") {
+			skipped++
+			continue
+		}
+		blocks = append(blocks, codeBlock(templ, m, m[0], m[1]))
+	}
+	slices.SortFunc(blocks, func(a, b migratedBlock) int { return a.start - b.start })
+	return blocks, skipped
+}
+
+// codeBlock returns the block matched by preCodeBlock in s, which is at start
+// to end of the .templ file.
+func codeBlock(s string, m []int, start, end int) migratedBlock {
+	var attrs string
+	for i := 2; i < 6; i += 2 {
+		if m[i] >= 0 {
+			attrs += s[m[i]:m[i+1]]
+		}
+	}
+	b := migratedBlock{start: start, end: end}
+	if lang := languageClass.FindStringSubmatch(attrs); lang != nil {
+		if lexer := lexers.Get(lang[1]); lexer != nil {
+			b.language = lexer.Config().Name
+		}
+	}
+	code := html.UnescapeString(tag.ReplaceAllString(s[m[6]:m[7]], ""))
+	// Browsers ignore a newline straight after <pre>, as templ formats them.
+	code = strings.TrimPrefix(strings.TrimPrefix(code, "\r"), "\n")
+	b.code = strings.TrimRight(code, " \t\r\n") + "\n"
+	return b
+}
+
+// inRaw reports whether offset is within one of the @templ.Raw calls.
+func inRaw(raw [][]int, offset int) bool {
+	for _, m := range raw {
+		if offset >= m[0] && offset < m[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// extension returns the snippet extension of a chroma lexer, e.g. ".go", or
+// ".txt" if it has none.
+func extension(language string) string {
+	if lexer := lexers.Get(language); lexer != nil && language != "" {
+		for _, pattern := range lexer.Config().Filenames {
+			if ext := strings.TrimPrefix(pattern, "*"); strings.HasPrefix(ext, ".") && !strings.ContainsAny(ext, "*?[") {
+				return ext
+			}
+		}
+	}
+	return ".txt"
+}
+
+// snippetContents returns the snippet of a block, pinning its language in
+// front matter, if known.
+func snippetContents(b migratedBlock) (string, error) {
+	if b.language == "" {
+		if strings.HasPrefix(b.code, "---\n") {
+			return "---\n---\n" + b.code, nil
+		}
+		return b.code, nil
+	}
+	fm, err := yaml.Marshal(map[string]string{"language": b.language})
+	if err != nil {
+		return "", err
+	}
+	return "---\n" + string(fm) + "---\n" + b.code, nil
+}
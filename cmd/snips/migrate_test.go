@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMigrateCmd(t *testing.T) {
+	dir := t.TempDir()
+	templ := "package docs\n\n" +
+		"templ Index() {\n" +
+		"\t<pre><code class=\"language-go\">\nfmt.Println(&#34;hi&#34;)\n</code></pre>\n" +
+		"\t@templ.Raw(\"<pre class=\\\"lang-sql\\\"><span>SELECT</span> 1 &lt; 2;</pre>\")\n" +
+		"\t@templ.Raw(\"<p>Not code</p>\")\n" +
+		"\t<pre><code>{ name }</code></pre>\n" +
+		"\t<pre>plain &amp; simple</pre>\n" +
+		"}\n"
+	fileName := filepath.Join(dir, "index.templ")
+	if err := os.WriteFile(fileName, []byte(templ), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run(&stdout, &stderr, []string{"snips", "migrate", "-path", dir, "-dry-run"}); code != 0 {
+		t.Fatalf("migrate failed with code %d: %s", code, stderr.String())
+	}
+	if contents, _ := os.ReadFile(fileName); string(contents) != templ {
+		t.Errorf("expected -dry-run not to change the .templ file, got:\n%s", contents)
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	if code := run(&stdout, &stderr, []string{"snips", "migrate", "-path", dir}); code != 0 {
+		t.Fatalf("migrate failed with code %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stderr.String(), "skipped 1 code blocks") {
+		t.Errorf("expected the block with a templ expression to be skipped, got:\n%s", stderr.String())
+	}
+
+	expected := "package docs\n\n" +
+		"templ Index() {\n" +
+		"\t@Index1Go()\n" +
+		"\t@Index2Sql()\n" +
+		"\t@templ.Raw(\"<p>Not code</p>\")\n" +
+		"\t<pre><code>{ name }</code></pre>\n" +
+		"\t@Index3Txt()\n" +
+		"}\n"
+	if contents, _ := os.ReadFile(fileName); string(contents) != expected {
+		t.Errorf("expected the blocks to be replaced:\n%s\ngot:\n%s", expected, contents)
+	}
+	for name, want := range map[string]string{
+		"index_1.code.go":  "---\nlanguage: Go\n---\nfmt.Println(\"hi\")\n",
+		"index_2.code.sql": "---\nlanguage: SQL\n---\nSELECT 1 < 2;\n",
+		"index_3.code.txt": "plain & simple\n",
+	} {
+		contents, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil || string(contents) != want {
+			t.Errorf("expected %s to be %q, got %q, %v", name, want, contents, err)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"cmp"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/fatih/color"
+	"github.com/garrettladley/snips/cmd/snips/generatecmd"
+)
+
+const statsUsageText = `usage: snips stats [<args>...]
+
+Reports the languages, line counts and token types of all snippets, and which entries of the
+-style are exercised by them, e.g. to focus the design of a custom style on the token types
+that are actually used. Tokens of whitespace only aren't counted. Accepts the args of snips
+generate, and reads the config file, so that snippets are highlighted as generate does.
+Nothing is written.
+
+The STYLE column names the entry of the style that each token type is rendered with, which
+is inherited from its category, e.g. Name, if the style doesn't style the type itself, or -
+if the style doesn't style it at all.
+
+Args:
+  -help
+    Print help and exit.
+`
+
+// languageStats are the snippets and lines highlighted with a lexer.
+type languageStats struct {
+	snippets int
+	lines    int
+}
+
+// corpusStats aggregates the tokens of snippets as they're highlighted. It's
+// safe for concurrent use.
+type corpusStats struct {
+	mu        sync.Mutex
+	languages map[string]*languageStats
+	tokens    map[chroma.TokenType]int
+}
+
+func newCorpusStats() *corpusStats {
+	return &corpusStats{languages: make(map[string]*languageStats), tokens: make(map[chroma.TokenType]int)}
+}
+
+func (s *corpusStats) add(fileName, lexer string, tokens []chroma.Token) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	l := s.languages[lexer]
+	if l == nil {
+		l = &languageStats{}
+		s.languages[lexer] = l
+	}
+	l.snippets++
+	var text strings.Builder
+	for _, t := range tokens {
+		text.WriteString(t.Value)
+		if strings.TrimSpace(t.Value) != "" {
+			s.tokens[t.Type]++
+		}
+	}
+	if code := strings.TrimSuffix(text.String(), "\n"); code != "" {
+		l.lines += strings.Count(code, "\n") + 1
+	}
+}
+
+// styledBy returns the entry of the style that tokens of the type are
+// rendered with, the type itself, its sub-category or its category, or false
+// if the style doesn't style any of them.
+func styledBy(styled map[chroma.TokenType]bool, t chroma.TokenType) (chroma.TokenType, bool) {
+	for _, entry := range []chroma.TokenType{t, t.SubCategory(), t.Category()} {
+		if styled[entry] {
+			return entry, true
+		}
+	}
+	return 0, false
+}
+
+// write reports the stats, and the entries of the style that aren't used by
+// any token.
+func (s *corpusStats) write(w io.Writer, style *chroma.Style) error {
+	var snippets, lines int
+	for _, l := range s.languages {
+		snippets += l.snippets
+		lines += l.lines
+	}
+	fmt.Fprintf(w, "%d snippets, %d lines\n\n", snippets, lines)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "LANGUAGE\tSNIPPETS\tLINES")
+	for _, lexer := range slices.SortedFunc(maps.Keys(s.languages), func(a, b string) int {
+		return cmp.Or(cmp.Compare(s.languages[b].snippets, s.languages[a].snippets), strings.Compare(a, b))
+	}) {
+		fmt.Fprintf(tw, "%s\t%d\t%d\n", lexer, s.languages[lexer].snippets, s.languages[lexer].lines)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	// Pseudo token types, such as Background and LineNumbers, style the
+	// wrapper rather than tokens.
+	styled := make(map[chroma.TokenType]bool)
+	for _, t := range style.Types() {
+		if t >= 0 {
+			styled[t] = true
+		}
+	}
+	used := make(map[chroma.TokenType]bool)
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TOKEN TYPE\tCOUNT\tSTYLE")
+	for _, t := range slices.SortedFunc(maps.Keys(s.tokens), func(a, b chroma.TokenType) int {
+		return cmp.Or(cmp.Compare(s.tokens[b], s.tokens[a]), cmp.Compare(a, b))
+	}) {
+		entry, ok := styledBy(styled, t)
+		by := "-"
+		if ok {
+			by, used[entry] = entry.String(), true
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\n", t, s.tokens[t], by)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	var unused []chroma.TokenType
+	for t := range styled {
+		if !used[t] {
+			unused = append(unused, t)
+		}
+	}
+	slices.Sort(unused)
+	fmt.Fprintf(w, "\n%d of %d entries of style %s are unused:\n", len(unused), len(styled), style.Name)
+	for _, t := range unused {
+		fmt.Fprintln(w, "  "+t.String())
+	}
+	return nil
+}
+
+func statsCmd(stdout, stderr io.Writer, args []string) (code int) {
+	f := &generateFlags{}
+	f.flagSet = newGenerateFlagSet(f, flag.ContinueOnError)
+	f.flagSet.SetOutput(io.Discard)
+	if err := f.flagSet.Parse(args); err != nil || f.flagSet.NArg() > 0 {
+		fmt.Fprint(stderr, statsUsageText)
+		return 64 // EX_USAGE
+	}
+	if f.help {
+		fmt.Fprint(stdout, statsUsageText)
+		return 0
+	}
+	fail := func(err error) int {
+		color.New(color.FgRed).Fprint(stderr, "(✗) ")
+		fmt.Fprintln(stderr, "Command failed: "+err.Error())
+		return 1
+	}
+	if err := f.applyConfigFile(); err != nil {
+		return fail(err)
+	}
+	root, err := filepath.Abs(f.args.Path)
+	if err != nil {
+		return fail(fmt.Errorf("failed to get absolute path: %w", err))
+	}
+	f.args.Path = root
+	style, err := generatecmd.NewGenerate(nil, f.args).Style()
+	if err != nil {
+		return fail(err)
+	}
+
+	stats := newCorpusStats()
+	f.args.TokenReport = stats.add
+	discard := generatecmd.FileWriterFunc(func(string, []byte) error { return nil })
+	if err = generateInto(f, "stats", discard, stderr); err != nil {
+		return fail(err)
+	}
+	if err = stats.write(stdout, style); err != nil {
+		return fail(err)
+	}
+	return 0
+}
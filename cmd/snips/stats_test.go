@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStatsCmd(t *testing.T) {
+	dir := writeSnippets(t, map[string]string{
+		"main.code.go":  "---\nlanguage: go\n---\npackage main\n\nfunc main() {}\n",
+		"other.code.go": "---\nlanguage: go\n---\npackage other\n",
+		"run.code.sh":   "---\nlanguage: bash\n---\necho hi\n",
+	})
+	var stdout, stderr bytes.Buffer
+	if code := run(&stdout, &stderr, []string{"snips", "stats", "-path", dir, "-style", "github"}); code != 0 {
+		t.Fatalf("stats failed with code %d: %s", code, stderr.String())
+	}
+	out := stdout.String()
+	for _, expected := range []string{
+		"3 snippets, 5 lines\n",
+		"\nGo        2         4\nBash      1         1\n",
+		"\nKeywordNamespace    2      Keyword\n",
+		"\nNameBuiltin         1      NameBuiltin\n",
+		"\nNameOther           2      -\n",
+		"\n37 of 40 entries of style github are unused:\n",
+		"\n  CommentPreproc\n",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, out)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "main.code.go_templ.go")); !os.IsNotExist(err) {
+		t.Errorf("expected nothing to be written, got %v", err)
+	}
+}
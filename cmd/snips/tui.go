@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/fatih/color"
+	"github.com/garrettladley/snips/cmd/snips/generatecmd"
+)
+
+const tuiUsageText = `usage: snips tui [<args>...]
+
+Generates the snippets, then walks through those that failed, one at a time, to triage them,
+e.g. after a migration, instead of scrolling back through the errors. For each snippet:
+  e   edit it in $VISUAL or $EDITOR, then retry it
+  r   retry generating it
+  i   ignore it, adding it to the -baseline file (default snips-baseline.json)
+  s   skip it, leaving it failing
+  q   quit
+Fails if any snippets are left failing. Accepts the args of snips generate, and reads the
+config file. Can't be used with -watch, -stdout, -check, -format or -update-baseline.
+
+Args:
+  -help
+    Print help and exit.
+`
+
+// defaultBaselineFileName is where ignored snippets are recorded without
+// -baseline.
+const defaultBaselineFileName = "snips-baseline.json"
+
+// triage walks through the snippets that failed to generate.
+type triage struct {
+	in     *bufio.Reader
+	out    io.Writer
+	args   generatecmd.Arguments
+	root   string
+	log    *slog.Logger
+	editor func(fileName string) error
+	// baseline is the file ignored snippets are added to.
+	baseline string
+}
+
+// generate generates the snippets of args, returning the errors of those that
+// failed, by file name, or the error of the run.
+func (t *triage) generate(args generatecmd.Arguments) (failed map[string]error, err error) {
+	failed = make(map[string]error)
+	var mu sync.Mutex
+	var runErrs []error
+	args.OnError = func(fileName string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if fileName == "" {
+			runErrs = append(runErrs, err)
+			return
+		}
+		failed[fileName] = err
+	}
+	err = generatecmd.Run(context.Background(), t.log, args)
+	if len(runErrs) > 0 || (err != nil && len(failed) == 0) {
+		return nil, errors.Join(append(runErrs, err)...)
+	}
+	return failed, nil
+}
+
+// retry generates a single snippet again, reporting whether it was fixed, or
+// updating its error in failed.
+func (t *triage) retry(fileName string, failed map[string]error) (fixed bool, err error) {
+	args := t.args
+	args.FileName, args.Files, args.Only = "", []string{fileName}, nil
+	args.Since, args.Staged = "", false
+	args.Baseline, args.UpdateBaseline = "", false
+	args.History, args.Feed = false, ""
+	retried, err := t.generate(args)
+	if err != nil {
+		return false, err
+	}
+	if snippetErr, ok := retried[fileName]; ok {
+		failed[fileName] = snippetErr
+		return false, nil
+	}
+	return true, nil
+}
+
+// rel returns the path of a snippet relative to the root.
+func (t *triage) rel(fileName string) string {
+	if rel, err := filepath.Rel(t.root, fileName); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return fileName
+}
+
+// run prompts for an action for each failed snippet, returning the number of
+// snippets left failing.
+func (t *triage) run(failed map[string]error) (remaining int, err error) {
+	fileNames := slices.Sorted(maps.Keys(failed))
+	fmt.Fprintf(t.out, "%d snippets failed:\n", len(fileNames))
+	for _, fileName := range fileNames {
+		fmt.Fprintf(t.out, "  %s\n", t.rel(fileName))
+	}
+	var fixed, ignored int
+	for i, fileName := range fileNames {
+	prompt:
+		for {
+			fmt.Fprintln(t.out)
+			color.New(color.Bold).Fprintf(t.out, "[%d/%d] %s\n", i+1, len(fileNames), t.rel(fileName))
+			// Errors name snippets by absolute path.
+			fmt.Fprintln(t.out, strings.ReplaceAll(failed[fileName].Error(), t.root+string(filepath.Separator), ""))
+			fmt.Fprint(t.out, "(e)dit, (r)etry, (i)gnore, (s)kip, (q)uit? ")
+			line, readErr := t.in.ReadString('\n')
+			if readErr != nil && line == "" {
+				// Input ended, e.g. with Ctrl-D.
+				fmt.Fprintln(t.out)
+				return len(fileNames) - fixed - ignored, nil
+			}
+			switch strings.ToLower(strings.TrimSpace(line)) {
+			case "e", "edit":
+				if err = t.editor(fileName); err != nil {
+					return 0, fmt.Errorf("failed to edit %s: %w", t.rel(fileName), err)
+				}
+				fallthrough
+			case "r", "retry":
+				ok, err := t.retry(fileName, failed)
+				if err != nil {
+					return 0, err
+				}
+				if ok {
+					color.New(color.FgGreen).Fprint(t.out, "(✓) ")
+					fmt.Fprintln(t.out, "Generated "+t.rel(fileName))
+					fixed++
+					break prompt
+				}
+			case "i", "ignore":
+				if err = generatecmd.AddToBaseline(t.baseline, t.root, fileName, failed[fileName]); err != nil {
+					return 0, err
+				}
+				fmt.Fprintf(t.out, "Added %s to %s\n", t.rel(fileName), t.baseline)
+				ignored++
+				break prompt
+			case "s", "skip":
+				break prompt
+			case "q", "quit":
+				return len(fileNames) - fixed - ignored, nil
+			default:
+				fmt.Fprintln(t.out, "Unknown action, expected e, r, i, s or q")
+			}
+		}
+	}
+	return len(fileNames) - fixed - ignored, nil
+}
+
+// runEditor opens fileName in $VISUAL or $EDITOR, or vi, in the terminal.
+func runEditor(fileName string) error {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+	}
+	// The editor may have arguments, e.g. "code --wait".
+	fields := strings.Fields(editor)
+	cmd := exec.Command(fields[0], append(fields[1:], fileName)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd.Run()
+}
+
+func tuiCmd(stdin io.Reader, stdout, stderr io.Writer, args []string) (code int) {
+	f := &generateFlags{}
+	f.flagSet = newGenerateFlagSet(f, flag.ContinueOnError)
+	f.flagSet.SetOutput(io.Discard)
+	if err := f.flagSet.Parse(args); err != nil || f.flagSet.NArg() > 0 {
+		fmt.Fprint(stderr, tuiUsageText)
+		return 64 // EX_USAGE
+	}
+	if f.help {
+		fmt.Fprint(stdout, tuiUsageText)
+		return 0
+	}
+	fail := func(err error) int {
+		color.New(color.FgRed).Fprint(stderr, "(✗) ")
+		fmt.Fprintln(stderr, "Command failed: "+err.Error())
+		return 1
+	}
+	if err := f.applyConfigFile(); err != nil {
+		return fail(err)
+	}
+	for _, refused := range []struct {
+		flag string
+		set  bool
+	}{
+		{flag: "-watch", set: f.args.Watch},
+		{flag: "-stdout", set: f.toStdout},
+		{flag: "-check", set: f.args.Check},
+		{flag: "-format", set: f.format != ""},
+		{flag: "-update-baseline", set: f.args.UpdateBaseline},
+	} {
+		if refused.set {
+			return fail(fmt.Errorf("cannot use %s with snips tui", refused.flag))
+		}
+	}
+	root, err := filepath.Abs(f.args.Path)
+	if err != nil {
+		return fail(fmt.Errorf("failed to get absolute path: %w", err))
+	}
+	f.args.Path = root
+	f.args.Options = runOptions(f.flagSet)
+	if f.files != "" {
+		if f.args.Files, err = readFileList(f.files); err != nil {
+			return fail(err)
+		}
+	}
+
+	t := &triage{
+		in:       bufio.NewReader(stdin),
+		out:      stdout,
+		args:     f.args,
+		root:     root,
+		editor:   runEditor,
+		baseline: f.args.Baseline,
+		// The errors are shown by the triage, so only debug logs are useful.
+		log: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	if f.verbose || f.logLevel == "debug" {
+		t.log = newLogger(f.logLevel, f.verbose, stderr)
+	}
+	if t.baseline == "" {
+		t.baseline = defaultBaselineFileName
+	}
+	if _, err = os.Stat(f.args.Baseline); f.args.Baseline != "" && errors.Is(err, os.ErrNotExist) {
+		// The baseline is created by ignoring the first snippet.
+		f.args.Baseline = ""
+	}
+	failed, err := t.generate(f.args)
+	if err != nil {
+		return fail(err)
+	}
+	if len(failed) == 0 {
+		color.New(color.FgGreen).Fprint(stdout, "(✓) ")
+		fmt.Fprintln(stdout, "No snippets failed")
+		return 0
+	}
+	remaining, err := t.run(failed)
+	if err != nil {
+		return fail(err)
+	}
+	if remaining > 0 {
+		return fail(fmt.Errorf("%d snippets are still failing", remaining))
+	}
+	color.New(color.FgGreen).Fprint(stdout, "(✓) ")
+	fmt.Fprintln(stdout, "All failed snippets were triaged")
+	return 0
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTuiCmd(t *testing.T) {
+	invalid := "---\nlanguage: unknown\n---\npackage main\n"
+	dir := writeSnippets(t, map[string]string{
+		"a.code.go":  invalid,
+		"b.code.go":  invalid,
+		"c.code.go":  invalid,
+		"ok.code.go": "package main\n",
+	})
+	// The editor fixes the snippet by replacing it.
+	fixed := filepath.Join(t.TempDir(), "fixed.code.go")
+	if err := os.WriteFile(fixed, []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "cp "+fixed)
+	baseline := filepath.Join(t.TempDir(), "baseline.json")
+
+	var stdout, stderr bytes.Buffer
+	// Edit a, ignore b, retry c, then skip it.
+	code := tuiCmd(strings.NewReader("e\ni\nx\nr\ns\n"), &stdout, &stderr, []string{"-path", dir, "-baseline", baseline})
+	if code != 1 || !strings.Contains(stderr.String(), "1 snippets are still failing") {
+		t.Fatalf("expected c to be left failing, got code %d: %s", code, stderr.String())
+	}
+	for _, expected := range []string{
+		"3 snippets failed:\n  a.code.go\n  b.code.go\n  c.code.go\n",
+		"[1/3] a.code.go\n",
+		"Generated a.code.go\n",
+		"Added b.code.go to " + baseline,
+		"Unknown action",
+		`c.code.go generation error: unknown language "unknown"`,
+	} {
+		if !strings.Contains(stdout.String(), expected) {
+			t.Errorf("expected the output to contain %q, got:\n%s", expected, stdout.String())
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a.code.go_templ.go")); err != nil {
+		t.Errorf("expected the edited snippet to be generated: %v", err)
+	}
+	data, err := os.ReadFile(baseline)
+	if err != nil || !strings.Contains(string(data), `"file": "b.code.go"`) || strings.Contains(string(data), "c.code.go") {
+		t.Errorf("expected only b to be in the baseline, got %v:\n%s", err, data)
+	}
+
+	// Once c is fixed, the baseline tolerates b.
+	if err = os.WriteFile(filepath.Join(dir, "c.code.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stdout.Reset()
+	stderr.Reset()
+	if code = tuiCmd(strings.NewReader(""), &stdout, &stderr, []string{"-path", dir, "-baseline", baseline}); code != 0 || !strings.Contains(stdout.String(), "No snippets failed") {
+		t.Errorf("expected no snippets to fail, got code %d: %s%s", code, stdout.String(), stderr.String())
+	}
+
+	if code = tuiCmd(strings.NewReader(""), &stdout, &stderr, []string{"-path", dir, "-watch"}); code != 1 || !strings.Contains(stderr.String(), "cannot use -watch") {
+		t.Errorf("expected -watch to be refused, got code %d: %s", code, stderr.String())
+	}
+}
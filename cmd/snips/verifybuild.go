@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/garrettladley/snips"
+	"github.com/garrettladley/snips/cmd/snips/generatecmd/watcher"
+)
+
+const verifyBuildUsageText = `usage: snips verify-build [<args>...]
+
+Builds the packages in path that contain generated files with go build, and attributes the
+errors to the snippets they were generated from, so that CI quickly checks that the generated
+code compiles against the installed templ version. Nothing is written. Errors in other files of
+the packages are listed as they are.
+
+Args:
+  -path <path>
+    Builds the packages with generated files in path. (default .)
+  -vet
+    Runs go vet instead of go build. (default false)
+  -offline
+    Fails instead of downloading missing modules or Go toolchains, by running go with
+    GOPROXY=off and GOTOOLCHAIN=local, for air-gapped build environments. (default false)
+  -help
+    Print help and exit.
+`
+
+// facadeAndSharedFiles are the names of the generated files of
+// -max-files-per-package facades, of the -dedupe package and of
+// -aggregate-dirs directories, which aren't generated from a single snippet.
+var facadeAndSharedFiles = []string{"snips_facade.go", "snips_shared.go", "snips_generated_templ.go"}
+
+// goErrorLine matches the lines of go build and go vet errors, e.g.
+// "docs/main.code.go_templ.go:12:5: undefined: x".
+var goErrorLine = regexp.MustCompile(`^(?:vet: )?(\S+\.go):(\d+(?::\d+)?): (.*)$`)
+
+func verifyBuildCmd(stdout, stderr io.Writer, args []string) (code int) {
+	cmd := flag.NewFlagSet("verify-build", flag.ContinueOnError)
+	cmd.SetOutput(io.Discard)
+	path := cmd.String("path", ".", "")
+	vet := cmd.Bool("vet", false, "")
+	offline := cmd.Bool("offline", false, "")
+	help := cmd.Bool("help", false, "")
+	if err := cmd.Parse(args); err != nil || cmd.NArg() > 0 {
+		fmt.Fprint(stderr, verifyBuildUsageText)
+		return 64 // EX_USAGE
+	}
+	if *help {
+		fmt.Fprint(stdout, verifyBuildUsageText)
+		return 0
+	}
+	fail := func(err error) int {
+		color.New(color.FgRed).Fprint(stderr, "(✗) ")
+		fmt.Fprintln(stderr, "Command failed: "+err.Error())
+		return 1
+	}
+
+	root, err := filepath.Abs(*path)
+	if err != nil {
+		return fail(fmt.Errorf("failed to get absolute path: %w", err))
+	}
+	packages, err := generatedPackages(root)
+	if err != nil {
+		return fail(err)
+	}
+	if len(packages) == 0 {
+		color.New(color.FgYellow).Fprint(stderr, "(!) ")
+		fmt.Fprintln(stderr, "No generated files found")
+		return 0
+	}
+
+	output, err := goBuild(root, packages, *vet, *offline)
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return fail(err)
+	}
+	tool := "go build"
+	if *vet {
+		tool = "go vet"
+	}
+	if err == nil {
+		color.New(color.FgGreen).Fprint(stderr, "(✓) ")
+		fmt.Fprintf(stderr, "%s passed for %d packages with generated files\n", tool, len(packages))
+		return 0
+	}
+
+	bySnippet, other := attributeBuildErrors(root, output)
+	for _, snippet := range slices.Sorted(maps.Keys(bySnippet)) {
+		fmt.Fprintln(stdout, snippet+":")
+		for _, line := range bySnippet[snippet] {
+			fmt.Fprintln(stdout, "  "+line)
+		}
+	}
+	for _, line := range other {
+		fmt.Fprintln(stdout, line)
+	}
+	color.New(color.FgRed).Fprint(stderr, "(✗) ")
+	if len(bySnippet) == 0 {
+		fmt.Fprintf(stderr, "%s failed\n", tool)
+	} else {
+		fmt.Fprintf(stderr, "%s failed for %d snippets\n", tool, len(bySnippet))
+	}
+	return 1
+}
+
+// generatedPackages returns the directories of the packages in root that
+// contain generated files, relative to root, as go package patterns, e.g.
+// ./docs. The directories that generate skips are skipped.
+func generatedPackages(root string) (packages []string, err error) {
+	dirs := make(map[string]struct{})
+	err = filepath.WalkDir(root, func(fileName string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, fileName)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if watcher.SkipDir(filepath.ToSlash(rel)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if isGeneratedFile(d.Name()) {
+			dirs["./"+filepath.ToSlash(filepath.Dir(rel))] = struct{}{}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return slices.Sorted(maps.Keys(dirs)), nil
+}
+
+// isGeneratedFile reports whether the file is generated by snips.
+func isGeneratedFile(name string) bool {
+	if slices.Contains(facadeAndSharedFiles, name) {
+		return true
+	}
+	return snips.ContainsDotCodeDot(name) && strings.HasSuffix(name, "_templ.go")
+}
+
+// offlineGoEnv stops go from downloading modules and toolchains.
+var offlineGoEnv = []string{"GOPROXY=off", "GOTOOLCHAIN=local"}
+
+// goBuild runs go build, or go vet, for the packages in root, returning its
+// combined output. Offline, go fails instead of downloading anything.
+func goBuild(root string, packages []string, vet, offline bool) (output string, err error) {
+	args := []string{"vet"}
+	if !vet {
+		args = []string{"build"}
+		// The results of building several packages are discarded, but the
+		// binary of a single main package is written, unless it's discarded.
+		if len(packages) == 1 {
+			args = append(args, "-o", os.DevNull)
+		}
+	}
+	var out bytes.Buffer
+	c := exec.Command("go", append(args, packages...)...)
+	c.Dir = root
+	if offline {
+		c.Env = append(os.Environ(), offlineGoEnv...)
+	}
+	c.Stdout, c.Stderr = &out, &out
+	err = c.Run()
+	return out.String(), err
+}
+
+// attributeBuildErrors groups the errors of go build output by the snippet
+// whose generated file they're in, by path relative to root. The errors of
+// facade and shared files are grouped by the file, and other lines, apart
+// from the package headers, are returned as they are.
+func attributeBuildErrors(root, output string) (bySnippet map[string][]string, other []string) {
+	bySnippet = make(map[string][]string)
+	for _, line := range strings.Split(strings.TrimRight(output, "\n"), "\n") {
+		if line == "" || strings.HasPrefix(line, "# ") {
+			continue
+		}
+		m := goErrorLine.FindStringSubmatch(line)
+		if m == nil || !isGeneratedFile(filepath.Base(m[1])) {
+			other = append(other, line)
+			continue
+		}
+		fileName := m[1]
+		if !filepath.IsAbs(fileName) {
+			fileName = filepath.Join(root, fileName)
+		}
+		source := snippetOf(fileName)
+		rel, err := filepath.Rel(root, source)
+		if err != nil {
+			rel = source
+		}
+		generated, err := filepath.Rel(root, fileName)
+		if err != nil {
+			generated = fileName
+		}
+		bySnippet[filepath.ToSlash(rel)] = append(bySnippet[filepath.ToSlash(rel)], fmt.Sprintf("%s:%s: %s", filepath.ToSlash(generated), m[2], m[3]))
+	}
+	return bySnippet, other
+}
+
+// snippetOf returns the snippet that a generated file was generated from,
+// which is in the parent directory for the sub-packages of
+// -max-files-per-package, or the file itself for facade and shared files.
+func snippetOf(fileName string) string {
+	snippet, ok := strings.CutSuffix(fileName, "_templ.go")
+	if !ok {
+		return fileName
+	}
+	if _, err := os.Stat(snippet); err == nil {
+		return snippet
+	}
+	parent := filepath.Join(filepath.Dir(filepath.Dir(snippet)), filepath.Base(snippet))
+	if _, err := os.Stat(parent); err == nil {
+		return parent
+	}
+	return snippet
+}
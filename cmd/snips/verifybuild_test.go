@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestVerifyBuildCmd(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go is not installed")
+	}
+	root := t.TempDir()
+	for name, contents := range map[string]string{
+		"go.mod":                             "module example.com/site\n\ngo 1.21\n",
+		"docs/main.code.txt":                 "x = 1\n",
+		"docs/main.code.txt_templ.go":        "package docs\n\nfunc Main() string { return \"\" }\n",
+		"views/hello.code.txt":               "x = 1\n",
+		"views/hello.code.txt_templ.go":      "package views\n\nfunc Hello() string { return 1 }\n",
+		"views/handwritten.go":               "package views\n",
+		"other/other.go":                     "package other\n\nfunc Other() string { return 1 }\n",
+		"views/shard1/big.code.txt_templ.go": "package shard1\n\nvar x int = \"\"\n",
+		"views/big.code.txt":                 "x = 1\n",
+	} {
+		fileName := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fileName), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fileName, []byte(contents), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	packages, err := generatedPackages(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"./docs", "./views", "./views/shard1"}, packages); diff != "" {
+		t.Errorf("unexpected packages:\n%s", diff)
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := run(&stdout, &stderr, []string{"snips", "verify-build", "-path", root})
+	if code != 1 {
+		t.Fatalf("expected the build to fail, got code %d: %s", code, stderr.String())
+	}
+	for _, expected := range []string{
+		"views/hello.code.txt:\n  views/hello.code.txt_templ.go:3:",
+		"views/big.code.txt:\n  views/shard1/big.code.txt_templ.go:3:",
+	} {
+		if !strings.Contains(stdout.String(), expected) {
+			t.Errorf("expected the output to contain %q, got:\n%s", expected, stdout.String())
+		}
+	}
+	if strings.Contains(stdout.String(), "other") {
+		t.Errorf("expected packages without generated files not to be built, got:\n%s", stdout.String())
+	}
+	if !strings.Contains(stderr.String(), "go build failed for 2 snippets") {
+		t.Errorf("unexpected summary: %s", stderr.String())
+	}
+
+	for _, name := range []string{"views/hello.code.txt_templ.go", "views/shard1/big.code.txt_templ.go"} {
+		if err := os.Remove(filepath.Join(root, filepath.FromSlash(name))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stdout.Reset()
+	stderr.Reset()
+	if code := run(&stdout, &stderr, []string{"snips", "verify-build", "-path", root, "-vet"}); code != 0 {
+		t.Errorf("expected go vet to pass, got code %d:\n%s%s", code, stdout.String(), stderr.String())
+	}
+}
@@ -0,0 +1,31 @@
+package snips
+
+import (
+	"context"
+	"io"
+
+	"github.com/a-h/templ"
+)
+
+// Component returns a component that renders the highlighted HTML of contents,
+// as Highlight returns it, for snippets that aren't known when generating
+// code, e.g. the code blocks of docs from a CMS. The HTML is highlighted when
+// first rendered, and cached like that of Highlight, see ConfigureCache.
+// Invalid options fail rendering.
+func Component(contents []byte, opts ...Option) templ.Component {
+	o, err := newHighlightOptions(opts)
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		html, err := o.cachedHighlight(contents)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, html)
+		return err
+	})
+}
@@ -0,0 +1,41 @@
+package snips_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/garrettladley/snips"
+)
+
+func TestComponent(t *testing.T) {
+	contents := []byte("package main\n\nfunc main() {\n\tprintln(\"<hi>\")\n}\n")
+	want, err := snips.Highlight(context.Background(), contents, snips.WithLanguage("go"))
+	if err != nil {
+		t.Fatalf("failed to highlight: %v", err)
+	}
+
+	c := snips.Component(contents, snips.WithLanguage("go"))
+	// The second render is cached.
+	for range 2 {
+		var b strings.Builder
+		if err := c.Render(context.Background(), &b); err != nil {
+			t.Fatalf("failed to render: %v", err)
+		}
+		if b.String() != want {
+			t.Errorf("expected the highlighted HTML:\n%s\ngot:\n%s", want, b.String())
+		}
+	}
+
+	var b strings.Builder
+	if err := snips.Component(contents, snips.WithLanguage("go"), snips.WithTabWidth(2)).Render(context.Background(), &b); err != nil {
+		t.Fatalf("failed to render: %v", err)
+	}
+	if b.String() == want {
+		t.Error("expected other options not to render the cached HTML")
+	}
+
+	if err := snips.Component(contents, snips.WithStyle("unknown")).Render(context.Background(), &b); err == nil {
+		t.Error("expected invalid options to fail rendering")
+	}
+}
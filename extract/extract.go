@@ -0,0 +1,9 @@
+// Package extract splits snippets into named parts, so that each part can be
+// generated as its own component.
+package extract
+
+// Definition is a named part of a snippet.
+type Definition struct {
+	Name     string
+	Contents []byte
+}
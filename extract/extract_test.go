@@ -0,0 +1,120 @@
+package extract
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRegions(t *testing.T) {
+	t.Run("extracts nested regions and strips markers", func(t *testing.T) {
+		contents := "package main\n\n// snips:region Handler\nfunc Handler() {\n\t// snips:region Body\n\treturn\n\t// snips:endregion\n}\n// snips:endregion\n"
+
+		regions, stripped, err := Regions([]byte(contents))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		want := []Definition{
+			{Name: "Body", Contents: []byte("\treturn\n")},
+			{Name: "Handler", Contents: []byte("func Handler() {\n\treturn\n}\n")},
+		}
+		if diff := cmp.Diff(want, regions); diff != "" {
+			t.Errorf("unexpected regions (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff("package main\n\nfunc Handler() {\n\treturn\n}\n", string(stripped)); diff != "" {
+			t.Errorf("unexpected stripped contents (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("returns contents unchanged without regions", func(t *testing.T) {
+		regions, stripped, err := Regions([]byte("x = 1\n"))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if regions != nil || string(stripped) != "x = 1\n" {
+			t.Errorf("expected no regions and unchanged contents, got %v, %q", regions, stripped)
+		}
+	})
+
+	for name, contents := range map[string]string{
+		"unclosed":  "# snips:region A\nx = 1\n",
+		"unopened":  "x = 1\n# snips:endregion\n",
+		"duplicate": "# snips:region A\n# snips:endregion\n# snips:region A\n# snips:endregion\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := Regions([]byte(contents)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestProto(t *testing.T) {
+	contents := `syntax = "proto3";
+
+package users.v1;
+
+// User is a user.
+// It has a name.
+message User {
+  string name = 1; // The name, e.g. "}".
+  message Address {
+    string line = 1;
+  }
+}
+
+enum Role { ROLE_UNSPECIFIED = 0; }
+
+/* Users manages users. */
+service Users {
+  rpc GetUser(GetUserRequest) returns (User);
+}
+`
+
+	want := []Definition{
+		{Name: "User", Contents: []byte("// User is a user.\n// It has a name.\nmessage User {\n  string name = 1; // The name, e.g. \"}\".\n  message Address {\n    string line = 1;\n  }\n}\n")},
+		{Name: "Role", Contents: []byte("enum Role { ROLE_UNSPECIFIED = 0; }\n")},
+		{Name: "Users", Contents: []byte("/* Users manages users. */\nservice Users {\n  rpc GetUser(GetUserRequest) returns (User);\n}\n")},
+	}
+	if diff := cmp.Diff(want, Proto([]byte(contents))); diff != "" {
+		t.Errorf("unexpected definitions (-want +got):\n%s", diff)
+	}
+}
+
+func TestOpenAPI(t *testing.T) {
+	contents := `openapi: 3.0.0
+info:
+  title: Users
+paths:
+  /users:
+    parameters: []
+    get:
+      operationId: listUsers
+      responses:
+        "200":
+          description: OK
+  /users/{id}:
+    delete:
+      responses:
+        "204":
+          description: Deleted
+`
+
+	defs, err := OpenAPI([]byte(contents))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []Definition{
+		{Name: "listUsers", Contents: []byte("/users:\n  get:\n    operationId: listUsers\n    responses:\n      \"200\":\n        description: OK\n")},
+		{Name: "delete /users/{id}", Contents: []byte("/users/{id}:\n  delete:\n    responses:\n      \"204\":\n        description: Deleted\n")},
+	}
+	if diff := cmp.Diff(want, defs); diff != "" {
+		t.Errorf("unexpected definitions (-want +got):\n%s", diff)
+	}
+
+	if _, err := OpenAPI([]byte("name: not openapi\n")); !errors.Is(err, ErrNotOpenAPI) {
+		t.Errorf("expected ErrNotOpenAPI, got %v", err)
+	}
+}
@@ -0,0 +1,91 @@
+package extract
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrNotOpenAPI is returned when a document doesn't have an openapi or swagger key.
+var ErrNotOpenAPI = errors.New("not an OpenAPI document, missing the openapi or swagger key")
+
+var httpMethods = map[string]struct{}{
+	"get": {}, "put": {}, "post": {}, "delete": {}, "options": {}, "head": {}, "patch": {}, "trace": {},
+}
+
+// OpenAPI returns each operation of an OpenAPI (or Swagger) document as a YAML
+// document containing just the operation under its path. Operations are named
+// by their operationId, or by their method and path.
+func OpenAPI(contents []byte) (defs []Definition, err error) {
+	var doc yaml.Node
+	if err = yaml.Unmarshal(contents, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI document: %w", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, errors.New("OpenAPI document must be a mapping")
+	}
+	root := doc.Content[0]
+	if lookup(root, "openapi") == nil && lookup(root, "swagger") == nil {
+		return nil, ErrNotOpenAPI
+	}
+	paths := lookup(root, "paths")
+	if paths == nil || paths.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(paths.Content); i += 2 {
+		pathKey, pathItem := paths.Content[i], paths.Content[i+1]
+		if pathItem.Kind != yaml.MappingNode {
+			continue
+		}
+		for j := 0; j+1 < len(pathItem.Content); j += 2 {
+			methodKey, op := pathItem.Content[j], pathItem.Content[j+1]
+			if _, ok := httpMethods[strings.ToLower(methodKey.Value)]; !ok {
+				continue
+			}
+			name := methodKey.Value + " " + pathKey.Value
+			if id := lookup(op, "operationId"); id != nil && id.Value != "" {
+				name = id.Value
+			}
+			b, err := encode(&yaml.Node{
+				Kind: yaml.MappingNode,
+				Content: []*yaml.Node{pathKey, {
+					Kind:    yaml.MappingNode,
+					Content: []*yaml.Node{methodKey, op},
+				}},
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode operation %q: %w", name, err)
+			}
+			defs = append(defs, Definition{Name: name, Contents: b})
+		}
+	}
+	return defs, nil
+}
+
+func lookup(m *yaml.Node, key string) *yaml.Node {
+	if m.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func encode(n *yaml.Node) ([]byte, error) {
+	var b bytes.Buffer
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(n); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
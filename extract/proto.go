@@ -0,0 +1,87 @@
+package extract
+
+import (
+	"bytes"
+	"regexp"
+)
+
+var protoDefinition = regexp.MustCompile(`^(message|enum|service)\s+([A-Za-z_]\w*)`)
+
+// Proto returns the top level message, enum and service definitions of a
+// protobuf file, including the comments directly above them.
+func Proto(contents []byte) (defs []Definition) {
+	var (
+		offset, depth, start int
+		inComment, opened    bool
+		commentStart         = -1
+		name                 string
+	)
+	for _, line := range bytes.SplitAfter(contents, []byte("\n")) {
+		trimmed := bytes.TrimSpace(line)
+		if depth == 0 && name == "" {
+			if m := protoDefinition.FindSubmatch(trimmed); m != nil {
+				name, start = string(m[2]), offset
+				if commentStart != -1 {
+					start = commentStart
+				}
+			} else if inComment || bytes.HasPrefix(trimmed, []byte("//")) || bytes.HasPrefix(trimmed, []byte("/*")) {
+				if commentStart == -1 {
+					commentStart = offset
+				}
+			} else {
+				commentStart = -1
+			}
+		}
+
+		var sawOpen bool
+		depth, inComment, sawOpen = scanBraces(line, depth, inComment)
+		offset += len(line)
+		if name == "" {
+			continue
+		}
+		opened = opened || sawOpen
+		if opened && depth == 0 {
+			defs = append(defs, Definition{Name: name, Contents: bytes.Clone(contents[start:offset])})
+			name, opened, commentStart = "", false, -1
+		}
+	}
+	return defs
+}
+
+// scanBraces updates the brace depth with the braces on line that are outside
+// of comments and strings, and reports whether an opening brace was seen.
+func scanBraces(line []byte, depth int, inComment bool) (int, bool, bool) {
+	var sawOpen bool
+	for i := 0; i < len(line); i++ {
+		if inComment {
+			if line[i] == '*' && i+1 < len(line) && line[i+1] == '/' {
+				inComment = false
+				i++
+			}
+			continue
+		}
+		switch line[i] {
+		case '/':
+			if i+1 < len(line) && line[i+1] == '/' {
+				return depth, false, sawOpen
+			}
+			if i+1 < len(line) && line[i+1] == '*' {
+				inComment = true
+				i++
+			}
+		case '"', '\'':
+			quote := line[i]
+			for i++; i < len(line) && line[i] != quote; i++ {
+				if line[i] == '\\' {
+					i++
+				}
+			}
+		case '{':
+			depth++
+			sawOpen = true
+		case '}':
+			depth--
+		}
+	}
+	return depth, inComment, sawOpen
+}
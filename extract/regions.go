@@ -0,0 +1,64 @@
+package extract
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+)
+
+var (
+	regionStart = regexp.MustCompile(`snips:region\s+([\w.-]+)`)
+	regionEnd   = regexp.MustCompile(`snips:endregion\b`)
+)
+
+// Regions returns the regions of contents delimited by marker comments, in any
+// comment syntax, and contents with the marker lines removed, e.g.
+//
+//	// snips:region Handler
+//	func Handler() {}
+//	// snips:endregion
+//
+// Regions may be nested, marker lines are removed from the regions too.
+func Regions(contents []byte) (regions []Definition, stripped []byte, err error) {
+	type open struct {
+		name  string
+		start int
+	}
+	var (
+		stack []open
+		seen  = make(map[string]struct{})
+		lines = bytes.SplitAfter(contents, []byte("\n"))
+	)
+	stripped = make([]byte, 0, len(contents))
+	for i, line := range lines {
+		if m := regionStart.FindSubmatch(line); m != nil {
+			name := string(m[1])
+			if _, ok := seen[name]; ok {
+				return nil, contents, fmt.Errorf("line %d: duplicate region %q", i+1, name)
+			}
+			seen[name] = struct{}{}
+			stack = append(stack, open{name: name, start: len(stripped)})
+			continue
+		}
+		if regionEnd.Match(line) {
+			if len(stack) == 0 {
+				return nil, contents, fmt.Errorf("line %d: snips:endregion without a matching snips:region", i+1)
+			}
+			r := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			regions = append(regions, Definition{
+				Name:     r.name,
+				Contents: bytes.Clone(stripped[r.start:]),
+			})
+			continue
+		}
+		stripped = append(stripped, line...)
+	}
+	if len(stack) > 0 {
+		return nil, contents, fmt.Errorf("region %q is not closed, add a snips:endregion marker", stack[len(stack)-1].name)
+	}
+	if len(regions) == 0 {
+		return nil, contents, nil
+	}
+	return regions, stripped, nil
+}
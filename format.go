@@ -0,0 +1,176 @@
+package snips
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// directiveLine matches comment lines holding a snips directive, in any comment
+// syntax, spacing and case, e.g. "#snips: Redact" or "// SNIPS:end-region".
+var directiveLine = regexp.MustCompile(`^([ \t]*)(//|#|--|;+|%|/\*|<!--)[ \t]*(?i:snips)[ \t]*:[ \t]*(?i:(end[-_ ]?region|region|redact|ignore))\b[ \t]*(.*?)[ \t]*$`)
+
+// directiveClosers are the comment syntaxes that close a directive line.
+var directiveClosers = []string{"*/", "-->"}
+
+// significantTrailingWhitespace are the extensions of snippets whose trailing
+// whitespace is part of the content, such as the blank context lines of diffs
+// and the hard line breaks of Markdown.
+var significantTrailingWhitespace = []string{".diff", ".patch", ".md", ".markdown"}
+
+// Format canonicalizes a snippet, so that hand edits by different people
+// produce clean diffs:
+//
+//   - front matter keys are ordered as they're declared by FrontMatter,
+//   - trailing whitespace and trailing blank lines are trimmed, except in
+//     snippets where trailing whitespace is significant, such as diffs,
+//   - snips:region, snips:endregion, snips:redact and snips:ignore directives
+//     are spelled in lower case, with a single space after the comment syntax,
+//     e.g. "#snips: End-Region" becomes "# snips:endregion".
+//
+// The line endings of the snippet are kept.
+func Format(fileName string, contents []byte) (formatted []byte, err error) {
+	eol := "\n"
+	if first, _, ok := bytes.Cut(contents, []byte("\n")); ok && bytes.HasSuffix(first, []byte("\r")) {
+		eol = "\r\n"
+	}
+
+	_, body, err := ParseFrontMatter(contents)
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	if len(body) < len(contents) {
+		block, err := formatFrontMatter(contents[:len(contents)-len(body)])
+		if err != nil {
+			return nil, err
+		}
+		out.WriteString("---" + eol)
+		for _, line := range block {
+			out.WriteString(line + eol)
+		}
+		out.WriteString("---" + eol)
+	}
+
+	trim := !slices.Contains(significantTrailingWhitespace, filepath.Ext(stripCode(fileName)))
+	for _, line := range strings.SplitAfter(string(body), "\n") {
+		content := strings.TrimRight(line, "\r\n")
+		if trim {
+			content = strings.TrimRight(content, " \t")
+		}
+		content = formatDirective(content)
+		out.WriteString(content)
+		if strings.HasSuffix(line, "\n") {
+			out.WriteString(eol)
+		}
+	}
+
+	formatted = out.Bytes()
+	if trim {
+		end := len(bytes.TrimRight(formatted, " \t\r\n"))
+		if end < len(formatted) && end > 0 {
+			formatted = append(formatted[:end], eol...)
+		}
+	}
+	return formatted, nil
+}
+
+// formatDirective respells the snips directive of a comment line, if it has
+// one.
+func formatDirective(line string) string {
+	m := directiveLine.FindStringSubmatch(line)
+	if m == nil {
+		return line
+	}
+	indent, syntax, directive, rest := m[1], m[2], strings.ToLower(m[3]), m[4]
+	if directive != "region" && directive != "redact" && directive != "ignore" {
+		directive = "endregion"
+	}
+	closer := ""
+	for _, c := range directiveClosers {
+		if strings.HasSuffix(rest, c) {
+			rest, closer = strings.TrimRight(strings.TrimSuffix(rest, c), " \t"), " "+c
+			break
+		}
+	}
+	if rest != "" {
+		rest = " " + rest
+	}
+	return indent + syntax + " snips:" + directive + rest + closer
+}
+
+// formatFrontMatter returns the lines between the delimiters of a front matter
+// block, with its keys ordered as they're declared by FrontMatter.
+func formatFrontMatter(frontMatter []byte) (lines []string, err error) {
+	_, rest, _ := cutLine(frontMatter)
+	block := bytes.TrimSuffix(bytes.TrimRight(rest, "\r\n"), frontMatterDelimiter)
+	var doc yaml.Node
+	if err = yaml.Unmarshal(block, &doc); err != nil {
+		return nil, fmt.Errorf("invalid front matter: %w", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode || len(doc.Content[0].Content) == 0 {
+		// Keep empty and comment-only front matter as it is.
+		for _, line := range strings.SplitAfter(string(block), "\n") {
+			if line = strings.TrimRight(line, " \t\r\n"); line != "" || len(lines) > 0 {
+				lines = append(lines, line)
+			}
+		}
+		for len(lines) > 0 && lines[len(lines)-1] == "" {
+			lines = lines[:len(lines)-1]
+		}
+		return lines, nil
+	}
+
+	order := frontMatterKeys()
+	mapping := doc.Content[0]
+	type pair struct{ key, value *yaml.Node }
+	pairs := make([]pair, 0, len(mapping.Content)/2)
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		pairs = append(pairs, pair{mapping.Content[i], mapping.Content[i+1]})
+	}
+	slices.SortStableFunc(pairs, func(a, b pair) int {
+		return slices.Index(order, a.key.Value) - slices.Index(order, b.key.Value)
+	})
+	mapping.Content = mapping.Content[:0]
+	for _, p := range pairs {
+		mapping.Content = append(mapping.Content, p.key, p.value)
+	}
+
+	var b bytes.Buffer
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err = enc.Encode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to format front matter: %w", err)
+	}
+	if err = enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to format front matter: %w", err)
+	}
+	for _, line := range strings.Split(strings.TrimRight(b.String(), "\n"), "\n") {
+		lines = append(lines, strings.TrimRight(line, " \t"))
+	}
+	return lines, nil
+}
+
+// frontMatterKeys returns the front matter keys, in the order they're declared.
+func frontMatterKeys() (keys []string) {
+	t := reflect.TypeFor[FrontMatter]()
+	for i := range t.NumField() {
+		keys = append(keys, strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0])
+	}
+	return keys
+}
+
+// stripCode removes the .code part of a snippet file name, e.g. diff.code.patch
+// becomes diff.patch.
+func stripCode(fileName string) string {
+	if i := strings.LastIndex(fileName, ".code."); i >= 0 {
+		return fileName[:i] + fileName[i+len(".code"):]
+	}
+	return fileName
+}
@@ -0,0 +1,105 @@
+package snips_test
+
+import (
+	"testing"
+
+	"github.com/garrettladley/snips"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileName string
+		contents string
+		expected string
+		wantErr  bool
+	}{
+		{
+			name:     "formatted",
+			fileName: "handler.code.go",
+			contents: "---\nclass: example\nfocus: 3-5\n---\npackage main\n",
+			expected: "---\nclass: example\nfocus: 3-5\n---\npackage main\n",
+		},
+		{
+			name:     "front matter key order",
+			fileName: "handler.code.go",
+			contents: "---\nembedded: {strings: sql}\n# The anchor.\nid: handler\nredact: ['token=(\\w+)']\nclass: example\n---\npackage main\n",
+			expected: "---\nclass: example\n# The anchor.\nid: handler\nredact: ['token=(\\w+)']\nembedded: {strings: sql}\n---\npackage main\n",
+		},
+		{
+			name:     "empty front matter",
+			fileName: "config.code.yaml",
+			contents: "---\n---\n---\nkey: value\n",
+			expected: "---\n---\n---\nkey: value\n",
+		},
+		{
+			name:     "trailing whitespace",
+			fileName: "main.code.go",
+			contents: "package main \t\n\nfunc main() {}  \n\n\n",
+			expected: "package main\n\nfunc main() {}\n",
+		},
+		{
+			name:     "significant trailing whitespace",
+			fileName: "change.code.diff",
+			contents: "@@ -1,2 +1,2 @@\n \n-a\n+b\n",
+			expected: "@@ -1,2 +1,2 @@\n \n-a\n+b\n",
+		},
+		{
+			name:     "directives",
+			fileName: "main.code.go",
+			contents: "//snips: Region Handler\nfunc Handler() {}\n  //  SNIPS:end-region\n",
+			expected: "// snips:region Handler\nfunc Handler() {}\n  // snips:endregion\n",
+		},
+		{
+			name:     "directives in other comment syntaxes",
+			fileName: "page.code.html",
+			contents: "<!--snips:Redact-->\n<p>token</p>\n/*  snips:END_REGION */\n#snips:redact\n",
+			expected: "<!-- snips:redact -->\n<p>token</p>\n/* snips:endregion */\n# snips:redact\n",
+		},
+		{
+			name:     "ignore directive",
+			fileName: "main.code.go",
+			contents: "//SNIPS: Ignore\npackage main\n",
+			expected: "// snips:ignore\npackage main\n",
+		},
+		{
+			name:     "crlf line endings",
+			fileName: "main.code.go",
+			contents: "---\r\nid: main\r\nclass: example\r\n---\r\npackage main  \r\n",
+			expected: "---\r\nclass: example\r\nid: main\r\n---\r\npackage main\r\n",
+		},
+		{
+			name:     "no trailing newline",
+			fileName: "main.code.go",
+			contents: "package main  ",
+			expected: "package main",
+		},
+		{
+			name:     "unknown front matter key",
+			fileName: "main.code.go",
+			contents: "---\ncolour: red\n---\n",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			formatted, err := snips.Format(tt.fileName, []byte(tt.contents))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(formatted) != tt.expected {
+				t.Errorf("expected:\n%q\ngot:\n%q", tt.expected, formatted)
+			}
+			again, err := snips.Format(tt.fileName, formatted)
+			if err != nil || string(again) != string(formatted) {
+				t.Errorf("expected formatting to be idempotent, got %q, %v", again, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package snips
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FrontMatter holds per-snippet settings, declared in a YAML block at the top of
+// a snippet delimited by "---" lines, e.g.
+//
+//	---
+//	class: example
+//	id: example-handler
+//	---
+//	package main
+//
+// Snippets that themselves start with a "---" line (e.g. YAML documents) must
+// be preceded by a front matter block, which may be empty.
+type FrontMatter struct {
+	// Class is added to the snippet's wrapper element.
+	Class string `yaml:"class"`
+	// ID is set on the snippet's wrapper element, so it can be linked to.
+	ID string `yaml:"id"`
+	// LineNumbers overrides whether the snippet has line numbers, when set.
+	LineNumbers *bool `yaml:"line_numbers"`
+	// LineNumbersTable overrides whether the line numbers and code of the
+	// snippet are split in a table, when set.
+	LineNumbersTable *bool `yaml:"line_numbers_table"`
+	// BaseLine overrides the number of the first line of the snippet, when
+	// set, e.g. to number an excerpt as it is in the file it was taken from.
+	BaseLine *int `yaml:"base_line"`
+	// LinkableLines overrides whether the line numbers of the snippet link to
+	// themselves, when set.
+	LinkableLines *bool `yaml:"linkable_lines"`
+	// LineNumbersWidth is the minimum width of the line numbers, in characters.
+	LineNumbersWidth int `yaml:"line_numbers_width"`
+	// LineNumbersClass is added to line number elements.
+	LineNumbersClass string `yaml:"line_numbers_class"`
+	// Focus is the lines to focus on, e.g. "3-5,8", see ParseLineRanges.
+	Focus string `yaml:"focus"`
+	// WordDiff highlights the changed words within changed lines of diffs.
+	WordDiff bool `yaml:"word_diff"`
+	// Split generates a component for each definition of .proto and OpenAPI
+	// snippets, in addition to the component for the whole snippet.
+	Split bool `yaml:"split"`
+	// Redact is regular expressions matching content to replace with •••
+	// before highlighting, or only their submatches if they have capturing
+	// groups, see Redact.
+	Redact []string `yaml:"redact"`
+	// Language is the name or alias of the chroma lexer to highlight the
+	// snippet with, e.g. "go", instead of detecting it from the contents.
+	Language string `yaml:"language"`
+	// Style is the chroma style to highlight the snippet with, e.g. "monokai",
+	// a path to an XML style file, or an alias defined by -style-alias.
+	Style string `yaml:"style"`
+	// Embedded is the languages of the code embedded in the snippet, by
+	// context, e.g. {strings: sql} highlights string literals as SQL, see
+	// generator.WithEmbeddedLanguages.
+	Embedded map[string]string `yaml:"embedded"`
+	// License of third-party code, e.g. "MIT", written as a comment in the
+	// generated file, see generator.WithAttribution.
+	License string `yaml:"license"`
+	// SourceURL is where third-party code was copied from, written as a
+	// comment in the generated file, and linked to from the attribution
+	// footer, if enabled.
+	SourceURL string `yaml:"source_url"`
+	// Slug is the URL-safe name of the snippet, e.g. "http-mux", used instead
+	// of the component name for its wrapper id, unless ID is set, the anchors
+	// of its linkable lines, and its key in export manifests. See ValidSlug.
+	Slug string `yaml:"slug"`
+	// Caption describes the snippet in the doc comments of its generated
+	// components, see generator.WithCaption.
+	Caption string `yaml:"caption"`
+	// StripComments removes the comments of the snippet before highlighting,
+	// e.g. for a terse variant of verbose code, see generator.StripComments.
+	StripComments bool `yaml:"strip_comments"`
+	// CollapseBlankLines collapses runs of blank lines into a single blank
+	// line before highlighting, see generator.CollapseBlankLines.
+	CollapseBlankLines bool `yaml:"collapse_blank_lines"`
+	// TestPackage generates the snippet into a _test.go file of the external
+	// test package, e.g. views_test, for snippets only used by tests.
+	TestPackage bool `yaml:"test_package"`
+	// Ignore skips generating the snippet, e.g. while it's a work in progress,
+	// see Ignored.
+	Ignore bool `yaml:"ignore"`
+}
+
+// slugPattern matches the names that can be used unescaped in URL fragments
+// and HTML ids.
+var slugPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// ValidSlug returns an error unless slug starts with a letter or digit, and
+// only holds letters, digits, dots, hyphens and underscores.
+func ValidSlug(slug string) error {
+	if !slugPattern.MatchString(slug) {
+		return fmt.Errorf("invalid slug %q, expected letters, digits, '.', '-' and '_', starting with a letter or digit", slug)
+	}
+	return nil
+}
+
+var frontMatterDelimiter = []byte("---")
+
+// ParseFrontMatter splits the front matter from the snippet body. Contents
+// without front matter are returned unchanged.
+func ParseFrontMatter(contents []byte) (fm FrontMatter, body []byte, err error) {
+	first, rest, ok := cutLine(contents)
+	if !ok || !bytes.Equal(first, frontMatterDelimiter) {
+		return fm, contents, nil
+	}
+
+	var block []byte
+	for offset := 0; ; {
+		line, remaining, ok := cutLine(rest[offset:])
+		if bytes.Equal(line, frontMatterDelimiter) {
+			block, body = rest[:offset], remaining
+			break
+		}
+		if !ok {
+			return fm, contents, errors.New("unterminated front matter, expected a closing \"---\" line")
+		}
+		offset = len(rest) - len(remaining)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(block))
+	dec.KnownFields(true)
+	if err = dec.Decode(&fm); err != nil && !errors.Is(err, io.EOF) {
+		return fm, contents, fmt.Errorf("invalid front matter: %w", err)
+	}
+	return fm, body, nil
+}
+
+// cutLine slices s around the first newline, trimming any carriage return.
+// ok reports whether a newline was found.
+func cutLine(s []byte) (line, rest []byte, ok bool) {
+	line, rest, ok = bytes.Cut(s, []byte("\n"))
+	return bytes.TrimSuffix(line, []byte("\r")), rest, ok
+}
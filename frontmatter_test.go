@@ -0,0 +1,140 @@
+package snips_test
+
+import (
+	"testing"
+
+	"github.com/garrettladley/snips"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseFrontMatter(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		wantFM   snips.FrontMatter
+		wantBody string
+		wantErr  bool
+	}{
+		{
+			name:     "no front matter",
+			contents: "package main\n",
+			wantBody: "package main\n",
+		},
+		{
+			name:     "front matter",
+			contents: "---\nclass: example\nid: example-handler\n---\npackage main\n",
+			wantFM:   snips.FrontMatter{Class: "example", ID: "example-handler"},
+			wantBody: "package main\n",
+		},
+		{
+			name:     "redact rules",
+			contents: "---\nredact: ['token=(\\w+)']\n---\n",
+			wantFM:   snips.FrontMatter{Redact: []string{`token=(\w+)`}},
+		},
+		{
+			name:     "embedded languages",
+			contents: "---\nembedded: {strings: sql}\n---\n",
+			wantFM:   snips.FrontMatter{Embedded: map[string]string{"strings": "sql"}},
+		},
+		{
+			name:     "attribution",
+			contents: "---\nlicense: MIT\nsource_url: https://github.com/example/repo\n---\n",
+			wantFM:   snips.FrontMatter{License: "MIT", SourceURL: "https://github.com/example/repo"},
+		},
+		{
+			name:     "formatter overrides",
+			contents: "---\nline_numbers: false\nline_numbers_table: true\nbase_line: 42\nlinkable_lines: true\n---\n",
+			wantFM: snips.FrontMatter{
+				LineNumbers:      ptr(false),
+				LineNumbersTable: ptr(true),
+				BaseLine:         ptr(42),
+				LinkableLines:    ptr(true),
+			},
+		},
+		{
+			name:     "test package",
+			contents: "---\ntest_package: true\n---\n",
+			wantFM:   snips.FrontMatter{TestPackage: true},
+		},
+		{
+			name:     "caption",
+			contents: "---\ncaption: Serving HTTP with a mux\n---\n",
+			wantFM:   snips.FrontMatter{Caption: "Serving HTTP with a mux"},
+		},
+		{
+			name:     "slug",
+			contents: "---\nslug: http-mux\n---\n",
+			wantFM:   snips.FrontMatter{Slug: "http-mux"},
+		},
+		{
+			name:     "token filters",
+			contents: "---\nstrip_comments: true\ncollapse_blank_lines: true\n---\n",
+			wantFM:   snips.FrontMatter{StripComments: true, CollapseBlankLines: true},
+		},
+		{
+			name:     "scalar focus",
+			contents: "---\nfocus: 8\n---\n",
+			wantFM:   snips.FrontMatter{Focus: "8"},
+			wantBody: "",
+		},
+		{
+			name:     "crlf line endings",
+			contents: "---\r\nclass: example\r\n---\r\npackage main\r\n",
+			wantFM:   snips.FrontMatter{Class: "example"},
+			wantBody: "package main\r\n",
+		},
+		{
+			name:     "empty front matter",
+			contents: "---\n---\n---\nkey: value\n",
+			wantBody: "---\nkey: value\n",
+		},
+		{
+			name:     "unterminated",
+			contents: "---\nclass: example\n",
+			wantErr:  true,
+		},
+		{
+			name:     "unknown key",
+			contents: "---\nclas: example\n---\n",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fm, body, err := snips.ParseFrontMatter([]byte(tt.contents))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := cmp.Diff(tt.wantFM, fm); diff != "" {
+				t.Errorf("unexpected front matter (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantBody, string(body)); diff != "" {
+				t.Errorf("unexpected body (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestValidSlug(t *testing.T) {
+	for _, slug := range []string{"http-mux", "v1.2_example", "A1"} {
+		if err := snips.ValidSlug(slug); err != nil {
+			t.Errorf("expected %q to be valid, got %v", slug, err)
+		}
+	}
+	for _, slug := range []string{"", "-mux", "http mux", "mux#1", "caf\u00e9"} {
+		if err := snips.ValidSlug(slug); err == nil {
+			t.Errorf("expected %q to be invalid", slug)
+		}
+	}
+}
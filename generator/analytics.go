@@ -0,0 +1,40 @@
+package generator
+
+import (
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+)
+
+const (
+	// nameAttribute is the wrapper attribute naming the component, e.g.
+	// data-snips-name="HandlerGo".
+	nameAttribute = "data-snips-name"
+	// langAttribute is the wrapper attribute naming the language of the
+	// component, e.g. data-snips-lang="go".
+	langAttribute = "data-snips-lang"
+)
+
+// WithAnalyticsAttributes adds data-snips-name and data-snips-lang attributes
+// to the wrapper of each component, naming the component and its language, so
+// that product analytics can track which snippets readers view or copy.
+func WithAnalyticsAttributes() GenerateOpt {
+	return func(g *generator) error {
+		g.analytics = true
+		return nil
+	}
+}
+
+// analyticsLanguage returns the data-snips-lang value of a lexer, its first
+// alias, e.g. "go", or its lower case name if it has none. Snippets that
+// aren't recognised are "text".
+func analyticsLanguage(lexer chroma.Lexer) string {
+	config := lexer.Config()
+	if config.Name == "fallback" {
+		return "text"
+	}
+	if len(config.Aliases) > 0 {
+		return config.Aliases[0]
+	}
+	return strings.ToLower(config.Name)
+}
@@ -0,0 +1,53 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/chroma/v2/formatters/html"
+)
+
+func TestAnalyticsAttributes(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		expected string
+	}{
+		{
+			name:     "language",
+			contents: "#!/bin/bash\necho hello\n",
+			expected: `<div data-snips-name=\"Example\" data-snips-lang=\"bash\">`,
+		},
+		{
+			name:     "plain text",
+			contents: "hello\n",
+			expected: `<div data-snips-name=\"Example\" data-snips-lang=\"text\">`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := generator{f: html.New(), contents: []byte(tt.contents), componentName: "Example"}
+			if err := WithAnalyticsAttributes()(&g); err != nil {
+				t.Fatal(err)
+			}
+			s, err := g.chroma()
+			if err != nil {
+				t.Fatalf("failed to highlight: %v", err)
+			}
+			if !strings.HasPrefix(s, tt.expected) {
+				t.Errorf("expected output to start with %s, got:\n%s", tt.expected, s)
+			}
+		})
+	}
+
+	t.Run("omitted by default", func(t *testing.T) {
+		g := generator{f: html.New(), contents: []byte("hello\n"), componentName: "Example"}
+		s, err := g.chroma()
+		if err != nil {
+			t.Fatalf("failed to highlight: %v", err)
+		}
+		if strings.Contains(s, "data-snips-") {
+			t.Errorf("expected no analytics attributes, got:\n%s", s)
+		}
+	})
+}
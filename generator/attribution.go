@@ -0,0 +1,115 @@
+package generator
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"strings"
+
+	"github.com/garrettladley/snips/runtime"
+)
+
+const (
+	// attributedClass is added to the wrapper of snippets with an attribution
+	// footer.
+	attributedClass = "snips-attributed"
+	// attributionClass is the class of the attribution footer.
+	attributionClass = "snips-attribution"
+)
+
+// attributionCSS styles the attribution footer as a small caption below the
+// code.
+const attributionCSS = `<style>` +
+	`.` + attributionClass + `{padding:.25em .5em;font-size:.75em;opacity:.7;text-align:right}` +
+	`</style>`
+
+// Attribution is where a snippet was copied from, and its license, for
+// third-party code that must be credited.
+type Attribution struct {
+	// License of the snippet, e.g. "MIT" or "Apache-2.0".
+	License string
+	// SourceURL is the http or https URL the snippet was copied from.
+	SourceURL string
+}
+
+// Validate checks that the attribution can be written as comments and linked
+// to.
+func (a Attribution) Validate() error {
+	if strings.ContainsAny(a.License, "\r\n") {
+		return fmt.Errorf("license %q must be a single line", a.License)
+	}
+	if a.SourceURL == "" {
+		return nil
+	}
+	u, err := url.Parse(a.SourceURL)
+	if err != nil {
+		return fmt.Errorf("invalid source URL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("source URL %q must be an absolute http or https URL", a.SourceURL)
+	}
+	return nil
+}
+
+// WithAttribution records the license and source of the snippet as comments in
+// the generated file, and in a footer below the code if WithAttributionFooter
+// is set.
+func WithAttribution(a Attribution) GenerateOpt {
+	return func(g *generator) error {
+		a.License, a.SourceURL = strings.TrimSpace(a.License), strings.TrimSpace(a.SourceURL)
+		if err := a.Validate(); err != nil {
+			return err
+		}
+		g.attribution = a
+		return nil
+	}
+}
+
+// WithAttributionFooter renders a footer below the code of snippets with an
+// attribution, linking to the source and naming the license. Its text is
+// localized by WithMessages.
+func WithAttributionFooter() GenerateOpt {
+	return func(g *generator) error {
+		g.attributionFooter = true
+		return nil
+	}
+}
+
+func (g *generator) writeAttributionComment() (err error) {
+	if g.attribution.License != "" {
+		if _, err = g.w.Write("// snips: license: " + g.attribution.License + "\n"); err != nil {
+			return err
+		}
+	}
+	if g.attribution.SourceURL != "" {
+		_, err = g.w.Write("// snips: source: " + g.attribution.SourceURL + "\n")
+	}
+	return err
+}
+
+// hasAttributionFooter reports whether the snippet is rendered with an
+// attribution footer.
+func (g *generator) hasAttributionFooter() bool {
+	return g.attributionFooter && g.attribution != (Attribution{})
+}
+
+// attributionFooterHTML returns the HTML of the attribution footer, e.g.
+// "Source: github.com/org/repo (MIT)", localized by the messages.
+func (g *generator) attributionFooterHTML() string {
+	var sb strings.Builder
+	sb.WriteString(g.inlineCSS(attributionCSS))
+	sb.WriteString(`<div class="` + attributionClass + `">`)
+	if g.attribution.SourceURL != "" {
+		label := strings.TrimPrefix(strings.TrimPrefix(g.attribution.SourceURL, "https://"), "http://")
+		sb.WriteString(html.EscapeString(g.messages.Get(runtime.MessageSource)) + ` <a href="` + html.EscapeString(g.attribution.SourceURL) + `" rel="noopener">` + html.EscapeString(label) + `</a>`)
+	}
+	if g.attribution.License != "" {
+		if g.attribution.SourceURL != "" {
+			sb.WriteString(" (" + html.EscapeString(g.attribution.License) + ")")
+		} else {
+			sb.WriteString(html.EscapeString(g.messages.Get(runtime.MessageLicense)) + " " + html.EscapeString(g.attribution.License))
+		}
+	}
+	sb.WriteString(`</div>`)
+	return sb.String()
+}
@@ -0,0 +1,86 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/garrettladley/snips/runtime"
+)
+
+func TestAttribution(t *testing.T) {
+	attribution := Attribution{License: "MIT", SourceURL: "https://github.com/example/repo?a=1&b=2"}
+
+	t.Run("comments", func(t *testing.T) {
+		var b strings.Builder
+		_, err := Generate(&b, Config{
+			Contents:      []byte("package main\n"),
+			PackageName:   "main",
+			ComponentName: "Main",
+		}, WithAttribution(attribution))
+		if err != nil {
+			t.Fatalf("failed to generate: %v", err)
+		}
+		expected := "// snips: license: MIT\n// snips: source: https://github.com/example/repo?a=1&b=2\n"
+		if !strings.Contains(b.String(), expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, b.String())
+		}
+		if strings.Contains(b.String(), attributionClass) {
+			t.Errorf("expected no footer without WithAttributionFooter, got:\n%s", b.String())
+		}
+	})
+
+	t.Run("footer", func(t *testing.T) {
+		g := generator{f: html.New(), contents: []byte("package main\n")}
+		for _, opt := range []GenerateOpt{WithAttribution(attribution), WithAttributionFooter()} {
+			if err := opt(&g); err != nil {
+				t.Fatal(err)
+			}
+		}
+		s, err := g.chroma()
+		if err != nil {
+			t.Fatalf("failed to highlight: %v", err)
+		}
+		if !strings.HasPrefix(s, `<div class=\"snips-attributed\">`) {
+			t.Errorf("expected an attributed wrapper, got:\n%s", s)
+		}
+		expected := `<div class=\"snips-attribution\">Source: <a href=\"https://github.com/example/repo?a=1&amp;b=2\" rel=\"noopener\">github.com/example/repo?a=1&amp;b=2</a> (MIT)</div></div>`
+		if !strings.HasSuffix(s, expected) {
+			t.Errorf("expected output to end with %s, got:\n%s", expected, s)
+		}
+	})
+
+	t.Run("localized footer", func(t *testing.T) {
+		g := generator{f: html.New(), contents: []byte("package main\n")}
+		for _, opt := range []GenerateOpt{
+			WithAttribution(Attribution{License: "MIT"}),
+			WithAttributionFooter(),
+			WithMessages(runtime.Messages{runtime.MessageLicense: "Lizenz & Co:"}),
+		} {
+			if err := opt(&g); err != nil {
+				t.Fatal(err)
+			}
+		}
+		s, err := g.chroma()
+		if err != nil {
+			t.Fatalf("failed to highlight: %v", err)
+		}
+		expected := `<div class=\"snips-attribution\">Lizenz &amp; Co: MIT</div></div>`
+		if !strings.HasSuffix(s, expected) {
+			t.Errorf("expected output to end with %s, got:\n%s", expected, s)
+		}
+		if err := WithMessages(runtime.Messages{"truncated": "..."})(&generator{}); err == nil {
+			t.Error("expected an unknown message to be invalid")
+		}
+	})
+
+	for _, invalid := range []Attribution{
+		{SourceURL: "javascript:alert(1)"},
+		{SourceURL: "/relative"},
+		{License: "MIT\npackage evil"},
+	} {
+		if err := WithAttribution(invalid)(&generator{}); err == nil {
+			t.Errorf("expected an error for %+v", invalid)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package generator
+
+const (
+	// badgedClass is added to the wrapper of snippets with a language badge.
+	badgedClass = "snips-badged"
+	// badgeClass is the class of the language badge.
+	badgeClass = "snips-badge"
+)
+
+// badgeCSS positions the language badge in the top right corner of the wrapper.
+const badgeCSS = `<style>` +
+	`.` + badgedClass + `{position:relative}` +
+	`.` + badgeClass + `{position:absolute;top:.5em;right:.5em;padding:.1em .5em;border-radius:.25em;font-size:.75em;line-height:1.5;opacity:.7;pointer-events:none;user-select:none}` +
+	`</style>`
+
+// languageLabels are the display names of lexers whose names aren't how the
+// language is usually written.
+var languageLabels = map[string]string{
+	"Bash":                   "Shell",
+	"Bash Session":           "Shell",
+	"Docker":                 "Dockerfile",
+	"EmacsLisp":              "Emacs Lisp",
+	"Go HTML Template":       "Go Template",
+	"Go Text Template":       "Go Template",
+	"PostgreSQL SQL dialect": "SQL",
+	"Protocol Buffer":        "Protobuf",
+	"markdown":               "Markdown",
+	"reStructuredText":       "reST",
+	"fallback":               "",
+	"plaintext":              "",
+}
+
+// LanguageLabel returns the badge label of the lexer with the given name, e.g.
+// "Go" or "Protobuf". Plain text has no label.
+func LanguageLabel(lexerName string) string {
+	if label, ok := languageLabels[lexerName]; ok {
+		return label
+	}
+	return lexerName
+}
+
+// WithLanguageBadge renders a badge naming the language of the snippet in the
+// top right corner of its wrapper. label maps the name of the chroma lexer to
+// the text of the badge, and defaults to LanguageLabel. Snippets are rendered
+// without a badge when label returns an empty string.
+func WithLanguageBadge(label func(lexerName string) string) GenerateOpt {
+	return func(g *generator) error {
+		if label == nil {
+			label = LanguageLabel
+		}
+		g.badgeLabel = label
+		return nil
+	}
+}
@@ -0,0 +1,61 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/chroma/v2/formatters/html"
+)
+
+func TestLanguageBadge(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		label    func(lexerName string) string
+		expected string
+	}{
+		{
+			name:     "default label",
+			contents: "#!/bin/bash\necho hello\n",
+			expected: `<span class=\"snips-badge\" aria-hidden=\"true\">Shell</span>`,
+		},
+		{
+			name:     "custom label",
+			contents: "#!/bin/bash\necho hello\n",
+			label:    func(lexerName string) string { return "<" + lexerName + ">" },
+			expected: `<span class=\"snips-badge\" aria-hidden=\"true\">&lt;Bash&gt;</span>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := generator{f: html.New(), contents: []byte(tt.contents)}
+			if err := WithLanguageBadge(tt.label)(&g); err != nil {
+				t.Fatal(err)
+			}
+			s, err := g.chroma()
+			if err != nil {
+				t.Fatalf("failed to highlight: %v", err)
+			}
+			if !strings.HasPrefix(s, `<div class=\"snips-badged\">`) {
+				t.Errorf("expected a badged wrapper, got:\n%s", s)
+			}
+			if !strings.Contains(s, tt.expected) {
+				t.Errorf("expected output to contain %s, got:\n%s", tt.expected, s)
+			}
+		})
+	}
+
+	t.Run("omitted for plain text", func(t *testing.T) {
+		g := generator{f: html.New(), contents: []byte("hello\n")}
+		if err := WithLanguageBadge(nil)(&g); err != nil {
+			t.Fatal(err)
+		}
+		s, err := g.chroma()
+		if err != nil {
+			t.Fatalf("failed to highlight: %v", err)
+		}
+		if strings.Contains(s, "snips-badge") {
+			t.Errorf("expected no badge, got:\n%s", s)
+		}
+	})
+}
@@ -0,0 +1,71 @@
+package generator
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/garrettladley/snips/internal/bidi"
+)
+
+// Markers isolating right-to-left text, built from private use code points
+// which chroma's HTML formatter passes through unescaped.
+const (
+	isolateStart = "\uE005"
+	isolateEnd   = "\uE006"
+)
+
+// bidiControlClass is the class of the visible replacements of bidi controls.
+const bidiControlClass = "snips-bidi-control"
+
+// bidiReplacer isolates the marked right-to-left text, and replaces bidi
+// controls with a visible label, e.g. RLO, so that they can't reorder the code.
+var bidiReplacer = func() *strings.Replacer {
+	replacements := []string{isolateStart, "<bdi>", isolateEnd, "</bdi>"}
+	for r, abbr := range bidi.Controls {
+		replacements = append(replacements, string(r),
+			fmt.Sprintf(`<span class="%s" title="U+%04X" style="border:1px solid;border-radius:2px;font-size:.75em">%s</span>`, bidiControlClass, r, abbr),
+		)
+	}
+	return strings.NewReplacer(replacements...)
+}()
+
+// WithBidiSafety renders the code left-to-right regardless of the page, isolates
+// right-to-left text such as Arabic or Hebrew comments so that it can't reorder
+// the code around it, and shows bidi control characters instead of applying
+// them.
+func WithBidiSafety() GenerateOpt {
+	return func(g *generator) error {
+		g.bidiSafe = true
+		return nil
+	}
+}
+
+// isolateRTL marks each line of the tokens containing right-to-left text for
+// isolation. Lines are isolated separately, since the formatter splits tokens
+// into lines.
+func isolateRTL(tokens []chroma.Token) []chroma.Token {
+	out := make([]chroma.Token, 0, len(tokens))
+	for _, tok := range tokens {
+		if strings.IndexFunc(tok.Value, isRTL) < 0 {
+			out = append(out, tok)
+			continue
+		}
+		lines := strings.SplitAfter(tok.Value, "\n")
+		for i, line := range lines {
+			content := strings.TrimSuffix(line, "\n")
+			if strings.IndexFunc(content, isRTL) >= 0 {
+				lines[i] = isolateStart + content + isolateEnd + line[len(content):]
+			}
+		}
+		out = append(out, chroma.Token{Type: tok.Type, Value: strings.Join(lines, "")})
+	}
+	return out
+}
+
+// isRTL reports whether r is a letter of a right-to-left script.
+func isRTL(r rune) bool {
+	return unicode.In(r, unicode.Arabic, unicode.Hebrew, unicode.Syriac, unicode.Thaana,
+		unicode.Nko, unicode.Samaritan, unicode.Mandaic, unicode.Adlam)
+}
@@ -0,0 +1,41 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/chroma/v2/formatters/html"
+)
+
+func TestBidiSafety(t *testing.T) {
+	// A Trojan Source style comment, with a right-to-left override.
+	contents := "package main\n\n// שלום\nvar access = \"user\u202e \u2066// admin\u2069 \u2066\"\n"
+	g := generator{f: html.New(), contents: []byte(contents)}
+	if err := WithBidiSafety()(&g); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := g.chroma()
+	if err != nil {
+		t.Fatalf("failed to highlight: %v", err)
+	}
+	if !strings.HasPrefix(s, `<div dir=\"ltr\">`) {
+		t.Errorf("expected a left-to-right wrapper, got:\n%s", s)
+	}
+	for _, expected := range []string{
+		`<bdi>שלום</bdi>`,
+		`title=\"U+202E\"`,
+		`>RLO</span>`,
+		`>LRI</span>`,
+		`>PDI</span>`,
+	} {
+		if !strings.Contains(s, expected) {
+			t.Errorf("expected output to contain %s, got:\n%s", expected, s)
+		}
+	}
+	for _, control := range []string{"\u202e", "\u2066", "\u2069"} {
+		if strings.Contains(s, control) {
+			t.Errorf("expected %U to be replaced, got:\n%s", []rune(control)[0], s)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package generator
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+)
+
+// The sources of a Detection.
+const (
+	// DetectedByAnalysis is the source of lexers picked by analysing the
+	// contents, which may guess wrong, especially for short snippets.
+	DetectedByAnalysis = "analysis"
+	// DetectedByLanguage is the source of lexers set by WithLanguage, or
+	// Config.Lang.
+	DetectedByLanguage = "language"
+	// DetectedByModeline is the source of lexers named by a vim or Emacs
+	// modeline, e.g. "# vim: ft=python".
+	DetectedByModeline = "modeline"
+	// DetectedByShebang is the source of lexers picked by the interpreter of
+	// a shebang line, e.g. "#!/usr/bin/env python3".
+	DetectedByShebang = "shebang"
+	// DetectedByFileName is the source of lexers matching the file name of
+	// the snippet without .code, e.g. main.go for main.code.go.
+	DetectedByFileName = "filename"
+	// DetectedByFallback is the source of the plain text lexer, used when
+	// analysis doesn't recognise the contents.
+	DetectedByFallback = "fallback"
+)
+
+// Detection is how the lexer of a component was picked.
+type Detection struct {
+	// Lexer is the name of the chroma lexer, e.g. "Go".
+	Lexer string
+	// Confidence is the weight given to the lexer by analysis, from 0 to 1.
+	Confidence float32
+	// Source is how the lexer was picked, e.g. DetectedByAnalysis.
+	Source string
+}
+
+// WithLanguage highlights the snippet with the chroma lexer of the given name
+// or alias, e.g. "go" or "Protocol Buffer", instead of picking it by analysing
+// the contents.
+func WithLanguage(name string) GenerateOpt {
+	return func(g *generator) error {
+		lexer := lexers.Get(name)
+		if lexer == nil {
+			return fmt.Errorf("unknown language %q", name)
+		}
+		g.language = lexer
+		return nil
+	}
+}
+
+// WithDetectionReport calls report with how the lexer of each generated
+// component was picked.
+func WithDetectionReport(report func(componentName string, d Detection)) GenerateOpt {
+	return func(g *generator) error {
+		g.reportDetection = report
+		return nil
+	}
+}
+
+// lexer returns the lexer to highlight contents with.
+func (g *generator) lexer(contents string) chroma.Lexer {
+	lexer := g.language
+	d := Detection{Source: DetectedByLanguage, Confidence: 1}
+	if lexer == nil {
+		lexer, d.Source = modelineLexer(contents), DetectedByModeline
+	}
+	if lexer == nil {
+		lexer, d.Source = shebangLexer(contents), DetectedByShebang
+	}
+	if lexer == nil {
+		lexer, d.Source = fileNameLexer(g.fileName), DetectedByFileName
+	}
+	if lexer == nil {
+		lexer, d.Confidence = analyse(contents)
+		d.Source = DetectedByAnalysis
+	}
+	if lexer == nil {
+		lexer, d.Confidence, d.Source = lexers.Fallback, 0, DetectedByFallback
+	}
+	d.Lexer = lexer.Config().Name
+	if g.reportDetection != nil {
+		g.reportDetection(g.componentName, d)
+	}
+	return lexer
+}
+
+// modelineLines is the number of lines at the start and end of the contents
+// searched for vim modelines, as vim does by default.
+const modelineLines = 5
+
+var (
+	// vimModeline matches vim modelines setting the filetype, e.g.
+	// "vim: set ft=python:" or "vi: syntax=sh".
+	vimModeline = regexp.MustCompile(`(?:^|\s)(?:vi|vim|ex):.*?\b(?:ft|filetype|syn|syntax)=([\w+-]+)`)
+	// emacsModeline matches Emacs modelines naming the mode, e.g.
+	// "-*- mode: python -*-" or "-*- python -*-".
+	emacsModeline = regexp.MustCompile(`-\*-\s*(?:.*?\bmode:\s*([\w+-]+)|([\w+-]+))\s*(?:;.*?)?-\*-`)
+)
+
+// modelineLexer returns the lexer named by a modeline, if any. Emacs modelines
+// are on the first line, or the second after a shebang, and vim modelines on
+// the first or last lines.
+func modelineLexer(contents string) chroma.Lexer {
+	lines := strings.Split(strings.TrimRight(contents, "\n"), "\n")
+	for i, line := range lines {
+		if i > 1 || i == 1 && !strings.HasPrefix(lines[0], "#!") {
+			break
+		}
+		if m := emacsModeline.FindStringSubmatch(line); m != nil {
+			name := m[1] + m[2]
+			if lexer := lexers.Get(name); lexer != nil {
+				return lexer
+			}
+		}
+	}
+	for i, line := range lines {
+		if i >= modelineLines && i < len(lines)-modelineLines {
+			continue
+		}
+		if m := vimModeline.FindStringSubmatch(line); m != nil {
+			if lexer := lexers.Get(m[1]); lexer != nil {
+				return lexer
+			}
+		}
+	}
+	return nil
+}
+
+// interpreters are the languages of interpreters whose names aren't chroma
+// lexer names or aliases.
+var interpreters = map[string]string{
+	"node":    "javascript",
+	"nodejs":  "javascript",
+	"bun":     "javascript",
+	"deno":    "typescript",
+	"ts-node": "typescript",
+	"dash":    "bash",
+	"Rscript": "r",
+	"tclsh":   "tcl",
+	"wish":    "tcl",
+	"guile":   "scheme",
+	"sbcl":    "common-lisp",
+}
+
+// shebangLexer returns the lexer of the interpreter named by a shebang line,
+// if any, e.g. Python for "#!/usr/bin/env python3". Versions are ignored when
+// they aren't part of a lexer name, e.g. python3.12 is Python.
+func shebangLexer(contents string) chroma.Lexer {
+	line, ok := strings.CutPrefix(strings.SplitN(contents, "\n", 2)[0], "#!")
+	if !ok {
+		return nil
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+	interpreter := path.Base(fields[0])
+	if interpreter == "env" {
+		// Skip env's options and variables, e.g. env -S VAR=1 python3.
+		interpreter = ""
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") && !strings.Contains(field, "=") {
+				interpreter = path.Base(field)
+				break
+			}
+		}
+	}
+	if interpreter == "" {
+		return nil
+	}
+	for _, name := range []string{interpreter, strings.TrimRight(interpreter, "0123456789.")} {
+		if language, ok := interpreters[name]; ok {
+			name = language
+		}
+		if lexer := lexers.Get(name); lexer != nil {
+			return lexer
+		}
+	}
+	return nil
+}
+
+// fileNameLexer returns the lexer matching the file name of a snippet without
+// .code, if any, e.g. Go for main.code.go.
+func fileNameLexer(fileName string) chroma.Lexer {
+	if fileName == "" {
+		return nil
+	}
+	name := path.Base(filepath.ToSlash(fileName))
+	if i := strings.LastIndex(name, ".code."); i >= 0 {
+		name = name[:i] + name[i+len(".code"):]
+	}
+	return lexers.Match(name)
+}
+
+// analyse returns the lexer that lexers.Analyse picks for text, and its
+// weight.
+func analyse(text string) (picked chroma.Lexer, weight float32) {
+	for _, lexer := range lexers.GlobalLexerRegistry.Lexers {
+		analyser, ok := lexer.(chroma.Analyser)
+		if !ok {
+			continue
+		}
+		if w := analyser.AnalyseText(text); w > weight {
+			picked, weight = lexer, w
+		}
+	}
+	return picked, weight
+}
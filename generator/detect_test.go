@@ -0,0 +1,154 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDetectionReport(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		fileName string
+		lang     string
+		opts     []GenerateOpt
+		expected Detection
+	}{
+		{
+			name:     "analysis",
+			contents: "package main\n\nimport \"fmt\"\n\nfunc main() { fmt.Println() }\n",
+			expected: Detection{Lexer: "Go", Confidence: 0.5, Source: DetectedByAnalysis},
+		},
+		{
+			name:     "language",
+			contents: "x = 1\n",
+			opts:     []GenerateOpt{WithLanguage("python")},
+			expected: Detection{Lexer: "Python", Confidence: 1, Source: DetectedByLanguage},
+		},
+		{
+			name:     "config language",
+			contents: "x = 1\n",
+			lang:     "python",
+			expected: Detection{Lexer: "Python", Confidence: 1, Source: DetectedByLanguage},
+		},
+		{
+			name:     "language overrides config",
+			contents: "x = 1\n",
+			lang:     "python",
+			opts:     []GenerateOpt{WithLanguage("ruby")},
+			expected: Detection{Lexer: "Ruby", Confidence: 1, Source: DetectedByLanguage},
+		},
+		{
+			name:     "file name",
+			contents: "x = 1\n",
+			fileName: "views/main.code.py",
+			expected: Detection{Lexer: "Python", Confidence: 1, Source: DetectedByFileName},
+		},
+		{
+			name:     "modeline overrides file name",
+			contents: "# vim: ft=ruby\nx = 1\n",
+			fileName: "views/main.code.py",
+			expected: Detection{Lexer: "Ruby", Confidence: 1, Source: DetectedByModeline},
+		},
+		{
+			name:     "unknown file name",
+			contents: "package main\n\nimport \"fmt\"\n\nfunc main() { fmt.Println() }\n",
+			fileName: "views/main.code.snip",
+			expected: Detection{Lexer: "Go", Confidence: 0.5, Source: DetectedByAnalysis},
+		},
+		{
+			name:     "shebang",
+			contents: "#!/usr/bin/env python3\nprint('hi')\n",
+			expected: Detection{Lexer: "Python", Confidence: 1, Source: DetectedByShebang},
+		},
+		{
+			name:     "modeline",
+			contents: "#!/bin/sh\n# vim: set ts=2 ft=zsh:\necho hi\n",
+			expected: Detection{Lexer: "Bash", Confidence: 1, Source: DetectedByModeline},
+		},
+		{
+			name:     "fallback",
+			contents: "Hello\n",
+			expected: Detection{Lexer: "fallback", Source: DetectedByFallback},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make(map[string]Detection)
+			opts := append(tt.opts, WithDetectionReport(func(componentName string, d Detection) {
+				got[componentName] = d
+			}))
+			var b strings.Builder
+			_, err := Generate(&b, Config{
+				Contents:      []byte(tt.contents),
+				PackageName:   "main",
+				ComponentName: "Main",
+				Lang:          tt.lang,
+				FileName:      tt.fileName,
+			}, opts...)
+			if err != nil {
+				t.Fatalf("failed to generate: %v", err)
+			}
+			if diff := cmp.Diff(map[string]Detection{"Main": tt.expected}, got); diff != "" {
+				t.Errorf("unexpected detection:\n%s", diff)
+			}
+		})
+	}
+
+	if err := WithLanguage("not-a-language")(&generator{}); err == nil {
+		t.Error("expected an error for an unknown language")
+	}
+}
+
+func TestShebangLexer(t *testing.T) {
+	tests := []struct {
+		contents string
+		expected string
+	}{
+		{contents: "#!/usr/bin/env python3\n", expected: "Python"},
+		{contents: "#!/usr/bin/python3.12 -u\n", expected: "Python"},
+		{contents: "#!/usr/bin/env -S VAR=1 node --harmony\n", expected: "JavaScript"},
+		{contents: "#!/bin/bash\necho hi\n", expected: "Bash"},
+		{contents: "#! /usr/bin/env ruby\n", expected: "Ruby"},
+		{contents: "#!/usr/bin/env\n"},
+		{contents: "#!/usr/bin/unknown-interpreter\n"},
+		{contents: "echo hi\n#!/bin/bash\n"},
+	}
+	for _, tt := range tests {
+		var got string
+		if lexer := shebangLexer(tt.contents); lexer != nil {
+			got = lexer.Config().Name
+		}
+		if got != tt.expected {
+			t.Errorf("%q: expected %q, got %q", tt.contents, tt.expected, got)
+		}
+	}
+}
+
+func TestModelineLexer(t *testing.T) {
+	tests := []struct {
+		contents string
+		expected string
+	}{
+		{contents: "# -*- mode: python; coding: utf-8 -*-\n", expected: "Python"},
+		{contents: "#!/bin/sh\n# -*- ruby -*-\n", expected: "Ruby"},
+		{contents: "x\n# -*- ruby -*-\n"},
+		{contents: "# -*- coding: utf-8 -*-\n"},
+		{contents: "// vim: set ts=4 filetype=go:\n", expected: "Go"},
+		{contents: "a\nb\nc\nd\ne\nf\ng\n# vi: ft=lua\n", expected: "Lua"},
+		{contents: "# vim: ft=lua\na\nb\nc\nd\ne\nf\n", expected: "Lua"},
+		{contents: "a\nb\nc\nd\ne\n# vim: ft=lua\nf\ng\nh\ni\nj\nk\n"},
+		{contents: "// regex: ft=lua\n"},
+	}
+	for _, tt := range tests {
+		var got string
+		if lexer := modelineLexer(tt.contents); lexer != nil {
+			got = lexer.Config().Name
+		}
+		if got != tt.expected {
+			t.Errorf("%q: expected %q, got %q", tt.contents, tt.expected, got)
+		}
+	}
+}
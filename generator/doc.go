@@ -0,0 +1,79 @@
+package generator
+
+import (
+	"strconv"
+	"strings"
+)
+
+// WithSource names the snippet that components are generated from in their
+// doc comments, e.g. views/handler.code.go.
+func WithSource(fileName string) GenerateOpt {
+	return func(g *generator) error {
+		g.source = fileName
+		return nil
+	}
+}
+
+// WithCaption adds a caption to the doc comments of components, e.g. "Serving
+// HTTP with a mux". Its lines are joined into a single paragraph, since gofmt
+// would turn short lines of their own into headings.
+func WithCaption(caption string) GenerateOpt {
+	return func(g *generator) error {
+		g.caption = strings.Join(strings.Fields(caption), " ")
+		return nil
+	}
+}
+
+// docComment returns the doc comment of the component being generated, which
+// describes its language, source and line count, so that go doc and gopls
+// hovers show what it renders, e.g.
+//
+//	// HandlerGo renders the highlighted Go code of views/handler.code.go, 12 lines.
+//
+// Control characters of the source and caption are escaped, so that they
+// can't end the comment.
+func (g *generator) docComment() string {
+	var b []byte
+	b = append(b, "// "+g.componentName+" renders the highlighted "...)
+	if lexer := g.lexerNames[g.componentName]; lexer != "" {
+		b = appendEscapedText(b, lexer)
+		b = append(b, ' ')
+	}
+	b = append(b, "code"...)
+	if g.source != "" {
+		b = append(b, " of "...)
+		b = appendEscapedText(b, g.source)
+	}
+	lines := lineCount(expandVariables(string(g.contents), g.vars))
+	b = append(b, ", "+strconv.Itoa(lines)...)
+	if lines == 1 {
+		b = append(b, " line.\n"...)
+	} else {
+		b = append(b, " lines.\n"...)
+	}
+	if g.caption != "" {
+		b = append(b, "//\n// "...)
+		b = appendEscapedText(b, g.caption)
+		b = append(b, '\n')
+	}
+	return string(b)
+}
+
+// writeDocComment writes the doc comment of the component being generated,
+// unless the layout predates them.
+func (g *generator) writeDocComment() (err error) {
+	if g.compat == "v0.1" {
+		return nil
+	}
+	_, err = g.w.Write(g.docComment())
+	return err
+}
+
+// lineCount returns the number of lines of text, not counting the empty line
+// after a trailing newline.
+func lineCount(text string) int {
+	if text == "" {
+		return 0
+	}
+	return strings.Count(strings.TrimSuffix(text, "\n"), "\n") + 1
+}
@@ -0,0 +1,66 @@
+package generator
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestDocComments(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		opts     []GenerateOpt
+		expected string
+	}{
+		{
+			name:     "language and line count",
+			contents: "package main\n\nfunc main() {}\n",
+			opts:     []GenerateOpt{WithLanguage("go")},
+			expected: "// Main renders the highlighted Go code, 3 lines.\n",
+		},
+		{
+			name:     "source and caption",
+			contents: "x = 1",
+			opts:     []GenerateOpt{WithLanguage("python"), WithSource("views/x.code.py"), WithCaption("  Assigning\n a variable ")},
+			expected: "// Main renders the highlighted Python code of views/x.code.py, 1 line.\n//\n// Assigning a variable\n",
+		},
+		{
+			name:     "control characters",
+			contents: "x\n",
+			opts:     []GenerateOpt{WithLanguage("text"), WithSource("a\x1b.code.txt"), WithCaption("*/ \u202e")},
+			expected: "// Main renders the highlighted plaintext code of a\\x1b.code.txt, 1 line.\n//\n// */ \\u202e\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			_, err := Generate(&b, Config{
+				Contents:      []byte(tt.contents),
+				PackageName:   "main",
+				ComponentName: "Main",
+			}, tt.opts...)
+			if err != nil {
+				t.Fatalf("failed to generate: %v", err)
+			}
+			if !strings.Contains(b.String(), tt.expected+"func Main() templ.Component {") {
+				t.Errorf("expected the component to be documented with %q, got:\n%s", tt.expected, b.String())
+			}
+			if _, err = parser.ParseFile(token.NewFileSet(), "main.go", b.String(), parser.ParseComments); err != nil {
+				t.Fatalf("generated invalid Go: %v\n%s", err, b.String())
+			}
+		})
+	}
+
+	t.Run("compat", func(t *testing.T) {
+		var b strings.Builder
+		_, err := Generate(&b, Config{Contents: []byte("x\n"), PackageName: "main", ComponentName: "Main"}, WithCompat("v0.1"), WithSource("x.code.txt"))
+		if err != nil {
+			t.Fatalf("failed to generate: %v", err)
+		}
+		if strings.Contains(b.String(), "// Main renders") {
+			t.Errorf("expected no doc comment in the v0.1 layout, got:\n%s", b.String())
+		}
+	})
+}
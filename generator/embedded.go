@@ -0,0 +1,188 @@
+package generator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+)
+
+// embeddedContexts are where embedded languages can be highlighted: the
+// string literals of any language, and the contents of HTML <script> and
+// <style> elements.
+var embeddedContexts = []string{"strings", "script", "style"}
+
+// WithEmbeddedLanguages highlights the code embedded in a snippet with the
+// lexer of another language, by context, e.g. {"strings": "sql"} highlights
+// string literals as SQL. The contexts are "strings", "script" and "style".
+// The contents of <script> and <style> elements are otherwise highlighted as
+// JavaScript and CSS.
+func WithEmbeddedLanguages(languages map[string]string) GenerateOpt {
+	return func(g *generator) error {
+		embedded := make(map[string]chroma.Lexer, len(languages))
+		for context, language := range languages {
+			if !slices.Contains(embeddedContexts, context) {
+				return fmt.Errorf("unknown embedded language context %q, expected one of %s", context, strings.Join(embeddedContexts, ", "))
+			}
+			lexer := lexers.Get(language)
+			if lexer == nil {
+				return fmt.Errorf("unknown embedded language %q", language)
+			}
+			embedded[context] = lexer
+		}
+		g.embedded = embedded
+		return nil
+	}
+}
+
+// highlightEmbedded highlights the embedded code of tokens, which must not
+// have been coalesced, with the lexers of its context.
+func highlightEmbedded(tokens []chroma.Token, embedded map[string]chroma.Lexer) (out []chroma.Token, err error) {
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		if lexer := embedded["strings"]; lexer != nil && tok.Type.InSubCategory(chroma.LiteralString) {
+			end := i + 1
+			for end < len(tokens) && tokens[end].Type.InSubCategory(chroma.LiteralString) {
+				end++
+			}
+			if out, err = appendString(out, tokens[i:end], lexer); err != nil {
+				return nil, err
+			}
+			i = end - 1
+			continue
+		}
+		out = append(out, tok)
+		if tok.Type != chroma.NameTag || !isOpeningTag(tokens[:i]) {
+			continue
+		}
+		lexer := embedded[strings.ToLower(tok.Value)]
+		if lexer == nil {
+			continue
+		}
+		start, end := elementContents(tokens, i, tok.Value)
+		if start < 0 {
+			continue
+		}
+		out = append(out, tokens[i+1:start]...)
+		var contents strings.Builder
+		for _, t := range tokens[start:end] {
+			contents.WriteString(t.Value)
+		}
+		if out, err = appendTokenised(out, lexer, contents.String()); err != nil {
+			return nil, err
+		}
+		i = end - 1
+	}
+	return out, nil
+}
+
+// appendString appends the tokens of a string literal, highlighting its
+// contents, between its quotes, with lexer.
+func appendString(out, literal []chroma.Token, lexer chroma.Lexer) ([]chroma.Token, error) {
+	var b strings.Builder
+	for _, t := range literal {
+		b.WriteString(t.Value)
+	}
+	s := b.String()
+	quotes := quoteLength(s)
+	if quotes == 0 {
+		return append(out, literal...), nil
+	}
+	quote := chroma.Token{Type: literal[0].Type, Value: s[:quotes]}
+	out = append(out, quote)
+	out, err := appendTokenised(out, lexer, s[quotes:len(s)-quotes])
+	if err != nil {
+		return nil, err
+	}
+	quote.Value = s[len(s)-quotes:]
+	return append(out, quote), nil
+}
+
+// quoteLength returns the length of the quotes around s, e.g. 1 for "s" or 3
+// for """s""", or 0 if s isn't quoted.
+func quoteLength(s string) int {
+	if len(s) < 2 || !strings.ContainsRune("\"'`", rune(s[0])) {
+		return 0
+	}
+	leading := len(s) - len(strings.TrimLeft(s, s[:1]))
+	trailing := len(s) - len(strings.TrimRight(s, s[:1]))
+	// Empty strings, such as "", are all quotes.
+	return min(leading, trailing, len(s)/2)
+}
+
+// appendTokenised appends the tokens of contents, as lexed by lexer.
+func appendTokenised(out []chroma.Token, lexer chroma.Lexer, contents string) ([]chroma.Token, error) {
+	if contents == "" {
+		return out, nil
+	}
+	it, err := lexer.Tokenise(&chroma.TokeniseOptions{State: "root", Nested: true}, contents)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := collectTokens(lexer, it)
+	if err != nil {
+		return nil, err
+	}
+	return append(out, tokens...), nil
+}
+
+// isOpeningTag reports whether the tag name following tokens is that of an
+// opening tag, e.g. script in <script>, rather than </script>.
+func isOpeningTag(tokens []chroma.Token) bool {
+	tokens = trimTrailingText(tokens)
+	return len(tokens) > 0 && tokens[len(tokens)-1] == chroma.Token{Type: chroma.Punctuation, Value: "<"}
+}
+
+// elementContents returns the range of tokens that are the contents of the
+// element whose opening tag name is tokens[i], or -1 if it has none.
+func elementContents(tokens []chroma.Token, i int, name string) (start, end int) {
+	start = slices.IndexFunc(tokens[i:], func(t chroma.Token) bool {
+		return t.Type == chroma.Punctuation && strings.HasSuffix(t.Value, ">")
+	})
+	if start < 0 {
+		return -1, -1
+	}
+	start += i + 1
+	for end = start; end < len(tokens); end++ {
+		if tokens[end].Type != chroma.NameTag || !strings.EqualFold(tokens[end].Value, name) {
+			continue
+		}
+		closing := trimTrailingText(tokens[start:end])
+		if len(closing) < 2 || closing[len(closing)-1] != (chroma.Token{Type: chroma.Punctuation, Value: "/"}) {
+			continue
+		}
+		closing = trimTrailingText(closing[:len(closing)-1])
+		if len(closing) == 0 || closing[len(closing)-1] != (chroma.Token{Type: chroma.Punctuation, Value: "<"}) {
+			continue
+		}
+		return start, start + len(closing) - 1
+	}
+	return -1, -1
+}
+
+// trimTrailingText trims the whitespace tokens from the end of tokens.
+func trimTrailingText(tokens []chroma.Token) []chroma.Token {
+	for len(tokens) > 0 && tokens[len(tokens)-1].Type == chroma.Text && strings.TrimSpace(tokens[len(tokens)-1].Value) == "" {
+		tokens = tokens[:len(tokens)-1]
+	}
+	return tokens
+}
+
+// coalesce merges adjacent tokens of the same type, like chroma.Coalesce.
+func coalesce(tokens []chroma.Token) []chroma.Token {
+	var out []chroma.Token
+	for _, t := range tokens {
+		if t.Value == "" {
+			continue
+		}
+		// chroma.Coalesce stops merging tokens at 8KiB.
+		if n := len(out); n > 0 && out[n-1].Type == t.Type && len(out[n-1].Value) < 8192 {
+			out[n-1].Value += t.Value
+			continue
+		}
+		out = append(out, t)
+	}
+	return out
+}
@@ -0,0 +1,118 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+)
+
+func TestHighlightEmbedded(t *testing.T) {
+	tests := []struct {
+		name      string
+		lexer     string
+		contents  string
+		languages map[string]string
+		expected  []chroma.Token
+	}{
+		{
+			name:      "sql strings in go",
+			lexer:     "Go",
+			contents:  "rows, err := db.Query(`SELECT id FROM users`, \"WHERE name = 'x'\")\n",
+			languages: map[string]string{"strings": "sql"},
+			expected: []chroma.Token{
+				{Type: chroma.LiteralString, Value: "`"},
+				{Type: chroma.Keyword, Value: "SELECT"},
+				{Type: chroma.Keyword, Value: "WHERE"},
+				{Type: chroma.LiteralStringSingle, Value: "'x'"},
+				{Type: chroma.LiteralString, Value: "\""},
+			},
+		},
+		{
+			name:      "typescript in script elements",
+			lexer:     "HTML",
+			contents:  "<p>let</p>\n<script type=\"module\">\nlet x: number = 1\n</script>\n",
+			languages: map[string]string{"script": "typescript"},
+			expected: []chroma.Token{
+				{Type: chroma.Text, Value: "let"},
+				{Type: chroma.KeywordDeclaration, Value: "let"},
+				{Type: chroma.KeywordType, Value: "number"},
+				{Type: chroma.NameTag, Value: "script"},
+			},
+		},
+		{
+			name:      "scss in style elements",
+			lexer:     "HTML",
+			contents:  "<style>\n.a { .b { color: red; } }\n</style>\n",
+			languages: map[string]string{"style": "scss"},
+			expected: []chroma.Token{
+				{Type: chroma.NameClass, Value: ".b"},
+				{Type: chroma.NameTag, Value: "style"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := generator{}
+			if err := WithEmbeddedLanguages(tt.languages)(&g); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			it, err := lexers.Get(tt.lexer).Tokenise(nil, tt.contents)
+			if err != nil {
+				t.Fatalf("failed to tokenise: %v", err)
+			}
+			tokens, err := highlightEmbedded(it.Tokens(), g.embedded)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			tokens = coalesce(tokens)
+			var b strings.Builder
+			for _, tok := range tokens {
+				b.WriteString(tok.Value)
+			}
+			if b.String() != tt.contents {
+				t.Errorf("expected the contents to be unchanged, got %q", b.String())
+			}
+			// The expected tokens are found in order.
+			remaining := tt.expected
+			for _, tok := range tokens {
+				if len(remaining) > 0 && tok == remaining[0] {
+					remaining = remaining[1:]
+				}
+			}
+			if len(remaining) > 0 {
+				t.Errorf("expected token %v, got %v", remaining[0], tokens)
+			}
+		})
+	}
+}
+
+func TestWithEmbeddedLanguagesErrors(t *testing.T) {
+	for _, languages := range []map[string]string{
+		{"comments": "sql"},
+		{"strings": "not-a-language"},
+	} {
+		if err := WithEmbeddedLanguages(languages)(&generator{}); err == nil {
+			t.Errorf("expected an error for %v", languages)
+		}
+	}
+}
+
+func TestQuoteLength(t *testing.T) {
+	for s, want := range map[string]int{
+		`"a"`:     1,
+		`""`:      1,
+		"`a`":     1,
+		`"""a"""`: 3,
+		`"a\""`:   1,
+		`f"a"`:    0,
+		`"`:       0,
+		`'''''' `: 0,
+		`''''''`:  3,
+	} {
+		if got := quoteLength(s); got != want {
+			t.Errorf("quoteLength(%q) = %d, want %d", s, got, want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package generator
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+)
+
+// TokenizeError is the error of a lexer that failed part way through a
+// snippet, positioned after the last token it produced. Snippets that fail to
+// tokenize fail to generate, rather than being highlighted in part.
+type TokenizeError struct {
+	// Lexer is the name of the lexer, e.g. "Go".
+	Lexer string
+	// Line and Column are where the lexer failed, from 1, in the snippet with
+	// its variables expanded, or in the embedded code the lexer highlights.
+	Line, Column int
+	Err          error
+}
+
+func (e *TokenizeError) Error() string {
+	return fmt.Sprintf("line %d, column %d: failed to tokenize as %s: %v", e.Line, e.Column, e.Lexer, e.Err)
+}
+
+func (e *TokenizeError) Unwrap() error {
+	return e.Err
+}
+
+// collectTokens returns the tokens of iterator, converting the panics of
+// lexers, e.g. those of rules pushing unknown states, into a TokenizeError.
+func collectTokens(lexer chroma.Lexer, iterator chroma.Iterator) (tokens []chroma.Token, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			line, column := endPosition(tokens)
+			err = &TokenizeError{Lexer: lexer.Config().Name, Line: line, Column: column, Err: fmt.Errorf("%v", r)}
+		}
+	}()
+	for t := iterator(); t != chroma.EOF; t = iterator() {
+		tokens = append(tokens, t)
+	}
+	return tokens, nil
+}
+
+// endPosition returns the line and column after tokens, from 1.
+func endPosition(tokens []chroma.Token) (line, column int) {
+	line, column = 1, 1
+	for _, t := range tokens {
+		if i := strings.LastIndexByte(t.Value, '\n'); i >= 0 {
+			line += strings.Count(t.Value, "\n")
+			column = 1 + len([]rune(t.Value[i+1:]))
+			continue
+		}
+		column += len([]rune(t.Value))
+	}
+	return line, column
+}
+
+// formatTokens formats tokens with f, converting the panics of formatters into
+// errors.
+func formatTokens(f chroma.Formatter, w io.Writer, style *chroma.Style, tokens []chroma.Token) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("formatter panicked: %v", r)
+		}
+	}()
+	return f.Format(w, style, chroma.Literator(tokens...))
+}
@@ -0,0 +1,89 @@
+package generator
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/chroma/v2"
+)
+
+// withMalformedLexer highlights snippets with a lexer that fails on the first
+// newline, by pushing a state it doesn't define.
+func withMalformedLexer() GenerateOpt {
+	return func(g *generator) error {
+		g.language = chroma.MustNewLexer(&chroma.Config{Name: "Malformed"}, func() chroma.Rules {
+			return chroma.Rules{
+				"root": {
+					{Pattern: `\n`, Type: chroma.Text, Mutator: chroma.Push("missing")},
+					{Pattern: `.`, Type: chroma.Text},
+				},
+			}
+		})
+		return nil
+	}
+}
+
+func TestGenerateTokenizeError(t *testing.T) {
+	var b strings.Builder
+	_, err := Generate(&b, Config{
+		Contents:      []byte("package main\n"),
+		PackageName:   "main",
+		ComponentName: "Main",
+		Components: []Component{
+			{Name: "MainFunc", Contents: []byte("func main() {\n}\n")},
+		},
+	}, withMalformedLexer())
+	var tokenizeErr *TokenizeError
+	if !errors.As(err, &tokenizeErr) {
+		t.Fatalf("expected a TokenizeError, got %v", err)
+	}
+	if tokenizeErr.Lexer != "Malformed" || tokenizeErr.Line != 2 || tokenizeErr.Column != 1 {
+		t.Errorf("expected Malformed at line 2, column 1, got %s at line %d, column %d", tokenizeErr.Lexer, tokenizeErr.Line, tokenizeErr.Column)
+	}
+	expected := "failed to highlight MainFunc: line 2, column 1: failed to tokenize as Malformed: "
+	if !strings.HasPrefix(err.Error(), expected) {
+		t.Errorf("expected error starting with %q, got %q", expected, err.Error())
+	}
+	if b.Len() > 0 {
+		t.Errorf("expected no partial output, got:\n%s", b.String())
+	}
+}
+
+func TestEndPosition(t *testing.T) {
+	tests := []struct {
+		values       []string
+		line, column int
+	}{
+		{values: nil, line: 1, column: 1},
+		{values: []string{"package", " ", "main"}, line: 1, column: 13},
+		{values: []string{"package main\n"}, line: 2, column: 1},
+		{values: []string{"a\nb\n", "héllo"}, line: 3, column: 6},
+	}
+	for _, test := range tests {
+		var tokens []chroma.Token
+		for _, value := range test.values {
+			tokens = append(tokens, chroma.Token{Type: chroma.Text, Value: value})
+		}
+		line, column := endPosition(tokens)
+		if line != test.line || column != test.column {
+			t.Errorf("%q: expected line %d, column %d, got line %d, column %d", test.values, test.line, test.column, line, column)
+		}
+	}
+}
+
+// panickingFormatter panics like formatters with bugs, as chroma.FormatterFunc
+// recovers panics itself.
+type panickingFormatter struct{}
+
+func (panickingFormatter) Format(w io.Writer, style *chroma.Style, iterator chroma.Iterator) error {
+	panic("unexpected token")
+}
+
+func TestFormatTokensPanic(t *testing.T) {
+	err := formatTokens(panickingFormatter{}, io.Discard, nil, []chroma.Token{{Type: chroma.Text, Value: "x"}})
+	if err == nil || err.Error() != "formatter panicked: unexpected token" {
+		t.Errorf("expected the panic as an error, got %v", err)
+	}
+}
@@ -1,27 +1,139 @@
 package generator
 
-import "io"
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"unicode/utf8"
+)
 
+// escapeBufferSize is the size of the buffer that escaped output is collected
+// in before it's written. Longer runs of bytes that don't need escaping are
+// written as they are, rather than copied.
+const escapeBufferSize = 32 << 10
+
+// byteEscapes are the escapes of the ASCII bytes that strconv.Quote escapes,
+// and of the bytes that can start invalid UTF-8, so that escaping them doesn't
+// allocate. Bytes that aren't escaped have no entry.
+var byteEscapes = func() (escapes [256][]byte) {
+	for b := range len(escapes) {
+		if b >= utf8.RuneSelf || b < ' ' || b == 0x7f {
+			escapes[b] = fmt.Appendf(nil, `\x%02x`, b)
+		}
+	}
+	for b, escape := range map[byte]string{
+		'\a': `\a`, '\b': `\b`, '\f': `\f`, '\n': `\n`, '\r': `\r`, '\t': `\t`, '\v': `\v`,
+		'"': `\"`, '\\': `\\`,
+	} {
+		escapes[b] = []byte(escape)
+	}
+	return escapes
+}()
+
+// EscapeWriter escapes what it writes for use in a Go string literal, the same
+// as strconv.Quote without the quotes, so that unquoting the literal always
+// gives back the bytes written. Bytes that aren't valid UTF-8, which Go source
+// can't contain, are written as \x escapes, so each Write must be given whole
+// characters. Its buffer is reused across writes, so it isn't safe for
+// concurrent use.
 type EscapeWriter struct {
-	w io.Writer
+	w   io.Writer
+	buf []byte
 }
 
 func NewEscapeWriter(w io.Writer) *EscapeWriter {
-	return &EscapeWriter{w: w}
+	return &EscapeWriter{w: w, buf: make([]byte, 0, escapeBufferSize)}
 }
 
+// Write writes p escaped, returning the number of bytes of p written, which is
+// len(p) unless there's an error, not the length of the escaped output.
 func (w *EscapeWriter) Write(p []byte) (n int, err error) {
-	var processed []byte
-	for i := 0; i < len(p); i++ {
-		switch p[i] {
-		case '"':
-			processed = append(processed, '\\', '"')
-		case '\n':
-			processed = append(processed, '\\', 'n')
-		default:
-			processed = append(processed, p[i])
+	w.buf = w.buf[:0]
+	// run is the start of the bytes of p that are copied unescaped, and
+	// buffered is the end of those whose escaped form is in w.buf.
+	run, buffered := 0, 0
+	flush := func() error {
+		if len(w.buf) > 0 {
+			if _, err := w.w.Write(w.buf); err != nil {
+				return err
+			}
+			w.buf = w.buf[:0]
+		}
+		n = buffered
+		return nil
+	}
+	// add buffers p[run:end], followed by escaped, the escape of the size bytes
+	// at end, if any.
+	add := func(end int, escaped []byte, size int) error {
+		if len(w.buf)+end-run+len(escaped) > cap(w.buf) {
+			if err := flush(); err != nil {
+				return err
+			}
+			if end-run > cap(w.buf) {
+				if _, err := w.w.Write(p[run:end]); err != nil {
+					return err
+				}
+				run, buffered, n = end, end, end
+			}
+		}
+		w.buf = append(w.buf, p[run:end]...)
+		w.buf = append(w.buf, escaped...)
+		if len(escaped) > 0 {
+			end += size
+		}
+		run, buffered = end, end
+		return nil
+	}
+
+	// runeEscape holds the \u or \U escape of a non-printable character.
+	var runeEscape [10]byte
+	for i := 0; i < len(p); {
+		escaped, size := byteEscapes[p[i]], 1
+		if p[i] >= utf8.RuneSelf {
+			r, width := utf8.DecodeRune(p[i:])
+			if r != utf8.RuneError || width != 1 {
+				escaped, size = nil, width
+				if !strconv.IsPrint(r) {
+					escaped = appendRuneEscape(runeEscape[:0], r)
+				}
+			}
+		}
+		if len(escaped) == 0 {
+			i += size
+			continue
+		}
+		if err = add(i, escaped, size); err != nil {
+			return n, err
 		}
+		i += size
+	}
+	if run == 0 {
+		// Nothing needed escaping.
+		return w.w.Write(p)
+	}
+	if err = add(len(p), nil, 0); err != nil {
+		return n, err
 	}
+	if err = flush(); err != nil {
+		return n, err
+	}
+	return len(p), nil
+}
 
-	return w.w.Write(processed)
+// appendRuneEscape appends the escape of a non-printable character, as
+// strconv.Quote writes it.
+func appendRuneEscape(b []byte, r rune) []byte {
+	const hex = "0123456789abcdef"
+	if r < 0x10000 {
+		b = append(b, '\\', 'u')
+		for shift := 12; shift >= 0; shift -= 4 {
+			b = append(b, hex[r>>shift&0xf])
+		}
+		return b
+	}
+	b = append(b, '\\', 'U')
+	for shift := 28; shift >= 0; shift -= 4 {
+		b = append(b, hex[r>>shift&0xf])
+	}
+	return b
 }
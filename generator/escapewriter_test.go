@@ -2,6 +2,11 @@ package generator
 
 import (
 	"bytes"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -38,7 +43,7 @@ func TestEscapeWriter(t *testing.T) {
 		if err != nil {
 			t.Fatalf("failed to write: %v", err)
 		}
-		if n != len(expected) {
+		if n != len(input) {
 			t.Errorf("expected to write %d bytes, wrote %d", len(input), n)
 		}
 		if diff := cmp.Diff(expected, w.String()); diff != "" {
@@ -57,7 +62,7 @@ func TestEscapeWriter(t *testing.T) {
 		if err != nil {
 			t.Fatalf("failed to write: %v", err)
 		}
-		if n != len(expected) {
+		if n != len(input) {
 			t.Errorf("expected to write %d bytes, wrote %d", len(input), n)
 		}
 		if diff := cmp.Diff(expected, w.String()); diff != "" {
@@ -76,7 +81,76 @@ func TestEscapeWriter(t *testing.T) {
 		if err != nil {
 			t.Fatalf("failed to write: %v", err)
 		}
-		if n != len(expected) {
+		if n != len(input) {
+			t.Errorf("expected to write %d bytes, wrote %d", len(input), n)
+		}
+		if diff := cmp.Diff(expected, w.String()); diff != "" {
+			t.Errorf("unexpected output (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("escapes invalid UTF-8", func(t *testing.T) {
+		w := new(bytes.Buffer)
+		ew := NewEscapeWriter(w)
+
+		input := []byte("caf\u00e9 \xff\xfe")
+		expected := "caf\u00e9 " + `\xff\xfe`
+
+		if _, err := ew.Write(input); err != nil {
+			t.Fatalf("failed to write: %v", err)
+		}
+		if diff := cmp.Diff(expected, w.String()); diff != "" {
+			t.Errorf("unexpected output (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("escapes backslashes", func(t *testing.T) {
+		w := new(bytes.Buffer)
+		ew := NewEscapeWriter(w)
+
+		input := []byte(`fmt.Println("a\nb")`)
+		expected := `fmt.Println(\"a\\nb\")`
+
+		n, err := ew.Write(input)
+		if err != nil {
+			t.Fatalf("failed to write: %v", err)
+		}
+		if n != len(input) {
+			t.Errorf("expected to write %d bytes, wrote %d", len(input), n)
+		}
+		if diff := cmp.Diff(expected, w.String()); diff != "" {
+			t.Errorf("unexpected output (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("escapes control and non-printable characters", func(t *testing.T) {
+		w := new(bytes.Buffer)
+		ew := NewEscapeWriter(w)
+
+		input := []byte("\tx\r\x00\x7f\u00a0\ufeff\U000e0001")
+		expected := `\tx\r\x00\x7f\u00a0\ufeff\U000e0001`
+
+		if _, err := ew.Write(input); err != nil {
+			t.Fatalf("failed to write: %v", err)
+		}
+		if diff := cmp.Diff(expected, w.String()); diff != "" {
+			t.Errorf("unexpected output (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("escapes input longer than its buffer", func(t *testing.T) {
+		w := new(bytes.Buffer)
+		ew := NewEscapeWriter(w)
+
+		// Runs both shorter and longer than the buffer.
+		input := []byte(strings.Repeat("a\"b\n", escapeBufferSize) + strings.Repeat("c", 2*escapeBufferSize) + "\xff")
+		expected := strings.Repeat(`a\"b\n`, escapeBufferSize) + strings.Repeat("c", 2*escapeBufferSize) + `\xff`
+
+		n, err := ew.Write(input)
+		if err != nil {
+			t.Fatalf("failed to write: %v", err)
+		}
+		if n != len(input) {
 			t.Errorf("expected to write %d bytes, wrote %d", len(input), n)
 		}
 		if diff := cmp.Diff(expected, w.String()); diff != "" {
@@ -84,6 +158,21 @@ func TestEscapeWriter(t *testing.T) {
 		}
 	})
 
+	t.Run("returns the bytes written before an error", func(t *testing.T) {
+		ew := NewEscapeWriter(&failingWriter{n: 1})
+
+		input := []byte(strings.Repeat("\"", escapeBufferSize))
+
+		n, err := ew.Write(input)
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+		// Each quote is escaped as two bytes, so the first buffer holds half.
+		if n != escapeBufferSize/2 {
+			t.Errorf("expected to write %d bytes, wrote %d", escapeBufferSize/2, n)
+		}
+	})
+
 	t.Run("handles empty input", func(t *testing.T) {
 		w := new(bytes.Buffer)
 		ew := NewEscapeWriter(w)
@@ -103,3 +192,104 @@ func TestEscapeWriter(t *testing.T) {
 		}
 	})
 }
+
+func FuzzEscapeWriter(f *testing.F) {
+	for _, seed := range []string{
+		"",
+		`<span class="s">"a\nb"</span>` + "\n",
+		"\t\r\x00\x7f\\",
+		"caf\u00e9 \xff\xfe\xed\xa0\x80",
+		"\u00a0\u200b\ufeff\U0001f600\U000e0001",
+	} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, input []byte) {
+		var b strings.Builder
+		n, err := NewEscapeWriter(&b).Write(input)
+		if err != nil {
+			t.Fatalf("failed to write: %v", err)
+		}
+		if n != len(input) {
+			t.Errorf("expected to write %d bytes, wrote %d", len(input), n)
+		}
+		literal := `"` + b.String() + `"`
+		if literal != strconv.Quote(string(input)) {
+			t.Errorf("expected %s, got %s", strconv.Quote(string(input)), literal)
+		}
+		unquoted, err := strconv.Unquote(literal)
+		if err != nil {
+			t.Fatalf("failed to unquote %s: %v", literal, err)
+		}
+		if unquoted != string(input) {
+			t.Errorf("expected %q to unquote to the input, got %q", literal, unquoted)
+		}
+	})
+}
+
+// FuzzGenerateLiteral checks that unquoting the string literal of each
+// generated component gives back exactly the HTML that chroma highlighted.
+func FuzzGenerateLiteral(f *testing.F) {
+	for _, seed := range []string{
+		"package main\n\nfunc main() {\n\tfmt.Println(\"a\\tb\\n\")\n}\n",
+		"s := `C:\\dir\\` + \"\\\\\" // \u00e9\u200b\r\n",
+		"x := '\\x00'\x00\x7f\xff",
+	} {
+		f.Add([]byte(seed))
+	}
+	literal := regexp.MustCompile(`templ_7745c5c3_Buffer\.WriteString\((".*")\)\n`)
+	f.Fuzz(func(t *testing.T, contents []byte) {
+		var html string
+		var b strings.Builder
+		_, err := Generate(&b, Config{
+			Contents:      contents,
+			PackageName:   "main",
+			ComponentName: "Main",
+		}, WithLanguage("go"), WithHTMLReport(func(_, h string) { html = h }))
+		if err != nil {
+			t.Fatalf("failed to generate: %v", err)
+		}
+		m := literal.FindStringSubmatch(b.String())
+		if m == nil {
+			t.Fatalf("expected the literal of the component, got:\n%s", b.String())
+		}
+		unquoted, err := strconv.Unquote(m[1])
+		if err != nil {
+			t.Fatalf("failed to unquote %s: %v", m[1], err)
+		}
+		if unquoted != html {
+			t.Errorf("expected the literal to unquote to the highlighted HTML (-want +got):\n%s", cmp.Diff(html, unquoted))
+		}
+	})
+}
+
+// failingWriter fails after n writes.
+type failingWriter struct {
+	n int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.n == 0 {
+		return 0, errors.New("write failed")
+	}
+	w.n--
+	return len(p), nil
+}
+
+func BenchmarkEscapeWriter(b *testing.B) {
+	// Highlighted HTML, which is mostly ASCII, with quoted attributes, newlines
+	// and some multi-byte characters.
+	line := []byte(`<span class="line"><span class="cl"><span style="color:#f92672">func</span> <span style="color:#a6e22e">main</span>() { <span style="color:#75715e">// caf` + "é" + `</span>` + "\n")
+	for _, size := range []int{4 << 10, 4 << 20} {
+		input := bytes.Repeat(line, size/len(line)+1)[:size]
+		b.Run(strconv.Itoa(size>>10)+"KB", func(b *testing.B) {
+			ew := NewEscapeWriter(io.Discard)
+			b.SetBytes(int64(len(input)))
+			b.ReportAllocs()
+			for range b.N {
+				if _, err := ew.Write(input); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
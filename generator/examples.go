@@ -0,0 +1,86 @@
+package generator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/alecthomas/chroma/v2"
+)
+
+// Example is the plain text of a generated component, see GenerateExamples.
+type Example struct {
+	ComponentName string
+	Text          string
+}
+
+// ExampleText returns the text of tokens, as it's rendered by the component
+// they're highlighted into.
+func ExampleText(tokens []chroma.Token) string {
+	var sb strings.Builder
+	for _, t := range tokens {
+		sb.WriteString(t.Value)
+	}
+	return sb.String()
+}
+
+// GenerateExamples writes a test file of packageName with an example function
+// for each component, e.g. ExampleMainGo, that prints the plain text of the
+// component with runtime.PlainText, and whose output comment is the text, so
+// that go test checks that components render their snippets, and godoc shows
+// them as examples. Only the header and build constraint options apply.
+func GenerateExamples(w io.Writer, packageName string, examples []Example, opts ...GenerateOpt) (err error) {
+	var buf bytes.Buffer
+	g := generator{w: NewRangeWriter(&buf), packageName: packageName}
+	for _, opt := range opts {
+		if err = opt(&g); err != nil {
+			return err
+		}
+	}
+	if err = g.writeCodeGeneratedComment(); err != nil {
+		return err
+	}
+	if _, err = g.w.Write("package " + packageName + "\n\nimport (\n\t\"fmt\"\n\n\t" + runtimePackageAlias + " \"" + runtimeImportPath + "\"\n)\n"); err != nil {
+		return err
+	}
+	for _, e := range examples {
+		if err = checkExampleText(e.Text); err != nil {
+			return fmt.Errorf("%s can't be an example: %w", e.ComponentName, err)
+		}
+		var b strings.Builder
+		b.WriteString("\nfunc Example" + e.ComponentName + "() {\n")
+		b.WriteString("\ttext, err := " + runtimePackageAlias + ".PlainText(" + e.ComponentName + "())\n")
+		b.WriteString("\tif err != nil {\n\t\tpanic(err)\n\t}\n")
+		b.WriteString("\tfmt.Println(text)\n")
+		b.WriteString("\t// Output:\n")
+		for _, line := range strings.Split(strings.TrimRight(e.Text, " \t\r\n"), "\n") {
+			if line = strings.TrimRight(line, " \t\r"); line == "" {
+				b.WriteString("\t//\n")
+				continue
+			}
+			b.WriteString("\t// " + line + "\n")
+		}
+		b.WriteString("}\n")
+		if _, err = g.w.Write(b.String()); err != nil {
+			return err
+		}
+	}
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+// checkExampleText returns an error if text can't be written in a comment of
+// Go source.
+func checkExampleText(text string) error {
+	if !utf8.ValidString(text) {
+		return errors.New("invalid UTF-8")
+	}
+	if i := strings.IndexAny(text, "\x00\uFEFF"); i >= 0 {
+		r, _ := utf8.DecodeRuneInString(text[i:])
+		return fmt.Errorf("Go source can't contain %U", r)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGenerateExamples(t *testing.T) {
+	var b strings.Builder
+	err := GenerateExamples(&b, "docs", []Example{
+		{ComponentName: "MainTxt", Text: "func main() {  \n\n\tfmt.Println()\n}\n"},
+	}, WithBuildConstraint("docs"))
+	if err != nil {
+		t.Fatalf("failed to generate: %v", err)
+	}
+	expected := `//go:build docs
+
+// Code generated by snips - DO NOT EDIT.
+
+package docs
+
+import (
+	"fmt"
+
+	snipsruntime "github.com/garrettladley/snips/runtime"
+)
+
+func ExampleMainTxt() {
+	text, err := snipsruntime.PlainText(MainTxt())
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(text)
+	// Output:
+	// func main() {
+	//
+	// 	fmt.Println()
+	// }
+}
+`
+	if diff := cmp.Diff(expected, b.String()); diff != "" {
+		t.Errorf("unexpected examples (-want +got):\n%s", diff)
+	}
+
+	b.Reset()
+	err = GenerateExamples(&b, "docs", []Example{{ComponentName: "NulTxt", Text: "a\x00b"}})
+	if err == nil || !strings.Contains(err.Error(), "NulTxt can't be an example") {
+		t.Errorf("expected NUL to be refused, got %v", err)
+	}
+	if b.Len() > 0 {
+		t.Errorf("expected no partial output, got:\n%s", b.String())
+	}
+}
@@ -0,0 +1,191 @@
+package generator
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/alecthomas/chroma/v2"
+)
+
+// TokenFilter transforms the tokens of a component after tokenizing, and
+// before they're formatted, e.g. to drop comments. It may modify tokens in
+// place. Line numbers, focus ranges and linkable lines refer to the lines of
+// the filtered tokens. Plain text, see GenerateText, isn't filtered.
+type TokenFilter func(tokens []chroma.Token) []chroma.Token
+
+// CollapseBlankLines is a TokenFilter that collapses runs of blank lines into
+// a single blank line.
+func CollapseBlankLines(tokens []chroma.Token) []chroma.Token {
+	var out []chroma.Token
+	var previousBlank bool
+	for _, line := range chroma.SplitTokensIntoLines(tokens) {
+		blank := !slices.ContainsFunc(line, func(t chroma.Token) bool {
+			return strings.TrimSpace(t.Value) != ""
+		})
+		if blank && previousBlank {
+			continue
+		}
+		previousBlank = blank
+		out = append(out, line...)
+	}
+	return out
+}
+
+// StripComments is a TokenFilter that removes comments, and the lines that only
+// held comments, along with the blank lines that follow leading comments such
+// as license headers. Preprocessor directives and shebangs are kept, since
+// they're code.
+func StripComments(tokens []chroma.Token) []chroma.Token {
+	var out []chroma.Token
+	// header is whether comments were stripped before the first line of code.
+	var started, header bool
+	for _, line := range chroma.SplitTokensIntoLines(tokens) {
+		var stripped, code bool
+		kept := make([]chroma.Token, 0, len(line))
+		for _, t := range line {
+			if isComment(t.Type) {
+				stripped = stripped || t.Value != ""
+				if strings.HasSuffix(t.Value, "\n") {
+					// Line comments may include the newline.
+					kept = append(kept, chroma.Token{Type: chroma.TextWhitespace, Value: "\n"})
+				}
+				continue
+			}
+			code = code || strings.TrimSpace(t.Value) != ""
+			kept = append(kept, t)
+		}
+		switch {
+		case !code && stripped:
+			header = header || !started
+			continue
+		case !code && header && !started:
+			continue
+		case stripped:
+			kept = trimTrailingSpace(kept)
+		}
+		started = started || code
+		out = append(out, kept...)
+	}
+	return out
+}
+
+// isComment reports whether tokens of type t are comments, excluding
+// preprocessor directives and shebangs.
+func isComment(t chroma.TokenType) bool {
+	switch t {
+	case chroma.CommentPreproc, chroma.CommentPreprocFile, chroma.CommentHashbang:
+		return false
+	}
+	return t.InCategory(chroma.Comment)
+}
+
+// trimTrailingSpace removes the spaces and tabs before the newline of a line of
+// tokens, left behind by a removed comment.
+func trimTrailingSpace(line []chroma.Token) []chroma.Token {
+	for i := len(line) - 1; i >= 0; i-- {
+		value, newline := strings.CutSuffix(line[i].Value, "\n")
+		trimmed := strings.TrimRight(value, " \t")
+		if newline {
+			trimmed += "\n"
+		}
+		line[i].Value = trimmed
+		if strings.TrimRight(value, " \t") != "" {
+			break
+		}
+	}
+	return slices.DeleteFunc(line, func(t chroma.Token) bool { return t.Value == "" })
+}
+
+var (
+	tokenFiltersMutex sync.Mutex
+	// tokenFilters are the registered filters, by name.
+	tokenFilters = map[string]TokenFilter{
+		"collapse-blank-lines": CollapseBlankLines,
+		"strip-comments":       StripComments,
+	}
+)
+
+// RegisterTokenFilter registers a filter under name, so that it can be
+// enabled by name, see WithTokenFilterNamed. It panics if the name is taken.
+func RegisterTokenFilter(name string, filter TokenFilter) {
+	tokenFiltersMutex.Lock()
+	defer tokenFiltersMutex.Unlock()
+	if _, ok := tokenFilters[name]; ok {
+		panic(fmt.Sprintf("token filter %q is already registered", name))
+	}
+	tokenFilters[name] = filter
+}
+
+// TokenFilterNames returns the names of the registered filters, sorted.
+func TokenFilterNames() []string {
+	tokenFiltersMutex.Lock()
+	defer tokenFiltersMutex.Unlock()
+	names := make([]string, 0, len(tokenFilters))
+	for name := range tokenFilters {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// WithTokenFilter transforms the tokens of each component with filter before
+// formatting them. Filters run in the order they're added.
+func WithTokenFilter(filter TokenFilter) GenerateOpt {
+	return func(g *generator) error {
+		g.tokenFilters = append(g.tokenFilters, filter)
+		return nil
+	}
+}
+
+// WithTokenFilterNamed transforms the tokens of each component with the
+// registered filter of the given name, e.g. "collapse-blank-lines".
+func WithTokenFilterNamed(name string) GenerateOpt {
+	return func(g *generator) error {
+		tokenFiltersMutex.Lock()
+		filter, ok := tokenFilters[name]
+		tokenFiltersMutex.Unlock()
+		if !ok {
+			return fmt.Errorf("unknown token filter %q, expected one of %s", name, strings.Join(TokenFilterNames(), ", "))
+		}
+		g.tokenFilters = append(g.tokenFilters, filter)
+		return nil
+	}
+}
+
+// HTMLFilter transforms the highlighted HTML of a component, before it's
+// wrapped, e.g. to add notes after the code.
+type HTMLFilter func(componentName, html string) (string, error)
+
+// WithHTMLFilter transforms the highlighted HTML of each component with
+// filter. Filters run in the order they're added.
+func WithHTMLFilter(filter HTMLFilter) GenerateOpt {
+	return func(g *generator) error {
+		g.htmlFilters = append(g.htmlFilters, filter)
+		return nil
+	}
+}
+
+// filterHTML runs the HTML filters over the highlighted HTML of the component.
+func (g *generator) filterHTML(html string) (string, error) {
+	for _, filter := range g.htmlFilters {
+		var err error
+		if html, err = filter(g.componentName, html); err != nil {
+			return "", err
+		}
+	}
+	return html, nil
+}
+
+// filterTokens runs the token filters over a copy of tokens, which may be
+// shared with the token cache.
+func (g *generator) filterTokens(tokens []chroma.Token) []chroma.Token {
+	if len(g.tokenFilters) > 0 {
+		tokens = slices.Clone(tokens)
+	}
+	for _, filter := range g.tokenFilters {
+		tokens = filter(tokens)
+	}
+	return tokens
+}
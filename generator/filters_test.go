@@ -0,0 +1,144 @@
+package generator
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+)
+
+func TestCollapseBlankLines(t *testing.T) {
+	tokens := []chroma.Token{
+		{Type: chroma.Keyword, Value: "package"},
+		{Type: chroma.Text, Value: " "},
+		{Type: chroma.Name, Value: "main"},
+		{Type: chroma.Text, Value: "\n\n \t\n\n"},
+		{Type: chroma.Comment, Value: "/* a\n\n\nb */"},
+		{Type: chroma.Text, Value: "\n"},
+	}
+	var got strings.Builder
+	for _, t := range CollapseBlankLines(tokens) {
+		got.WriteString(t.Value)
+	}
+	expected := "package main\n\n/* a\n\nb */\n"
+	if got.String() != expected {
+		t.Errorf("expected %q, got %q", expected, got.String())
+	}
+}
+
+func TestTokenFilters(t *testing.T) {
+	upper := func(tokens []chroma.Token) []chroma.Token {
+		for i, t := range tokens {
+			if t.Type.InCategory(chroma.Keyword) {
+				tokens[i].Value = strings.ToUpper(t.Value)
+			}
+		}
+		return tokens
+	}
+	RegisterTokenFilter("test-uppercase-keywords", upper)
+	defer func() {
+		tokenFiltersMutex.Lock()
+		delete(tokenFilters, "test-uppercase-keywords")
+		tokenFiltersMutex.Unlock()
+	}()
+
+	var reported string
+	var b strings.Builder
+	_, err := Generate(&b, Config{
+		Contents:      []byte("package main\n\n\n\nfunc main() {}\n"),
+		PackageName:   "main",
+		ComponentName: "Main",
+	},
+		WithLanguage("go"),
+		WithTokenFilterNamed("collapse-blank-lines"),
+		WithTokenFilterNamed("test-uppercase-keywords"),
+		WithHTMLReport(func(_, html string) { reported = html }),
+	)
+	if err != nil {
+		t.Fatalf("failed to generate: %v", err)
+	}
+	if !strings.Contains(reported, ">PACKAGE<") || !strings.Contains(reported, ">FUNC<") {
+		t.Errorf("expected the keywords to be uppercased, got:\n%s", reported)
+	}
+	if n := strings.Count(reported, "<span style=\"display:flex;\">"); n != 3 {
+		t.Errorf("expected the blank lines to be collapsed into one, got %d lines:\n%s", n, reported)
+	}
+
+	if _, err = Generate(&b, Config{Contents: []byte("x")}, WithTokenFilterNamed("unknown")); err == nil || !strings.Contains(err.Error(), "collapse-blank-lines") {
+		t.Errorf("expected an unknown filter to list the registered filters, got %v", err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("expected registering a taken name to panic")
+		}
+	}()
+	RegisterTokenFilter("collapse-blank-lines", upper)
+}
+
+func TestStripComments(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		contents string
+		expected string
+	}{
+		{
+			name:     "go",
+			language: "go",
+			contents: "// Copyright 2024 Example\n\n// Package main serves.\npackage main\n\nimport \"fmt\" // for Println\n\nfunc main() {\n\t/* say\n\thello */\n\tfmt.Println(\"hi\") /* inline */\n}\n",
+			expected: "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(\"hi\")\n}\n",
+		},
+		{
+			name:     "python keeps the shebang",
+			language: "python",
+			contents: "#!/usr/bin/env python3\n# greet\nprint('hi')  # inline\n",
+			expected: "#!/usr/bin/env python3\nprint('hi')\n",
+		},
+		{
+			name:     "c keeps preprocessor directives",
+			language: "c",
+			contents: "#include <stdio.h>\n// main\nint main() { return 0; }\n",
+			expected: "#include <stdio.h>\nint main() { return 0; }\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			iterator, err := lexers.Get(tt.language).Tokenise(nil, tt.contents)
+			if err != nil {
+				t.Fatalf("failed to tokenise: %v", err)
+			}
+			var got strings.Builder
+			for _, t := range StripComments(iterator.Tokens()) {
+				got.WriteString(t.Value)
+			}
+			if got.String() != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got.String())
+			}
+		})
+	}
+}
+
+func TestHTMLFilter(t *testing.T) {
+	note := func(componentName, html string) (string, error) {
+		return html + "<aside>" + componentName + "</aside>", nil
+	}
+	var reported string
+	var b strings.Builder
+	_, err := Generate(&b, Config{Contents: []byte("x\n"), PackageName: "main", ComponentName: "Main"},
+		WithHTMLFilter(note),
+		WithHTMLReport(func(_, html string) { reported = html }),
+	)
+	if err != nil {
+		t.Fatalf("failed to generate: %v", err)
+	}
+	if !strings.Contains(reported, "</pre><aside>Main</aside>") {
+		t.Errorf("expected the note after the code, got:\n%s", reported)
+	}
+
+	failing := func(string, string) (string, error) { return "", errors.New("refused") }
+	if _, err = Generate(&b, Config{Contents: []byte("x\n"), PackageName: "main", ComponentName: "Main"}, WithHTMLFilter(failing)); err == nil || err.Error() != "refused" {
+		t.Errorf("expected the filter's error, got %v", err)
+	}
+}
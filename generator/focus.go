@@ -0,0 +1,56 @@
+package generator
+
+import (
+	"math"
+	"regexp"
+	"slices"
+)
+
+const (
+	// focusClass is added to the wrapper of snippets with focused lines.
+	focusClass = "snips-focus"
+	// unfocusedClass is added to lines outside the focused ranges.
+	unfocusedClass = "snips-unfocused"
+	// unfocusedMarker is a CSS custom property appended to the inline style of
+	// unfocused lines, so they can be found in the formatted HTML.
+	unfocusedMarker = "--snips-unfocused:1"
+)
+
+// focusCSS dims unfocused lines until the snippet is hovered or clicked.
+const focusCSS = `<style>` +
+	`.` + focusClass + ` .` + unfocusedClass + `{opacity:.4;transition:opacity .2s}` +
+	`.` + focusClass + `:hover .` + unfocusedClass + `,.` + focusClass + `:focus-within .` + unfocusedClass + `{opacity:1}` +
+	`</style>`
+
+var unfocusedStyle = regexp.MustCompile(` style="([^"]*?);?` + regexp.QuoteMeta(unfocusedMarker) + `"`)
+
+// highlightedClasses matches the classes of the lines chroma highlights when
+// highlighting with CSS classes, see WithThemes.
+var highlightedClasses = regexp.MustCompile(`class="(line|lnt) hl"`)
+
+// unfocusedRanges returns the inclusive line ranges not covered by focus.
+// Focus is implemented with chroma's line highlighting, so the unfocused
+// lines are the ones highlighted.
+func unfocusedRanges(focus [][2]int) (ranges [][2]int) {
+	focus = slices.Clone(focus)
+	slices.SortFunc(focus, func(a, b [2]int) int { return a[0] - b[0] })
+	next := math.MinInt32
+	for _, r := range focus {
+		if r[0] > next {
+			ranges = append(ranges, [2]int{next, r[0] - 1})
+		}
+		next = max(next, r[1]+1)
+	}
+	return append(ranges, [2]int{next, math.MaxInt32})
+}
+
+// classifyUnfocused adds the unfocused class to the lines marked by customCSS.
+func (g *generator) classifyUnfocused(s string) string {
+	if len(g.focus) == 0 {
+		return s
+	}
+	if g.classes() {
+		return highlightedClasses.ReplaceAllString(s, `class="$1 `+unfocusedClass+`"`)
+	}
+	return unfocusedStyle.ReplaceAllString(s, ` class="`+unfocusedClass+`" style="$1"`)
+}
@@ -0,0 +1,64 @@
+package generator
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUnfocusedRanges(t *testing.T) {
+	tests := []struct {
+		name  string
+		focus [][2]int
+		want  [][2]int
+	}{
+		{
+			name:  "single range",
+			focus: [][2]int{{3, 5}},
+			want:  [][2]int{{math.MinInt32, 2}, {6, math.MaxInt32}},
+		},
+		{
+			name:  "unsorted and overlapping ranges",
+			focus: [][2]int{{8, 8}, {3, 5}, {4, 6}},
+			want:  [][2]int{{math.MinInt32, 2}, {7, 7}, {9, math.MaxInt32}},
+		},
+		{
+			name:  "adjacent ranges",
+			focus: [][2]int{{1, 2}, {3, 4}},
+			want:  [][2]int{{math.MinInt32, 0}, {5, math.MaxInt32}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := cmp.Diff(tt.want, unfocusedRanges(tt.focus)); diff != "" {
+				t.Errorf("unexpected ranges (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFocus(t *testing.T) {
+	var b strings.Builder
+	_, err := Generate(&b, Config{
+		Contents:      []byte("package main\n\nfunc main() {\n}\n"),
+		PackageName:   "main",
+		ComponentName: "Main",
+	}, WithFocusLines([][2]int{{3, 4}}))
+	if err != nil {
+		t.Fatalf("failed to generate: %v", err)
+	}
+
+	s := b.String()
+	if n := strings.Count(s, `class=\"`+unfocusedClass+`\"`); n != 2 {
+		t.Errorf("expected 2 unfocused lines, got %d:\n%s", n, s)
+	}
+	if strings.Contains(s, unfocusedMarker) {
+		t.Errorf("expected the marker to be removed, got:\n%s", s)
+	}
+	if !strings.Contains(s, `class=\"`+focusClass+`\" tabindex=\"0\"`) {
+		t.Errorf("expected a focusable wrapper, got:\n%s", s)
+	}
+}
@@ -2,14 +2,17 @@ package generator
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"io"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/alecthomas/chroma/v2"
 	"github.com/alecthomas/chroma/v2/formatters/html"
-	"github.com/alecthomas/chroma/v2/lexers"
 	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/garrettladley/snips/runtime"
 )
 
 type GenerateOpt func(g *generator) error
@@ -39,6 +42,137 @@ func WithExtractStrings() GenerateOpt {
 	}
 }
 
+// WithSymbolLinks wraps identifiers found in links with anchors to the mapped URL,
+// e.g. {"http.Handler": "https://pkg.go.dev/net/http#Handler"}.
+func WithSymbolLinks(links map[string]string) GenerateOpt {
+	return func(g *generator) error {
+		g.links = links
+		return nil
+	}
+}
+
+// WithClass adds a class to the element wrapping the highlighted code.
+func WithClass(class string) GenerateOpt {
+	return func(g *generator) error {
+		g.class = class
+		return nil
+	}
+}
+
+// WithID sets the id of the element wrapping the highlighted code, so that it
+// can be linked to.
+func WithID(id string) GenerateOpt {
+	return func(g *generator) error {
+		g.id = id
+		return nil
+	}
+}
+
+// WithLineNumbersWidth right aligns line numbers in a column at least width
+// characters wide, regardless of the number of digits or the font.
+func WithLineNumbersWidth(width int) GenerateOpt {
+	return func(g *generator) error {
+		if width < 0 {
+			return fmt.Errorf("line numbers width must not be negative, got %d", width)
+		}
+		g.lineNumbersWidth = width
+		return nil
+	}
+}
+
+// WithLineNumbersClass adds a class to line number elements.
+func WithLineNumbersClass(class string) GenerateOpt {
+	return func(g *generator) error {
+		g.lineNumbersClass = class
+		return nil
+	}
+}
+
+// WithFocusLines dims the lines outside the given inclusive line ranges until
+// the snippet is hovered or clicked.
+func WithFocusLines(ranges [][2]int) GenerateOpt {
+	return func(g *generator) error {
+		for _, r := range ranges {
+			if r[0] > r[1] {
+				return fmt.Errorf("invalid focus range %d-%d", r[0], r[1])
+			}
+		}
+		g.focus = ranges
+		return nil
+	}
+}
+
+// WithWordDiff highlights the words that changed between pairs of removed and
+// added lines, when the snippet is a diff.
+func WithWordDiff() GenerateOpt {
+	return func(g *generator) error {
+		g.wordDiff = true
+		return nil
+	}
+}
+
+// WithVariables replaces {{NAME}} placeholders in the contents with the value of
+// the variable before highlighting, e.g. {{SNIPS_VERSION}}.
+func WithVariables(vars map[string]string) GenerateOpt {
+	return func(g *generator) error {
+		g.vars = vars
+		return nil
+	}
+}
+
+// WithLiteralSizes calls report with the size in bytes of the escaped string
+// literal of each generated component, for size budgeting.
+func WithLiteralSizes(report func(componentName string, size int)) GenerateOpt {
+	return func(g *generator) error {
+		g.reportSize = report
+		return nil
+	}
+}
+
+// WithHTMLReport calls report with the highlighted HTML of each generated
+// component, as it's rendered, e.g. to export it for pages that aren't built
+// with templ.
+func WithHTMLReport(report func(componentName, html string)) GenerateOpt {
+	return func(g *generator) error {
+		g.reportHTML = report
+		return nil
+	}
+}
+
+// WithTokenReport calls report with the name of the lexer and the tokens of
+// each generated component, as it's highlighted, e.g. to find the token types
+// that styles need to cover. The tokens mustn't be modified.
+func WithTokenReport(report func(componentName, lexer string, tokens []chroma.Token)) GenerateOpt {
+	return func(g *generator) error {
+		g.reportTokens = report
+		return nil
+	}
+}
+
+// WithTrace calls start when each phase of highlighting, "tokenize" and
+// "format", starts, and the function it returns when the phase ends.
+func WithTrace(start func(phase string) (end func())) GenerateOpt {
+	return func(g *generator) error {
+		g.trace = start
+		return nil
+	}
+}
+
+// sharedPackageAlias is the import alias of the shared package.
+const sharedPackageAlias = "snipsshared"
+
+// WithSharedLiterals references the highlighted HTML from the package at
+// importPath instead of embedding it, so that identical snippets are only
+// compiled once. add is given the contents of the Go string literal, without
+// quotes, and returns the name of the constant in the shared package.
+func WithSharedLiterals(importPath string, add func(literal string) (name string)) GenerateOpt {
+	return func(g *generator) error {
+		g.sharedImportPath = importPath
+		g.sharedAdd = add
+		return nil
+	}
+}
+
 // WithSkipCodeGeneratedComment skips the code generated comment at the top of the file.
 // gopls disables edit related functionality for generated files, so the templ LSP may
 // wish to skip generation of this comment so that gopls provides expected results.
@@ -52,11 +186,21 @@ func WithSkipCodeGeneratedComment() GenerateOpt {
 type generator struct {
 	f chroma.Formatter
 	w *RangeWriter
+	// tableF formats the table variants of components, see
+	// WithTableVariants.
+	tableF chroma.Formatter
+	// tableVariants generates a table variant of each component.
+	tableVariants bool
+	// tables are the names of the table variants of components.
+	tables map[string]bool
 
 	// version of templ.
 	version string
 	// generatedDate to include as a comment.
 	generatedDate string
+	// provenanceHash adds a comment for the hash of the file, see
+	// WithProvenanceHash.
+	provenanceHash bool
 	// style to use for the generated HTML.
 	style string
 	// the contents to be syntax highlighted.
@@ -65,8 +209,107 @@ type generator struct {
 	packageName string
 	// componentName to use in the generated code.
 	componentName string
+	// components to generate after the main component.
+	components []Component
 	// skipCodeGeneratedComment skips the code generated comment at the top of the file.
 	skipCodeGeneratedComment bool
+	// links maps identifiers to the URL they should link to.
+	links map[string]string
+	// class of the wrapper element.
+	class string
+	// id of the wrapper element.
+	id string
+	// lineNumbersWidth is the minimum width of line numbers, in characters.
+	lineNumbersWidth int
+	// lineNumbersClass is added to line number elements.
+	lineNumbersClass string
+	// focus is the inclusive line ranges to focus on, other lines are dimmed.
+	focus [][2]int
+	// wordDiff highlights the changed words within changed lines of diffs.
+	wordDiff bool
+	// vars to substitute for {{NAME}} placeholders in the contents.
+	vars map[string]string
+	// sharedImportPath of the package holding the highlighted HTML.
+	sharedImportPath string
+	// sharedAdd stores highlighted HTML in the shared package.
+	sharedAdd func(literal string) (name string)
+	// reportSize is called with the size of the literal of each component.
+	reportSize func(componentName string, size int)
+	// reportHTML is called with the highlighted HTML of each component.
+	reportHTML func(componentName, html string)
+	// reportTokens is called with the tokens of each component.
+	reportTokens func(componentName, lexer string, tokens []chroma.Token)
+	// streamThreshold is the size of highlighted HTML above which components
+	// are streamed from a compressed blob.
+	streamThreshold int
+	// highlighted HTML of each component, by name, when computed in advance.
+	highlighted map[string]string
+	// streams are the compressed blobs of streamed components, by name.
+	streams map[string][]byte
+	// badgeLabel returns the language badge of a lexer, if badges are enabled.
+	badgeLabel func(lexerName string) string
+	// badge is the language badge of the component being highlighted.
+	badge string
+	// bidiSafe isolates right-to-left text and shows bidi controls.
+	bidiSafe bool
+	// embedded are the lexers of the languages embedded in the snippet, by
+	// context, see WithEmbeddedLanguages.
+	embedded map[string]chroma.Lexer
+	// language is the lexer to use, instead of analysing the contents.
+	language chroma.Lexer
+	// fileName of the snippet, whose lexer is preferred to analysing the
+	// contents.
+	fileName string
+	// reportDetection is called with how the lexer of each component was picked.
+	reportDetection func(componentName string, d Detection)
+	// header is the comments at the top of the file.
+	header Header
+	// buildConstraint is the //go:build expression of the file, if any.
+	buildConstraint string
+	// attribution is the license and source of the snippet.
+	attribution Attribution
+	// attributionFooter renders the attribution below the code.
+	attributionFooter bool
+	// messages localize the UI text of the wrapper, see WithMessages.
+	messages runtime.Messages
+	// runtime renders the styles of wrappers with the runtime package.
+	runtime bool
+	// themes are the light and dark styles to highlight with CSS classes.
+	themes [2]string
+	// analytics adds the name and language of components to their wrappers.
+	analytics bool
+	// lang is the data-snips-lang value of the component being highlighted.
+	lang string
+	// chromaStyle overrides the style named by style.
+	chromaStyle *chroma.Style
+	// rawInvalidUTF8 keeps the bytes of snippets that aren't valid UTF-8.
+	rawInvalidUTF8 bool
+	// contentHash adds the hash of the HTML of components to their wrappers.
+	contentHash bool
+	// hash is the data-snips-hash value of the component being highlighted.
+	hash string
+	// compat is the previous layout to write, see WithCompat.
+	compat string
+	// trace is called when each phase of highlighting starts.
+	trace func(phase string) (end func())
+	// tokenCache stores the tokens of contents, see WithTokenCache.
+	tokenCache TokenCache
+	// baseLine is the number of the first line of plain text.
+	baseLine int
+	// tokenFilters transform the tokens of components before formatting.
+	tokenFilters []TokenFilter
+	// htmlFilters transform the highlighted HTML of components.
+	htmlFilters []HTMLFilter
+	// source is the snippet named by doc comments, see WithSource.
+	source string
+	// caption is added to doc comments, see WithCaption.
+	caption string
+	// lexerNames are the names of the lexers of the highlighted components.
+	lexerNames map[string]string
+	// positionComments comment the literal of each line of HTML with its line,
+	// offset by positionOffset, see WithPositionComments.
+	positionComments bool
+	positionOffset   int
 }
 
 type Config struct {
@@ -75,28 +318,102 @@ type Config struct {
 	Contents      []byte
 	PackageName   string
 	ComponentName string
+	// Components to generate in the same file, in addition to ComponentName.
+	Components []Component
+	// Lang is the name or alias of the chroma lexer to highlight the snippet
+	// with, e.g. "go", instead of detecting it. WithLanguage overrides it.
+	Lang string
+	// FileName is the name of the snippet, e.g. views/main.code.go, whose
+	// lexer is preferred to analysing the contents, see Detection.
+	FileName string
+}
+
+// Component is a syntax highlighted component.
+type Component struct {
+	Name     string
+	Contents []byte
 }
 
 func Generate(w io.Writer, config Config, opts ...GenerateOpt) (literals string, err error) {
+	// Generated code is buffered, so that snippets failing part way through
+	// never leave partial components in w.
+	var buf bytes.Buffer
+	g, err := newGenerator(&buf, config, opts)
+	if err != nil {
+		return "", err
+	}
+	if err = g.generate(); err != nil {
+		return
+	}
+	literals = g.w.literalWriter.literals()
+	_, err = buf.WriteTo(w)
+	return
+}
+
+// Highlight returns the HTML of the main component that Generate writes into
+// its generated code, wrapper included, without generating code, e.g. to
+// highlight code at request time the same as generated snippets. Components
+// are ignored. WithRuntime and WithThemes are refused, since the HTML would
+// depend on styles that components render separately.
+func Highlight(config Config, opts ...GenerateOpt) (s string, err error) {
+	config.Components = nil
+	g, err := newGenerator(io.Discard, config, opts)
+	if err != nil {
+		return "", err
+	}
+	if g.runtime || g.classes() {
+		return "", errors.New("can't highlight with WithRuntime or WithThemes, whose styles are rendered separately")
+	}
+	return g.highlight()
+}
 
-	g := generator{
-		f:             html.New(config.HTMLOpts...),
+// newGenerator returns a generator of config, writing to w, with the options
+// applied.
+func newGenerator(w io.Writer, config Config, opts []GenerateOpt) (g *generator, err error) {
+	g = &generator{
 		w:             NewRangeWriter(w),
 		style:         config.Style,
 		contents:      config.Contents,
 		packageName:   config.PackageName,
 		componentName: config.ComponentName,
+		components:    config.Components,
+		fileName:      config.FileName,
+	}
+	if config.Lang != "" {
+		if err = WithLanguage(config.Lang)(g); err != nil {
+			return nil, err
+		}
 	}
 
 	for _, opt := range opts {
-		if err = opt(&g); err != nil {
-			return
+		if err = opt(g); err != nil {
+			return nil, err
 		}
 	}
 
-	err = g.generate()
-	literals = g.w.literalWriter.literals()
-	return
+	g.f = html.New(g.htmlOpts(config.HTMLOpts)...)
+	if g.tableVariants {
+		if g.f, g.tableF, err = g.addTableVariants(config.HTMLOpts); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+// htmlOpts returns the formatter options, including those needed by the
+// generate options.
+func (g *generator) htmlOpts(opts []html.Option) []html.Option {
+	opts = slices.Clip(opts)
+	if css := g.customCSS(); len(css) > 0 {
+		opts = append(opts, html.WithCustomCSS(css))
+	}
+	if len(g.focus) > 0 {
+		opts = append(opts, html.HighlightLines(unfocusedRanges(g.focus)))
+	}
+	if g.classes() {
+		opts = append(opts, html.WithClasses(true))
+	}
+	return opts
 }
 
 func (g *generator) generate() (err error) {
@@ -109,18 +426,33 @@ func (g *generator) generate() (err error) {
 	if err = g.writeGeneratedDateComment(); err != nil {
 		return
 	}
+	if err = g.writeProvenanceComment(); err != nil {
+		return
+	}
+	if err = g.writeAttributionComment(); err != nil {
+		return
+	}
 	if err = g.writePackage(); err != nil {
 		return
 	}
+	if err = g.compressStreams(); err != nil {
+		return
+	}
 	if err = g.writeImports(); err != nil {
 		return
 	}
 	if err = g.writeComponent(); err != nil {
 		return
 	}
+	if err = g.writeComponents(); err != nil {
+		return
+	}
 	if err = g.writeBlankAssignmentForRuntimeImport(); err != nil {
 		return
 	}
+	if err = g.writeBlobs(); err != nil {
+		return
+	}
 
 	return err
 }
@@ -129,12 +461,15 @@ func (g *generator) generate() (err error) {
 // Automatically generated files have a comment in the header that instructs the LSP
 // to stop operating.
 func (g *generator) writeCodeGeneratedComment() (err error) {
+	if _, err = g.w.Write(BuildConstraintComment(g.buildConstraint)); err != nil {
+		return err
+	}
 	if g.skipCodeGeneratedComment {
 		// Write an empty comment so that the file is the same shape.
 		_, err = g.w.Write("//\n\n")
 		return err
 	}
-	_, err = g.w.Write("// Code generated by snips - DO NOT EDIT.\n\n")
+	_, err = g.w.Write(g.header.Comment())
 	return err
 }
 
@@ -153,31 +488,28 @@ func (g *generator) writeGeneratedDateComment() (err error) {
 }
 
 func (g *generator) writePackage() (err error) {
-	if _, err := g.w.Write("package " + g.packageName + "\n\n"); err != nil {
+	if _, err := g.w.Write(g.header.PragmaComment() + "package " + g.packageName + "\n\n"); err != nil {
 		return err
 	}
-	if _, err = g.w.Write("//lint:file-ignore SA4006 This context is only used if a nested component is present.\n\n"); err != nil {
+	if _, err = g.w.Write(g.header.lintIgnore()); err != nil {
 		return err
 	}
 	return err
 }
 
-func (g *generator) writeImports() error {
-	var err error
-	// Always import templ because it's the interface type of all templates.
-	if _, err = g.w.Write("import \"github.com/a-h/templ\"\n"); err != nil {
-		return err
-	}
-	if _, err = g.w.Write("import templruntime \"github.com/a-h/templ/runtime\"\n"); err != nil {
-		return err
+func (g *generator) writeComponent() (err error) {
+	// The component is highlighted first, so that its doc comment can name
+	// the language it was highlighted as.
+	blob, streamed := g.streams[g.componentName]
+	var out string
+	if !streamed {
+		if out, err = g.highlight(); err != nil {
+			return err
+		}
 	}
-	if _, err = g.w.Write("\n"); err != nil {
+	if err = g.writeDocComment(); err != nil {
 		return err
 	}
-	return nil
-}
-
-func (g *generator) writeComponent() (err error) {
 	if _, err = g.w.Write("func " + g.componentName + "() templ.Component {\n"); err != nil {
 		return
 	}
@@ -242,12 +574,39 @@ func (g *generator) writeComponent() (err error) {
 		return
 	}
 
-	chromaString, err := g.chroma()
+	if err = g.writeRuntimeStyles(); err != nil {
+		return err
+	}
+	if err = g.writeThemeStyles(); err != nil {
+		return err
+	}
+	if streamed {
+		if g.reportHTML != nil {
+			g.reportHTML(g.componentName, g.highlighted[g.componentName])
+		}
+		return g.writeStreamedComponentBody(blob)
+	}
+
+	if g.reportHTML != nil {
+		g.reportHTML(g.componentName, out)
+	}
+	chromaString, err := escape(out)
 	if err != nil {
 		return err
 	}
 
-	if _, err = g.w.Write("\t\t_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(\"" + chromaString + "\")\n"); err != nil {
+	if g.reportSize != nil {
+		g.reportSize(g.componentName, len(chromaString))
+	}
+	literal := "\"" + chromaString + "\""
+	if g.sharedAdd != nil {
+		literal = sharedPackageAlias + "." + g.sharedAdd(chromaString)
+	} else if g.positionComments {
+		if literal, err = g.positionLiteral(out); err != nil {
+			return err
+		}
+	}
+	if _, err = g.w.Write("\t\t_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(" + literal + ")\n"); err != nil {
 		return
 	}
 	if _, err = g.w.Write("\t\tif templ_7745c5c3_Err != nil {\n"); err != nil {
@@ -271,42 +630,179 @@ func (g *generator) writeComponent() (err error) {
 	return nil
 }
 
+// writeComponents writes the additional components. The wrapper id is only set
+// on the main component, so that it stays unique within a page.
+func (g *generator) writeComponents() (err error) {
+	g.id, g.positionOffset = "", 0
+	for _, c := range g.orderedComponents() {
+		g.componentName, g.contents = c.Name, c.Contents
+		if _, err = g.w.Write("\n"); err != nil {
+			return err
+		}
+		if err = g.writeComponent(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chroma returns the highlighted HTML of the component, escaped for use in a
+// Go string literal.
 func (g *generator) chroma() (s string, err error) {
+	out, err := g.highlight()
+	if err != nil {
+		return s, err
+	}
+	return escape(out)
+}
+
+// escape escapes highlighted HTML for use in a Go string literal.
+func escape(html string) (string, error) {
+	var b bytes.Buffer
+	if _, err := NewEscapeWriter(&b).Write([]byte(html)); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// highlight returns the highlighted HTML of the component.
+func (g *generator) highlight() (s string, err error) {
+	if s, ok := g.highlighted[g.componentName]; ok {
+		return s, nil
+	}
+
 	contents, err := io.ReadAll(bytes.NewReader(g.contents))
 	if err != nil {
 		return s, err
 	}
 
-	strContents := string(contents)
+	strContents := expandVariables(string(contents), g.vars)
+	if g.rawInvalidUTF8 {
+		strContents = protectInvalidUTF8(strContents)
+	}
 
-	lexer := lexers.Analyse(strContents)
-	if lexer == nil {
-		lexer = lexers.Fallback
+	lexer := g.lexer(strContents)
+	if g.lexerNames == nil {
+		g.lexerNames = make(map[string]string)
 	}
-	lexer = chroma.Coalesce(lexer)
+	g.lexerNames[g.componentName] = lexer.Config().Name
 
-	style := styles.Get(g.style)
+	style := g.chromaStyle
 	if style == nil {
-		style = styles.Fallback
+		style = styles.Get(g.style)
 	}
 
-	iterator, err := lexer.Tokenise(nil, strContents)
+	tokens, err := g.tokenise(lexer, strContents)
+	if err != nil {
+		return s, fmt.Errorf("failed to highlight %s: %w", g.componentName, err)
+	}
+	if g.wordDiff && lexer.Config().Name == "Diff" {
+		tokens = diffWords(tokens)
+	}
+	tokens = g.filterTokens(tokens)
+	if g.reportTokens != nil {
+		g.reportTokens(g.componentName, lexer.Config().Name, tokens)
+	}
 
-	var b bytes.Buffer
-	ew := NewEscapeWriter(&b)
-	if err := g.f.Format(ew, style, iterator); err != nil {
+	g.lang = ""
+	if g.analytics {
+		g.lang = analyticsLanguage(lexer)
+	}
+
+	g.badge = ""
+	if g.badgeLabel != nil {
+		g.badge = g.badgeLabel(lexer.Config().Name)
+	}
+
+	var code bytes.Buffer
+	end := g.startPhase("format")
+	err = g.format(&code, style, tokens)
+	end()
+	if err != nil {
+		return s, fmt.Errorf("failed to format %s: %w", g.componentName, err)
+	}
+
+	out := code.String()
+	if g.rawInvalidUTF8 {
+		out = restoreInvalidUTF8(out)
+	}
+	if out, err = g.filterHTML(out); err != nil {
 		return s, err
 	}
 
+	g.hash = ""
+	if s, err = g.wrap(out); err != nil || !g.contentHash {
+		return s, err
+	}
+	// The hash covers everything the component renders, but itself.
+	g.hash = contentHash(s)
+	return g.wrap(out)
+}
+
+// wrap returns the highlighted code within its wrapper.
+func (g *generator) wrap(code string) (s string, err error) {
+	var b bytes.Buffer
+	if err = g.writeWrapperOpen(&b); err != nil {
+		return s, err
+	}
+	b.WriteString(code)
+	if err = g.writeWrapperClose(&b); err != nil {
+		return s, err
+	}
 	return b.String(), nil
 }
 
+// startPhase starts the named phase of highlighting, returning the function
+// that ends it.
+func (g *generator) startPhase(name string) (end func()) {
+	if g.trace == nil {
+		return func() {}
+	}
+	return g.trace(name)
+}
+
+func (g *generator) format(w io.Writer, style *chroma.Style, tokens []chroma.Token) error {
+	var replacer *strings.Replacer
+	if len(g.links) > 0 {
+		tokens, replacer = linkSymbols(tokens, g.links)
+	}
+
+	if g.bidiSafe {
+		tokens = isolateRTL(tokens)
+	}
+
+	var formatted bytes.Buffer
+	f := g.f
+	if g.tables[g.componentName] {
+		f = g.tableF
+	}
+	if err := formatTokens(f, &formatted, style, tokens); err != nil {
+		return err
+	}
+
+	out := formatted.String()
+	if replacer != nil {
+		out = replacer.Replace(out)
+	}
+	if g.wordDiff {
+		out = wordDiffReplacer.Replace(out)
+	}
+	if g.bidiSafe {
+		out = bidiReplacer.Replace(out)
+	}
+	out = g.classifyLineNumbers(out)
+	out = g.classifyUnfocused(out)
+
+	_, err := io.WriteString(w, out)
+	return err
+}
+
 // writeBlankAssignmentForRuntimeImport writes out a blank identifier assignment.
 // This ensures that even if the github.com/a-h/templ/runtime package is not used in the generated code,
 // the Go compiler will not complain about the unused import.
 func (g *generator) writeBlankAssignmentForRuntimeImport() error {
 	var err error
-	if _, err = g.w.Write("var _ = templruntime.GeneratedTemplate"); err != nil {
+	if _, err = g.w.Write("\nvar _ = templruntime.GeneratedTemplate\n"); err != nil {
 		return err
 	}
 	return nil
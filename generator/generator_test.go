@@ -0,0 +1,32 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTrace(t *testing.T) {
+	var phases []string
+	trace := func(phase string) func() {
+		phases = append(phases, phase)
+		return func() { phases = append(phases, phase+" end") }
+	}
+	var b strings.Builder
+	_, err := Generate(&b, Config{
+		Contents:      []byte("package main\n"),
+		PackageName:   "main",
+		ComponentName: "Main",
+		Components: []Component{
+			{Name: "MainPackage", Contents: []byte("package main\n")},
+		},
+	}, WithTrace(trace))
+	if err != nil {
+		t.Fatalf("failed to generate: %v", err)
+	}
+	expected := []string{"tokenize", "tokenize end", "format", "format end"}
+	if diff := cmp.Diff(append(expected, expected...), phases); diff != "" {
+		t.Errorf("unexpected phases:\n%s", diff)
+	}
+}
@@ -0,0 +1,28 @@
+package generator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// hashAttribute is the wrapper attribute holding the hash of the component's
+// HTML, e.g. data-snips-hash="3f2a9c0d41b7e856".
+const hashAttribute = "data-snips-hash"
+
+// WithContentHash adds a data-snips-hash attribute to the wrapper of each
+// component, the hash of its highlighted HTML, so that HTTP caches and edge
+// renderers can use it as a cache key or ETag for snippet partials without
+// hashing the HTML themselves.
+func WithContentHash() GenerateOpt {
+	return func(g *generator) error {
+		g.contentHash = true
+		return nil
+	}
+}
+
+// contentHash returns the data-snips-hash value of highlighted HTML, the first
+// 16 hex digits of its SHA-256 hash.
+func contentHash(html string) string {
+	sum := sha256.Sum256([]byte(html))
+	return hex.EncodeToString(sum[:8])
+}
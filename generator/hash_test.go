@@ -0,0 +1,44 @@
+package generator
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/chroma/v2/formatters/html"
+)
+
+func TestContentHash(t *testing.T) {
+	highlight := func(contents string, opts ...GenerateOpt) string {
+		t.Helper()
+		g := generator{f: html.New(), contents: []byte(contents), componentName: "Example"}
+		for _, opt := range opts {
+			if err := opt(&g); err != nil {
+				t.Fatal(err)
+			}
+		}
+		s, err := g.highlight()
+		if err != nil {
+			t.Fatalf("failed to highlight: %v", err)
+		}
+		return s
+	}
+
+	s := highlight("package main\n", WithContentHash())
+	hash := regexp.MustCompile(`^<div data-snips-hash="([0-9a-f]{16})">`).FindStringSubmatch(s)
+	if hash == nil {
+		t.Fatalf("expected a wrapper with a hash, got:\n%s", s)
+	}
+	if expected := contentHash(highlight("package main\n")); hash[1] != expected {
+		t.Errorf("expected the hash of the HTML without it, %s, got %s", expected, hash[1])
+	}
+	if other := highlight("package other\n", WithContentHash()); strings.Contains(other, hash[1]) {
+		t.Errorf("expected the hash to change with the snippet, got:\n%s", other)
+	}
+	if !strings.Contains(highlight("package main\n", WithContentHash(), WithAnalyticsAttributes()), ` data-snips-hash="`) {
+		t.Error("expected the hash alongside other attributes")
+	}
+	if s := highlight("package main\n"); strings.Contains(s, hashAttribute) {
+		t.Errorf("expected no hash by default, got:\n%s", s)
+	}
+}
@@ -0,0 +1,143 @@
+package generator
+
+import (
+	"fmt"
+	"go/build/constraint"
+	"regexp"
+	"strings"
+)
+
+const (
+	// DefaultCodeGeneratedComment marks generated files, see Header.
+	DefaultCodeGeneratedComment = "Code generated by snips - DO NOT EDIT."
+	// DefaultLintIgnore is the lint pragma written after the package clause,
+	// see Header.
+	DefaultLintIgnore = "lint:file-ignore SA4006 This context is only used if a nested component is present."
+)
+
+// codeGeneratedComment matches comments that mark generated files, see
+// https://pkg.go.dev/cmd/go#hdr-Generate_Go_files_by_processing_source.
+var codeGeneratedComment = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+
+// Header is the comments at the top of generated files, which some projects
+// need to customize, e.g. to add a license or codegen tags. The zero value is
+// the default header.
+type Header struct {
+	// Text is written as comments above the code generated comment, e.g. a
+	// license. Lines that aren't already comments are commented out.
+	Text string
+	// CodeGenerated replaces DefaultCodeGeneratedComment. Go tools only
+	// recognise generated files by comments matching
+	// "Code generated .* DO NOT EDIT.", so it must too.
+	CodeGenerated string
+	// LintIgnore replaces DefaultLintIgnore, with a pragma on each line, or
+	// omits it if "none".
+	LintIgnore string
+	// Pragmas are written directly above the package clause, one per line,
+	// e.g. "//nolint:all" or "//coverage:ignore", where linters and coverage
+	// tools read file-wide directives. Lines that aren't already comments are
+	// commented out.
+	Pragmas string
+}
+
+// Validate checks that the code generated comment is still recognised by Go
+// tools.
+func (h Header) Validate() error {
+	if h.CodeGenerated != "" && !codeGeneratedComment.MatchString(h.codeGenerated()) {
+		return fmt.Errorf("code generated comment %q must match %q", h.CodeGenerated, "Code generated .* DO NOT EDIT.")
+	}
+	return nil
+}
+
+// Comment returns the comments to start generated files with, followed by a
+// blank line.
+func (h Header) Comment() string {
+	var sb strings.Builder
+	if h.Text != "" {
+		sb.WriteString(commentLines(h.Text, "// "))
+		sb.WriteString("\n")
+	}
+	sb.WriteString(h.codeGenerated() + "\n\n")
+	return sb.String()
+}
+
+func (h Header) codeGenerated() string {
+	if h.CodeGenerated == "" {
+		return "// " + DefaultCodeGeneratedComment
+	}
+	return "// " + strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(h.CodeGenerated), "//"))
+}
+
+// PragmaComment returns the pragmas to write directly above the package
+// clause, if any.
+func (h Header) PragmaComment() string {
+	if strings.TrimSpace(h.Pragmas) == "" {
+		return ""
+	}
+	return commentLines(h.Pragmas, "//")
+}
+
+// lintIgnore returns the lint pragmas, followed by a blank line, if any.
+func (h Header) lintIgnore() string {
+	switch h.LintIgnore {
+	case "":
+		return "//" + DefaultLintIgnore + "\n\n"
+	case "none":
+		return ""
+	}
+	return commentLines(h.LintIgnore, "//") + "\n"
+}
+
+// commentLines comments out each line of text that isn't already a comment
+// with prefix, returning the lines with a trailing newline.
+func commentLines(text, prefix string) string {
+	var sb strings.Builder
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		switch {
+		case strings.HasPrefix(line, "//"):
+			sb.WriteString(line)
+		case line == "":
+			sb.WriteString("//")
+		default:
+			sb.WriteString(prefix + line)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// WithHeader customizes the comments at the top of generated files.
+func WithHeader(h Header) GenerateOpt {
+	return func(g *generator) error {
+		if err := h.Validate(); err != nil {
+			return err
+		}
+		g.header = h
+		return nil
+	}
+}
+
+// BuildConstraintComment returns the //go:build line of expr, followed by a
+// blank line, or "" if expr is empty.
+func BuildConstraintComment(expr string) string {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return ""
+	}
+	return "//go:build " + expr + "\n\n"
+}
+
+// WithBuildConstraint starts generated files with a //go:build line, e.g.
+// "docs" to only build the components with the docs tag.
+func WithBuildConstraint(expr string) GenerateOpt {
+	return func(g *generator) error {
+		if expr = strings.TrimSpace(expr); expr != "" {
+			if _, err := constraint.Parse("//go:build " + expr); err != nil {
+				return fmt.Errorf("invalid build constraint %q: %w", expr, err)
+			}
+		}
+		g.buildConstraint = expr
+		return nil
+	}
+}
@@ -0,0 +1,80 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHeader(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   Header
+		expected string
+	}{
+		{
+			name:     "default",
+			expected: "// Code generated by snips - DO NOT EDIT.\n\npackage main\n\n//lint:file-ignore SA4006",
+		},
+		{
+			name: "license and codegen tags",
+			header: Header{
+				Text:          "Copyright 2024 Example Inc.\n\nSPDX-License-Identifier: MIT\n",
+				CodeGenerated: "Code generated by snips (//tools/codegen). DO NOT EDIT.",
+				LintIgnore:    "//nolint:all\nlint:file-ignore SA4006 Unused contexts.",
+			},
+			expected: "// Copyright 2024 Example Inc.\n//\n// SPDX-License-Identifier: MIT\n\n" +
+				"// Code generated by snips (//tools/codegen). DO NOT EDIT.\n\n" +
+				"package main\n\n//nolint:all\n//lint:file-ignore SA4006 Unused contexts.\n\n",
+		},
+		{
+			name:     "pragmas",
+			header:   Header{Pragmas: "//nolint:all\ncoverage:ignore\n"},
+			expected: "// Code generated by snips - DO NOT EDIT.\n\n//nolint:all\n//coverage:ignore\npackage main\n\n//lint:file-ignore SA4006",
+		},
+		{
+			name:     "no lint pragma",
+			header:   Header{LintIgnore: "none"},
+			expected: "// Code generated by snips - DO NOT EDIT.\n\npackage main\n\nimport",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			_, err := Generate(&b, Config{
+				Contents:      []byte("package main\n"),
+				PackageName:   "main",
+				ComponentName: "Main",
+			}, WithHeader(tt.header))
+			if err != nil {
+				t.Fatalf("failed to generate: %v", err)
+			}
+			if !strings.HasPrefix(b.String(), tt.expected) {
+				t.Errorf("expected output to start with %q, got:\n%s", tt.expected, b.String())
+			}
+		})
+	}
+
+	if err := WithHeader(Header{CodeGenerated: "Generated by snips."})(&generator{}); err == nil {
+		t.Error("expected an error for a code generated comment that Go tools don't recognise")
+	}
+}
+
+func TestBuildConstraint(t *testing.T) {
+	var b strings.Builder
+	_, err := Generate(&b, Config{
+		Contents:      []byte("package main\n"),
+		PackageName:   "main",
+		ComponentName: "Main",
+	}, WithBuildConstraint("docs && !prod"), WithHeader(Header{Text: "SPDX-License-Identifier: MIT"}))
+	if err != nil {
+		t.Fatalf("failed to generate: %v", err)
+	}
+	expected := "//go:build docs && !prod\n\n// SPDX-License-Identifier: MIT\n\n// Code generated by snips - DO NOT EDIT.\n\npackage main\n"
+	if !strings.HasPrefix(b.String(), expected) {
+		t.Errorf("expected output to start with %q, got:\n%s", expected, b.String())
+	}
+
+	if err := WithBuildConstraint("docs &&")(&generator{}); err == nil {
+		t.Error("expected an error for an invalid build constraint")
+	}
+}
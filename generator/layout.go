@@ -0,0 +1,160 @@
+package generator
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Generated files are laid out in a stable order, so that the diffs of
+// generated trees between snips versions only show what actually changed:
+//
+//  1. the build constraint
+//  2. the code generated, version, generation date and attribution comments
+//  3. the pragmas, then the package clause
+//  4. the lint ignore comment
+//  5. a single import block, the standard library first, then other
+//     packages, each sorted by path
+//  6. the main component, then the other components sorted by name, each
+//     after its doc comment
+//  7. the blank assignment of the templ runtime import
+//  8. the blob constants of streamed components, sorted by name
+//
+// The previous layouts are kept by WithCompat, so that upgrades of giant
+// generated trees can be made separately from layout changes.
+
+// compatLayouts are the previous layouts, by the minor version of the snips
+// releases that generated them.
+//
+// v0.1 imports each package on its own line, in the order the features that
+// need them were added, writes the other components in the order they're
+// defined, writes the blob constant of each streamed component after it, and
+// doesn't write doc comments.
+var compatLayouts = []string{"v0.1"}
+
+// compatVersion matches versions of snips, e.g. v0.1 or v0.1.0.
+var compatVersion = regexp.MustCompile(`^(v\d+\.\d+)(?:\.\d+)?$`)
+
+// CheckCompat checks that version is a snips version with a previous layout,
+// e.g. v0.1, returning its layout.
+func CheckCompat(version string) (layout string, err error) {
+	if m := compatVersion.FindStringSubmatch(version); m != nil && slices.Contains(compatLayouts, m[1]) {
+		return m[1], nil
+	}
+	return "", fmt.Errorf("no previous layout for %q, expected one of %s", version, strings.Join(compatLayouts, ", "))
+}
+
+// WithCompat lays generated files out as the snips version did, e.g. v0.1,
+// instead of in the current layout.
+func WithCompat(version string) GenerateOpt {
+	return func(g *generator) (err error) {
+		g.compat, err = CheckCompat(version)
+		return err
+	}
+}
+
+// goImport is an import of a generated file.
+type goImport struct {
+	alias string
+	path  string
+}
+
+func (i goImport) String() string {
+	if i.alias != "" {
+		return i.alias + " " + `"` + i.path + `"`
+	}
+	return `"` + i.path + `"`
+}
+
+// imports returns the imports of the generated file, in the order of the v0.1
+// layout.
+func (g *generator) imports() []goImport {
+	// Always import templ because it's the interface type of all templates.
+	imports := []goImport{
+		{path: "github.com/a-h/templ"},
+		{alias: "templruntime", path: "github.com/a-h/templ/runtime"},
+	}
+	if len(g.streams) > 0 {
+		imports = append(imports, goImport{path: "compress/gzip"}, goImport{path: "io"}, goImport{path: "strings"})
+	}
+	if g.usesRuntime() {
+		imports = append(imports, goImport{alias: runtimePackageAlias, path: runtimeImportPath})
+	}
+	if g.sharedImportPath != "" {
+		imports = append(imports, goImport{alias: sharedPackageAlias, path: g.sharedImportPath})
+	}
+	return imports
+}
+
+func (g *generator) writeImports() (err error) {
+	imports := g.imports()
+	if g.compat == "v0.1" {
+		for _, i := range imports {
+			if _, err = g.w.Write("import " + i.String() + "\n"); err != nil {
+				return err
+			}
+		}
+		_, err = g.w.Write("\n")
+		return err
+	}
+
+	var std, other []goImport
+	for _, i := range imports {
+		if IsStandardImportPath(i.path) {
+			std = append(std, i)
+		} else {
+			other = append(other, i)
+		}
+	}
+	byPath := func(a, b goImport) int { return strings.Compare(a.path, b.path) }
+	slices.SortFunc(std, byPath)
+	slices.SortFunc(other, byPath)
+	var sb strings.Builder
+	sb.WriteString("import (\n")
+	for _, i := range std {
+		sb.WriteString("\t" + i.String() + "\n")
+	}
+	if len(std) > 0 && len(other) > 0 {
+		sb.WriteString("\n")
+	}
+	for _, i := range other {
+		sb.WriteString("\t" + i.String() + "\n")
+	}
+	sb.WriteString(")\n\n")
+	_, err = g.w.Write(sb.String())
+	return err
+}
+
+// IsStandardImportPath reports whether the import path is of the standard
+// library, whose first element has no dot.
+func IsStandardImportPath(path string) bool {
+	first, _, _ := strings.Cut(path, "/")
+	return !strings.Contains(first, ".")
+}
+
+// orderedComponents returns the components after the main component, in the
+// order they're written.
+func (g *generator) orderedComponents() []Component {
+	if g.compat == "v0.1" {
+		return g.components
+	}
+	return slices.SortedStableFunc(slices.Values(g.components), func(a, b Component) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+}
+
+// writeBlobs writes the blob constants of streamed components, which the v0.1
+// layout writes after each component instead.
+func (g *generator) writeBlobs() (err error) {
+	if g.compat == "v0.1" {
+		return nil
+	}
+	for _, name := range slices.Sorted(maps.Keys(g.streams)) {
+		if err = g.writeBlob(name, g.streams[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
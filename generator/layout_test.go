@@ -0,0 +1,74 @@
+package generator
+
+import (
+	"flag"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var update = flag.Bool("update", false, "update the golden files of generated code")
+
+func TestLayoutGolden(t *testing.T) {
+	config := Config{
+		Contents:      []byte("package main\n\nfunc main() {}\n"),
+		PackageName:   "docs",
+		ComponentName: "MainGo",
+		// Out of order, so that the layout sorts them.
+		Components: []Component{
+			{Name: "MainGoZeta", Contents: []byte("var zeta = 1\n")},
+			{Name: "MainGoAlpha", Contents: []byte("var alpha = \"a\\tb\"\n")},
+		},
+	}
+	for _, compat := range []string{"", "v0.1"} {
+		name := "layout"
+		// Only MainGoAlpha is large enough to stream, so that the layouts place its
+		// blob differently.
+		opts := []GenerateOpt{WithRuntime(), WithStreaming(300), WithSource("views/main.code.go"), WithCaption("The main\n\nfunction.")}
+		if compat != "" {
+			name += "_" + compat
+			opts = append(opts, WithCompat(compat))
+		}
+		t.Run(name, func(t *testing.T) {
+			var b strings.Builder
+			if _, err := Generate(&b, config, opts...); err != nil {
+				t.Fatalf("failed to generate: %v", err)
+			}
+			got, err := format.Source([]byte(b.String()))
+			if err != nil {
+				t.Fatalf("generated invalid Go: %v\n%s", err, b.String())
+			}
+			golden := filepath.Join("testdata", name+".golden")
+			if *update {
+				if err = os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("failed to read golden file, run with -update to create it: %v", err)
+			}
+			if diff := cmp.Diff(string(want), string(got)); diff != "" {
+				t.Errorf("generated code differs from %s, run with -update if the change is intended (-want +got):\n%s", golden, diff)
+			}
+		})
+	}
+}
+
+func TestCheckCompat(t *testing.T) {
+	for version, want := range map[string]string{"v0.1": "v0.1", "v0.1.3": "v0.1"} {
+		got, err := CheckCompat(version)
+		if err != nil || got != want {
+			t.Errorf("CheckCompat(%q) = %q, %v, want %q", version, got, err, want)
+		}
+	}
+	for _, version := range []string{"", "0.1", "v0.2", "v0.1.x", "latest"} {
+		if _, err := CheckCompat(version); err == nil {
+			t.Errorf("CheckCompat(%q) expected an error", version)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package generator
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+
+	"github.com/alecthomas/chroma/v2"
+)
+
+// lineNumbersMarker is a CSS custom property appended to the inline style of
+// line number elements, so they can be found in the formatted HTML.
+const lineNumbersMarker = "--snips-line-numbers:1"
+
+var lineNumbersStyle = regexp.MustCompile(` style="([^"]*?);?` + regexp.QuoteMeta(lineNumbersMarker) + `"`)
+
+// lineNumbersClasses matches the classes of line numbers when highlighting
+// with CSS classes, see WithThemes.
+var lineNumbersClasses = regexp.MustCompile(`class="(lnt?)([" ])`)
+
+// customCSS returns the CSS to add to chroma's styles, by token type.
+func (g *generator) customCSS() map[chroma.TokenType]string {
+	css := make(map[chroma.TokenType]string)
+	var lineNumbers string
+	if g.lineNumbersWidth > 0 {
+		lineNumbers += "display:inline-block;min-width:" + strconv.Itoa(g.lineNumbersWidth) + "ch;text-align:right;"
+	}
+	if g.lineNumbersClass != "" {
+		lineNumbers += lineNumbersMarker
+	}
+	if lineNumbers != "" {
+		css[chroma.LineNumbers] = lineNumbers
+		css[chroma.LineNumbersTable] = lineNumbers
+	}
+	if len(g.focus) > 0 {
+		css[chroma.LineHighlight] = "background-color:transparent;" + unfocusedMarker
+	}
+	return css
+}
+
+// classifyLineNumbers adds the line numbers class to the elements marked by
+// customCSS.
+func (g *generator) classifyLineNumbers(s string) string {
+	if g.lineNumbersClass == "" {
+		return s
+	}
+	if g.classes() {
+		return lineNumbersClasses.ReplaceAllString(s, `class="$1 `+html.EscapeString(g.lineNumbersClass)+`$2`)
+	}
+	return lineNumbersStyle.ReplaceAllString(s, ` class="`+html.EscapeString(g.lineNumbersClass)+`" style="$1"`)
+}
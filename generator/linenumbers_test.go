@@ -0,0 +1,37 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/chroma/v2/formatters/html"
+)
+
+func TestLineNumbers(t *testing.T) {
+	for _, table := range []bool{false, true} {
+		g := generator{
+			contents:         []byte("package main\n\nfunc main() {}\n"),
+			lineNumbersWidth: 4,
+			lineNumbersClass: "ln",
+		}
+		g.f = html.New(
+			html.WithLineNumbers(true),
+			html.LineNumbersInTable(table),
+			html.WithCustomCSS(g.customCSS()),
+		)
+
+		s, err := g.chroma()
+		if err != nil {
+			t.Fatalf("failed to highlight: %v", err)
+		}
+		if n := strings.Count(s, `class=\"ln\"`); n != 3 {
+			t.Errorf("table=%v: expected 3 classified line numbers, got %d:\n%s", table, n, s)
+		}
+		if strings.Contains(s, lineNumbersMarker) {
+			t.Errorf("table=%v: expected the marker to be removed, got:\n%s", table, s)
+		}
+		if !strings.Contains(s, "min-width:4ch") {
+			t.Errorf("table=%v: expected the line numbers width to be set, got:\n%s", table, s)
+		}
+	}
+}
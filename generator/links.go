@@ -0,0 +1,67 @@
+package generator
+
+import (
+	"html"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+)
+
+// Placeholders are built from private use code points, which chroma's HTML
+// formatter passes through unescaped.
+const (
+	placeholderStart = "\uE000"
+	placeholderEnd   = "\uE001"
+)
+
+// linkSymbols swaps identifier tokens found in links for placeholders, so that
+// the anchors can be spliced into the formatted HTML afterwards. Identifiers are
+// matched qualified first (e.g. "http.Handler"), then unqualified ("Handler").
+// The returned replacer maps each placeholder to its anchor.
+func linkSymbols(tokens []chroma.Token, links map[string]string) ([]chroma.Token, *strings.Replacer) {
+	var (
+		out          = make([]chroma.Token, 0, len(tokens))
+		placeholders = make(map[string]string)
+		replacements []string
+		qualifier    string
+		afterDot     bool
+	)
+	for _, tok := range tokens {
+		if !tok.Type.InCategory(chroma.Name) {
+			afterDot = qualifier != "" && tok.Value == "."
+			if !afterDot {
+				qualifier = ""
+			}
+			out = append(out, tok)
+			continue
+		}
+
+		name := tok.Value
+		url, ok := "", false
+		if afterDot {
+			url, ok = links[qualifier+"."+name]
+		}
+		if !ok {
+			url, ok = links[name]
+		}
+		qualifier, afterDot = name, false
+		if !ok {
+			out = append(out, tok)
+			continue
+		}
+
+		key := url + "\x00" + name
+		placeholder, seen := placeholders[key]
+		if !seen {
+			placeholder = placeholderStart + strconv.Itoa(len(placeholders)) + placeholderEnd
+			placeholders[key] = placeholder
+			replacements = append(replacements,
+				placeholder,
+				`<a href="`+html.EscapeString(url)+`">`+html.EscapeString(name)+`</a>`,
+			)
+		}
+		out = append(out, chroma.Token{Type: tok.Type, Value: placeholder})
+	}
+	return out, strings.NewReplacer(replacements...)
+}
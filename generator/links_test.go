@@ -0,0 +1,66 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/chroma/v2/formatters/html"
+)
+
+func TestSymbolLinks(t *testing.T) {
+	t.Run("links unqualified identifiers", func(t *testing.T) {
+		g := generator{
+			f:        html.New(),
+			contents: []byte("package main\n\nfunc Handler() {}\n"),
+			links:    map[string]string{"Handler": "https://example.com/#Handler"},
+		}
+
+		s, err := g.chroma()
+		if err != nil {
+			t.Fatalf("failed to highlight: %v", err)
+		}
+		expected := `<a href=\"https://example.com/#Handler\">Handler</a>`
+		if !strings.Contains(s, expected) {
+			t.Errorf("expected output to contain %s, got:\n%s", expected, s)
+		}
+	})
+
+	t.Run("prefers qualified identifiers", func(t *testing.T) {
+		g := generator{
+			f:        html.New(),
+			contents: []byte("package main\n\nimport \"net/http\"\n\nvar h http.Handler\n"),
+			links: map[string]string{
+				"Handler":      "https://example.com/#Handler",
+				"http.Handler": "https://pkg.go.dev/net/http#Handler",
+			},
+		}
+
+		s, err := g.chroma()
+		if err != nil {
+			t.Fatalf("failed to highlight: %v", err)
+		}
+		expected := `<a href=\"https://pkg.go.dev/net/http#Handler\">Handler</a>`
+		if !strings.Contains(s, expected) {
+			t.Errorf("expected output to contain %s, got:\n%s", expected, s)
+		}
+	})
+
+	t.Run("leaves unmatched identifiers alone", func(t *testing.T) {
+		g := generator{
+			f:        html.New(),
+			contents: []byte("package main\n\nfunc Handler() {}\n"),
+			links:    map[string]string{"Other": "https://example.com/#Other"},
+		}
+
+		s, err := g.chroma()
+		if err != nil {
+			t.Fatalf("failed to highlight: %v", err)
+		}
+		if strings.Contains(s, "<a ") {
+			t.Errorf("expected no links, got:\n%s", s)
+		}
+		if strings.ContainsAny(s, placeholderStart+placeholderEnd) {
+			t.Errorf("expected no placeholders, got:\n%s", s)
+		}
+	})
+}
@@ -0,0 +1,17 @@
+package generator
+
+import "github.com/garrettladley/snips/runtime"
+
+// WithMessages localizes the UI text rendered into generated components, e.g.
+// the attribution footer, by message ID. Messages that aren't set are
+// rendered in English. The components of the runtime package are localized
+// when rendered instead, see runtime.WithMessages.
+func WithMessages(m runtime.Messages) GenerateOpt {
+	return func(g *generator) error {
+		if err := m.Validate(); err != nil {
+			return err
+		}
+		g.messages = m
+		return nil
+	}
+}
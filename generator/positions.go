@@ -0,0 +1,79 @@
+package generator
+
+import (
+	"strconv"
+	"strings"
+)
+
+// WithPositionComments writes the highlighted HTML of each component as a
+// string literal per line of its code, each preceded by a /* line N */
+// comment, so that a rendering glitch in the HTML can be traced back to the
+// snippet line it came from. It's meant for debugging escaping issues, since
+// it makes the generated code larger. offset is added to the line numbers of
+// the main component, e.g. the number of lines of front matter, so that they
+// number the lines of the snippet file. Other components number the lines of
+// their own code. Streamed and shared literals are written without comments.
+func WithPositionComments(offset int) GenerateOpt {
+	return func(g *generator) error {
+		g.positionComments = true
+		g.positionOffset = offset
+		return nil
+	}
+}
+
+// positionLiteral returns the highlighted HTML of the component as the
+// concatenation of a string literal per line, commented with the line of the
+// component's code it came from. Markup before the first line and after the
+// last is kept with them. Line numbers in a table come before the code, and
+// are commented with the line they number.
+func (g *generator) positionLiteral(html string) (string, error) {
+	lines := strings.Count(string(g.contents), "\n")
+	if len(g.contents) > 0 && g.contents[len(g.contents)-1] != '\n' {
+		lines++
+	}
+	lines = max(lines, 1)
+	pieces := splitLines(html)
+	if last := len(pieces) - 1; last > 0 && last%lines == 0 {
+		// The markup after the last newline closes the last line, unless the
+		// code doesn't end with a newline, and it's the last line.
+		pieces[last-1] += pieces[last]
+		pieces = pieces[:last]
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\n")
+	for i, piece := range pieces {
+		escaped, err := escape(piece)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString("\t\t\t/* line " + strconv.Itoa(g.positionOffset+i%lines+1) + " */ \"" + escaped + "\"")
+		if i < len(pieces)-1 {
+			sb.WriteString(" +\n")
+		}
+	}
+	return sb.String(), nil
+}
+
+// splitLines splits highlighted HTML after the end of each line, a newline
+// followed by the spans closing the line, e.g. "\n</span></span>". Newlines
+// followed by other markup, such as those between the cells of a line number
+// table, don't end lines. The markup after the last line is returned as the
+// last piece.
+func splitLines(html string) (pieces []string) {
+	start := 0
+	for i := 0; i < len(html); i++ {
+		if html[i] != '\n' {
+			continue
+		}
+		end := i + 1
+		for strings.HasPrefix(html[end:], "</span>") {
+			end += len("</span>")
+		}
+		if end > i+1 {
+			pieces = append(pieces, html[start:end])
+			start, i = end, end-1
+		}
+	}
+	return append(pieces, html[start:])
+}
@@ -0,0 +1,81 @@
+package generator
+
+import (
+	"go/format"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/chroma/v2/formatters/html"
+)
+
+// lineComment matches the position comments of literals, and the literals.
+var lineComment = regexp.MustCompile(`/\* line (\d+) \*/ ("(?:[^"\\]|\\.)*")`)
+
+func TestPositionComments(t *testing.T) {
+	html := make(map[string]string)
+	var b strings.Builder
+	_, err := Generate(&b, Config{
+		Contents:      []byte("package main\n\nfunc main() {\n\tprintln(\"<hi>\")\n}\n"),
+		PackageName:   "main",
+		ComponentName: "MainGo",
+		Components:    []Component{{Name: "MainGoFunc", Contents: []byte("func main() {\n}")}},
+	}, WithPositionComments(3), WithHTMLReport(func(componentName, h string) { html[componentName] = h }))
+	if err != nil {
+		t.Fatalf("failed to generate: %v", err)
+	}
+	if _, err := format.Source([]byte(b.String())); err != nil {
+		t.Fatalf("expected valid Go, got %v:\n%s", err, b.String())
+	}
+
+	components := strings.Split(b.String(), "\nfunc MainGoFunc()")
+	for i, tt := range []struct {
+		name  string
+		lines []int
+	}{
+		{name: "MainGo", lines: []int{4, 5, 6, 7, 8}},
+		{name: "MainGoFunc", lines: []int{1, 2}},
+	} {
+		var lines []int
+		var literals strings.Builder
+		for _, m := range lineComment.FindAllStringSubmatch(components[i], -1) {
+			line, _ := strconv.Atoi(m[1])
+			lines = append(lines, line)
+			s, err := strconv.Unquote(m[2])
+			if err != nil {
+				t.Fatalf("invalid literal %s: %v", m[2], err)
+			}
+			literals.WriteString(s)
+		}
+		if !slices.Equal(lines, tt.lines) {
+			t.Errorf("expected %s to have literals for lines %v, got %v:\n%s", tt.name, tt.lines, lines, components[i])
+		}
+		if literals.String() != html[tt.name] {
+			t.Errorf("expected the literals of %s to concatenate to its HTML %q, got %q", tt.name, html[tt.name], literals.String())
+		}
+	}
+}
+
+func TestPositionCommentsTable(t *testing.T) {
+	var b strings.Builder
+	_, err := Generate(&b, Config{
+		HTMLOpts:      []html.Option{html.WithLineNumbers(true), html.LineNumbersInTable(true), html.WithClasses(true)},
+		Contents:      []byte("a\nb\n"),
+		PackageName:   "main",
+		ComponentName: "MainText",
+	}, WithPositionComments(0))
+	if err != nil {
+		t.Fatalf("failed to generate: %v", err)
+	}
+	var lines []int
+	for _, m := range lineComment.FindAllStringSubmatch(b.String(), -1) {
+		line, _ := strconv.Atoi(m[1])
+		lines = append(lines, line)
+	}
+	// The line numbers come before the code.
+	if want := []int{1, 2, 1, 2}; !slices.Equal(lines, want) {
+		t.Errorf("expected literals for lines %v, got %v:\n%s", want, lines, b.String())
+	}
+}
@@ -0,0 +1,85 @@
+package generator
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+)
+
+// provenancePrefix starts the header comment recording the hash of a
+// generated file, e.g. "// snips: hash: 3f2a9c0d41b7e856".
+const provenancePrefix = "// snips: hash: "
+
+// unstamped is the hash written by the generator, until StampProvenance
+// replaces it. A file is hashed with it in place of its own hash.
+const unstamped = "0000000000000000"
+
+// WithProvenanceHash adds a comment to the header of generated files for the
+// hash of their contents, set by StampProvenance once they're formatted, so
+// that HandEdited can tell when they were edited after being generated.
+func WithProvenanceHash() GenerateOpt {
+	return func(g *generator) error {
+		g.provenanceHash = true
+		return nil
+	}
+}
+
+func (g *generator) writeProvenanceComment() (err error) {
+	if g.provenanceHash {
+		_, err = g.w.Write(provenancePrefix + unstamped + "\n")
+	}
+	return err
+}
+
+// StampProvenance returns code with the hash in its provenance comment, if
+// it has one, set to the hash of its contents. Code that was stamped before is
+// stamped again, e.g. after it was formatted or merged.
+func StampProvenance(code []byte) []byte {
+	i, ok := provenanceIndex(code)
+	if !ok {
+		return code
+	}
+	stamped := slices.Clone(code)
+	copy(stamped[i:], unstamped)
+	copy(stamped[i:], provenanceHash(stamped))
+	return stamped
+}
+
+// HandEdited reports whether code has a provenance comment whose hash doesn't
+// match its contents, because it was changed since it was generated. Code
+// without one, e.g. generated by an earlier version, is never reported.
+func HandEdited(code []byte) bool {
+	i, ok := provenanceIndex(code)
+	if !ok {
+		return false
+	}
+	recorded := string(code[i : i+len(unstamped)])
+	code = slices.Clone(code)
+	copy(code[i:], unstamped)
+	return recorded != provenanceHash(code)
+}
+
+// provenanceIndex returns the index of the hash in the provenance comment of
+// code, which is only looked for in the header, before the package clause.
+func provenanceIndex(code []byte) (i int, ok bool) {
+	head := code
+	if end := bytes.Index(code, []byte("\npackage ")); end >= 0 {
+		head = code[:end]
+	}
+	if i = bytes.Index(head, []byte("\n"+provenancePrefix)); i < 0 {
+		return 0, false
+	}
+	i += 1 + len(provenancePrefix)
+	if len(head) < i+len(unstamped) {
+		return 0, false
+	}
+	return i, true
+}
+
+// provenanceHash returns the hash of unstamped code, the first 16 hex digits
+// of its SHA-256 hash.
+func provenanceHash(code []byte) string {
+	sum := sha256.Sum256(code)
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,44 @@
+package generator
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+)
+
+func TestProvenanceHash(t *testing.T) {
+	var b bytes.Buffer
+	_, err := Generate(&b, Config{
+		Contents:      []byte("package main\n"),
+		PackageName:   "main",
+		ComponentName: "Main",
+	}, WithProvenanceHash())
+	if err != nil {
+		t.Fatalf("failed to generate: %v", err)
+	}
+	if !bytes.Contains(b.Bytes(), []byte("\n"+provenancePrefix+unstamped+"\n")) {
+		t.Fatalf("expected an unstamped provenance comment, got:\n%s", b.Bytes())
+	}
+
+	code := StampProvenance(b.Bytes())
+	if !regexp.MustCompile(`\n// snips: hash: [0-9a-f]{16}\n`).Match(code) || bytes.Contains(code, []byte(unstamped)) {
+		t.Fatalf("expected a stamped provenance comment, got:\n%s", code)
+	}
+	if HandEdited(code) {
+		t.Error("expected the stamped code not to be reported as edited")
+	}
+	if restamped := StampProvenance(code); !bytes.Equal(restamped, code) {
+		t.Errorf("expected stamping again to keep the hash, got:\n%s", restamped)
+	}
+
+	edited := bytes.Replace(code, []byte("package main"), []byte("package main // edited"), 1)
+	if !HandEdited(edited) {
+		t.Error("expected the edited code to be reported")
+	}
+	if HandEdited(StampProvenance(edited)) {
+		t.Error("expected the edited code to be accepted once stamped again")
+	}
+	if HandEdited([]byte("// Code generated by snips. DO NOT EDIT.\n\npackage main\n")) {
+		t.Error("expected code without a provenance comment not to be reported")
+	}
+}
@@ -0,0 +1,45 @@
+package generator
+
+const (
+	// runtimeImportPath is the package of the wrapper components.
+	runtimeImportPath = "github.com/garrettladley/snips/runtime"
+	// runtimePackageAlias is the import alias of the runtime package, which
+	// would otherwise clash with templ's.
+	runtimePackageAlias = "snipsruntime"
+)
+
+// WithRuntime renders the styles of wrappers with runtime.Styles, once per
+// page, instead of inlining them into every component. The module of the
+// generated code must require github.com/garrettladley/snips.
+func WithRuntime() GenerateOpt {
+	return func(g *generator) error {
+		g.runtime = true
+		return nil
+	}
+}
+
+// inlineCSS returns css, unless the styles are rendered by the runtime
+// package.
+func (g *generator) inlineCSS(css string) string {
+	if g.runtime {
+		return ""
+	}
+	return css
+}
+
+func (g *generator) writeRuntimeStyles() (err error) {
+	if !g.runtime {
+		return nil
+	}
+	if _, err = g.w.Write("\t\ttempl_7745c5c3_Err = " + runtimePackageAlias + ".Styles().Render(ctx, templ_7745c5c3_Buffer)\n"); err != nil {
+		return err
+	}
+	if _, err = g.w.Write("\t\tif templ_7745c5c3_Err != nil {\n"); err != nil {
+		return err
+	}
+	if _, err = g.w.Write("\t\t\treturn templ_7745c5c3_Err\n"); err != nil {
+		return err
+	}
+	_, err = g.w.Write("\t\t}\n")
+	return err
+}
@@ -0,0 +1,49 @@
+package generator
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/a-h/templ"
+	"github.com/garrettladley/snips/runtime"
+)
+
+func TestRuntime(t *testing.T) {
+	var b strings.Builder
+	_, err := Generate(&b, Config{
+		Contents:      []byte("package main\n"),
+		PackageName:   "main",
+		ComponentName: "Main",
+	}, WithRuntime(), WithLanguageBadge(nil), WithFocusLines([][2]int{{1, 1}}))
+	if err != nil {
+		t.Fatalf("failed to generate: %v", err)
+	}
+	for _, expected := range []string{
+		"\tsnipsruntime \"github.com/garrettladley/snips/runtime\"\n",
+		"templ_7745c5c3_Err = snipsruntime.Styles().Render(ctx, templ_7745c5c3_Buffer)",
+		`class=\"snips-focus snips-badged\"`,
+	} {
+		if !strings.Contains(b.String(), expected) {
+			t.Errorf("expected output to contain %s, got:\n%s", expected, b.String())
+		}
+	}
+	if strings.Contains(b.String(), "<style>") {
+		t.Errorf("expected no inline styles, got:\n%s", b.String())
+	}
+}
+
+// TestRuntimeStyles checks that the styles of the runtime package are those
+// otherwise inlined.
+func TestRuntimeStyles(t *testing.T) {
+	var sb strings.Builder
+	if err := runtime.Styles().Render(templ.InitializeContext(context.Background()), &sb); err != nil {
+		t.Fatal(err)
+	}
+	for _, css := range []string{focusCSS, badgeCSS, attributionCSS} {
+		rules := strings.TrimSuffix(strings.TrimPrefix(css, "<style>"), "</style>")
+		if !strings.Contains(sb.String(), rules) {
+			t.Errorf("expected the runtime styles to contain %s, got:\n%s", rules, sb.String())
+		}
+	}
+}
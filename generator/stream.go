@@ -0,0 +1,120 @@
+package generator
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"strconv"
+)
+
+// WithStreaming renders components whose highlighted HTML is larger than
+// threshold bytes by decompressing an embedded gzip blob, rather than writing
+// one giant string constant. This trades a little CPU at render time for much
+// smaller binaries and faster compiles.
+func WithStreaming(threshold int) GenerateOpt {
+	return func(g *generator) error {
+		if threshold <= 0 {
+			return fmt.Errorf("stream threshold must be positive, got %d", threshold)
+		}
+		g.streamThreshold = threshold
+		return nil
+	}
+}
+
+// compressStreams highlights every component in advance, and compresses those
+// over the stream threshold, so that the imports they need are known before
+// any component is written.
+func (g *generator) compressStreams() error {
+	if g.streamThreshold <= 0 {
+		return nil
+	}
+	componentName, contents, id := g.componentName, g.contents, g.id
+	defer func() {
+		g.componentName, g.contents, g.id = componentName, contents, id
+	}()
+
+	g.highlighted = make(map[string]string)
+	g.streams = make(map[string][]byte)
+	all := append([]Component{{Name: componentName, Contents: contents}}, g.components...)
+	for i, c := range all {
+		// Match writeComponents, which only sets the id on the main component.
+		if i > 0 {
+			g.id = ""
+		}
+		g.componentName, g.contents = c.Name, c.Contents
+		s, err := g.highlight()
+		if err != nil {
+			return err
+		}
+		g.highlighted[c.Name] = s
+		if len(s) <= g.streamThreshold {
+			continue
+		}
+		var b bytes.Buffer
+		zw, err := gzip.NewWriterLevel(&b, gzip.BestCompression)
+		if err != nil {
+			return err
+		}
+		if _, err = zw.Write([]byte(s)); err != nil {
+			return err
+		}
+		if err = zw.Close(); err != nil {
+			return err
+		}
+		g.streams[c.Name] = b.Bytes()
+	}
+	return nil
+}
+
+// blobName is the name of the constant holding the compressed blob of a
+// streamed component.
+func blobName(componentName string) string {
+	return "snipsBlob" + componentName
+}
+
+// writeStreamedComponentBody writes the rest of a streamed component, which
+// decompresses blob into the output, followed by the blob constant in the v0.1
+// layout.
+func (g *generator) writeStreamedComponentBody(blob []byte) (err error) {
+	if g.reportSize != nil {
+		g.reportSize(g.componentName, len(strconv.Quote(string(blob)))-2)
+	}
+	if _, err = g.w.Write("\t\ttempl_7745c5c3_Reader, templ_7745c5c3_Err := gzip.NewReader(strings.NewReader(" + blobName(g.componentName) + "))\n"); err != nil {
+		return
+	}
+	if _, err = g.w.Write("\t\tif templ_7745c5c3_Err != nil {\n"); err != nil {
+		return
+	}
+	if _, err = g.w.Write("\t\t\treturn templ_7745c5c3_Err\n"); err != nil {
+		return
+	}
+	if _, err = g.w.Write("\t\t}\n"); err != nil {
+		return
+	}
+	if _, err = g.w.Write("\t\t_, templ_7745c5c3_Err = io.Copy(templ_7745c5c3_Buffer, templ_7745c5c3_Reader)\n"); err != nil {
+		return
+	}
+	if _, err = g.w.Write("\t\treturn templ_7745c5c3_Err\n"); err != nil {
+		return
+	}
+	if _, err = g.w.Write("\t})\n"); err != nil {
+		return
+	}
+	if _, err = g.w.Write("}\n"); err != nil {
+		return
+	}
+	if g.compat == "v0.1" {
+		return g.writeBlob(g.componentName, blob)
+	}
+	return nil
+}
+
+// writeBlob writes the constant holding the compressed blob of a streamed
+// component.
+func (g *generator) writeBlob(componentName string, blob []byte) (err error) {
+	if _, err = g.w.Write("\n// " + blobName(componentName) + " is the gzip compressed HTML of " + componentName + ".\n"); err != nil {
+		return
+	}
+	_, err = g.w.Write("const " + blobName(componentName) + " = " + strconv.Quote(string(blob)) + "\n")
+	return err
+}
@@ -0,0 +1,72 @@
+package generator
+
+import (
+	"compress/gzip"
+	"go/format"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestStreaming(t *testing.T) {
+	var plain strings.Builder
+	config := Config{
+		Contents:      []byte("package main\n\nfunc main() {}\n"),
+		PackageName:   "main",
+		ComponentName: "Main",
+		Components: []Component{
+			{Name: "Small", Contents: []byte("x")},
+		},
+	}
+	if _, err := Generate(&plain, config, WithID("main")); err != nil {
+		t.Fatalf("failed to generate: %v", err)
+	}
+
+	var b strings.Builder
+	if _, err := Generate(&b, config, WithID("main"), WithStreaming(200)); err != nil {
+		t.Fatalf("failed to generate: %v", err)
+	}
+	s := b.String()
+	if _, err := format.Source([]byte(s)); err != nil {
+		t.Fatalf("generated invalid Go: %v\n%s", err, s)
+	}
+	if !strings.Contains(s, "\t\"compress/gzip\"\n") {
+		t.Errorf("expected the gzip import, got:\n%s", s)
+	}
+	if strings.Contains(s, "snipsBlobSmall") {
+		t.Errorf("expected the small component not to be streamed, got:\n%s", s)
+	}
+
+	m := regexp.MustCompile(`const snipsBlobMain = (".*")\n`).FindStringSubmatch(s)
+	if m == nil {
+		t.Fatalf("expected a blob for the main component, got:\n%s", s)
+	}
+	blob, err := strconv.Unquote(m[1])
+	if err != nil {
+		t.Fatalf("failed to unquote blob: %v", err)
+	}
+	zr, err := gzip.NewReader(strings.NewReader(blob))
+	if err != nil {
+		t.Fatalf("failed to read blob: %v", err)
+	}
+	html, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("failed to decompress blob: %v", err)
+	}
+	var escaped strings.Builder
+	if _, err = NewEscapeWriter(&escaped).Write(html); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(plain.String(), `WriteString("`+escaped.String()+`")`) {
+		t.Errorf("expected the decompressed blob to match the unstreamed HTML, got %s", html)
+	}
+}
+
+func TestStreamingInvalidThreshold(t *testing.T) {
+	var b strings.Builder
+	if _, err := Generate(&b, Config{PackageName: "main", ComponentName: "Main"}, WithStreaming(0)); err == nil {
+		t.Error("expected an error for a zero threshold")
+	}
+}
@@ -0,0 +1,12 @@
+package generator
+
+import "github.com/alecthomas/chroma/v2"
+
+// WithStyle highlights the snippet with the given chroma style, e.g. one
+// loaded from an XML file, instead of the style named by Config.Style.
+func WithStyle(style *chroma.Style) GenerateOpt {
+	return func(g *generator) error {
+		g.chromaStyle = style
+		return nil
+	}
+}
@@ -0,0 +1,90 @@
+package generator
+
+import (
+	"regexp"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+)
+
+// templDeclaration matches the start of a templ, css or script declaration.
+var templDeclaration = regexp.MustCompile(`(?m)^(?:templ|css|script) [^\n{]*\{\s*$`)
+
+// The Templ lexer highlights templ files, such as .code.templ snippets, with
+// the Go lexer for Go code and expressions, and the HTML lexer for the markup
+// of templ components. It's registered with chroma, and picked over Go for
+// snippets with templ declarations.
+var _ = lexers.Register(templLexer{chroma.DelegatingLexer(lexers.HTML, chroma.MustNewLexer(
+	&chroma.Config{
+		Name:      "Templ",
+		Aliases:   []string{"templ"},
+		Filenames: []string{"*.templ"},
+		MimeTypes: []string{"text/x-templ"},
+		EnsureNL:  true,
+	},
+	templRules,
+))})
+
+// templLexer analyses text for templ declarations, since the analyser of a
+// delegating lexer is that of its root, HTML.
+type templLexer struct {
+	chroma.Lexer
+}
+
+func (templLexer) AnalyseText(text string) float32 {
+	if templDeclaration.MatchString(text) {
+		return 1.0
+	}
+	return 0.0
+}
+
+func templRules() chroma.Rules {
+	return chroma.Rules{
+		"root": {
+			{Pattern: `^(templ)(\s+)([^\n{]*?)(\s*)(\{)(\s*)$`, Type: chroma.ByGroups(chroma.KeywordDeclaration, chroma.Text, chroma.Using("Go"), chroma.Text, chroma.Punctuation, chroma.Text), Mutator: chroma.Push("templ")},
+			{Pattern: `^(css)(\s+)([^\n{]*?)(\s*)(\{)(\s*)$`, Type: chroma.ByGroups(chroma.KeywordDeclaration, chroma.Text, chroma.Using("Go"), chroma.Text, chroma.Punctuation, chroma.Text), Mutator: chroma.Push("css")},
+			{Pattern: `^(script)(\s+)([^\n{]*?)(\s*)(\{)(\s*)$`, Type: chroma.ByGroups(chroma.KeywordDeclaration, chroma.Text, chroma.Using("Go"), chroma.Text, chroma.Punctuation, chroma.Text), Mutator: chroma.Push("script")},
+			// Go code, up to the next declaration.
+			{Pattern: `(?s).+?(?=^(?:templ|css|script)\s|\z)`, Type: chroma.Using("Go")},
+		},
+		"templ": {
+			{Pattern: `^\}`, Type: chroma.Punctuation, Mutator: chroma.Pop(1)},
+			// Let HTML handle comments, scripts and styles, whose braces aren't
+			// expressions.
+			{Pattern: `(?s)<!--.*?-->`, Type: chroma.Other},
+			{Pattern: `(?s)<(script|style)\b.*?</(script|style)\s*>`, Type: chroma.Other},
+			{Pattern: `^(\s*)(if|for|switch)(\b[^\n{]*?)(\s*)(\{)(\s*)$`, Type: chroma.ByGroups(chroma.Text, chroma.Keyword, chroma.Using("Go"), chroma.Text, chroma.Punctuation, chroma.Text)},
+			{Pattern: `^(\s*)(\})(\s*)(else)(\s+)(if)(\b[^\n{]*?)(\s*)(\{)(\s*)$`, Type: chroma.ByGroups(chroma.Text, chroma.Punctuation, chroma.Text, chroma.Keyword, chroma.Text, chroma.Keyword, chroma.Using("Go"), chroma.Text, chroma.Punctuation, chroma.Text)},
+			{Pattern: `^(\s*)(\})(\s*)(else)(\s*)(\{)(\s*)$`, Type: chroma.ByGroups(chroma.Text, chroma.Punctuation, chroma.Text, chroma.Keyword, chroma.Text, chroma.Punctuation, chroma.Text)},
+			{Pattern: `^(\s*)(case)(\b[^\n:]*)(:)(\s*)$`, Type: chroma.ByGroups(chroma.Text, chroma.Keyword, chroma.Using("Go"), chroma.Punctuation, chroma.Text)},
+			{Pattern: `^(\s*)(default)(:)(\s*)$`, Type: chroma.ByGroups(chroma.Text, chroma.Keyword, chroma.Punctuation, chroma.Text)},
+			{Pattern: `^(\s*)(\})(\s*)$`, Type: chroma.ByGroups(chroma.Text, chroma.Punctuation, chroma.Text)},
+			// Component calls, e.g. @Button("Save"), which may have children.
+			{Pattern: `(@)([\w.]+(?:\([^\n]*\))?)((?:\s*\{)?)`, Type: chroma.ByGroups(chroma.Operator, chroma.Using("Go"), chroma.Punctuation)},
+			// Attribute values, e.g. class={ name }, whose = isn't passed to
+			// HTML so that it sees an attribute without a value.
+			{Pattern: `(=)(\s*)(\{)`, Type: chroma.ByGroups(chroma.Operator, chroma.Text, chroma.Punctuation), Mutator: chroma.Push("expression")},
+			{Pattern: `\{`, Type: chroma.Punctuation, Mutator: chroma.Push("expression")},
+			{Pattern: `[^{}@<=\n]+`, Type: chroma.Other},
+			{Pattern: `[}@<=\n]`, Type: chroma.Other},
+		},
+		"expression": {
+			{Pattern: `\}`, Type: chroma.Punctuation, Mutator: chroma.Pop(1)},
+			// Let Go handle strings, including the braces inside them.
+			{Pattern: "(?s)\"(?:\\\\.|[^\"\\\\])*\"|`[^`]*`|'(?:\\\\.|[^'\\\\])*'", Type: chroma.Using("Go")},
+			{Pattern: `\{`, Type: chroma.Punctuation, Mutator: chroma.Push("expression")},
+			{Pattern: "[^{}\"'`]+", Type: chroma.Using("Go")},
+		},
+		"css": {
+			{Pattern: `^\}`, Type: chroma.Punctuation, Mutator: chroma.Pop(1)},
+			{Pattern: `(\s*)([\w-]+)(\s*)(:)(\s*)(\{)([^}\n]*)(\})(;?)`, Type: chroma.ByGroups(chroma.Text, chroma.Keyword, chroma.Text, chroma.Punctuation, chroma.Text, chroma.Punctuation, chroma.Using("Go"), chroma.Punctuation, chroma.Punctuation)},
+			{Pattern: `(\s*)([\w-]+)(\s*)(:)(\s*)([^;\n]*)(;?)`, Type: chroma.ByGroups(chroma.Text, chroma.Keyword, chroma.Text, chroma.Punctuation, chroma.Text, chroma.LiteralString, chroma.Punctuation)},
+			{Pattern: `\s+`, Type: chroma.Text},
+			{Pattern: `[^\n]+`, Type: chroma.Text},
+		},
+		"script": {
+			{Pattern: `^\}`, Type: chroma.Punctuation, Mutator: chroma.Pop(1)},
+			{Pattern: `(?s).+?(?=^\})`, Type: chroma.Using("JavaScript")},
+		},
+	}
+}
@@ -0,0 +1,70 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+)
+
+const templSnippet = `package views
+
+templ Hello(name string, items []string) {
+	<div class={ "greeting" }>
+		Hello, { name }!
+		for _, item := range items {
+			<li>{ item }</li>
+		}
+		@Button("Save") {
+			<span>child</span>
+		}
+		<script>if (x) { y() }</script>
+	</div>
+}
+
+css red() {
+	color: { "red" };
+}
+
+script hello(name string) {
+	alert(name);
+}
+`
+
+func TestTemplLexer(t *testing.T) {
+	lexer := lexers.Analyse(templSnippet)
+	if lexer == nil || lexer.Config().Name != "Templ" {
+		t.Fatalf("expected templ snippets to be analysed as Templ, got %v", lexer)
+	}
+	if lexer := lexers.Analyse("package main\n\nimport \"fmt\"\n\nfunc main() { fmt.Println() }\n"); lexer.Config().Name != "Go" {
+		t.Errorf("expected Go snippets to be analysed as Go, got %q", lexer.Config().Name)
+	}
+
+	it, err := lexer.Tokenise(nil, templSnippet)
+	if err != nil {
+		t.Fatalf("failed to tokenise: %v", err)
+	}
+	found := make(map[chroma.Token]bool)
+	for _, tok := range it.Tokens() {
+		if tok.Type == chroma.Error {
+			t.Errorf("unexpected error token %q", tok.Value)
+		}
+		found[tok] = true
+	}
+	for _, expected := range []chroma.Token{
+		{Type: chroma.KeywordDeclaration, Value: "templ"},
+		{Type: chroma.KeywordDeclaration, Value: "css"},
+		{Type: chroma.NameTag, Value: "div"},
+		{Type: chroma.NameAttribute, Value: "class"},
+		{Type: chroma.Keyword, Value: "for"},
+		{Type: chroma.Keyword, Value: "range"},
+		{Type: chroma.Operator, Value: "@"},
+		{Type: chroma.LiteralString, Value: `"Save"`},
+		{Type: chroma.NameTag, Value: "script"},
+		{Type: chroma.NameOther, Value: "alert"},
+	} {
+		if !found[expected] {
+			t.Errorf("expected token %v", expected)
+		}
+	}
+}
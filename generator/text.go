@@ -0,0 +1,77 @@
+package generator
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// WithBaseLine numbers the lines of plain text from line, instead of 1, see
+// GenerateText.
+func WithBaseLine(line int) GenerateOpt {
+	return func(g *generator) error {
+		g.baseLine = line
+		return nil
+	}
+}
+
+// GenerateText writes the plain text of the snippet, with its variables
+// expanded, each line prefixed with its number, for consumers that need the
+// text rather than HTML, e.g. man pages. Control and other non-printable
+// characters, apart from tabs, are escaped as in Go strings, e.g. \x1b or
+// \u202e, so that the text is safe to print. The options of highlighting,
+// such as the style, are ignored.
+func GenerateText(w io.Writer, config Config, opts ...GenerateOpt) (err error) {
+	g := generator{baseLine: 1, contents: config.Contents}
+	for _, opt := range opts {
+		if err = opt(&g); err != nil {
+			return err
+		}
+	}
+
+	text := expandVariables(string(g.contents), g.vars)
+	text = strings.TrimSuffix(text, "\n")
+	lines := strings.Split(text, "\n")
+	width := max(len(strconv.Itoa(g.baseLine+len(lines)-1)), g.lineNumbersWidth)
+	var b []byte
+	for i, line := range lines {
+		b = b[:0]
+		number := strconv.Itoa(g.baseLine + i)
+		for range width - len(number) {
+			b = append(b, ' ')
+		}
+		b = append(b, number...)
+		if line = strings.TrimSuffix(line, "\r"); line != "" {
+			b = append(b, ' ', ' ')
+			b = appendEscapedText(b, line)
+		}
+		b = append(b, '\n')
+		if _, err = w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendEscapedText appends s with its control and non-printable characters,
+// apart from tabs, escaped.
+func appendEscapedText(b []byte, s string) []byte {
+	for i := 0; i < len(s); {
+		r, width := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && width == 1:
+			b = append(b, byteEscapes[s[i]]...)
+		case r == '\t':
+			b = append(b, '\t')
+		case r < utf8.RuneSelf && (r < 0x20 || r == 0x7f):
+			b = append(b, byteEscapes[s[i]]...)
+		case !strconv.IsPrint(r):
+			b = appendRuneEscape(b, r)
+		default:
+			b = append(b, s[i:i+width]...)
+		}
+		i += width
+	}
+	return b
+}
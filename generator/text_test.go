@@ -0,0 +1,60 @@
+package generator
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestGenerateText(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		opts     []GenerateOpt
+		expected string
+	}{
+		{
+			name:     "line numbers",
+			contents: "package main\n\nfunc main() {}\n",
+			expected: "1  package main\n2\n3  func main() {}\n",
+		},
+		{
+			name:     "aligned line numbers",
+			contents: "a\nb\nc\n",
+			opts:     []GenerateOpt{WithBaseLine(9)},
+			expected: " 9  a\n10  b\n11  c\n",
+		},
+		{
+			name:     "line numbers width",
+			contents: "a\n",
+			opts:     []GenerateOpt{WithLineNumbersWidth(3)},
+			expected: "  1  a\n",
+		},
+		{
+			name:     "control characters",
+			contents: "\tcolor := \"\x1b[31m\"\r\nx := \"\u202eevil\"\n",
+			expected: "1  \tcolor := \"\\x1b[31m\"\n2  x := \"\\u202eevil\"\n",
+		},
+		{
+			name:     "invalid UTF-8",
+			contents: "a\xffb",
+			expected: "1  a\\xffb\n",
+		},
+		{
+			name:     "variables",
+			contents: "version := \"{{VERSION}}\"\n",
+			opts:     []GenerateOpt{WithVariables(map[string]string{"VERSION": "v1.2.3"})},
+			expected: "1  version := \"v1.2.3\"\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b bytes.Buffer
+			if err := GenerateText(&b, Config{Contents: []byte(tt.contents)}, tt.opts...); err != nil {
+				t.Fatalf("failed to generate: %v", err)
+			}
+			if b.String() != tt.expected {
+				t.Errorf("expected:\n%q\ngot:\n%q", tt.expected, b.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package generator
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+// themesAttribute is set on the wrappers of snippets highlighted with CSS
+// classes, to the names of their light and dark themes.
+const themesAttribute = "data-snips-themes"
+
+// WithThemes highlights with CSS classes instead of inline styles, so that
+// readers can switch between the light and dark chroma styles, e.g. "github"
+// and "monokai", with runtime.ThemeSwitcher. The generated components render
+// the CSS of both with runtime.ThemeStyles, once per page, so the module of the
+// generated code must require github.com/garrettladley/snips. Line number
+// widths aren't supported, as they're inline styles.
+func WithThemes(light, dark string) GenerateOpt {
+	return func(g *generator) error {
+		for _, name := range []string{light, dark} {
+			if _, ok := styles.Registry[name]; !ok {
+				return fmt.Errorf("unknown style %q", name)
+			}
+		}
+		g.themes = [2]string{light, dark}
+		return nil
+	}
+}
+
+// classes reports whether the snippet is highlighted with CSS classes.
+func (g *generator) classes() bool {
+	return g.themes != [2]string{}
+}
+
+// usesRuntime reports whether the generated code imports the runtime package.
+func (g *generator) usesRuntime() bool {
+	return g.runtime || g.classes()
+}
+
+func (g *generator) writeThemeStyles() (err error) {
+	if !g.classes() {
+		return nil
+	}
+	if _, err = g.w.Write("\t\ttempl_7745c5c3_Err = " + runtimePackageAlias + ".ThemeStyles(" + strconv.Quote(g.themes[0]) + ", " + strconv.Quote(g.themes[1]) + ").Render(ctx, templ_7745c5c3_Buffer)\n"); err != nil {
+		return err
+	}
+	if _, err = g.w.Write("\t\tif templ_7745c5c3_Err != nil {\n"); err != nil {
+		return err
+	}
+	if _, err = g.w.Write("\t\t\treturn templ_7745c5c3_Err\n"); err != nil {
+		return err
+	}
+	_, err = g.w.Write("\t\t}\n")
+	return err
+}
@@ -0,0 +1,36 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestThemes(t *testing.T) {
+	var b strings.Builder
+	_, err := Generate(&b, Config{
+		Contents:      []byte("package main\n\nfunc main() {}\n"),
+		PackageName:   "main",
+		ComponentName: "Main",
+	}, WithLanguage("go"), WithThemes("github", "monokai"), WithFocusLines([][2]int{{3, 3}}))
+	if err != nil {
+		t.Fatalf("failed to generate: %v", err)
+	}
+	for _, expected := range []string{
+		"\tsnipsruntime \"github.com/garrettladley/snips/runtime\"\n",
+		`templ_7745c5c3_Err = snipsruntime.ThemeStyles("github", "monokai").Render(ctx, templ_7745c5c3_Buffer)`,
+		`data-snips-themes=\"github monokai\"`,
+		`<pre class=\"chroma\">`,
+		`<span class=\"line snips-unfocused\"><span class=\"cl\"><span class=\"kn\">package</span>`,
+	} {
+		if !strings.Contains(b.String(), expected) {
+			t.Errorf("expected output to contain %s, got:\n%s", expected, b.String())
+		}
+	}
+	if strings.Contains(b.String(), "color:") {
+		t.Errorf("expected no inline colors, got:\n%s", b.String())
+	}
+
+	if err := WithThemes("github", "no-such-style")(&generator{}); err == nil {
+		t.Error("expected an error for an unknown style")
+	}
+}
@@ -0,0 +1,75 @@
+package generator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"maps"
+	"slices"
+
+	"github.com/alecthomas/chroma/v2"
+)
+
+// TokenCache stores the tokens of highlighted contents, so that generations
+// that only change presentation options, such as the style or tab width, skip
+// tokenizing and only run the formatter. It must be safe for concurrent use.
+type TokenCache interface {
+	// Get returns the tokens stored for key, which the caller may modify.
+	Get(key string) (tokens []chroma.Token, ok bool)
+	// Put stores the tokens for key, which mustn't be modified afterwards.
+	Put(key string, tokens []chroma.Token)
+}
+
+// WithTokenCache reuses the tokens of contents that were tokenized by the
+// same lexers before, from cache.
+func WithTokenCache(cache TokenCache) GenerateOpt {
+	return func(g *generator) error {
+		g.tokenCache = cache
+		return nil
+	}
+}
+
+// tokensKey returns the key of the tokens of contents, which depend on the
+// lexer and the lexers of embedded languages.
+func tokensKey(lexer chroma.Lexer, embedded map[string]chroma.Lexer, contents string) string {
+	h := sha256.New()
+	h.Write([]byte(lexer.Config().Name + "\x00"))
+	for _, context := range slices.Sorted(maps.Keys(embedded)) {
+		h.Write([]byte(context + "=" + embedded[context].Config().Name + "\x00"))
+	}
+	h.Write([]byte(contents))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// tokenise returns the tokens of contents, from the token cache if they were
+// tokenized before.
+func (g *generator) tokenise(lexer chroma.Lexer, contents string) (tokens []chroma.Token, err error) {
+	var key string
+	if g.tokenCache != nil {
+		key = tokensKey(lexer, g.embedded, contents)
+		if tokens, ok := g.tokenCache.Get(key); ok {
+			return tokens, nil
+		}
+	}
+
+	end := g.startPhase("tokenize")
+	defer end()
+	iterator, err := lexer.Tokenise(nil, contents)
+	if err != nil {
+		return nil, &TokenizeError{Lexer: lexer.Config().Name, Line: 1, Column: 1, Err: err}
+	}
+	if tokens, err = collectTokens(lexer, iterator); err != nil {
+		return nil, err
+	}
+	// Embedded languages are found in the uncoalesced tokens, as are the
+	// positions of tokenize errors.
+	if len(g.embedded) > 0 {
+		if tokens, err = highlightEmbedded(tokens, g.embedded); err != nil {
+			return nil, err
+		}
+	}
+	tokens = coalesce(tokens)
+	if g.tokenCache != nil {
+		g.tokenCache.Put(key, slices.Clone(tokens))
+	}
+	return tokens, nil
+}
@@ -0,0 +1,67 @@
+package generator
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters/html"
+)
+
+// mapTokenCache is a TokenCache counting its hits.
+type mapTokenCache struct {
+	mu     sync.Mutex
+	tokens map[string][]chroma.Token
+	hits   int
+}
+
+func (c *mapTokenCache) Get(key string) ([]chroma.Token, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	tokens, ok := c.tokens[key]
+	if ok {
+		c.hits++
+	}
+	return tokens, ok
+}
+
+func (c *mapTokenCache) Put(key string, tokens []chroma.Token) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tokens[key] = tokens
+}
+
+func TestTokenCache(t *testing.T) {
+	highlight := func(contents, style string, opts ...GenerateOpt) string {
+		t.Helper()
+		g := generator{f: html.New(), contents: []byte(contents), componentName: "Example", style: style}
+		for _, opt := range append([]GenerateOpt{WithLanguage("go")}, opts...) {
+			if err := opt(&g); err != nil {
+				t.Fatal(err)
+			}
+		}
+		s, err := g.highlight()
+		if err != nil {
+			t.Fatalf("failed to highlight: %v", err)
+		}
+		return s
+	}
+
+	cache := &mapTokenCache{tokens: make(map[string][]chroma.Token)}
+	highlight("package main\n", "swapoff", WithTokenCache(cache))
+	if len(cache.tokens) != 1 || cache.hits != 0 {
+		t.Fatalf("expected the tokens to be stored, got %d stored and %d hits", len(cache.tokens), cache.hits)
+	}
+	// Changing the style only reruns the formatter.
+	if actual, expected := highlight("package main\n", "monokai", WithTokenCache(cache)), highlight("package main\n", "monokai"); actual != expected {
+		t.Errorf("expected the cached tokens to highlight the same, got:\n%s\nexpected:\n%s", actual, expected)
+	}
+	if cache.hits != 1 {
+		t.Errorf("expected a hit, got %d", cache.hits)
+	}
+	highlight("package other\n", "monokai", WithTokenCache(cache))
+	highlight("package main\n", "monokai", WithTokenCache(cache), WithLanguage("text"))
+	if len(cache.tokens) != 3 || cache.hits != 1 {
+		t.Errorf("expected other contents and lexers to miss, got %d stored and %d hits", len(cache.tokens), cache.hits)
+	}
+}
@@ -0,0 +1,61 @@
+package generator
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// rawByteBase is the first of the private use characters that stand in for
+// the bytes of a snippet that aren't valid UTF-8 while it's highlighted, since
+// lexers replace them with U+FFFD.
+const rawByteBase = '\U0010FF00'
+
+// WithRawInvalidUTF8 keeps the bytes of snippets that aren't valid UTF-8 in
+// the highlighted HTML, instead of the U+FFFD that lexers replace them with.
+// They're escaped in the generated code.
+func WithRawInvalidUTF8() GenerateOpt {
+	return func(g *generator) error {
+		g.rawInvalidUTF8 = true
+		return nil
+	}
+}
+
+// protectInvalidUTF8 replaces the bytes of s that aren't valid UTF-8 with the
+// private use characters that stand in for them.
+func protectInvalidUTF8(s string) string {
+	if utf8.ValidString(s) {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			sb.WriteRune(rawByteBase + rune(s[i]))
+		} else {
+			sb.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	return sb.String()
+}
+
+// restoreInvalidUTF8 replaces the private use characters standing in for the
+// bytes that weren't valid UTF-8 with the bytes.
+func restoreInvalidUTF8(s string) string {
+	if !strings.ContainsFunc(s, isRawByte) {
+		return s
+	}
+	var sb strings.Builder
+	for _, r := range s {
+		if isRawByte(r) {
+			sb.WriteByte(byte(r - rawByteBase))
+		} else {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+func isRawByte(r rune) bool {
+	return r >= rawByteBase && r <= rawByteBase+0xff
+}
@@ -0,0 +1,20 @@
+package generator
+
+import "regexp"
+
+var variablePlaceholder = regexp.MustCompile(`\{\{([A-Z][A-Z0-9_]*)\}\}`)
+
+// expandVariables replaces {{NAME}} placeholders with the value of the variable.
+// Placeholders of unknown variables are left as they are, since snippets may
+// contain templates of their own.
+func expandVariables(s string, vars map[string]string) string {
+	if len(vars) == 0 {
+		return s
+	}
+	return variablePlaceholder.ReplaceAllStringFunc(s, func(placeholder string) string {
+		if v, ok := vars[placeholder[2:len(placeholder)-2]]; ok {
+			return v
+		}
+		return placeholder
+	})
+}
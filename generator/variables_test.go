@@ -0,0 +1,40 @@
+package generator
+
+import "testing"
+
+func TestExpandVariables(t *testing.T) {
+	vars := map[string]string{
+		"SNIPS_VERSION": "v0.1.0",
+		"PROJECT":       "snips",
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "known variables",
+			input: "go get github.com/garrettladley/{{PROJECT}}@{{SNIPS_VERSION}}",
+			want:  "go get github.com/garrettladley/snips@v0.1.0",
+		},
+		{
+			name:  "unknown variables",
+			input: "{{UNKNOWN}}",
+			want:  "{{UNKNOWN}}",
+		},
+		{
+			name:  "templates",
+			input: "{{ .Name }} {{.PROJECT}}",
+			want:  "{{ .Name }} {{.PROJECT}}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expandVariables(tt.input, vars); got != tt.want {
+				t.Errorf("expandVariables(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package generator
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/alecthomas/chroma/v2/formatters/html"
+)
+
+// tableVariantSuffix is appended to the names of the table variants of
+// components, see WithTableVariants.
+const tableVariantSuffix = "Table"
+
+// WithTableVariants generates each component twice, with line numbers inline,
+// e.g. MainGo, and in a table, e.g. MainGoTable, so that sites can use the
+// table layout on wide pages, and the inline layout in narrow cards, from the
+// same snippet.
+func WithTableVariants() GenerateOpt {
+	return func(g *generator) error {
+		g.tableVariants = true
+		return nil
+	}
+}
+
+// TableVariantName returns the name of the table variant of a component, see
+// WithTableVariants.
+func TableVariantName(componentName string) string {
+	return componentName + tableVariantSuffix
+}
+
+// addTableVariants adds the table variant of each component to the components
+// to generate, returning the formatters of the inline and table variants.
+func (g *generator) addTableVariants(opts []html.Option) (inline, table *html.Formatter, err error) {
+	names := []string{g.componentName}
+	for _, c := range g.components {
+		names = append(names, c.Name)
+	}
+	variants := []Component{{Name: TableVariantName(g.componentName), Contents: g.contents}}
+	for _, c := range g.components {
+		variants = append(variants, Component{Name: TableVariantName(c.Name), Contents: c.Contents})
+	}
+	g.tables = make(map[string]bool)
+	for _, v := range variants {
+		if slices.Contains(names, v.Name) {
+			return nil, nil, fmt.Errorf("table variant %s is already a component", v.Name)
+		}
+		g.tables[v.Name] = true
+	}
+	g.components = append(slices.Clip(g.components), variants...)
+	inline = html.New(g.htmlOpts(slices.Concat(opts, []html.Option{html.WithLineNumbers(true), html.LineNumbersInTable(false)}))...)
+	table = html.New(g.htmlOpts(slices.Concat(opts, []html.Option{html.WithLineNumbers(true), html.LineNumbersInTable(true)}))...)
+	return inline, table, nil
+}
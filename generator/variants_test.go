@@ -0,0 +1,42 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTableVariants(t *testing.T) {
+	html := make(map[string]string)
+	var b strings.Builder
+	_, err := Generate(&b, Config{
+		Contents:      []byte("package main\n"),
+		PackageName:   "main",
+		ComponentName: "MainGo",
+		Components:    []Component{{Name: "MainGoPackage", Contents: []byte("package main\n")}},
+	}, WithTableVariants(), WithHTMLReport(func(componentName, h string) { html[componentName] = h }))
+	if err != nil {
+		t.Fatalf("failed to generate: %v", err)
+	}
+	for _, name := range []string{"MainGo", "MainGoTable", "MainGoPackage", "MainGoPackageTable"} {
+		if !strings.Contains(b.String(), "\nfunc "+name+"() templ.Component {\n") {
+			t.Errorf("expected component %s, got:\n%s", name, b.String())
+		}
+		table := strings.HasSuffix(name, "Table")
+		if got := strings.Contains(html[name], "<table"); got != table {
+			t.Errorf("expected %s to have a table %v, got:\n%s", name, table, html[name])
+		}
+		if !strings.Contains(html[name], ">1</span>") && !strings.Contains(html[name], ">1\n</span>") {
+			t.Errorf("expected %s to have line numbers, got:\n%s", name, html[name])
+		}
+	}
+
+	_, err = Generate(&strings.Builder{}, Config{
+		Contents:      []byte("package main\n"),
+		PackageName:   "main",
+		ComponentName: "MainGo",
+		Components:    []Component{{Name: "MainGoTable", Contents: []byte("package main\n")}},
+	}, WithTableVariants())
+	if err == nil {
+		t.Error("expected an error for a component named like a table variant")
+	}
+}
@@ -0,0 +1,174 @@
+package generator
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/alecthomas/chroma/v2"
+)
+
+// Markers wrapping changed words, built from private use code points which
+// chroma's HTML formatter passes through unescaped.
+const (
+	deletedWordsStart  = "\uE002"
+	insertedWordsStart = "\uE003"
+	changedWordsEnd    = "\uE004"
+)
+
+var wordDiffReplacer = strings.NewReplacer(
+	deletedWordsStart, `<span class="snips-diff-deleted" style="background-color:rgba(248,81,73,.4)">`,
+	insertedWordsStart, `<span class="snips-diff-inserted" style="background-color:rgba(46,160,67,.4)">`,
+	changedWordsEnd, `</span>`,
+)
+
+// diffWords marks the changed words between each block of removed lines and
+// the block of added lines that follows it, pairing the lines in order.
+func diffWords(tokens []chroma.Token) []chroma.Token {
+	lines := chroma.SplitTokensIntoLines(tokens)
+	for i, line := range lines {
+		// Splitting leaves the empty tail of a token at the start of the next line.
+		lines[i] = slices.DeleteFunc(line, func(t chroma.Token) bool { return t.Value == "" })
+	}
+	for i := 0; i < len(lines); {
+		deleted := countLines(lines[i:], chroma.GenericDeleted, "-")
+		inserted := countLines(lines[i+deleted:], chroma.GenericInserted, "+")
+		if deleted == 0 || inserted == 0 {
+			i += max(deleted+inserted, 1)
+			continue
+		}
+		for j := range min(deleted, inserted) {
+			a, b := lines[i+j][0].Value, lines[i+deleted+j][0].Value
+			a, b = markChangedWords(a, b)
+			lines[i+j] = []chroma.Token{{Type: chroma.GenericDeleted, Value: a}}
+			lines[i+deleted+j] = []chroma.Token{{Type: chroma.GenericInserted, Value: b}}
+		}
+		i += deleted + inserted
+	}
+
+	out := make([]chroma.Token, 0, len(tokens))
+	for _, line := range lines {
+		out = append(out, line...)
+	}
+	return out
+}
+
+// countLines counts the leading single token lines of the given type, which
+// start with prefix. File headers ("---", "+++") are not counted.
+func countLines(lines [][]chroma.Token, typ chroma.TokenType, prefix string) (n int) {
+	for _, line := range lines {
+		if len(line) != 1 || line[0].Type != typ {
+			break
+		}
+		if !strings.HasPrefix(line[0].Value, prefix) || strings.HasPrefix(line[0].Value, strings.Repeat(prefix, 3)) {
+			break
+		}
+		n++
+	}
+	return n
+}
+
+// markChangedWords wraps the words that differ between the removed line a and
+// the added line b with markers. Lines without any words in common are
+// returned unchanged, since every word would be marked.
+func markChangedWords(a, b string) (string, string) {
+	aPrefix, aBody, aNewline := splitDiffLine(a)
+	bPrefix, bBody, bNewline := splitDiffLine(b)
+	aWords, bWords := splitWords(aBody), splitWords(bBody)
+	aChanged, bChanged, common := diffSequences(aWords, bWords)
+	if !common {
+		return a, b
+	}
+	return aPrefix + markWords(aWords, aChanged, deletedWordsStart) + aNewline,
+		bPrefix + markWords(bWords, bChanged, insertedWordsStart) + bNewline
+}
+
+func splitDiffLine(s string) (prefix, body, newline string) {
+	if strings.HasSuffix(s, "\n") {
+		s, newline = s[:len(s)-1], "\n"
+	}
+	return s[:1], s[1:], newline
+}
+
+// splitWords splits s into runs of letters and digits, runs of whitespace and
+// individual punctuation characters.
+func splitWords(s string) (words []string) {
+	class := func(r rune) int {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			return 1
+		case unicode.IsSpace(r):
+			return 2
+		}
+		return 0
+	}
+	start, prev := 0, -1
+	for i, r := range s {
+		c := class(r)
+		if i > start && (c != prev || c == 0) {
+			words = append(words, s[start:i])
+			start = i
+		}
+		prev = c
+	}
+	if start < len(s) {
+		words = append(words, s[start:])
+	}
+	return words
+}
+
+// diffSequences reports which elements of a and b are not part of their
+// longest common subsequence, and whether any non-whitespace elements are.
+func diffSequences(a, b []string) (aChanged, bChanged []bool, common bool) {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	aChanged, bChanged = make([]bool, len(a)), make([]bool, len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			common = common || strings.TrimSpace(a[i]) != ""
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			aChanged[i] = true
+			i++
+		default:
+			bChanged[j] = true
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		aChanged[i] = true
+	}
+	for ; j < len(b); j++ {
+		bChanged[j] = true
+	}
+	return aChanged, bChanged, common
+}
+
+func markWords(words []string, changed []bool, start string) string {
+	var sb strings.Builder
+	for i, word := range words {
+		if changed[i] && (i == 0 || !changed[i-1]) {
+			sb.WriteString(start)
+		}
+		sb.WriteString(word)
+		if changed[i] && (i == len(words)-1 || !changed[i+1]) {
+			sb.WriteString(changedWordsEnd)
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,39 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDiffWords(t *testing.T) {
+	tokens := []chroma.Token{
+		{Type: chroma.GenericDeleted, Value: "--- a.go\n"},
+		{Type: chroma.GenericInserted, Value: "+++ b.go\n"},
+		{Type: chroma.Text, Value: " ctx\n"},
+		{Type: chroma.GenericDeleted, Value: "-x := foo(bar)\n-unrelated\n"},
+		{Type: chroma.GenericInserted, Value: "+x := foo(baz)\n+different\n"},
+	}
+
+	want := []chroma.Token{
+		{Type: chroma.GenericDeleted, Value: "--- a.go\n"},
+		{Type: chroma.GenericInserted, Value: "+++ b.go\n"},
+		{Type: chroma.Text, Value: " ctx\n"},
+		{Type: chroma.GenericDeleted, Value: "-x := foo(" + deletedWordsStart + "bar" + changedWordsEnd + ")\n"},
+		{Type: chroma.GenericDeleted, Value: "-unrelated\n"},
+		{Type: chroma.GenericInserted, Value: "+x := foo(" + insertedWordsStart + "baz" + changedWordsEnd + ")\n"},
+		{Type: chroma.GenericInserted, Value: "+different\n"},
+	}
+
+	if diff := cmp.Diff(want, diffWords(tokens)); diff != "" {
+		t.Errorf("unexpected tokens (-want +got):\n%s", diff)
+	}
+}
+
+func TestSplitWords(t *testing.T) {
+	want := []string{"x", " ", ":", "=", " ", "foo_1", "(", "bar", ")"}
+	if diff := cmp.Diff(want, splitWords("x := foo_1(bar)")); diff != "" {
+		t.Errorf("unexpected words (-want +got):\n%s", diff)
+	}
+}
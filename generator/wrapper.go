@@ -0,0 +1,90 @@
+package generator
+
+import (
+	"html"
+	"io"
+	"strings"
+)
+
+type attribute struct {
+	name  string
+	value string
+}
+
+// wrapperAttributes returns the attributes of the element wrapping the
+// highlighted code. The wrapper is only written if there are attributes.
+func (g *generator) wrapperAttributes() (attrs []attribute) {
+	if g.id != "" {
+		attrs = append(attrs, attribute{name: "id", value: g.id})
+	}
+	var classes []string
+	if len(g.focus) > 0 {
+		classes = append(classes, focusClass)
+	}
+	if g.badge != "" {
+		classes = append(classes, badgedClass)
+	}
+	if g.hasAttributionFooter() {
+		classes = append(classes, attributedClass)
+	}
+	if g.class != "" {
+		classes = append(classes, g.class)
+	}
+	if len(classes) > 0 {
+		attrs = append(attrs, attribute{name: "class", value: strings.Join(classes, " ")})
+	}
+	if g.bidiSafe {
+		attrs = append(attrs, attribute{name: "dir", value: "ltr"})
+	}
+	if g.analytics {
+		attrs = append(attrs, attribute{name: nameAttribute, value: g.componentName})
+		if g.lang != "" {
+			attrs = append(attrs, attribute{name: langAttribute, value: g.lang})
+		}
+	}
+	if g.hash != "" {
+		attrs = append(attrs, attribute{name: hashAttribute, value: g.hash})
+	}
+	if g.classes() {
+		attrs = append(attrs, attribute{name: themesAttribute, value: g.themes[0] + " " + g.themes[1]})
+	}
+	if len(g.focus) > 0 {
+		// Allow the snippet to be focused by clicking, to reveal dimmed lines.
+		attrs = append(attrs, attribute{name: "tabindex", value: "0"})
+	}
+	return attrs
+}
+
+func (g *generator) writeWrapperOpen(w io.Writer) error {
+	attrs := g.wrapperAttributes()
+	if len(attrs) == 0 {
+		return nil
+	}
+	var sb strings.Builder
+	sb.WriteString("<div")
+	for _, attr := range attrs {
+		sb.WriteString(" " + attr.name + `="` + html.EscapeString(attr.value) + `"`)
+	}
+	sb.WriteString(">")
+	if len(g.focus) > 0 {
+		sb.WriteString(g.inlineCSS(focusCSS))
+	}
+	if g.badge != "" {
+		sb.WriteString(g.inlineCSS(badgeCSS))
+		sb.WriteString(`<span class="` + badgeClass + `" aria-hidden="true">` + html.EscapeString(g.badge) + `</span>`)
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func (g *generator) writeWrapperClose(w io.Writer) error {
+	if len(g.wrapperAttributes()) == 0 {
+		return nil
+	}
+	footer := ""
+	if g.hasAttributionFooter() {
+		footer = g.attributionFooterHTML()
+	}
+	_, err := io.WriteString(w, footer+"</div>")
+	return err
+}
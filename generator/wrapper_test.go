@@ -0,0 +1,99 @@
+package generator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/chroma/v2/formatters/html"
+)
+
+func TestWrapper(t *testing.T) {
+	t.Run("omits the wrapper by default", func(t *testing.T) {
+		g := generator{f: html.New(), contents: []byte("package main\n")}
+
+		s, err := g.chroma()
+		if err != nil {
+			t.Fatalf("failed to highlight: %v", err)
+		}
+		if strings.HasPrefix(s, "<div") {
+			t.Errorf("expected no wrapper, got:\n%s", s)
+		}
+	})
+
+	t.Run("wraps with id and class", func(t *testing.T) {
+		g := generator{
+			f:        html.New(),
+			contents: []byte("package main\n"),
+			id:       "example-handler",
+			class:    `example "quoted"`,
+		}
+
+		s, err := g.chroma()
+		if err != nil {
+			t.Fatalf("failed to highlight: %v", err)
+		}
+		expected := `<div id=\"example-handler\" class=\"example &#34;quoted&#34;\">`
+		if !strings.HasPrefix(s, expected) {
+			t.Errorf("expected output to start with %s, got:\n%s", expected, s)
+		}
+		if !strings.HasSuffix(s, "</div>") {
+			t.Errorf("expected output to end with </div>, got:\n%s", s)
+		}
+	})
+}
+
+func TestComponents(t *testing.T) {
+	var b strings.Builder
+	_, err := Generate(&b, Config{
+		Contents:      []byte("package main\n"),
+		PackageName:   "main",
+		ComponentName: "Main",
+		Components: []Component{
+			{Name: "MainPackage", Contents: []byte("package main\n")},
+		},
+	}, WithID("main"))
+	if err != nil {
+		t.Fatalf("failed to generate: %v", err)
+	}
+
+	s := b.String()
+	for _, expected := range []string{"func Main() templ.Component {", "func MainPackage() templ.Component {"} {
+		if !strings.Contains(s, expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, s)
+		}
+	}
+	if n := strings.Count(s, `id=\"main\"`); n != 1 {
+		t.Errorf("expected the id to be set once, got %d", n)
+	}
+}
+
+func TestSharedLiterals(t *testing.T) {
+	var (
+		b        strings.Builder
+		literals []string
+	)
+	_, err := Generate(&b, Config{
+		Contents:      []byte("package main\n"),
+		PackageName:   "main",
+		ComponentName: "Main",
+	}, WithSharedLiterals("example.com/shared", func(literal string) string {
+		literals = append(literals, literal)
+		return "Snippet1"
+	}))
+	if err != nil {
+		t.Fatalf("failed to generate: %v", err)
+	}
+
+	s := b.String()
+	for _, expected := range []string{
+		"\tsnipsshared \"example.com/shared\"\n",
+		"templ_7745c5c3_Buffer.WriteString(snipsshared.Snippet1)",
+	} {
+		if !strings.Contains(s, expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, s)
+		}
+	}
+	if len(literals) != 1 || !strings.HasPrefix(literals[0], "<pre") {
+		t.Errorf("expected the highlighted HTML to be shared, got %q", literals)
+	}
+}
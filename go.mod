@@ -3,11 +3,13 @@ module github.com/garrettladley/snips
 go 1.23.2
 
 require (
+	github.com/BurntSushi/toml v1.5.0
 	github.com/a-h/templ v0.2.793
 	github.com/fatih/color v1.17.0
 	github.com/fsnotify/fsnotify v1.7.0
 	github.com/google/go-cmp v0.6.0
 	golang.org/x/mod v0.20.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require github.com/dlclark/regexp2 v1.11.0 // indirect
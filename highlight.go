@@ -0,0 +1,158 @@
+package snips
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/garrettladley/snips/generator"
+)
+
+// defaultStyle is the style of snips generate, unless -style is set.
+const defaultStyle = "swapoff"
+
+// Option configures Highlight.
+type Option func(o *highlightOptions) error
+
+type highlightOptions struct {
+	style          string
+	lang           string
+	fileName       string
+	tabWidth       int
+	baseLine       int
+	lines          bool
+	linesTable     bool
+	rawInvalidUTF8 bool
+	generateOpts   []generator.GenerateOpt
+}
+
+// WithStyle highlights with the chroma style of the given name, e.g.
+// "monokai", instead of swapoff, as snips generate -style does.
+func WithStyle(name string) Option {
+	return func(o *highlightOptions) error {
+		if _, ok := styles.Registry[name]; !ok {
+			return fmt.Errorf("unknown style %q", name)
+		}
+		o.style = name
+		return nil
+	}
+}
+
+// WithLanguage highlights with the chroma lexer of the given name or alias,
+// e.g. "go", instead of detecting it, as snips generate -lang does.
+func WithLanguage(name string) Option {
+	return func(o *highlightOptions) error {
+		if lexers.Get(name) == nil {
+			return fmt.Errorf("unknown language %q", name)
+		}
+		o.lang = name
+		return nil
+	}
+}
+
+// WithFileName detects the lexer from the file name of the code, e.g.
+// main.go, before analysing its contents, as snips generate does with the
+// names of snippets.
+func WithFileName(name string) Option {
+	return func(o *highlightOptions) error {
+		o.fileName = name
+		return nil
+	}
+}
+
+// WithTabWidth sets the width of tabs, 8 by default.
+func WithTabWidth(width int) Option {
+	return func(o *highlightOptions) error {
+		o.tabWidth = width
+		return nil
+	}
+}
+
+// WithLineNumbers numbers the lines from base, in a table if table is set, as
+// snips generate -line-numbers, -line-numbers-table and -base-line do.
+func WithLineNumbers(base int, table bool) Option {
+	return func(o *highlightOptions) error {
+		o.lines, o.linesTable, o.baseLine = true, table, base
+		return nil
+	}
+}
+
+// WithRawInvalidUTF8 keeps the bytes of invalid UTF-8 as they are, instead of
+// replacing them with U+FFFD, as snips generate -invalid-utf8 raw does.
+func WithRawInvalidUTF8() Option {
+	return func(o *highlightOptions) error {
+		o.rawInvalidUTF8 = true
+		return nil
+	}
+}
+
+// WithGenerateOptions applies generator options, the same as generation does,
+// e.g. generator.WithFocusLines or generator.WithBidiSafety.
+// generator.WithRuntime and generator.WithThemes fail highlighting. Snippets
+// highlighted with generator options skip the cache, see ConfigureCache.
+func WithGenerateOptions(opts ...generator.GenerateOpt) Option {
+	return func(o *highlightOptions) error {
+		o.generateOpts = append(o.generateOpts, opts...)
+		return nil
+	}
+}
+
+// Highlight returns the highlighted HTML of contents, selecting the lexer,
+// styling, formatting and handling invalid UTF-8 as snips generate does with
+// the equivalent flags, so that code highlighted at request time, e.g. code
+// that users submit, looks the same as generated snippets. Unlike snippets,
+// contents isn't parsed for front matter or regions. The HTML of the most
+// recently highlighted snippets is cached by the hash of their contents and
+// options, see ConfigureCache.
+func Highlight(ctx context.Context, contents []byte, opts ...Option) (html string, err error) {
+	o, err := newHighlightOptions(opts)
+	if err != nil {
+		return "", err
+	}
+	if err = ctx.Err(); err != nil {
+		return "", err
+	}
+	return o.cachedHighlight(contents)
+}
+
+func newHighlightOptions(opts []Option) (o highlightOptions, err error) {
+	o = highlightOptions{style: defaultStyle, tabWidth: 8, baseLine: 1}
+	for _, opt := range opts {
+		if err = opt(&o); err != nil {
+			return o, err
+		}
+	}
+	return o, nil
+}
+
+func (o highlightOptions) highlight(contents []byte) (html string, err error) {
+	generateOpts := o.generateOpts
+	if o.rawInvalidUTF8 {
+		// Clipped, so that concurrent highlights of the same options don't
+		// share the appended option.
+		generateOpts = append(slices.Clip(generateOpts), generator.WithRawInvalidUTF8())
+	} else {
+		contents = bytes.ToValidUTF8(contents, []byte("\uFFFD"))
+	}
+	return generator.Highlight(generator.Config{
+		HTMLOpts:      o.htmlOpts(),
+		Style:         o.style,
+		Contents:      contents,
+		ComponentName: "Snippet",
+		Lang:          o.lang,
+		FileName:      o.fileName,
+	}, generateOpts...)
+}
+
+func (o highlightOptions) htmlOpts() []html.Option {
+	return []html.Option{
+		html.TabWidth(o.tabWidth),
+		html.BaseLineNumber(o.baseLine),
+		html.WithLineNumbers(o.lines),
+		html.LineNumbersInTable(o.linesTable),
+	}
+}
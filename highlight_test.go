@@ -0,0 +1,68 @@
+package snips_test
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/garrettladley/snips"
+	"github.com/garrettladley/snips/generator"
+)
+
+func TestHighlight(t *testing.T) {
+	contents := []byte("package main\n\nfunc main() {\n\tprintln(\"<hi>\", \"\xff\")\n}\n")
+
+	// The HTML that generation writes with the equivalent settings.
+	var generated string
+	_, err := generator.Generate(io.Discard, generator.Config{
+		HTMLOpts: []html.Option{
+			html.TabWidth(4),
+			html.BaseLineNumber(10),
+			html.WithLineNumbers(true),
+			html.LineNumbersInTable(false),
+		},
+		Style:         "monokai",
+		Contents:      []byte(strings.ToValidUTF8(string(contents), "\uFFFD")),
+		PackageName:   "main",
+		ComponentName: "MainGo",
+		FileName:      "main.go",
+	}, generator.WithHTMLReport(func(_, h string) { generated = h }))
+	if err != nil {
+		t.Fatalf("failed to generate: %v", err)
+	}
+
+	highlighted, err := snips.Highlight(context.Background(), contents,
+		snips.WithStyle("monokai"),
+		snips.WithFileName("main.go"),
+		snips.WithTabWidth(4),
+		snips.WithLineNumbers(10, false),
+	)
+	if err != nil {
+		t.Fatalf("failed to highlight: %v", err)
+	}
+	if highlighted != generated {
+		t.Errorf("expected the generated HTML:\n%s\ngot:\n%s", generated, highlighted)
+	}
+	if !strings.Contains(highlighted, "&lt;hi&gt;") || !strings.Contains(highlighted, "\uFFFD") {
+		t.Errorf("expected the code to be escaped and invalid UTF-8 replaced, got:\n%s", highlighted)
+	}
+
+	for name, opt := range map[string]snips.Option{
+		"an unknown style":    snips.WithStyle("unknown"),
+		"an unknown language": snips.WithLanguage("unknown"),
+		"runtime styles":      snips.WithGenerateOptions(generator.WithRuntime()),
+		"themes":              snips.WithGenerateOptions(generator.WithThemes("github", "monokai")),
+	} {
+		if _, err := snips.Highlight(context.Background(), contents, opt); err == nil {
+			t.Errorf("expected an error for %s", name)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := snips.Highlight(ctx, contents); err != context.Canceled {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+}
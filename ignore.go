@@ -0,0 +1,37 @@
+package snips
+
+import (
+	"bytes"
+	"regexp"
+)
+
+// ignoreDirective matches a snips:ignore comment line, in any comment syntax.
+var ignoreDirective = regexp.MustCompile(`^[ \t]*(?://|#|--|;+|%|/\*|<!--)[ \t]*(?i:snips)[ \t]*:[ \t]*(?i:ignore)\b`)
+
+// Ignored reports whether a snippet is marked as a work in progress, which
+// isn't generated, by an ignore front matter key, or by a snips:ignore comment
+// on its first non-blank line, before or after its front matter, e.g.
+//
+//	// snips:ignore
+//	package main
+//
+// Snippets with invalid front matter are only checked for the comment.
+func Ignored(contents []byte) bool {
+	fm, body, err := ParseFrontMatter(contents)
+	if err == nil && fm.Ignore {
+		return true
+	}
+	return ignoreDirective.Match(firstLine(contents)) || ignoreDirective.Match(firstLine(body))
+}
+
+// firstLine returns the first non-blank line of s.
+func firstLine(s []byte) []byte {
+	for len(s) > 0 {
+		line, rest, _ := cutLine(s)
+		if len(bytes.TrimSpace(line)) > 0 {
+			return line
+		}
+		s = rest
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package snips_test
+
+import (
+	"testing"
+
+	"github.com/garrettladley/snips"
+)
+
+func TestIgnored(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		expected bool
+	}{
+		{name: "not ignored", contents: "package main\n"},
+		{name: "comment", contents: "// snips:ignore\npackage main\n", expected: true},
+		{name: "comment syntax and case", contents: "\n#SNIPS: Ignore\nx = 1\n", expected: true},
+		{name: "html comment", contents: "<!-- snips:ignore -->\n<p></p>\n", expected: true},
+		{name: "comment after front matter", contents: "---\nclass: example\n---\n// snips:ignore\npackage main\n", expected: true},
+		{name: "comment before front matter", contents: "// snips:ignore\n---\nclass: example\n---\npackage main\n", expected: true},
+		{name: "front matter", contents: "---\nignore: true\n---\npackage main\n", expected: true},
+		{name: "invalid front matter", contents: "---\nunknown: true\n---\n// snips:ignore\n"},
+		{name: "not the first line", contents: "package main\n\n// snips:ignore\n"},
+		{name: "other directive", contents: "// snips:ignored\npackage main\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := snips.Ignored([]byte(tt.contents)); actual != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+// Package bidi holds the Unicode bidirectional control characters, shared by
+// the snips and generator packages.
+package bidi
+
+// Controls are the Unicode bidirectional control characters, by their
+// abbreviations. They mustn't be modified.
+var Controls = map[rune]string{
+	'\u061C': "ALM",
+	'\u200E': "LRM",
+	'\u200F': "RLM",
+	'\u202A': "LRE",
+	'\u202B': "RLE",
+	'\u202C': "PDF",
+	'\u202D': "LRO",
+	'\u202E': "RLO",
+	'\u2066': "LRI",
+	'\u2067': "RLI",
+	'\u2068': "FSI",
+	'\u2069': "PDI",
+}
@@ -0,0 +1,121 @@
+// Package lru implements a fixed size cache that evicts the least recently
+// used entries.
+package lru
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Cache holds at most size entries, evicting the least recently used entry to
+// add another, and dropping entries older than their TTL. It's safe for
+// concurrent use.
+type Cache[K comparable, V any] struct {
+	size int
+	ttl  time.Duration
+	// now is the clock of TTLs, replaced by tests.
+	now func() time.Time
+
+	mu sync.Mutex
+	// order of the entries, most recently used first.
+	order   *list.List
+	entries map[K]*list.Element
+	stats   Stats
+}
+
+// Stats are the counters of a cache, for sizing it.
+type Stats struct {
+	// Hits are the lookups of cached entries.
+	Hits uint64 `json:"hits"`
+	// Misses are the lookups of entries that weren't cached, or had expired.
+	Misses uint64 `json:"misses"`
+	// Evictions are the entries evicted to add others.
+	Evictions uint64 `json:"evictions"`
+	// Expirations are the entries dropped because they were older than the
+	// TTL.
+	Expirations uint64 `json:"expirations"`
+	// Entries is the number of cached entries.
+	Entries int `json:"entries"`
+	// Size is the maximum number of entries.
+	Size int `json:"size"`
+}
+
+type entry[K comparable, V any] struct {
+	key   K
+	value V
+	added time.Time
+}
+
+// New returns a cache of at most size entries, and at least one, which expire
+// ttl after they're added, or never if ttl is zero.
+func New[K comparable, V any](size int, ttl time.Duration) *Cache[K, V] {
+	return &Cache[K, V]{
+		size:    max(size, 1),
+		ttl:     ttl,
+		now:     time.Now,
+		order:   list.New(),
+		entries: make(map[K]*list.Element),
+	}
+}
+
+// Get returns the value of the key, if cached, marking it as recently used.
+func (c *Cache[K, V]) Get(key K) (value V, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if ok && c.expired(e.Value.(*entry[K, V])) {
+		c.remove(e)
+		c.stats.Expirations++
+		ok = false
+	}
+	if !ok {
+		c.stats.Misses++
+		return value, false
+	}
+	c.stats.Hits++
+	c.order.MoveToFront(e)
+	return e.Value.(*entry[K, V]).value, true
+}
+
+// Add caches the value of the key, evicting the least recently used entry if
+// the cache is full.
+func (c *Cache[K, V]) Add(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		e.Value = &entry[K, V]{key: key, value: value, added: c.now()}
+		c.order.MoveToFront(e)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&entry[K, V]{key: key, value: value, added: c.now()})
+	if c.order.Len() > c.size {
+		c.remove(c.order.Back())
+		c.stats.Evictions++
+	}
+}
+
+// Len returns the number of cached entries.
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// Stats returns the counters of the cache.
+func (c *Cache[K, V]) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Entries, stats.Size = c.order.Len(), c.size
+	return stats
+}
+
+func (c *Cache[K, V]) expired(e *entry[K, V]) bool {
+	return c.ttl > 0 && c.now().Sub(e.added) >= c.ttl
+}
+
+func (c *Cache[K, V]) remove(e *list.Element) {
+	c.order.Remove(e)
+	delete(c.entries, e.Value.(*entry[K, V]).key)
+}
@@ -0,0 +1,58 @@
+package lru
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	c := New[string, int](2, 0)
+	c.Add("a", 1)
+	c.Add("b", 2)
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Errorf("Get(a) = %d, %v, want 1, true", v, ok)
+	}
+	// b is the least recently used.
+	c.Add("c", 3)
+	if _, ok := c.Get("b"); ok {
+		t.Error("expected b to be evicted")
+	}
+	for key, want := range map[string]int{"a": 1, "c": 3} {
+		if v, ok := c.Get(key); !ok || v != want {
+			t.Errorf("Get(%s) = %d, %v, want %d, true", key, v, ok, want)
+		}
+	}
+
+	c.Add("a", 4)
+	if v, _ := c.Get("a"); v != 4 || c.Len() != 2 {
+		t.Errorf("expected a to be replaced, got %d with %d entries", v, c.Len())
+	}
+	want := Stats{Hits: 4, Misses: 1, Evictions: 1, Entries: 2, Size: 2}
+	if got := c.Stats(); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	now := time.Now()
+	c := New[string, int](2, time.Minute)
+	c.now = func() time.Time { return now }
+	c.Add("a", 1)
+	now = now.Add(30 * time.Second)
+	c.Add("b", 2)
+	if _, ok := c.Get("a"); !ok {
+		t.Error("expected a to be cached before its TTL")
+	}
+
+	now = now.Add(30 * time.Second)
+	if _, ok := c.Get("a"); ok {
+		t.Error("expected a to expire after its TTL, even though it was used")
+	}
+	if _, ok := c.Get("b"); !ok {
+		t.Error("expected b to be cached before its TTL")
+	}
+	want := Stats{Hits: 2, Misses: 1, Expirations: 1, Entries: 1, Size: 2}
+	if got := c.Stats(); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+}
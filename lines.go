@@ -0,0 +1,34 @@
+package snips
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ParseLineRanges parses a comma separated list of inclusive line numbers and
+// ranges, e.g. "3-5,8", into ranges of line numbers.
+func ParseLineRanges(s string) (ranges [][2]int, err error) {
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		from, to, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(strings.TrimSpace(from))
+		if err != nil {
+			return nil, fmt.Errorf("invalid line range %q", part)
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(strings.TrimSpace(to)); err != nil {
+				return nil, fmt.Errorf("invalid line range %q", part)
+			}
+		}
+		if end < start {
+			return nil, fmt.Errorf("invalid line range %q, end is before start", part)
+		}
+		ranges = append(ranges, [2]int{start, end})
+	}
+	return ranges, nil
+}
@@ -0,0 +1,54 @@
+package snips_test
+
+import (
+	"testing"
+
+	"github.com/garrettladley/snips"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseLineRanges(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    [][2]int
+		wantErr bool
+	}{
+		{
+			name:  "empty",
+			input: "",
+		},
+		{
+			name:  "single line",
+			input: "8",
+			want:  [][2]int{{8, 8}},
+		},
+		{
+			name:  "ranges and lines",
+			input: "3-5, 8",
+			want:  [][2]int{{3, 5}, {8, 8}},
+		},
+		{
+			name:    "reversed range",
+			input:   "5-3",
+			wantErr: true,
+		},
+		{
+			name:    "not a number",
+			input:   "a-b",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := snips.ParseLineRanges(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseLineRanges(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("unexpected ranges (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -1,18 +1,32 @@
 package snips
 
 import (
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
 )
 
 func PackageName(dir string) (name string) {
-	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+	return packageName(os.DirFS(dir), ".", dir)
+}
+
+// PackageNameFS returns the package name of dir, a slash separated path in
+// fsys, as PackageName does for directories on disk.
+func PackageNameFS(fsys fs.FS, dir string) (name string) {
+	if dir == "." {
+		return packageName(fsys, dir, dir)
+	}
+	return packageName(fsys, dir, "./"+dir)
+}
+
+func packageName(fsys fs.FS, root, dir string) (name string) {
+	err := fs.WalkDir(fsys, root, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
-		if !info.IsDir() && strings.HasSuffix(info.Name(), ".templ") {
-			content, err := os.ReadFile(path)
+		if !d.IsDir() && strings.HasSuffix(d.Name(), ".templ") {
+			content, err := fs.ReadFile(fsys, path)
 			if err != nil {
 				return err
 			}
@@ -20,7 +34,7 @@ func PackageName(dir string) (name string) {
 			for _, line := range lines {
 				if strings.HasPrefix(strings.TrimSpace(line), "package ") {
 					name = strings.TrimSpace(strings.TrimPrefix(line, "package"))
-					return filepath.SkipAll // stop walking, we found a package name
+					return fs.SkipAll // stop walking, we found a package name
 				}
 			}
 		}
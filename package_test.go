@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"testing/fstest"
 
 	"github.com/garrettladley/snips"
 )
@@ -62,3 +63,20 @@ func createTempFile(t *testing.T, path, content string) {
 		t.Fatal(err)
 	}
 }
+
+func TestPackageNameFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"views/foo/bar.templ":  {Data: []byte("package bar\n")},
+		"views/baz/ex.code.rs": {Data: []byte("fn main() {}\n")},
+	}
+	for dir, want := range map[string]string{
+		"views/foo": "bar",
+		"views/baz": "baz",
+		"views":     "bar",
+		"docs":      "docs",
+	} {
+		if pkg := snips.PackageNameFS(fsys, dir); pkg != want {
+			t.Errorf("PackageNameFS(%q) = %q, want %q", dir, pkg, want)
+		}
+	}
+}
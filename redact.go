@@ -0,0 +1,104 @@
+package snips
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+)
+
+// Redacted replaces redacted content.
+const Redacted = "•••"
+
+var (
+	redactMarker = regexp.MustCompile(`snips:redact\b`)
+	// redactValue matches the value of a line after its first = or :, without
+	// any trailing comma or semicolon.
+	redactValue = regexp.MustCompile(`^([^=:]*[=:]\s*)(.*?)([,;]?\s*)$`)
+	// redactStrings matches the contents of quoted strings.
+	redactStrings = regexp.MustCompile("\"([^\"]*)\"|'([^']*)'|`([^`]*)`")
+)
+
+// Redact replaces sensitive content with Redacted, so that snippets based on
+// real configuration can be published. The line after a snips:redact marker
+// comment, in any comment syntax, is redacted and the marker line removed, e.g.
+//
+//	# snips:redact
+//	password: hunter2
+//
+// becomes "password: •••". The contents of quoted strings after the first = or
+// : of the line are redacted if there are any, otherwise the whole value is.
+//
+// Content matching any of rules is redacted too, or only the submatches of
+// rules with capturing groups, e.g. `token=(\w+)`.
+func Redact(contents []byte, rules []*regexp.Regexp) (redacted []byte, err error) {
+	lines := bytes.SplitAfter(contents, []byte("\n"))
+	redacted = make([]byte, 0, len(contents))
+	for i := 0; i < len(lines); i++ {
+		if !redactMarker.Match(lines[i]) {
+			redacted = append(redacted, lines[i]...)
+			continue
+		}
+		i++
+		if i == len(lines) || len(lines[i]) == 0 {
+			return nil, fmt.Errorf("line %d: snips:redact must be followed by the line to redact", i)
+		}
+		redacted = append(redacted, redactLine(lines[i])...)
+	}
+	for _, rule := range rules {
+		redacted = redactMatches(redacted, rule)
+	}
+	return redacted, nil
+}
+
+// redactLine redacts the value of a line.
+func redactLine(line []byte) []byte {
+	content := bytes.TrimRight(line, "\r\n")
+	eol := line[len(content):]
+	indent := content[:len(content)-len(bytes.TrimLeft(content, " \t"))]
+
+	var out []byte
+	if m := redactValue.FindSubmatchIndex(content); m != nil && m[5] > m[4] {
+		value := content[m[4]:m[5]]
+		if redactStrings.Match(value) {
+			value = redactMatches(value, redactStrings)
+		} else {
+			value = []byte(Redacted)
+		}
+		out = append(out, content[:m[4]]...)
+		out = append(out, value...)
+		out = append(out, content[m[5]:]...)
+	} else {
+		out = append(out, indent...)
+		out = append(out, Redacted...)
+	}
+	return append(out, eol...)
+}
+
+// redactMatches redacts the matches of re in contents, or the submatches if
+// re has capturing groups.
+func redactMatches(contents []byte, re *regexp.Regexp) []byte {
+	var (
+		out  []byte
+		last int
+	)
+	for _, m := range re.FindAllSubmatchIndex(contents, -1) {
+		groups := [][2]int{{m[0], m[1]}}
+		if re.NumSubexp() > 0 {
+			groups = groups[:0]
+			for g := 1; g <= re.NumSubexp(); g++ {
+				if m[2*g] >= 0 {
+					groups = append(groups, [2]int{m[2*g], m[2*g+1]})
+				}
+			}
+		}
+		for _, g := range groups {
+			if g[0] < last || g[0] == g[1] {
+				continue
+			}
+			out = append(out, contents[last:g[0]]...)
+			out = append(out, Redacted...)
+			last = g[1]
+		}
+	}
+	return append(out, contents[last:]...)
+}
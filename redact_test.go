@@ -0,0 +1,61 @@
+package snips_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/garrettladley/snips"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		rules    []string
+		want     string
+		wantErr  bool
+	}{
+		{
+			name:     "unquoted value",
+			contents: "user: admin\n# snips:redact\npassword: hunter2\n",
+			want:     "user: admin\npassword: •••\n",
+		},
+		{
+			name:     "quoted values",
+			contents: "{\n  // snips:redact\n  \"token\": \"abc123\",\n  \"id\": 1\n}\n",
+			want:     "{\n  \"token\": \"•••\",\n  \"id\": 1\n}\n",
+		},
+		{
+			name:     "no value",
+			contents: "  -- snips:redact\n  SELECT secret FROM vault;\n",
+			want:     "  •••\n",
+		},
+		{
+			name:     "rules",
+			contents: "curl https://api.example.com?token=abc123&user=bob\nkey sk-live-0123\n",
+			rules:    []string{`token=(\w+)`, `sk-live-\d+`},
+			want:     "curl https://api.example.com?token=•••&user=bob\nkey •••\n",
+		},
+		{
+			name:     "marker on the last line",
+			contents: "a = 1\n// snips:redact\n",
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rules []*regexp.Regexp
+			for _, rule := range tt.rules {
+				rules = append(rules, regexp.MustCompile(rule))
+			}
+			got, err := snips.Redact([]byte(tt.contents), rules)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if diff := cmp.Diff(tt.want, string(got)); !tt.wantErr && diff != "" {
+				t.Errorf("unexpected redaction (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
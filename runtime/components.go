@@ -0,0 +1,110 @@
+package runtime
+
+import (
+	"context"
+	"io"
+	"strconv"
+
+	"github.com/a-h/templ"
+)
+
+var copyScript = script(`document.addEventListener("click",function(e){` +
+	`var b=e.target.closest("[data-snips-copy]");if(!b)return;` +
+	`var t=document.getElementById(b.dataset.snipsCopy);if(!t||!navigator.clipboard)return;` +
+	`navigator.clipboard.writeText(t.innerText).then(function(){` +
+	`var l=b.textContent;b.textContent=b.dataset.snipsCopied;setTimeout(function(){b.textContent=l},1500)})})`)
+
+// CopyButton renders a button that copies the text of the element with the
+// given id, e.g. the id a snippet sets in front matter, to the clipboard. Its
+// text is localized by the messages of the context, see WithMessages.
+func CopyButton(targetID string) templ.Component {
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		m := MessagesFrom(ctx)
+		return render(ctx, w, Styles(), copyScript.Once(), templ.Raw(
+			`<button type="button" class="snips-copy" data-snips-copy="`+templ.EscapeString(targetID)+
+				`" data-snips-copied="`+templ.EscapeString(m.Get(MessageCopied))+
+				`" aria-label="`+templ.EscapeString(m.Get(MessageCopyLabel))+`">`+templ.EscapeString(m.Get(MessageCopy))+`</button>`))
+	})
+}
+
+// Caption renders its children, e.g. a snippet, in a figure captioned with
+// text, such as the file name of the snippet.
+func Caption(text string) templ.Component {
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		children := templ.GetChildren(ctx)
+		ctx = templ.ClearChildren(ctx)
+		return render(ctx, w,
+			Styles(),
+			templ.Raw(`<figure class="snips-figure">`),
+			children,
+			templ.Raw(`<figcaption class="snips-caption">`+templ.EscapeString(text)+`</figcaption></figure>`),
+		)
+	})
+}
+
+// Tab is a tab of Tabs.
+type Tab struct {
+	// Label is the text of the tab, e.g. "Go".
+	Label string
+	// Content is shown when the tab is selected, e.g. a snippet.
+	Content templ.Component
+}
+
+var tabsScript = script(`document.addEventListener("click",function(e){` +
+	`var t=e.target.closest(".snips-tabs [role=tab]");if(!t)return;` +
+	`t.parentNode.querySelectorAll("[role=tab]").forEach(function(o){` +
+	`var s=o===t;o.setAttribute("aria-selected",s);document.getElementById(o.getAttribute("aria-controls")).hidden=!s})})`)
+
+// Tabs renders tabs that switch between their contents, e.g. the same example
+// in several languages, showing the first. id prefixes the ids of the tabs and
+// their panels, so it must be unique within the page.
+func Tabs(id string, tabs ...Tab) templ.Component {
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		components := []templ.Component{Styles(), tabsScript.Once(), templ.Raw(`<div class="snips-tabs"><div role="tablist">`)}
+		for i, tab := range tabs {
+			selected := strconv.FormatBool(i == 0)
+			components = append(components, templ.Raw(`<button type="button" role="tab" id="`+tabID(id, i)+`" aria-selected="`+selected+
+				`" aria-controls="`+panelID(id, i)+`">`+templ.EscapeString(tab.Label)+`</button>`))
+		}
+		components = append(components, templ.Raw(`</div>`))
+		for i, tab := range tabs {
+			hidden := ""
+			if i > 0 {
+				hidden = " hidden"
+			}
+			components = append(components, templ.Raw(`<div role="tabpanel" id="`+panelID(id, i)+`" aria-labelledby="`+tabID(id, i)+`"`+hidden+`>`))
+			if tab.Content != nil {
+				components = append(components, tab.Content)
+			}
+			components = append(components, templ.Raw(`</div>`))
+		}
+		components = append(components, templ.Raw(`</div>`))
+		return render(ctx, w, components...)
+	})
+}
+
+func tabID(id string, i int) string {
+	return templ.EscapeString(id + "-tab-" + strconv.Itoa(i))
+}
+
+func panelID(id string, i int) string {
+	return templ.EscapeString(id + "-panel-" + strconv.Itoa(i))
+}
+
+var lineHighlighterScript = script(`(function(){function h(){` +
+	`document.querySelectorAll(".snips-highlighted").forEach(function(l){l.classList.remove("snips-highlighted")});` +
+	`var m=/^#(.+)-L(\d+)(?:-L?(\d+))?$/.exec(decodeURIComponent(location.hash));if(!m)return;` +
+	`var s=document.getElementById(m[1]);if(!s)return;` +
+	`var ls=s.querySelectorAll("pre code > span"),a=+m[2],b=+(m[3]||m[2]);` +
+	`for(var i=a;i<=b&&i<=ls.length;i++)ls[i-1].classList.add("snips-highlighted");` +
+	`if(ls[a-1])ls[a-1].scrollIntoView({block:"center"})}` +
+	`addEventListener("hashchange",h);document.readyState==="loading"?document.addEventListener("DOMContentLoaded",h):h()})()`)
+
+// LineHighlighter renders a script that highlights the lines of a snippet
+// linked to by the URL fragment, e.g. #handler-L3-L5 highlights lines 3 to 5
+// of the snippet whose id is handler. Render it once per page.
+func LineHighlighter() templ.Component {
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		return render(ctx, w, Styles(), lineHighlighterScript.Once())
+	})
+}
@@ -0,0 +1,86 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// IDs of the messages of the UI text that snips renders, which Messages
+// localize.
+const (
+	// MessageCopy is the text of copy buttons, "Copy".
+	MessageCopy = "copy"
+	// MessageCopyLabel is the accessible label of copy buttons, "Copy code".
+	MessageCopyLabel = "copy-label"
+	// MessageCopied replaces the text of copy buttons once the code is
+	// copied, "Copied".
+	MessageCopied = "copied"
+	// MessageTheme is the text of theme switchers, "Theme".
+	MessageTheme = "theme"
+	// MessageThemeLabel is the accessible label of theme switchers, "Toggle
+	// dark theme".
+	MessageThemeLabel = "theme-label"
+	// MessageSource precedes the link to the source of snippets in
+	// attribution footers, "Source:".
+	MessageSource = "source"
+	// MessageLicense precedes the license of snippets without a source in
+	// attribution footers, "License:".
+	MessageLicense = "license"
+)
+
+// defaultMessages are the English messages, by ID.
+var defaultMessages = Messages{
+	MessageCopy:       "Copy",
+	MessageCopyLabel:  "Copy code",
+	MessageCopied:     "Copied",
+	MessageTheme:      "Theme",
+	MessageThemeLabel: "Toggle dark theme",
+	MessageSource:     "Source:",
+	MessageLicense:    "License:",
+}
+
+// Messages are the text of UI elements, by message ID, e.g. for non-English
+// docs sites. Messages that aren't set, or are empty, are rendered in
+// English.
+type Messages map[string]string
+
+// Validate checks that the messages have known IDs.
+func (m Messages) Validate() error {
+	for _, id := range slices.Sorted(maps.Keys(m)) {
+		if _, ok := defaultMessages[id]; !ok {
+			return fmt.Errorf("unknown message %q, expected one of %v", id, slices.Sorted(maps.Keys(defaultMessages)))
+		}
+	}
+	return nil
+}
+
+// Get returns the message with the given ID, or its English default.
+func (m Messages) Get(id string) string {
+	if s := m[id]; s != "" {
+		return s
+	}
+	return defaultMessages[id]
+}
+
+type messagesKey struct{}
+
+// WithMessages returns a context that renders the components of this package
+// with the given messages, e.g. ctx = runtime.WithMessages(ctx,
+// runtime.Messages{runtime.MessageCopy: "Kopieren"}). They're merged with the
+// messages of ctx, if any.
+func WithMessages(ctx context.Context, m Messages) context.Context {
+	merged := maps.Clone(MessagesFrom(ctx))
+	if merged == nil {
+		merged = make(Messages, len(m))
+	}
+	maps.Copy(merged, m)
+	return context.WithValue(ctx, messagesKey{}, merged)
+}
+
+// MessagesFrom returns the messages set on ctx by WithMessages, if any.
+func MessagesFrom(ctx context.Context) Messages {
+	m, _ := ctx.Value(messagesKey{}).(Messages)
+	return m
+}
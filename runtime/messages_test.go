@@ -0,0 +1,33 @@
+package runtime_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/a-h/templ"
+	"github.com/garrettladley/snips/runtime"
+)
+
+func TestMessages(t *testing.T) {
+	ctx := templ.InitializeContext(context.Background())
+	ctx = runtime.WithMessages(ctx, runtime.Messages{runtime.MessageCopy: "Kopieren", runtime.MessageCopied: "Kopiert"})
+	ctx = runtime.WithMessages(ctx, runtime.Messages{runtime.MessageTheme: "<Design>"})
+
+	copyButton := render(t, ctx, runtime.CopyButton("handler"))
+	for _, expected := range []string{`data-snips-copied="Kopiert"`, `aria-label="Copy code"`, `>Kopieren</button>`} {
+		if !strings.Contains(copyButton, expected) {
+			t.Errorf("expected the copy button to contain %q, got %q", expected, copyButton)
+		}
+	}
+	if themeSwitcher := render(t, ctx, runtime.ThemeSwitcher()); !strings.Contains(themeSwitcher, `>&lt;Design&gt;</button>`) {
+		t.Errorf("expected the theme switcher to be localized and escaped, got %q", themeSwitcher)
+	}
+
+	if got := runtime.MessagesFrom(context.Background()).Get(runtime.MessageCopy); got != "Copy" {
+		t.Errorf("expected the English message without messages, got %q", got)
+	}
+	if err := (runtime.Messages{"view-source": "Quelle"}).Validate(); err == nil {
+		t.Error("expected an unknown message to be invalid")
+	}
+}
@@ -0,0 +1,60 @@
+// Package runtime provides templ components for the wrappers of snippets:
+// copy buttons, captions, tabs, line highlighting and theme switching. Code
+// generated with generator.WithRuntime references Styles instead of inlining
+// the styles of its wrapper into every component, and code generated with
+// generator.WithThemes references ThemeStyles.
+package runtime
+
+import (
+	"context"
+	"io"
+
+	"github.com/a-h/templ"
+)
+
+// wrapperCSS styles the wrappers of generated components: dimmed unfocused
+// lines, language badges and attribution footers. It's kept in sync with the
+// styles the generator otherwise inlines.
+const wrapperCSS = `.snips-focus .snips-unfocused{opacity:.4;transition:opacity .2s}` +
+	`.snips-focus:hover .snips-unfocused,.snips-focus:focus-within .snips-unfocused{opacity:1}` +
+	`.snips-badged{position:relative}` +
+	`.snips-badge{position:absolute;top:.5em;right:.5em;padding:.1em .5em;border-radius:.25em;font-size:.75em;line-height:1.5;opacity:.7;pointer-events:none;user-select:none}` +
+	`.snips-attribution{padding:.25em .5em;font-size:.75em;opacity:.7;text-align:right}`
+
+// componentCSS styles the components of this package.
+const componentCSS = `.snips-copy{float:right;margin:.25em;font-size:.75em;cursor:pointer}` +
+	`.snips-figure{margin:0}` +
+	`.snips-caption{padding:.25em .5em;font-size:.875em;opacity:.8}` +
+	`.snips-tabs [role=tablist]{display:flex;gap:.25em}` +
+	`.snips-tabs [role=tab]{padding:.25em .75em;border:0;border-bottom:2px solid transparent;background:none;cursor:pointer}` +
+	`.snips-tabs [role=tab][aria-selected=true]{border-bottom-color:currentColor}` +
+	`.snips-highlighted{background:rgba(255,213,0,.25)}`
+
+var wrapperStyles = templ.NewOnceHandle(templ.WithComponent(templ.Raw(`<style>` + CSS() + `</style>`)))
+
+// CSS returns the styles rendered by Styles, for pages that aren't rendered
+// with templ.
+func CSS() string {
+	return wrapperCSS + componentCSS
+}
+
+// Styles renders the styles of generated wrappers and of the components of
+// this package, once per render.
+func Styles() templ.Component {
+	return wrapperStyles.Once()
+}
+
+// script returns a component rendering a script once per render.
+func script(js string) *templ.OnceHandle {
+	return templ.NewOnceHandle(templ.WithComponent(templ.Raw(`<script>` + js + `</script>`)))
+}
+
+// render renders the components in order, stopping at the first error.
+func render(ctx context.Context, w io.Writer, components ...templ.Component) error {
+	for _, c := range components {
+		if err := c.Render(ctx, w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package runtime_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/a-h/templ"
+	"github.com/garrettladley/snips/runtime"
+)
+
+func render(t *testing.T, ctx context.Context, c templ.Component) string {
+	t.Helper()
+	var sb strings.Builder
+	if err := c.Render(ctx, &sb); err != nil {
+		t.Fatalf("failed to render: %v", err)
+	}
+	return sb.String()
+}
+
+func TestStylesOncePerRender(t *testing.T) {
+	ctx := templ.InitializeContext(context.Background())
+	if s := render(t, ctx, runtime.Styles()); !strings.HasPrefix(s, "<style>") {
+		t.Errorf("expected styles, got %q", s)
+	}
+	if s := render(t, ctx, runtime.Styles()); s != "" {
+		t.Errorf("expected the styles to be rendered once, got %q", s)
+	}
+}
+
+func TestComponents(t *testing.T) {
+	snippet := templ.Raw(`<pre id="handler"><code>x</code></pre>`)
+	tests := []struct {
+		name      string
+		component templ.Component
+		ctx       func(context.Context) context.Context
+		expected  []string
+	}{
+		{
+			name:      "copy button",
+			component: runtime.CopyButton(`handler"`),
+			expected:  []string{`<script>`, `data-snips-copy="handler&#34;"`, `aria-label="Copy code"`},
+		},
+		{
+			name:      "caption",
+			component: runtime.Caption("main.go"),
+			ctx:       func(ctx context.Context) context.Context { return templ.WithChildren(ctx, snippet) },
+			expected:  []string{`<figure class="snips-figure"><pre id="handler"><code>x</code></pre><figcaption class="snips-caption">main.go</figcaption></figure>`},
+		},
+		{
+			name: "tabs",
+			component: runtime.Tabs("install",
+				runtime.Tab{Label: "Go", Content: snippet},
+				runtime.Tab{Label: "<Shell>", Content: templ.Raw("sh")},
+			),
+			expected: []string{
+				`<button type="button" role="tab" id="install-tab-0" aria-selected="true" aria-controls="install-panel-0">Go</button>`,
+				`<button type="button" role="tab" id="install-tab-1" aria-selected="false" aria-controls="install-panel-1">&lt;Shell&gt;</button>`,
+				`<div role="tabpanel" id="install-panel-0" aria-labelledby="install-tab-0"><pre id="handler">`,
+				`<div role="tabpanel" id="install-panel-1" aria-labelledby="install-tab-1" hidden>sh</div>`,
+			},
+		},
+		{
+			name:      "line highlighter",
+			component: runtime.LineHighlighter(),
+			expected:  []string{`<script>`, `hashchange`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := templ.InitializeContext(context.Background())
+			if tt.ctx != nil {
+				ctx = tt.ctx(ctx)
+			}
+			s := render(t, ctx, tt.component)
+			if !strings.HasPrefix(s, "<style>") {
+				t.Errorf("expected the styles to be rendered, got:\n%s", s)
+			}
+			for _, expected := range tt.expected {
+				if !strings.Contains(s, expected) {
+					t.Errorf("expected output to contain %s, got:\n%s", expected, s)
+				}
+			}
+			if again := render(t, ctx, tt.component); strings.Contains(again, "<style>") || strings.Contains(again, "<script>") {
+				t.Errorf("expected styles and scripts to be rendered once, got:\n%s", again)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package runtime
+
+import (
+	"context"
+	"html"
+	"regexp"
+	"strings"
+
+	"github.com/a-h/templ"
+)
+
+// lineNumberClass matches the classes of chroma's line number elements, with
+// any prefix, e.g. "ln" or "chroma-lnt".
+var lineNumberClass = regexp.MustCompile(`class="(?:[^"]*[\s-])?lnt?(?:\s[^"]*)?"`)
+
+// PlainText renders a generated component, returning the text of its
+// highlighted code, without line numbers, with the trailing whitespace of each
+// line trimmed, and runs of blank lines collapsed, as go test does to the
+// output comments of examples. It's called by the examples that snips generate
+// -examples writes.
+func PlainText(c templ.Component) (string, error) {
+	var sb strings.Builder
+	if err := c.Render(templ.InitializeContext(context.Background()), &sb); err != nil {
+		return "", err
+	}
+	return codeText(sb.String()), nil
+}
+
+// codeText returns the text within the code elements of s, skipping line
+// numbers.
+func codeText(s string) string {
+	var text strings.Builder
+	// skipped are whether each open element within code is a line number, or
+	// within one.
+	var skipped []bool
+	inCode := 0
+	for s != "" {
+		i := strings.IndexByte(s, '<')
+		if i < 0 {
+			i = len(s)
+		}
+		if inCode > 0 && (len(skipped) == 0 || !skipped[len(skipped)-1]) {
+			text.WriteString(html.UnescapeString(s[:i]))
+		}
+		s = s[i:]
+		if s == "" {
+			break
+		}
+		tag := s[:tagEnd(s)]
+		s = s[len(tag):]
+		closing := strings.HasPrefix(tag, "</")
+		name := strings.TrimPrefix(tag[1:], "/")
+		if i := strings.IndexAny(name, " \t\n/>"); i >= 0 {
+			name = name[:i]
+		}
+		name = strings.ToLower(name)
+		switch {
+		case name == "code" && closing:
+			inCode = max(inCode-1, 0)
+		case name == "code":
+			inCode++
+		case inCode == 0 || strings.HasSuffix(tag, "/>"):
+		case closing:
+			if len(skipped) > 0 {
+				skipped = skipped[:len(skipped)-1]
+			}
+		default:
+			skip := len(skipped) > 0 && skipped[len(skipped)-1]
+			skip = skip || lineNumberClass.MatchString(tag) || strings.Contains(tag, "user-select:none")
+			skipped = append(skipped, skip)
+		}
+	}
+	var lines []string
+	for _, line := range strings.Split(text.String(), "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" && len(lines) > 0 && lines[len(lines)-1] == "" {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// tagEnd returns the index after the end of the tag that s starts with,
+// skipping over quoted attribute values.
+func tagEnd(s string) int {
+	var quote byte
+	for i := 1; i < len(s); i++ {
+		switch {
+		case quote != 0:
+			if s[i] == quote {
+				quote = 0
+			}
+		case s[i] == '"' || s[i] == '\'':
+			quote = s[i]
+		case s[i] == '>':
+			return i + 1
+		}
+	}
+	return len(s)
+}
@@ -0,0 +1,48 @@
+package runtime_test
+
+import (
+	"testing"
+
+	"github.com/a-h/templ"
+	"github.com/garrettladley/snips/runtime"
+)
+
+func TestPlainText(t *testing.T) {
+	tests := []struct {
+		name     string
+		html     string
+		expected string
+	}{
+		{
+			name:     "code",
+			html:     `<div id="main"><span class="snips-badge">Go</span><pre><code><span style="color:#fff">if</span> a &lt; b {  ` + "\n}\n</code></pre></div>",
+			expected: "if a < b {\n}\n",
+		},
+		{
+			name:     "inline line numbers",
+			html:     `<pre><code><span style="display:flex;"><span style="user-select:none;" id="L1"><a href="#L1">1</a></span><span>x := 1` + "\n" + `</span></span></code></pre>`,
+			expected: "x := 1\n",
+		},
+		{
+			name:     "line numbers table",
+			html:     `<table class="lntable"><tr><td class="lntd"><pre><code><span class="lnt">1` + "\n" + `</span></code></pre></td><td class="lntd"><pre><code><span class="line"><span class="cl">x := 1` + "\n" + `</span></span></code></pre></td></tr></table>`,
+			expected: "x := 1\n",
+		},
+		{
+			name:     "blank lines",
+			html:     "<pre><code>a\n\n\n\nb\n</code></pre>",
+			expected: "a\n\nb\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			text, err := runtime.PlainText(templ.Raw(test.html))
+			if err != nil {
+				t.Fatalf("failed to render: %v", err)
+			}
+			if text != test.expected {
+				t.Errorf("expected %q, got %q", test.expected, text)
+			}
+		})
+	}
+}
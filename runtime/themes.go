@@ -0,0 +1,119 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/a-h/templ"
+	"github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+const (
+	// themeAttribute is set on the root element to the selected theme, "light"
+	// or "dark". Snippets follow the system's color scheme until it's set.
+	themeAttribute = "data-snips-theme"
+	// themesAttribute is set on the wrappers of snippets highlighted with
+	// CSS classes, to the names of their light and dark themes.
+	themesAttribute = "data-snips-themes"
+)
+
+var themeStyles = struct {
+	mu sync.Mutex
+	// handles by light and dark theme, so that each pair is rendered once.
+	handles map[[2]string]*templ.OnceHandle
+}{handles: make(map[[2]string]*templ.OnceHandle)}
+
+// ThemeStyles renders the CSS of the light and dark chroma styles of snippets
+// highlighted with CSS classes, once per render. The dark style applies when
+// ThemeSwitcher selects it, or the system prefers dark colors and ThemeSwitcher
+// hasn't selected the light one.
+func ThemeStyles(light, dark string) templ.Component {
+	themeStyles.mu.Lock()
+	defer themeStyles.mu.Unlock()
+	key := [2]string{light, dark}
+	h, ok := themeStyles.handles[key]
+	if !ok {
+		css, err := ThemeCSS(light, dark)
+		h = templ.NewOnceHandle(templ.WithComponent(templ.Raw("<style>"+css+"</style>", err)))
+		themeStyles.handles[key] = h
+	}
+	return h.Once()
+}
+
+// ThemeCSS returns the CSS rendered by ThemeStyles, for pages that aren't
+// rendered with templ: the CSS of the light and dark chroma styles, scoped to
+// snippets highlighted with CSS classes and to the selected theme, or the
+// system's color scheme if none is. The scopes don't overlap, so that the rules
+// of one style don't leak into the other.
+func ThemeCSS(light, dark string) (string, error) {
+	var sb strings.Builder
+	scope := "[" + themesAttribute + "] "
+	for _, theme := range []struct {
+		name, scope string
+		// media wraps the rules in a media query, if set.
+		media string
+	}{
+		{name: light, scope: ":root[" + themeAttribute + "=light] " + scope},
+		{name: light, scope: ":root:not([" + themeAttribute + "=dark]) " + scope, media: "not all and (prefers-color-scheme:dark)"},
+		{name: dark, scope: ":root[" + themeAttribute + "=dark] " + scope},
+		{name: dark, scope: ":root:not([" + themeAttribute + "=light]) " + scope, media: "(prefers-color-scheme:dark)"},
+	} {
+		style, ok := styles.Registry[theme.name]
+		if !ok {
+			return "", fmt.Errorf("unknown style %q", theme.name)
+		}
+		var css bytes.Buffer
+		if err := html.New(html.WithClasses(true)).WriteCSS(&css, style); err != nil {
+			return "", err
+		}
+		if theme.media != "" {
+			sb.WriteString("@media " + theme.media + "{")
+		}
+		for _, rule := range strings.Split(css.String(), "\n") {
+			// Rules are written as "/* TokenType */ .chroma .k { color: #fff }".
+			if _, after, ok := strings.Cut(rule, "*/"); ok {
+				rule = after
+			}
+			selector, declarations, ok := strings.Cut(strings.TrimSpace(rule), "{")
+			if !ok {
+				continue
+			}
+			selectors := strings.Split(selector, ",")
+			for i, s := range selectors {
+				selectors[i] = theme.scope + strings.TrimSpace(s)
+			}
+			sb.WriteString(strings.Join(selectors, ",") + "{" + strings.TrimSpace(declarations))
+		}
+		if theme.media != "" {
+			sb.WriteString("}")
+		}
+	}
+	return sb.String(), nil
+}
+
+var themeSwitcherScript = script(`(function(){var r=document.documentElement,k="snips-theme";` +
+	`try{var t=localStorage.getItem(k);if(t)r.setAttribute("` + themeAttribute + `",t)}catch(e){}` +
+	`document.addEventListener("click",function(e){` +
+	`var b=e.target.closest("[data-snips-theme-switcher]");if(!b)return;` +
+	`var d=r.getAttribute("` + themeAttribute + `")==="dark"||(!r.hasAttribute("` + themeAttribute + `")&&matchMedia("(prefers-color-scheme:dark)").matches);` +
+	`var t=d?"light":"dark";r.setAttribute("` + themeAttribute + `",t);` +
+	`document.querySelectorAll("[data-snips-theme-switcher]").forEach(function(s){s.setAttribute("aria-pressed",t==="dark")});` +
+	`try{localStorage.setItem(k,t)}catch(e){}})})()`)
+
+// ThemeSwitcher renders a button that toggles snippets highlighted with CSS
+// classes between their light and dark themes, remembering the choice. The
+// themes are set when generating, see generator.WithThemes. Its text is
+// localized by the messages of the context, see WithMessages.
+func ThemeSwitcher() templ.Component {
+	return templ.ComponentFunc(func(ctx context.Context, w io.Writer) error {
+		m := MessagesFrom(ctx)
+		return render(ctx, w, themeSwitcherScript.Once(), templ.Raw(
+			`<button type="button" class="snips-theme-switcher" data-snips-theme-switcher aria-pressed="false" aria-label="`+
+				templ.EscapeString(m.Get(MessageThemeLabel))+`">`+templ.EscapeString(m.Get(MessageTheme))+`</button>`))
+	})
+}
@@ -0,0 +1,46 @@
+package runtime_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/a-h/templ"
+	"github.com/garrettladley/snips/runtime"
+)
+
+func TestThemeStyles(t *testing.T) {
+	ctx := templ.InitializeContext(context.Background())
+	s := render(t, ctx, runtime.ThemeStyles("github", "monokai"))
+	for _, expected := range []string{
+		// The keywords of github and monokai.
+		`:root[data-snips-theme=light] [data-snips-themes] .chroma .k{color: #000000; font-weight: bold }`,
+		`@media not all and (prefers-color-scheme:dark){:root:not([data-snips-theme=dark]) [data-snips-themes] .bg{`,
+		`:root[data-snips-theme=dark] [data-snips-themes] .chroma .k{color: #66d9ef }`,
+		`@media (prefers-color-scheme:dark){:root:not([data-snips-theme=light]) [data-snips-themes] .bg{`,
+		`:root:not([data-snips-theme=light]) [data-snips-themes] .chroma .k{color: #66d9ef }`,
+	} {
+		if !strings.Contains(s, expected) {
+			t.Errorf("expected styles to contain %s, got:\n%s", expected, s)
+		}
+	}
+	if again := render(t, ctx, runtime.ThemeStyles("github", "monokai")); again != "" {
+		t.Errorf("expected the styles to be rendered once, got %q", again)
+	}
+
+	var sb strings.Builder
+	if err := runtime.ThemeStyles("github", "no-such-style").Render(ctx, &sb); err == nil {
+		t.Error("expected an error for an unknown style")
+	}
+}
+
+func TestThemeSwitcher(t *testing.T) {
+	ctx := templ.InitializeContext(context.Background())
+	s := render(t, ctx, runtime.ThemeSwitcher())
+	if !strings.Contains(s, "<script>") || !strings.Contains(s, `<button type="button" class="snips-theme-switcher" data-snips-theme-switcher`) {
+		t.Errorf("expected a script and a button, got:\n%s", s)
+	}
+	if again := render(t, ctx, runtime.ThemeSwitcher()); strings.Contains(again, "<script>") {
+		t.Errorf("expected the script to be rendered once, got:\n%s", again)
+	}
+}
@@ -0,0 +1,106 @@
+package snips
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/garrettladley/snips/internal/bidi"
+)
+
+// bidiControls are the Unicode bidirectional control characters, by their
+// abbreviations.
+var bidiControls = bidi.Controls
+
+// BidiControl returns the abbreviation of r, e.g. "RLO", if it's a Unicode
+// bidirectional control character. Bidi controls can make code render in a
+// different order to how it's parsed, as in Trojan Source attacks.
+func BidiControl(r rune) (abbr string, ok bool) {
+	abbr, ok = bidiControls[r]
+	return abbr, ok
+}
+
+// BidiControls returns the Unicode bidirectional control characters, by their
+// abbreviations.
+func BidiControls() map[rune]string {
+	return maps.Clone(bidiControls)
+}
+
+// invisibleChars are the zero-width and other invisible characters, by their
+// abbreviations.
+var invisibleChars = map[rune]string{
+	'\u180E': "MVS",
+	'\u200B': "ZWSP",
+	'\u200C': "ZWNJ",
+	'\u200D': "ZWJ",
+	'\u2060': "WJ",
+	'\u2061': "FA",
+	'\u2062': "IT",
+	'\u2063': "IS",
+	'\u2064': "IP",
+	'\uFEFF': "ZWNBSP",
+}
+
+// SuspiciousChar is an invisible or bidi control character found by
+// ScanUnicode.
+type SuspiciousChar struct {
+	// Line and Column are 1-based, with columns counted in characters.
+	Line   int
+	Column int
+	Rune   rune
+	// Abbr is the character's abbreviation, e.g. "ZWSP" or "RLO".
+	Abbr string
+}
+
+func (c SuspiciousChar) String() string {
+	return fmt.Sprintf("%d:%d: %U %s", c.Line, c.Column, c.Rune, c.Abbr)
+}
+
+// ScanUnicode returns the zero-width and bidi control characters in contents,
+// which can hide or reorder code, as in Trojan Source attacks. A byte order
+// mark at the start of contents isn't reported.
+func ScanUnicode(contents []byte) (found []SuspiciousChar) {
+	s := strings.TrimPrefix(string(contents), "\uFEFF")
+	offset := 0
+	if len(s) < len(contents) {
+		offset = 1
+	}
+	line, column := 1, offset
+	for _, r := range s {
+		column++
+		if r == '\n' {
+			line, column = line+1, 0
+			continue
+		}
+		abbr, ok := bidiControls[r]
+		if !ok {
+			abbr, ok = invisibleChars[r]
+		}
+		if ok {
+			found = append(found, SuspiciousChar{Line: line, Column: column, Rune: r, Abbr: abbr})
+		}
+	}
+	return found
+}
+
+// InvalidUTF8 returns the 1-based line and column, counted in characters, of
+// the first byte of contents that isn't valid UTF-8, if any.
+func InvalidUTF8(contents []byte) (line, column int, found bool) {
+	if utf8.Valid(contents) {
+		return 0, 0, false
+	}
+	line = 1
+	for len(contents) > 0 {
+		r, size := utf8.DecodeRune(contents)
+		column++
+		if r == utf8.RuneError && size == 1 {
+			return line, column, true
+		}
+		if r == '\n' {
+			line, column = line+1, 0
+		}
+		contents = contents[size:]
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,32 @@
+package snips_test
+
+import (
+	"testing"
+
+	"github.com/garrettladley/snips"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestScanUnicode(t *testing.T) {
+	contents := "\uFEFFpackage main\n\nvar a\u200B = \"user\u202E // admin\"\n"
+	want := []snips.SuspiciousChar{
+		{Line: 3, Column: 6, Rune: '\u200B', Abbr: "ZWSP"},
+		{Line: 3, Column: 15, Rune: '\u202E', Abbr: "RLO"},
+	}
+	if diff := cmp.Diff(want, snips.ScanUnicode([]byte(contents))); diff != "" {
+		t.Errorf("unexpected characters (-want +got):\n%s", diff)
+	}
+	if found := snips.ScanUnicode([]byte("package main\n")); len(found) != 0 {
+		t.Errorf("expected nothing to be found, got %v", found)
+	}
+}
+
+func TestInvalidUTF8(t *testing.T) {
+	line, column, found := snips.InvalidUTF8([]byte("package main\n\nvar s = \"\u00e9\xff\"\n"))
+	if !found || line != 3 || column != 11 {
+		t.Errorf("expected invalid UTF-8 at 3:11, got %d:%d, %v", line, column, found)
+	}
+	if _, _, found := snips.InvalidUTF8([]byte("package main // \u00e9\n")); found {
+		t.Error("expected valid UTF-8")
+	}
+}